@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mr1hm/go-disaster-alerts/internal/backup"
+	"github.com/mr1hm/go-disaster-alerts/internal/config"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+// runBackup handles `disaster-alert backup [--out file]`. Without --out the backup goes to
+// BACKUP_DIR, rotated like scheduled backups. The server can keep running meanwhile.
+func runBackup(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	out := fs.String("out", "", "file to write the backup to (default: a new file in BACKUP_DIR)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New("usage: disaster-alert backup [--out file]")
+	}
+
+	db, err := openBackuper(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	path := *out
+	if path == "" {
+		info, err := backup.NewManager(db, cfg.Backup.Dir, cfg.Backup.Keep).Create(ctx)
+		if err != nil {
+			return err
+		}
+		path = info.Path
+	} else if err := db.Backup(ctx, path); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "backed up %s to %s\n", cfg.DB.Path, path)
+	return nil
+}
+
+// runRestore handles `disaster-alert restore --in file`. The server must be stopped first.
+func runRestore(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	in := fs.String("in", "", "backup file to restore")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *in == "" || fs.NArg() > 0 {
+		return errors.New("usage: disaster-alert restore --in file")
+	}
+	if cfg.DB.Driver != "sqlite" {
+		return fmt.Errorf("restore is not supported for the %s driver", cfg.DB.Driver)
+	}
+
+	version, err := repository.RestoreSQLite(context.Background(), *in, cfg.DB.Path)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "restored %s at schema version %d; the previous database is at %s\n",
+		cfg.DB.Path, version, cfg.DB.Path+repository.PreRestoreSuffix)
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mr1hm/go-disaster-alerts/internal/config"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+// openStore opens and migrates the configured database
+func openStore(cfg config.DatabaseConfig) (repository.Store, error) {
+	switch cfg.Driver {
+	case "postgres":
+		return repository.NewPostgresDB(cfg.DSN)
+	case "memory":
+		return repository.NewMemoryDB(), nil
+	}
+	return repository.NewSQLiteDB(cfg.Path)
+}
+
+// openMigrator opens the configured database without migrating it
+func openMigrator(cfg config.DatabaseConfig) (repository.Migrator, error) {
+	switch cfg.Driver {
+	case "postgres":
+		return repository.OpenPostgresDB(cfg.DSN)
+	case "memory":
+		return nil, errors.New("the memory driver has no schema to migrate")
+	}
+	return repository.OpenSQLiteDB(cfg.Path)
+}
+
+// openBackuper opens the configured database for backups, which only SQLite supports
+func openBackuper(cfg config.DatabaseConfig) (*repository.SQLiteDB, error) {
+	if cfg.Driver != "sqlite" {
+		return nil, fmt.Errorf("backups are not supported for the %s driver", cfg.Driver)
+	}
+	if _, err := os.Stat(cfg.Path); err != nil {
+		return nil, err
+	}
+	return repository.OpenSQLiteDB(cfg.Path)
+}
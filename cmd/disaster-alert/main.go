@@ -13,11 +13,15 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/mr1hm/go-disaster-alerts/internal/api"
+	"github.com/mr1hm/go-disaster-alerts/internal/backup"
 	"github.com/mr1hm/go-disaster-alerts/internal/config"
+	"github.com/mr1hm/go-disaster-alerts/internal/geofence"
 	internalgrpc "github.com/mr1hm/go-disaster-alerts/internal/grpc"
 	"github.com/mr1hm/go-disaster-alerts/internal/ingestion"
 	"github.com/mr1hm/go-disaster-alerts/internal/logging"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
 	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+	"github.com/mr1hm/go-disaster-alerts/internal/retention"
 )
 
 func main() {
@@ -27,9 +31,46 @@ func main() {
 	}
 	logging.Setup(cfg.Logging.Level)
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			logging.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "prune" {
+		if err := runPrune(cfg, os.Args[2:]); err != nil {
+			logging.Fatalf("Prune failed: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "backup" {
+		if err := runBackup(cfg, os.Args[2:]); err != nil {
+			logging.Fatalf("Backup failed: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := runExport(cfg, os.Args[2:]); err != nil {
+			logging.Fatalf("Export failed: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(cfg, os.Args[2:]); err != nil {
+			logging.Fatalf("Import failed: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "restore" {
+		if err := runRestore(cfg, os.Args[2:]); err != nil {
+			logging.Fatalf("Restore failed: %v", err)
+		}
+		return
+	}
+
 	slog.Info("Server starting", "host", cfg.Server.Host, "port", cfg.Server.Port)
 
-	db, err := repository.NewSQLiteDB(cfg.DB.Path)
+	db, err := openStore(cfg.DB)
 	if err != nil {
 		logging.Fatalf("Failed to initialize database: %v", err)
 	}
@@ -39,14 +80,52 @@ func main() {
 	defer cancel()
 
 	// Create broadcaster for gRPC streaming
-	broadcaster := internalgrpc.NewBroadcaster()
+	broadcaster := internalgrpc.NewBroadcaster(internalgrpc.WithEvictAfter(cfg.GRPC.EvictAfterDrops))
+
+	// Geofence matches are stored as alerts and streamed to each geofence's owner
+	owners, err := models.ParseOwnerTokens(cfg.Server.GeofenceTokens)
+	if err != nil {
+		logging.Fatalf("Invalid GEOFENCE_TOKENS: %v", err)
+	}
+	if len(owners) == 0 {
+		slog.Info("geofence endpoints disabled, set GEOFENCE_TOKENS to enable them")
+	}
+	alertBroadcaster := internalgrpc.NewAlertBroadcaster()
+	geofences := geofence.NewEvaluator(db, db, alertBroadcaster)
 
 	// Start ingestion manager
-	mgr := ingestion.NewManager(cfg, db, broadcaster)
+	mgr := ingestion.NewManager(cfg, db, broadcaster, ingestion.WithGeofences(geofences))
 	mgr.Start(ctx)
 
+	// Start retention job if rules are configured
+	var retentionJob *retention.Job
+	if cfg.Retention.Rules != "" {
+		rules, err := retention.ParseRules(cfg.Retention.Rules)
+		if err != nil {
+			logging.Fatalf("Invalid RETENTION_RULES: %v", err)
+		}
+		retentionJob = retention.NewJob(db, rules, cfg.Retention.Interval, cfg.Retention.DryRun)
+		retentionJob.Start(ctx)
+	}
+
+	// Backups through the admin endpoint and on a schedule, for SQLite
+	var backups *backup.Manager
+	if b, ok := db.(repository.Backuper); ok {
+		backups = backup.NewManager(b, cfg.Backup.Dir, cfg.Backup.Keep)
+		if cfg.Backup.Interval > 0 {
+			backups.Start(ctx, cfg.Backup.Interval)
+		}
+	}
+
 	// Start gRPC server
-	grpcServer := internalgrpc.NewServer(db, broadcaster)
+	grpcServer := internalgrpc.NewServer(db, broadcaster,
+		internalgrpc.WithHeartbeatInterval(cfg.GRPC.HeartbeatInterval),
+		internalgrpc.WithAckTimeout(cfg.GRPC.AckTimeout),
+		internalgrpc.WithMaxDeliveryAttempts(cfg.GRPC.MaxAttempts),
+		internalgrpc.WithMaxUnacked(cfg.GRPC.MaxUnacked),
+		internalgrpc.WithAlertBroadcaster(alertBroadcaster),
+		internalgrpc.WithOwnerTokens(owners),
+	)
 	go func() {
 		grpcAddr := fmt.Sprintf(":%d", cfg.GRPC.Port)
 		if err := grpcServer.Start(grpcAddr); err != nil {
@@ -60,14 +139,24 @@ func main() {
 	router.Use(gin.Recovery())
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		ExposeHeaders:    []string{"Content-Length", "Link"},
 		AllowCredentials: false, // Set to false when using wildcard origins
 	}))
 	router.Use(api.RateLimitMiddleware(5)) // 5 req/s global limit
 
-	handler := api.NewHandler(db, broadcaster)
+	handlerOpts := []api.HandlerOption{
+		api.WithGeofenceStore(db),
+		api.WithOwnerTokens(owners),
+		api.WithAdminToken(cfg.Server.AdminToken),
+		api.WithAuditLog(db),
+		api.WithGeofenceEvaluator(geofences),
+	}
+	if backups != nil {
+		handlerOpts = append(handlerOpts, api.WithBackups(backups))
+	}
+	handler := api.NewHandler(db, broadcaster, handlerOpts...)
 	handler.RegisterRoutes(router)
 
 	srv := &http.Server{
@@ -90,7 +179,14 @@ func main() {
 
 	cancel()
 	mgr.Stop()
+	if retentionJob != nil {
+		retentionJob.Stop()
+	}
+	if backups != nil {
+		backups.Stop()
+	}
 	broadcaster.Close() // Close all streams gracefully
+	alertBroadcaster.Close()
 	grpcServer.Stop()
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mr1hm/go-disaster-alerts/internal/config"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+var errUsage = errors.New("usage: disaster-alert migrate status | up [version] | down [version]")
+
+// runMigrate handles `disaster-alert migrate ...`. up defaults to the latest version and down to
+// one step below the current version.
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errUsage
+	}
+	target := 0
+	if len(args) == 2 {
+		v, err := strconv.Atoi(args[1])
+		if err != nil || v < 0 {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		target = v
+	}
+
+	db, err := openMigrator(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	switch args[0] {
+	case "status":
+		return printMigrationStatus(ctx, db)
+	case "up":
+		if err := db.MigrateUp(ctx, target); err != nil {
+			return err
+		}
+	case "down":
+		if len(args) == 1 {
+			current, err := db.SchemaVersion(ctx)
+			if err != nil {
+				return err
+			}
+			if current == 0 {
+				return errors.New("no migrations applied")
+			}
+			target = current - 1
+		}
+		if err := db.MigrateDown(ctx, target); err != nil {
+			return err
+		}
+	default:
+		return errUsage
+	}
+	return printMigrationStatus(ctx, db)
+}
+
+func printMigrationStatus(ctx context.Context, db repository.Migrator) error {
+	status, err := db.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range status {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = "applied " + s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(os.Stdout, "%3d  %-50s  %s\n", s.Version, s.Name, applied)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mr1hm/go-disaster-alerts/internal/config"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+	"github.com/mr1hm/go-disaster-alerts/internal/retention"
+)
+
+// runPrune handles `disaster-alert prune [--dry-run]`, applying RETENTION_RULES once and printing
+// each expired disaster. --dry-run prints what would be expired without changing anything.
+func runPrune(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("prune", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", cfg.Retention.DryRun, "report what would be pruned without changing anything")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New("usage: disaster-alert prune [--dry-run]")
+	}
+	if cfg.Retention.Rules == "" {
+		return errors.New("RETENTION_RULES is not set")
+	}
+	rules, err := retention.ParseRules(cfg.Retention.Rules)
+	if err != nil {
+		return err
+	}
+
+	db, err := openStore(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	report, err := db.ApplyRetention(context.Background(), rules, time.Now(), *dryRun)
+	if err != nil {
+		return err
+	}
+	printRetentionReport(report)
+	return nil
+}
+
+func printRetentionReport(report *repository.RetentionReport) {
+	for _, d := range report.Disasters {
+		fmt.Fprintf(os.Stdout, "%-10s  %-40s  %-10s  %-7s  %s\n",
+			d.Action, d.ID, d.Type, d.AlertLevel, d.Timestamp.Format(time.RFC3339))
+	}
+	verb := "pruned"
+	if report.DryRun {
+		verb = "would prune"
+	}
+	fmt.Fprintf(os.Stdout, "%s %d disasters: %d stripped, %d archived, %d deleted\n", verb, len(report.Disasters),
+		report.Count(repository.RetentionStripRaw), report.Count(repository.RetentionArchive), report.Count(repository.RetentionDelete))
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/config"
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+	"github.com/mr1hm/go-disaster-alerts/internal/transfer"
+)
+
+// runExport handles `disaster-alert export [--out file] [--format ndjson|csv] [filters]`, writing
+// every disaster matching the filter flags to a file or stdout
+func runExport(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	out := fs.String("out", "-", "file to write, - for stdout")
+	format := fs.String("format", "", "ndjson or csv (default: from the --out extension, else ndjson)")
+	parseFilter := filterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New("usage: disaster-alert export [--out file] [--format ndjson|csv] [filters]")
+	}
+	filter, err := parseFilter()
+	if err != nil {
+		return err
+	}
+	f, err := transfer.ParseFormat(*format, *out)
+	if err != nil {
+		return err
+	}
+
+	db, err := openStore(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	w := io.Writer(os.Stdout)
+	if *out != "-" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	n, err := transfer.Export(context.Background(), db, filter, w, f)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d disasters\n", n)
+	return nil
+}
+
+// runImport handles `disaster-alert import [--in file] [--format ndjson|csv]`, upserting the
+// disasters of a file or stdin
+func runImport(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	in := fs.String("in", "-", "file to read, - for stdin")
+	format := fs.String("format", "", "ndjson or csv (default: from the --in extension, else ndjson)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New("usage: disaster-alert import [--in file] [--format ndjson|csv]")
+	}
+	f, err := transfer.ParseFormat(*format, *in)
+	if err != nil {
+		return err
+	}
+
+	r := io.Reader(os.Stdin)
+	if *in != "-" {
+		file, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	db, err := openStore(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	result, err := transfer.Import(context.Background(), db, r, f, time.Now())
+	fmt.Fprintf(os.Stderr, "created %d, updated %d, unchanged %d\n", result.Created, result.Updated, result.Unchanged)
+	return err
+}
+
+// filterFlags defines a flag for each repository.Filter field on fs. The returned function builds
+// the filter once fs is parsed.
+func filterFlags(fs *flag.FlagSet) func() (repository.Filter, error) {
+	types := fs.String("type", "", "comma-separated disaster types, e.g. earthquake,flood")
+	alertLevel := fs.String("alert-level", "", "exact alert level: green, orange or red")
+	minAlertLevel := fs.String("min-alert-level", "", "minimum alert level")
+	minMagnitude := fs.String("min-magnitude", "", "minimum magnitude")
+	maxMagnitude := fs.String("max-magnitude", "", "maximum magnitude")
+	minPopulation := fs.String("min-population", "", "minimum affected population count")
+	countries := fs.String("country", "", "comma-separated ISO 3166-1 alpha-3 codes")
+	sources := fs.String("source", "", "comma-separated sources, e.g. GDACS")
+	since := fs.String("since", "", "at or after this time (RFC 3339)")
+	until := fs.String("until", "", "before this time (RFC 3339)")
+	timeField := fs.String("time-field", "event", "time that --since, --until and time sorting use: event or created")
+	bbox := fs.String("bbox", "", "minLon,minLat,maxLon,maxLat")
+	near := fs.String("near", "", "lat,lon,radius_km")
+	area := fs.String("area", "", "file of a GeoJSON Polygon or MultiPolygon to export disasters inside")
+	query := fs.String("q", "", "full-text query")
+	consumer := fs.String("consumer", "", "consumer that --delivered refers to")
+	delivered := fs.String("delivered", "", "true or false: whether --consumer has acknowledged the disaster")
+	sort := fs.String("sort", "", "time, magnitude, alert_level, population, distance or relevance")
+	order := fs.String("order", "", "asc or desc")
+	limit := fs.Int("limit", 0, "maximum number of disasters, 0 for all")
+	offset := fs.Int("offset", 0, "number of matching disasters to skip")
+
+	return func() (repository.Filter, error) {
+		filter := repository.Filter{
+			Countries:  splitFlag(*countries),
+			Sources:    splitFlag(*sources),
+			Query:      *query,
+			ConsumerID: *consumer,
+			Sort:       repository.SortField(*sort),
+			Limit:      *limit,
+			Offset:     *offset,
+		}
+		for _, name := range splitFlag(*types) {
+			t, ok := disastersv1.DisasterType_value[strings.ToUpper(name)]
+			if !ok {
+				return filter, fmt.Errorf("unknown disaster type %q", name)
+			}
+			filter.Types = append(filter.Types, disastersv1.DisasterType(t))
+		}
+		for _, f := range []struct {
+			value string
+			dst   **disastersv1.AlertLevel
+		}{{*alertLevel, &filter.AlertLevel}, {*minAlertLevel, &filter.MinAlertLevel}} {
+			flagValue, dst := f.value, f.dst
+			if flagValue == "" {
+				continue
+			}
+			l, ok := disastersv1.AlertLevel_value[strings.ToUpper(flagValue)]
+			if !ok {
+				return filter, fmt.Errorf("unknown alert level %q", flagValue)
+			}
+			level := disastersv1.AlertLevel(l)
+			*dst = &level
+		}
+		for _, f := range []struct {
+			value string
+			dst   **float64
+		}{{*minMagnitude, &filter.MinMagnitude}, {*maxMagnitude, &filter.MaxMagnitude}} {
+			flagValue, dst := f.value, f.dst
+			if flagValue == "" {
+				continue
+			}
+			v, err := strconv.ParseFloat(flagValue, 64)
+			if err != nil {
+				return filter, fmt.Errorf("invalid magnitude %q", flagValue)
+			}
+			*dst = &v
+		}
+		if *minPopulation != "" {
+			v, err := strconv.ParseInt(*minPopulation, 10, 64)
+			if err != nil {
+				return filter, fmt.Errorf("invalid population %q", *minPopulation)
+			}
+			filter.MinAffectedPopulationCount = &v
+		}
+		for _, f := range []struct {
+			value string
+			dst   **time.Time
+		}{{*since, &filter.Since}, {*until, &filter.Until}} {
+			flagValue, dst := f.value, f.dst
+			if flagValue == "" {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, flagValue)
+			if err != nil {
+				return filter, fmt.Errorf("invalid time %q", flagValue)
+			}
+			*dst = &t
+		}
+		switch *timeField {
+		case "event":
+		case "created":
+			filter.TimeField = repository.TimeFieldCreated
+		default:
+			return filter, fmt.Errorf("invalid time field %q, expected event or created", *timeField)
+		}
+		if *bbox != "" {
+			v, err := parseFloatList(*bbox, 4)
+			if err != nil {
+				return filter, fmt.Errorf("invalid bbox: %w", err)
+			}
+			filter.BBox = &geo.BBox{MinLon: v[0], MinLat: v[1], MaxLon: v[2], MaxLat: v[3]}
+			if err := filter.BBox.Validate(); err != nil {
+				return filter, fmt.Errorf("invalid bbox: %w", err)
+			}
+		}
+		if *near != "" {
+			v, err := parseFloatList(*near, 3)
+			if err != nil {
+				return filter, fmt.Errorf("invalid near: %w", err)
+			}
+			filter.Near = &geo.Circle{Lat: v[0], Lon: v[1], RadiusKm: v[2]}
+			if err := filter.Near.Validate(); err != nil {
+				return filter, fmt.Errorf("invalid near: %w", err)
+			}
+		}
+		if *area != "" {
+			data, err := os.ReadFile(*area)
+			if err != nil {
+				return filter, err
+			}
+			if filter.Area, err = geo.ParseGeoJSON(data); err != nil {
+				return filter, fmt.Errorf("invalid area: %w", err)
+			}
+		}
+		if *delivered != "" {
+			v, err := strconv.ParseBool(*delivered)
+			if err != nil {
+				return filter, fmt.Errorf("invalid delivered %q", *delivered)
+			}
+			if filter.ConsumerID == "" {
+				return filter, errors.New("--delivered requires --consumer")
+			}
+			filter.Delivered = &v
+		}
+		switch *order {
+		case "":
+		case "asc":
+			filter.Order = repository.SortOrderAsc
+		case "desc":
+			filter.Order = repository.SortOrderDesc
+		default:
+			return filter, fmt.Errorf("invalid order %q, expected asc or desc", *order)
+		}
+		if filter.Limit < 0 || filter.Offset < 0 {
+			return filter, errors.New("limit and offset must not be negative")
+		}
+		return filter, filter.Validate()
+	}
+}
+
+// splitFlag splits a comma-separated flag value, dropping empty entries
+func splitFlag(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func parseFloatList(s string, n int) ([]float64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != n {
+		return nil, fmt.Errorf("expected %d comma-separated numbers", n)
+	}
+	v := make([]float64, n)
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, err
+		}
+		v[i] = f
+	}
+	return v, nil
+}
@@ -139,6 +139,285 @@ func (AlertLevel) EnumDescriptor() ([]byte, []int) {
 	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{1}
 }
 
+// EventKind describes what happened to a disaster, or marks a stream control message.
+type EventKind int32
+
+const (
+	EventKind_EVENT_KIND_UNSPECIFIED EventKind = 0
+	EventKind_EVENT_KIND_CREATED     EventKind = 1 // First time the disaster was stored
+	EventKind_EVENT_KIND_UPDATED     EventKind = 2 // Source changed details (magnitude, population, location, ...)
+	EventKind_EVENT_KIND_ESCALATED   EventKind = 3 // Alert level increased
+	EventKind_EVENT_KIND_CLOSED      EventKind = 4 // Source reports the event is no longer current
+	EventKind_EVENT_KIND_HEARTBEAT   EventKind = 5 // Control: stream is alive but idle
+	EventKind_EVENT_KIND_GAP         EventKind = 6 // Control: the server dropped events for this client
+	EventKind_EVENT_KIND_REJECTED    EventKind = 7 // Control: Subscribe rejected a client message; the stream stays open
+)
+
+// Enum value maps for EventKind.
+var (
+	EventKind_name = map[int32]string{
+		0: "EVENT_KIND_UNSPECIFIED",
+		1: "EVENT_KIND_CREATED",
+		2: "EVENT_KIND_UPDATED",
+		3: "EVENT_KIND_ESCALATED",
+		4: "EVENT_KIND_CLOSED",
+		5: "EVENT_KIND_HEARTBEAT",
+		6: "EVENT_KIND_GAP",
+		7: "EVENT_KIND_REJECTED",
+	}
+	EventKind_value = map[string]int32{
+		"EVENT_KIND_UNSPECIFIED": 0,
+		"EVENT_KIND_CREATED":     1,
+		"EVENT_KIND_UPDATED":     2,
+		"EVENT_KIND_ESCALATED":   3,
+		"EVENT_KIND_CLOSED":      4,
+		"EVENT_KIND_HEARTBEAT":   5,
+		"EVENT_KIND_GAP":         6,
+		"EVENT_KIND_REJECTED":    7,
+	}
+)
+
+func (x EventKind) Enum() *EventKind {
+	p := new(EventKind)
+	*p = x
+	return p
+}
+
+func (x EventKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_disasters_v1_disasters_proto_enumTypes[2].Descriptor()
+}
+
+func (EventKind) Type() protoreflect.EnumType {
+	return &file_proto_disasters_v1_disasters_proto_enumTypes[2]
+}
+
+func (x EventKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventKind.Descriptor instead.
+func (EventKind) EnumDescriptor() ([]byte, []int) {
+	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{2}
+}
+
+// Time that since, until and SORT_BY_TIME use
+type TimeField int32
+
+const (
+	TimeField_TIME_FIELD_UNSPECIFIED TimeField = 0 // Same as TIME_FIELD_EVENT
+	TimeField_TIME_FIELD_EVENT       TimeField = 1 // When the disaster occurred (`timestamp`)
+	TimeField_TIME_FIELD_CREATED     TimeField = 2 // When the disaster was first stored
+)
+
+// Enum value maps for TimeField.
+var (
+	TimeField_name = map[int32]string{
+		0: "TIME_FIELD_UNSPECIFIED",
+		1: "TIME_FIELD_EVENT",
+		2: "TIME_FIELD_CREATED",
+	}
+	TimeField_value = map[string]int32{
+		"TIME_FIELD_UNSPECIFIED": 0,
+		"TIME_FIELD_EVENT":       1,
+		"TIME_FIELD_CREATED":     2,
+	}
+)
+
+func (x TimeField) Enum() *TimeField {
+	p := new(TimeField)
+	*p = x
+	return p
+}
+
+func (x TimeField) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TimeField) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_disasters_v1_disasters_proto_enumTypes[3].Descriptor()
+}
+
+func (TimeField) Type() protoreflect.EnumType {
+	return &file_proto_disasters_v1_disasters_proto_enumTypes[3]
+}
+
+func (x TimeField) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TimeField.Descriptor instead.
+func (TimeField) EnumDescriptor() ([]byte, []int) {
+	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{3}
+}
+
+// Ties are broken by time, newest first, then by ID
+type SortBy int32
+
+const (
+	SortBy_SORT_BY_UNSPECIFIED SortBy = 0 // Relevance when `query` is set, otherwise time
+	SortBy_SORT_BY_TIME        SortBy = 1
+	SortBy_SORT_BY_MAGNITUDE   SortBy = 2
+	SortBy_SORT_BY_ALERT_LEVEL SortBy = 3
+	SortBy_SORT_BY_POPULATION  SortBy = 4 // Affected population count
+	SortBy_SORT_BY_DISTANCE    SortBy = 5 // From the `near` center; requires `near`
+	SortBy_SORT_BY_RELEVANCE   SortBy = 6 // Full-text rank; requires `query`
+)
+
+// Enum value maps for SortBy.
+var (
+	SortBy_name = map[int32]string{
+		0: "SORT_BY_UNSPECIFIED",
+		1: "SORT_BY_TIME",
+		2: "SORT_BY_MAGNITUDE",
+		3: "SORT_BY_ALERT_LEVEL",
+		4: "SORT_BY_POPULATION",
+		5: "SORT_BY_DISTANCE",
+		6: "SORT_BY_RELEVANCE",
+	}
+	SortBy_value = map[string]int32{
+		"SORT_BY_UNSPECIFIED": 0,
+		"SORT_BY_TIME":        1,
+		"SORT_BY_MAGNITUDE":   2,
+		"SORT_BY_ALERT_LEVEL": 3,
+		"SORT_BY_POPULATION":  4,
+		"SORT_BY_DISTANCE":    5,
+		"SORT_BY_RELEVANCE":   6,
+	}
+)
+
+func (x SortBy) Enum() *SortBy {
+	p := new(SortBy)
+	*p = x
+	return p
+}
+
+func (x SortBy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SortBy) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_disasters_v1_disasters_proto_enumTypes[4].Descriptor()
+}
+
+func (SortBy) Type() protoreflect.EnumType {
+	return &file_proto_disasters_v1_disasters_proto_enumTypes[4]
+}
+
+func (x SortBy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SortBy.Descriptor instead.
+func (SortBy) EnumDescriptor() ([]byte, []int) {
+	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{4}
+}
+
+type SortOrder int32
+
+const (
+	SortOrder_SORT_ORDER_UNSPECIFIED SortOrder = 0 // Ascending for distance and relevance, descending otherwise
+	SortOrder_SORT_ORDER_ASC         SortOrder = 1
+	SortOrder_SORT_ORDER_DESC        SortOrder = 2
+)
+
+// Enum value maps for SortOrder.
+var (
+	SortOrder_name = map[int32]string{
+		0: "SORT_ORDER_UNSPECIFIED",
+		1: "SORT_ORDER_ASC",
+		2: "SORT_ORDER_DESC",
+	}
+	SortOrder_value = map[string]int32{
+		"SORT_ORDER_UNSPECIFIED": 0,
+		"SORT_ORDER_ASC":         1,
+		"SORT_ORDER_DESC":        2,
+	}
+)
+
+func (x SortOrder) Enum() *SortOrder {
+	p := new(SortOrder)
+	*p = x
+	return p
+}
+
+func (x SortOrder) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SortOrder) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_disasters_v1_disasters_proto_enumTypes[5].Descriptor()
+}
+
+func (SortOrder) Type() protoreflect.EnumType {
+	return &file_proto_disasters_v1_disasters_proto_enumTypes[5]
+}
+
+func (x SortOrder) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SortOrder.Descriptor instead.
+func (SortOrder) EnumDescriptor() ([]byte, []int) {
+	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{5}
+}
+
+// Period GetStats groups disasters by over time, in UTC
+type TimeBucket int32
+
+const (
+	TimeBucket_TIME_BUCKET_UNSPECIFIED TimeBucket = 0 // Same as TIME_BUCKET_DAY
+	TimeBucket_TIME_BUCKET_DAY         TimeBucket = 1
+	TimeBucket_TIME_BUCKET_WEEK        TimeBucket = 2 // ISO weeks, starting on Monday
+	TimeBucket_TIME_BUCKET_MONTH       TimeBucket = 3
+)
+
+// Enum value maps for TimeBucket.
+var (
+	TimeBucket_name = map[int32]string{
+		0: "TIME_BUCKET_UNSPECIFIED",
+		1: "TIME_BUCKET_DAY",
+		2: "TIME_BUCKET_WEEK",
+		3: "TIME_BUCKET_MONTH",
+	}
+	TimeBucket_value = map[string]int32{
+		"TIME_BUCKET_UNSPECIFIED": 0,
+		"TIME_BUCKET_DAY":         1,
+		"TIME_BUCKET_WEEK":        2,
+		"TIME_BUCKET_MONTH":       3,
+	}
+)
+
+func (x TimeBucket) Enum() *TimeBucket {
+	p := new(TimeBucket)
+	*p = x
+	return p
+}
+
+func (x TimeBucket) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TimeBucket) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_disasters_v1_disasters_proto_enumTypes[6].Descriptor()
+}
+
+func (TimeBucket) Type() protoreflect.EnumType {
+	return &file_proto_disasters_v1_disasters_proto_enumTypes[6]
+}
+
+func (x TimeBucket) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TimeBucket.Descriptor instead.
+func (TimeBucket) EnumDescriptor() ([]byte, []int) {
+	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{6}
+}
+
 type GetDisasterRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -198,6 +477,11 @@ type Disaster struct {
 	AffectedPopulation      string                 `protobuf:"bytes,11,opt,name=affected_population,json=affectedPopulation,proto3" json:"affected_population,omitempty"` // Text description (e.g., "1 thousand (in MMI>=VII)")
 	ReportUrl               string                 `protobuf:"bytes,12,opt,name=report_url,json=reportUrl,proto3" json:"report_url,omitempty"`
 	AffectedPopulationCount int64                  `protobuf:"varint,13,opt,name=affected_population_count,json=affectedPopulationCount,proto3" json:"affected_population_count,omitempty"` // Numeric population value for filtering
+	Seq                     int64                  `protobuf:"varint,14,opt,name=seq,proto3" json:"seq,omitempty"`                                                                          // Monotonically increasing sequence number assigned when stored
+	Gap                     *StreamGap             `protobuf:"bytes,15,opt,name=gap,proto3" json:"gap,omitempty"`                                                                           // StreamDisasters with report_gaps only: set on a standalone message with no id, sent after the server dropped events for this client
+	CountryIso              string                 `protobuf:"bytes,16,opt,name=country_iso,json=countryIso,proto3" json:"country_iso,omitempty"`                                           // ISO 3166-1 alpha-3 code of the country (e.g., "JPN"), empty if unknown
+	DistanceKm              *float64               `protobuf:"fixed64,17,opt,name=distance_km,json=distanceKm,proto3,oneof" json:"distance_km,omitempty"`                                   // ListDisasters only: great-circle distance from the `near` center
+	Snippet                 string                 `protobuf:"bytes,18,opt,name=snippet,proto3" json:"snippet,omitempty"`                                                                   // ListDisasters only: text matching `query`, with matched terms wrapped in <mark>
 	unknownFields           protoimpl.UnknownFields
 	sizeCache               protoimpl.SizeCache
 }
@@ -323,34 +607,66 @@ func (x *Disaster) GetAffectedPopulationCount() int64 {
 	return 0
 }
 
-type ListDisastersRequest struct {
-	state                      protoimpl.MessageState `protogen:"open.v1"`
-	Limit                      int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
-	Type                       *DisasterType          `protobuf:"varint,2,opt,name=type,proto3,enum=disasters.v1.DisasterType,oneof" json:"type,omitempty"`
-	MinMagnitude               *float64               `protobuf:"fixed64,3,opt,name=min_magnitude,json=minMagnitude,proto3,oneof" json:"min_magnitude,omitempty"`
-	AlertLevel                 *AlertLevel            `protobuf:"varint,4,opt,name=alert_level,json=alertLevel,proto3,enum=disasters.v1.AlertLevel,oneof" json:"alert_level,omitempty"`
-	MinAlertLevel              *AlertLevel            `protobuf:"varint,5,opt,name=min_alert_level,json=minAlertLevel,proto3,enum=disasters.v1.AlertLevel,oneof" json:"min_alert_level,omitempty"`             // >= this level (e.g., ORANGE includes ORANGE and RED)
-	DiscordSent                *bool                  `protobuf:"varint,6,opt,name=discord_sent,json=discordSent,proto3,oneof" json:"discord_sent,omitempty"`                                                  // Filter by discord_sent status (false = unsent)
-	Since                      *int64                 `protobuf:"varint,7,opt,name=since,proto3,oneof" json:"since,omitempty"`                                                                                 // Unix timestamp - only disasters after this time
-	MinAffectedPopulationCount *int64                 `protobuf:"varint,8,opt,name=min_affected_population_count,json=minAffectedPopulationCount,proto3,oneof" json:"min_affected_population_count,omitempty"` // Minimum affected population count
-	unknownFields              protoimpl.UnknownFields
-	sizeCache                  protoimpl.SizeCache
+func (x *Disaster) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
 }
 
-func (x *ListDisastersRequest) Reset() {
-	*x = ListDisastersRequest{}
+func (x *Disaster) GetGap() *StreamGap {
+	if x != nil {
+		return x.Gap
+	}
+	return nil
+}
+
+func (x *Disaster) GetCountryIso() string {
+	if x != nil {
+		return x.CountryIso
+	}
+	return ""
+}
+
+func (x *Disaster) GetDistanceKm() float64 {
+	if x != nil && x.DistanceKm != nil {
+		return *x.DistanceKm
+	}
+	return 0
+}
+
+func (x *Disaster) GetSnippet() string {
+	if x != nil {
+		return x.Snippet
+	}
+	return ""
+}
+
+// BoundingBox is a latitude/longitude rectangle. min_longitude > max_longitude crosses the antimeridian.
+type BoundingBox struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MinLatitude   float64                `protobuf:"fixed64,1,opt,name=min_latitude,json=minLatitude,proto3" json:"min_latitude,omitempty"`
+	MinLongitude  float64                `protobuf:"fixed64,2,opt,name=min_longitude,json=minLongitude,proto3" json:"min_longitude,omitempty"`
+	MaxLatitude   float64                `protobuf:"fixed64,3,opt,name=max_latitude,json=maxLatitude,proto3" json:"max_latitude,omitempty"`
+	MaxLongitude  float64                `protobuf:"fixed64,4,opt,name=max_longitude,json=maxLongitude,proto3" json:"max_longitude,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BoundingBox) Reset() {
+	*x = BoundingBox{}
 	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListDisastersRequest) String() string {
+func (x *BoundingBox) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListDisastersRequest) ProtoMessage() {}
+func (*BoundingBox) ProtoMessage() {}
 
-func (x *ListDisastersRequest) ProtoReflect() protoreflect.Message {
+func (x *BoundingBox) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -362,88 +678,63 @@ func (x *ListDisastersRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListDisastersRequest.ProtoReflect.Descriptor instead.
-func (*ListDisastersRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use BoundingBox.ProtoReflect.Descriptor instead.
+func (*BoundingBox) Descriptor() ([]byte, []int) {
 	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *ListDisastersRequest) GetLimit() int32 {
+func (x *BoundingBox) GetMinLatitude() float64 {
 	if x != nil {
-		return x.Limit
+		return x.MinLatitude
 	}
 	return 0
 }
 
-func (x *ListDisastersRequest) GetType() DisasterType {
-	if x != nil && x.Type != nil {
-		return *x.Type
-	}
-	return DisasterType_UNSPECIFIED
-}
-
-func (x *ListDisastersRequest) GetMinMagnitude() float64 {
-	if x != nil && x.MinMagnitude != nil {
-		return *x.MinMagnitude
+func (x *BoundingBox) GetMinLongitude() float64 {
+	if x != nil {
+		return x.MinLongitude
 	}
 	return 0
 }
 
-func (x *ListDisastersRequest) GetAlertLevel() AlertLevel {
-	if x != nil && x.AlertLevel != nil {
-		return *x.AlertLevel
-	}
-	return AlertLevel_UNKNOWN
-}
-
-func (x *ListDisastersRequest) GetMinAlertLevel() AlertLevel {
-	if x != nil && x.MinAlertLevel != nil {
-		return *x.MinAlertLevel
-	}
-	return AlertLevel_UNKNOWN
-}
-
-func (x *ListDisastersRequest) GetDiscordSent() bool {
-	if x != nil && x.DiscordSent != nil {
-		return *x.DiscordSent
-	}
-	return false
-}
-
-func (x *ListDisastersRequest) GetSince() int64 {
-	if x != nil && x.Since != nil {
-		return *x.Since
+func (x *BoundingBox) GetMaxLatitude() float64 {
+	if x != nil {
+		return x.MaxLatitude
 	}
 	return 0
 }
 
-func (x *ListDisastersRequest) GetMinAffectedPopulationCount() int64 {
-	if x != nil && x.MinAffectedPopulationCount != nil {
-		return *x.MinAffectedPopulationCount
+func (x *BoundingBox) GetMaxLongitude() float64 {
+	if x != nil {
+		return x.MaxLongitude
 	}
 	return 0
 }
 
-type ListDisastersResponse struct {
+// GeoRadius matches disasters within radius_km of a center point (great-circle distance).
+type GeoRadius struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Disasters     []*Disaster            `protobuf:"bytes,1,rep,name=disasters,proto3" json:"disasters,omitempty"`
+	Latitude      float64                `protobuf:"fixed64,1,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude     float64                `protobuf:"fixed64,2,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	RadiusKm      float64                `protobuf:"fixed64,3,opt,name=radius_km,json=radiusKm,proto3" json:"radius_km,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListDisastersResponse) Reset() {
-	*x = ListDisastersResponse{}
+func (x *GeoRadius) Reset() {
+	*x = GeoRadius{}
 	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListDisastersResponse) String() string {
+func (x *GeoRadius) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListDisastersResponse) ProtoMessage() {}
+func (*GeoRadius) ProtoMessage() {}
 
-func (x *ListDisastersResponse) ProtoReflect() protoreflect.Message {
+func (x *GeoRadius) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -455,42 +746,57 @@ func (x *ListDisastersResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListDisastersResponse.ProtoReflect.Descriptor instead.
-func (*ListDisastersResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use GeoRadius.ProtoReflect.Descriptor instead.
+func (*GeoRadius) Descriptor() ([]byte, []int) {
 	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *ListDisastersResponse) GetDisasters() []*Disaster {
+func (x *GeoRadius) GetLatitude() float64 {
 	if x != nil {
-		return x.Disasters
+		return x.Latitude
 	}
-	return nil
+	return 0
 }
 
-type StreamDisastersRequest struct {
+func (x *GeoRadius) GetLongitude() float64 {
+	if x != nil {
+		return x.Longitude
+	}
+	return 0
+}
+
+func (x *GeoRadius) GetRadiusKm() float64 {
+	if x != nil {
+		return x.RadiusKm
+	}
+	return 0
+}
+
+// StreamGap reports events the server dropped because a stream client could not keep up.
+// Backfill with ListDisasters or by reconnecting with resume_after = first_seq - 1.
+type StreamGap struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Type          *DisasterType          `protobuf:"varint,1,opt,name=type,proto3,enum=disasters.v1.DisasterType,oneof" json:"type,omitempty"`
-	MinMagnitude  *float64               `protobuf:"fixed64,2,opt,name=min_magnitude,json=minMagnitude,proto3,oneof" json:"min_magnitude,omitempty"`
-	AlertLevel    *AlertLevel            `protobuf:"varint,3,opt,name=alert_level,json=alertLevel,proto3,enum=disasters.v1.AlertLevel,oneof" json:"alert_level,omitempty"`
-	MinAlertLevel *AlertLevel            `protobuf:"varint,4,opt,name=min_alert_level,json=minAlertLevel,proto3,enum=disasters.v1.AlertLevel,oneof" json:"min_alert_level,omitempty"` // >= this level (e.g., ORANGE includes ORANGE and RED)
+	Missed        int64                  `protobuf:"varint,1,opt,name=missed,proto3" json:"missed,omitempty"`                     // Number of events dropped
+	FirstSeq      int64                  `protobuf:"varint,2,opt,name=first_seq,json=firstSeq,proto3" json:"first_seq,omitempty"` // Sequence number of the first dropped event
+	LastSeq       int64                  `protobuf:"varint,3,opt,name=last_seq,json=lastSeq,proto3" json:"last_seq,omitempty"`    // Sequence number of the last dropped event
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StreamDisastersRequest) Reset() {
-	*x = StreamDisastersRequest{}
+func (x *StreamGap) Reset() {
+	*x = StreamGap{}
 	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StreamDisastersRequest) String() string {
+func (x *StreamGap) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StreamDisastersRequest) ProtoMessage() {}
+func (*StreamGap) ProtoMessage() {}
 
-func (x *StreamDisastersRequest) ProtoReflect() protoreflect.Message {
+func (x *StreamGap) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -502,21 +808,937 @@ func (x *StreamDisastersRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StreamDisastersRequest.ProtoReflect.Descriptor instead.
-func (*StreamDisastersRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use StreamGap.ProtoReflect.Descriptor instead.
+func (*StreamGap) Descriptor() ([]byte, []int) {
 	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *StreamDisastersRequest) GetType() DisasterType {
-	if x != nil && x.Type != nil {
-		return *x.Type
+func (x *StreamGap) GetMissed() int64 {
+	if x != nil {
+		return x.Missed
 	}
-	return DisasterType_UNSPECIFIED
+	return 0
 }
 
-func (x *StreamDisastersRequest) GetMinMagnitude() float64 {
-	if x != nil && x.MinMagnitude != nil {
-		return *x.MinMagnitude
+func (x *StreamGap) GetFirstSeq() int64 {
+	if x != nil {
+		return x.FirstSeq
+	}
+	return 0
+}
+
+func (x *StreamGap) GetLastSeq() int64 {
+	if x != nil {
+		return x.LastSeq
+	}
+	return 0
+}
+
+// Rejected explains why Subscribe refused a client message. The previous filters stay in effect.
+type Rejected struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reason        string                 `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Rejected) Reset() {
+	*x = Rejected{}
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rejected) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rejected) ProtoMessage() {}
+
+func (x *Rejected) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rejected.ProtoReflect.Descriptor instead.
+func (*Rejected) Descriptor() ([]byte, []int) {
+	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Rejected) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// DisasterEvent is the envelope sent by StreamDisasterEvents.
+type DisasterEvent struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Kind         EventKind              `protobuf:"varint,1,opt,name=kind,proto3,enum=disasters.v1.EventKind" json:"kind,omitempty"`
+	Seq          int64                  `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`                                         // Event sequence number; for heartbeats, the last seq sent on this stream
+	ServerTimeMs int64                  `protobuf:"varint,3,opt,name=server_time_ms,json=serverTimeMs,proto3" json:"server_time_ms,omitempty"` // Unix timestamp in milliseconds when the server sent the message
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*DisasterEvent_Disaster
+	//	*DisasterEvent_Heartbeat
+	//	*DisasterEvent_Gap
+	//	*DisasterEvent_Rejected
+	Payload         isDisasterEvent_Payload `protobuf_oneof:"payload"`
+	DeliveryAttempt int32                   `protobuf:"varint,7,opt,name=delivery_attempt,json=deliveryAttempt,proto3" json:"delivery_attempt,omitempty"` // Subscribe only: 1 on first delivery, incremented on each redelivery
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DisasterEvent) Reset() {
+	*x = DisasterEvent{}
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DisasterEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DisasterEvent) ProtoMessage() {}
+
+func (x *DisasterEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DisasterEvent.ProtoReflect.Descriptor instead.
+func (*DisasterEvent) Descriptor() ([]byte, []int) {
+	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DisasterEvent) GetKind() EventKind {
+	if x != nil {
+		return x.Kind
+	}
+	return EventKind_EVENT_KIND_UNSPECIFIED
+}
+
+func (x *DisasterEvent) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *DisasterEvent) GetServerTimeMs() int64 {
+	if x != nil {
+		return x.ServerTimeMs
+	}
+	return 0
+}
+
+func (x *DisasterEvent) GetPayload() isDisasterEvent_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *DisasterEvent) GetDisaster() *Disaster {
+	if x != nil {
+		if x, ok := x.Payload.(*DisasterEvent_Disaster); ok {
+			return x.Disaster
+		}
+	}
+	return nil
+}
+
+func (x *DisasterEvent) GetHeartbeat() *Heartbeat {
+	if x != nil {
+		if x, ok := x.Payload.(*DisasterEvent_Heartbeat); ok {
+			return x.Heartbeat
+		}
+	}
+	return nil
+}
+
+func (x *DisasterEvent) GetGap() *StreamGap {
+	if x != nil {
+		if x, ok := x.Payload.(*DisasterEvent_Gap); ok {
+			return x.Gap
+		}
+	}
+	return nil
+}
+
+func (x *DisasterEvent) GetRejected() *Rejected {
+	if x != nil {
+		if x, ok := x.Payload.(*DisasterEvent_Rejected); ok {
+			return x.Rejected
+		}
+	}
+	return nil
+}
+
+func (x *DisasterEvent) GetDeliveryAttempt() int32 {
+	if x != nil {
+		return x.DeliveryAttempt
+	}
+	return 0
+}
+
+type isDisasterEvent_Payload interface {
+	isDisasterEvent_Payload()
+}
+
+type DisasterEvent_Disaster struct {
+	Disaster *Disaster `protobuf:"bytes,4,opt,name=disaster,proto3,oneof"` // CREATED, UPDATED, ESCALATED, CLOSED (state of the disaster after the event)
+}
+
+type DisasterEvent_Heartbeat struct {
+	Heartbeat *Heartbeat `protobuf:"bytes,5,opt,name=heartbeat,proto3,oneof"` // HEARTBEAT
+}
+
+type DisasterEvent_Gap struct {
+	Gap *StreamGap `protobuf:"bytes,6,opt,name=gap,proto3,oneof"` // GAP
+}
+
+type DisasterEvent_Rejected struct {
+	Rejected *Rejected `protobuf:"bytes,8,opt,name=rejected,proto3,oneof"` // REJECTED
+}
+
+func (*DisasterEvent_Disaster) isDisasterEvent_Payload() {}
+
+func (*DisasterEvent_Heartbeat) isDisasterEvent_Payload() {}
+
+func (*DisasterEvent_Gap) isDisasterEvent_Payload() {}
+
+func (*DisasterEvent_Rejected) isDisasterEvent_Payload() {}
+
+type SubscribeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Request:
+	//
+	//	*SubscribeRequest_Filter
+	//	*SubscribeRequest_Ack
+	Request       isSubscribeRequest_Request `protobuf_oneof:"request"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SubscribeRequest) GetRequest() isSubscribeRequest_Request {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *SubscribeRequest) GetFilter() *StreamDisastersRequest {
+	if x != nil {
+		if x, ok := x.Request.(*SubscribeRequest_Filter); ok {
+			return x.Filter
+		}
+	}
+	return nil
+}
+
+func (x *SubscribeRequest) GetAck() *Ack {
+	if x != nil {
+		if x, ok := x.Request.(*SubscribeRequest_Ack); ok {
+			return x.Ack
+		}
+	}
+	return nil
+}
+
+type isSubscribeRequest_Request interface {
+	isSubscribeRequest_Request()
+}
+
+type SubscribeRequest_Filter struct {
+	Filter *StreamDisastersRequest `protobuf:"bytes,1,opt,name=filter,proto3,oneof"` // Set or replace filters; resume_after is only read from the first message
+}
+
+type SubscribeRequest_Ack struct {
+	Ack *Ack `protobuf:"bytes,2,opt,name=ack,proto3,oneof"`
+}
+
+func (*SubscribeRequest_Filter) isSubscribeRequest_Request() {}
+
+func (*SubscribeRequest_Ack) isSubscribeRequest_Request() {}
+
+type Ack struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seqs          []int64                `protobuf:"varint,1,rep,packed,name=seqs,proto3" json:"seqs,omitempty"` // Sequence numbers of events the client has processed
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ack) Reset() {
+	*x = Ack{}
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ack) ProtoMessage() {}
+
+func (x *Ack) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ack.ProtoReflect.Descriptor instead.
+func (*Ack) Descriptor() ([]byte, []int) {
+	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Ack) GetSeqs() []int64 {
+	if x != nil {
+		return x.Seqs
+	}
+	return nil
+}
+
+type Heartbeat struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	IntervalSeconds int64                  `protobuf:"varint,1,opt,name=interval_seconds,json=intervalSeconds,proto3" json:"interval_seconds,omitempty"` // Time until the next heartbeat if the stream stays idle
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Heartbeat) Reset() {
+	*x = Heartbeat{}
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Heartbeat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Heartbeat) ProtoMessage() {}
+
+func (x *Heartbeat) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Heartbeat.ProtoReflect.Descriptor instead.
+func (*Heartbeat) Descriptor() ([]byte, []int) {
+	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Heartbeat) GetIntervalSeconds() int64 {
+	if x != nil {
+		return x.IntervalSeconds
+	}
+	return 0
+}
+
+type ListDisastersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Type          *DisasterType          `protobuf:"varint,2,opt,name=type,proto3,enum=disasters.v1.DisasterType,oneof" json:"type,omitempty"`
+	MinMagnitude  *float64               `protobuf:"fixed64,3,opt,name=min_magnitude,json=minMagnitude,proto3,oneof" json:"min_magnitude,omitempty"`
+	AlertLevel    *AlertLevel            `protobuf:"varint,4,opt,name=alert_level,json=alertLevel,proto3,enum=disasters.v1.AlertLevel,oneof" json:"alert_level,omitempty"`
+	MinAlertLevel *AlertLevel            `protobuf:"varint,5,opt,name=min_alert_level,json=minAlertLevel,proto3,enum=disasters.v1.AlertLevel,oneof" json:"min_alert_level,omitempty"` // >= this level (e.g., ORANGE includes ORANGE and RED)
+	// Deprecated: Marked as deprecated in proto/disasters/v1/disasters.proto.
+	DiscordSent                *bool          `protobuf:"varint,6,opt,name=discord_sent,json=discordSent,proto3,oneof" json:"discord_sent,omitempty"`                                                  // Use consumer_id + delivered. Same as delivered for consumer "discord"
+	Since                      *int64         `protobuf:"varint,7,opt,name=since,proto3,oneof" json:"since,omitempty"`                                                                                 // Unix timestamp - only disasters after this time
+	MinAffectedPopulationCount *int64         `protobuf:"varint,8,opt,name=min_affected_population_count,json=minAffectedPopulationCount,proto3,oneof" json:"min_affected_population_count,omitempty"` // Minimum affected population count
+	ConsumerId                 string         `protobuf:"bytes,9,opt,name=consumer_id,json=consumerId,proto3" json:"consumer_id,omitempty"`                                                            // Consumer whose deliveries `delivered` checks (default "discord")
+	Delivered                  *bool          `protobuf:"varint,10,opt,name=delivered,proto3,oneof" json:"delivered,omitempty"`                                                                        // Filter by whether consumer_id has acknowledged (false = unsent)
+	Types                      []DisasterType `protobuf:"varint,11,rep,packed,name=types,proto3,enum=disasters.v1.DisasterType" json:"types,omitempty"`                                                // Any of these types, combined with type if both are set
+	Countries                  []string       `protobuf:"bytes,12,rep,name=countries,proto3" json:"countries,omitempty"`                                                                               // ISO 3166-1 alpha-3 codes (e.g., "JPN", "PHL"), case-insensitive
+	Bbox                       *BoundingBox   `protobuf:"bytes,13,opt,name=bbox,proto3" json:"bbox,omitempty"`
+	Near                       *GeoRadius     `protobuf:"bytes,14,opt,name=near,proto3" json:"near,omitempty"`
+	Query                      string         `protobuf:"bytes,15,opt,name=query,proto3" json:"query,omitempty"`                          // Full-text search over title, description and country; results are ranked by relevance
+	PageToken                  string         `protobuf:"bytes,16,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"` // next_page_token of the previous page, with the same filters
+	Until                      *int64         `protobuf:"varint,17,opt,name=until,proto3,oneof" json:"until,omitempty"`                   // Unix timestamp - only disasters before this time
+	Sources                    []string       `protobuf:"bytes,18,rep,name=sources,proto3" json:"sources,omitempty"`                      // Any of these sources (e.g., "GDACS"), case-insensitive
+	MaxMagnitude               *float64       `protobuf:"fixed64,19,opt,name=max_magnitude,json=maxMagnitude,proto3,oneof" json:"max_magnitude,omitempty"`
+	TimeField                  TimeField      `protobuf:"varint,20,opt,name=time_field,json=timeField,proto3,enum=disasters.v1.TimeField" json:"time_field,omitempty"` // Time that since, until and SORT_BY_TIME use (default event time)
+	SortBy                     SortBy         `protobuf:"varint,21,opt,name=sort_by,json=sortBy,proto3,enum=disasters.v1.SortBy" json:"sort_by,omitempty"`
+	SortOrder                  SortOrder      `protobuf:"varint,22,opt,name=sort_order,json=sortOrder,proto3,enum=disasters.v1.SortOrder" json:"sort_order,omitempty"`
+	unknownFields              protoimpl.UnknownFields
+	sizeCache                  protoimpl.SizeCache
+}
+
+func (x *ListDisastersRequest) Reset() {
+	*x = ListDisastersRequest{}
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDisastersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDisastersRequest) ProtoMessage() {}
+
+func (x *ListDisastersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDisastersRequest.ProtoReflect.Descriptor instead.
+func (*ListDisastersRequest) Descriptor() ([]byte, []int) {
+	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListDisastersRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListDisastersRequest) GetType() DisasterType {
+	if x != nil && x.Type != nil {
+		return *x.Type
+	}
+	return DisasterType_UNSPECIFIED
+}
+
+func (x *ListDisastersRequest) GetMinMagnitude() float64 {
+	if x != nil && x.MinMagnitude != nil {
+		return *x.MinMagnitude
+	}
+	return 0
+}
+
+func (x *ListDisastersRequest) GetAlertLevel() AlertLevel {
+	if x != nil && x.AlertLevel != nil {
+		return *x.AlertLevel
+	}
+	return AlertLevel_UNKNOWN
+}
+
+func (x *ListDisastersRequest) GetMinAlertLevel() AlertLevel {
+	if x != nil && x.MinAlertLevel != nil {
+		return *x.MinAlertLevel
+	}
+	return AlertLevel_UNKNOWN
+}
+
+// Deprecated: Marked as deprecated in proto/disasters/v1/disasters.proto.
+func (x *ListDisastersRequest) GetDiscordSent() bool {
+	if x != nil && x.DiscordSent != nil {
+		return *x.DiscordSent
+	}
+	return false
+}
+
+func (x *ListDisastersRequest) GetSince() int64 {
+	if x != nil && x.Since != nil {
+		return *x.Since
+	}
+	return 0
+}
+
+func (x *ListDisastersRequest) GetMinAffectedPopulationCount() int64 {
+	if x != nil && x.MinAffectedPopulationCount != nil {
+		return *x.MinAffectedPopulationCount
+	}
+	return 0
+}
+
+func (x *ListDisastersRequest) GetConsumerId() string {
+	if x != nil {
+		return x.ConsumerId
+	}
+	return ""
+}
+
+func (x *ListDisastersRequest) GetDelivered() bool {
+	if x != nil && x.Delivered != nil {
+		return *x.Delivered
+	}
+	return false
+}
+
+func (x *ListDisastersRequest) GetTypes() []DisasterType {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+func (x *ListDisastersRequest) GetCountries() []string {
+	if x != nil {
+		return x.Countries
+	}
+	return nil
+}
+
+func (x *ListDisastersRequest) GetBbox() *BoundingBox {
+	if x != nil {
+		return x.Bbox
+	}
+	return nil
+}
+
+func (x *ListDisastersRequest) GetNear() *GeoRadius {
+	if x != nil {
+		return x.Near
+	}
+	return nil
+}
+
+func (x *ListDisastersRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *ListDisastersRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListDisastersRequest) GetUntil() int64 {
+	if x != nil && x.Until != nil {
+		return *x.Until
+	}
+	return 0
+}
+
+func (x *ListDisastersRequest) GetSources() []string {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+func (x *ListDisastersRequest) GetMaxMagnitude() float64 {
+	if x != nil && x.MaxMagnitude != nil {
+		return *x.MaxMagnitude
+	}
+	return 0
+}
+
+func (x *ListDisastersRequest) GetTimeField() TimeField {
+	if x != nil {
+		return x.TimeField
+	}
+	return TimeField_TIME_FIELD_UNSPECIFIED
+}
+
+func (x *ListDisastersRequest) GetSortBy() SortBy {
+	if x != nil {
+		return x.SortBy
+	}
+	return SortBy_SORT_BY_UNSPECIFIED
+}
+
+func (x *ListDisastersRequest) GetSortOrder() SortOrder {
+	if x != nil {
+		return x.SortOrder
+	}
+	return SortOrder_SORT_ORDER_UNSPECIFIED
+}
+
+type ListDisastersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Disasters     []*Disaster            `protobuf:"bytes,1,rep,name=disasters,proto3" json:"disasters,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"` // Set when limit is set and more disasters match; empty on the last page
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDisastersResponse) Reset() {
+	*x = ListDisastersResponse{}
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDisastersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDisastersResponse) ProtoMessage() {}
+
+func (x *ListDisastersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDisastersResponse.ProtoReflect.Descriptor instead.
+func (*ListDisastersResponse) Descriptor() ([]byte, []int) {
+	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListDisastersResponse) GetDisasters() []*Disaster {
+	if x != nil {
+		return x.Disasters
+	}
+	return nil
+}
+
+func (x *ListDisastersResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type GetStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filter        *ListDisastersRequest  `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"` // limit, page_token and sorting are ignored
+	Bucket        TimeBucket             `protobuf:"varint,2,opt,name=bucket,proto3,enum=disasters.v1.TimeBucket" json:"bucket,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsRequest) Reset() {
+	*x = GetStatsRequest{}
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsRequest) ProtoMessage() {}
+
+func (x *GetStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetStatsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetStatsRequest) GetFilter() *ListDisastersRequest {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *GetStatsRequest) GetBucket() TimeBucket {
+	if x != nil {
+		return x.Bucket
+	}
+	return TimeBucket_TIME_BUCKET_UNSPECIFIED
+}
+
+type StatsGroup struct {
+	state                   protoimpl.MessageState `protogen:"open.v1"`
+	Key                     string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Count                   int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	MaxMagnitude            float64                `protobuf:"fixed64,3,opt,name=max_magnitude,json=maxMagnitude,proto3" json:"max_magnitude,omitempty"` // 0 if no disaster in the group has a magnitude
+	TotalAffectedPopulation int64                  `protobuf:"varint,4,opt,name=total_affected_population,json=totalAffectedPopulation,proto3" json:"total_affected_population,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *StatsGroup) Reset() {
+	*x = StatsGroup{}
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsGroup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsGroup) ProtoMessage() {}
+
+func (x *StatsGroup) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsGroup.ProtoReflect.Descriptor instead.
+func (*StatsGroup) Descriptor() ([]byte, []int) {
+	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *StatsGroup) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *StatsGroup) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *StatsGroup) GetMaxMagnitude() float64 {
+	if x != nil {
+		return x.MaxMagnitude
+	}
+	return 0
+}
+
+func (x *StatsGroup) GetTotalAffectedPopulation() int64 {
+	if x != nil {
+		return x.TotalAffectedPopulation
+	}
+	return 0
+}
+
+// Groups are ordered by count, largest first, except by_time, which is oldest first and omits empty buckets
+type GetStatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         *StatsGroup            `protobuf:"bytes,1,opt,name=total,proto3" json:"total,omitempty"` // With an empty key
+	Bucket        TimeBucket             `protobuf:"varint,2,opt,name=bucket,proto3,enum=disasters.v1.TimeBucket" json:"bucket,omitempty"`
+	ByType        []*StatsGroup          `protobuf:"bytes,3,rep,name=by_type,json=byType,proto3" json:"by_type,omitempty"`                     // Keyed by DisasterType name (e.g., "EARTHQUAKE")
+	ByAlertLevel  []*StatsGroup          `protobuf:"bytes,4,rep,name=by_alert_level,json=byAlertLevel,proto3" json:"by_alert_level,omitempty"` // Keyed by AlertLevel name (e.g., "RED")
+	ByCountry     []*StatsGroup          `protobuf:"bytes,5,rep,name=by_country,json=byCountry,proto3" json:"by_country,omitempty"`            // Keyed by ISO 3166-1 alpha-3 code, empty if unknown
+	BySource      []*StatsGroup          `protobuf:"bytes,6,rep,name=by_source,json=bySource,proto3" json:"by_source,omitempty"`
+	ByTime        []*StatsGroup          `protobuf:"bytes,7,rep,name=by_time,json=byTime,proto3" json:"by_time,omitempty"` // Keyed by the bucket's first day, formatted YYYY-MM-DD
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsResponse) Reset() {
+	*x = GetStatsResponse{}
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsResponse) ProtoMessage() {}
+
+func (x *GetStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetStatsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetStatsResponse) GetTotal() *StatsGroup {
+	if x != nil {
+		return x.Total
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetBucket() TimeBucket {
+	if x != nil {
+		return x.Bucket
+	}
+	return TimeBucket_TIME_BUCKET_UNSPECIFIED
+}
+
+func (x *GetStatsResponse) GetByType() []*StatsGroup {
+	if x != nil {
+		return x.ByType
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetByAlertLevel() []*StatsGroup {
+	if x != nil {
+		return x.ByAlertLevel
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetByCountry() []*StatsGroup {
+	if x != nil {
+		return x.ByCountry
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetBySource() []*StatsGroup {
+	if x != nil {
+		return x.BySource
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetByTime() []*StatsGroup {
+	if x != nil {
+		return x.ByTime
+	}
+	return nil
+}
+
+type StreamDisastersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          *DisasterType          `protobuf:"varint,1,opt,name=type,proto3,enum=disasters.v1.DisasterType,oneof" json:"type,omitempty"`
+	MinMagnitude  *float64               `protobuf:"fixed64,2,opt,name=min_magnitude,json=minMagnitude,proto3,oneof" json:"min_magnitude,omitempty"`
+	AlertLevel    *AlertLevel            `protobuf:"varint,3,opt,name=alert_level,json=alertLevel,proto3,enum=disasters.v1.AlertLevel,oneof" json:"alert_level,omitempty"`
+	MinAlertLevel *AlertLevel            `protobuf:"varint,4,opt,name=min_alert_level,json=minAlertLevel,proto3,enum=disasters.v1.AlertLevel,oneof" json:"min_alert_level,omitempty"` // >= this level (e.g., ORANGE includes ORANGE and RED)
+	ResumeAfter   *int64                 `protobuf:"varint,5,opt,name=resume_after,json=resumeAfter,proto3,oneof" json:"resume_after,omitempty"`                                      // Replay stored disasters with seq > resume_after before streaming live
+	ConsumerId    string                 `protobuf:"bytes,6,opt,name=consumer_id,json=consumerId,proto3" json:"consumer_id,omitempty"`                                                // Subscribe only: consumer that in-band acks are recorded for (default "discord")
+	// Streams sharing a group split live events between them: each event goes to one member, and events
+	// a member had not handled (sent, or acked on Subscribe) are reassigned when it disconnects.
+	// Cannot be combined with resume_after. Fixed for the lifetime of a Subscribe stream.
+	Group                      string         `protobuf:"bytes,7,opt,name=group,proto3" json:"group,omitempty"`
+	Types                      []DisasterType `protobuf:"varint,8,rep,packed,name=types,proto3,enum=disasters.v1.DisasterType" json:"types,omitempty"`                                                  // Any of these types, combined with type if both are set
+	Countries                  []string       `protobuf:"bytes,9,rep,name=countries,proto3" json:"countries,omitempty"`                                                                                 // ISO 3166-1 alpha-3 codes (e.g., "JPN", "PHL"), case-insensitive
+	MinAffectedPopulationCount *int64         `protobuf:"varint,10,opt,name=min_affected_population_count,json=minAffectedPopulationCount,proto3,oneof" json:"min_affected_population_count,omitempty"` // Minimum affected population count
+	Bbox                       *BoundingBox   `protobuf:"bytes,11,opt,name=bbox,proto3" json:"bbox,omitempty"`
+	Near                       *GeoRadius     `protobuf:"bytes,12,opt,name=near,proto3" json:"near,omitempty"`
+	Sources                    []string       `protobuf:"bytes,13,rep,name=sources,proto3" json:"sources,omitempty"` // Any of these sources (e.g., "GDACS"), case-insensitive
+	MaxMagnitude               *float64       `protobuf:"fixed64,14,opt,name=max_magnitude,json=maxMagnitude,proto3,oneof" json:"max_magnitude,omitempty"`
+	ReportGaps                 bool           `protobuf:"varint,15,opt,name=report_gaps,json=reportGaps,proto3" json:"report_gaps,omitempty"` // StreamDisasters only: send gap notices as Disaster messages with only gap set
+	unknownFields              protoimpl.UnknownFields
+	sizeCache                  protoimpl.SizeCache
+}
+
+func (x *StreamDisastersRequest) Reset() {
+	*x = StreamDisastersRequest{}
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamDisastersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamDisastersRequest) ProtoMessage() {}
+
+func (x *StreamDisastersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamDisastersRequest.ProtoReflect.Descriptor instead.
+func (*StreamDisastersRequest) Descriptor() ([]byte, []int) {
+	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *StreamDisastersRequest) GetType() DisasterType {
+	if x != nil && x.Type != nil {
+		return *x.Type
+	}
+	return DisasterType_UNSPECIFIED
+}
+
+func (x *StreamDisastersRequest) GetMinMagnitude() float64 {
+	if x != nil && x.MinMagnitude != nil {
+		return *x.MinMagnitude
 	}
 	return 0
 }
@@ -535,16 +1757,94 @@ func (x *StreamDisastersRequest) GetMinAlertLevel() AlertLevel {
 	return AlertLevel_UNKNOWN
 }
 
+func (x *StreamDisastersRequest) GetResumeAfter() int64 {
+	if x != nil && x.ResumeAfter != nil {
+		return *x.ResumeAfter
+	}
+	return 0
+}
+
+func (x *StreamDisastersRequest) GetConsumerId() string {
+	if x != nil {
+		return x.ConsumerId
+	}
+	return ""
+}
+
+func (x *StreamDisastersRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *StreamDisastersRequest) GetTypes() []DisasterType {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+func (x *StreamDisastersRequest) GetCountries() []string {
+	if x != nil {
+		return x.Countries
+	}
+	return nil
+}
+
+func (x *StreamDisastersRequest) GetMinAffectedPopulationCount() int64 {
+	if x != nil && x.MinAffectedPopulationCount != nil {
+		return *x.MinAffectedPopulationCount
+	}
+	return 0
+}
+
+func (x *StreamDisastersRequest) GetBbox() *BoundingBox {
+	if x != nil {
+		return x.Bbox
+	}
+	return nil
+}
+
+func (x *StreamDisastersRequest) GetNear() *GeoRadius {
+	if x != nil {
+		return x.Near
+	}
+	return nil
+}
+
+func (x *StreamDisastersRequest) GetSources() []string {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+func (x *StreamDisastersRequest) GetMaxMagnitude() float64 {
+	if x != nil && x.MaxMagnitude != nil {
+		return *x.MaxMagnitude
+	}
+	return 0
+}
+
+func (x *StreamDisastersRequest) GetReportGaps() bool {
+	if x != nil {
+		return x.ReportGaps
+	}
+	return false
+}
+
 type AcknowledgeDisastersRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Ids           []string               `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"` // Disaster IDs successfully posted to Discord
+	Ids           []string               `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"`                                 // Disaster IDs successfully delivered by the consumer
+	ConsumerId    string                 `protobuf:"bytes,2,opt,name=consumer_id,json=consumerId,proto3" json:"consumer_id,omitempty"` // Consumer acknowledging the disasters (default "discord")
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *AcknowledgeDisastersRequest) Reset() {
 	*x = AcknowledgeDisastersRequest{}
-	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[5]
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -556,7 +1856,7 @@ func (x *AcknowledgeDisastersRequest) String() string {
 func (*AcknowledgeDisastersRequest) ProtoMessage() {}
 
 func (x *AcknowledgeDisastersRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[5]
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -569,7 +1869,7 @@ func (x *AcknowledgeDisastersRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AcknowledgeDisastersRequest.ProtoReflect.Descriptor instead.
 func (*AcknowledgeDisastersRequest) Descriptor() ([]byte, []int) {
-	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{5}
+	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *AcknowledgeDisastersRequest) GetIds() []string {
@@ -579,16 +1879,23 @@ func (x *AcknowledgeDisastersRequest) GetIds() []string {
 	return nil
 }
 
+func (x *AcknowledgeDisastersRequest) GetConsumerId() string {
+	if x != nil {
+		return x.ConsumerId
+	}
+	return ""
+}
+
 type AcknowledgeDisastersResponse struct {
 	state             protoimpl.MessageState `protogen:"open.v1"`
-	AcknowledgedCount int64                  `protobuf:"varint,1,opt,name=acknowledged_count,json=acknowledgedCount,proto3" json:"acknowledged_count,omitempty"` // Number of disasters marked as sent
+	AcknowledgedCount int64                  `protobuf:"varint,1,opt,name=acknowledged_count,json=acknowledgedCount,proto3" json:"acknowledged_count,omitempty"` // Number of disasters newly acknowledged for the consumer
 	unknownFields     protoimpl.UnknownFields
 	sizeCache         protoimpl.SizeCache
 }
 
 func (x *AcknowledgeDisastersResponse) Reset() {
 	*x = AcknowledgeDisastersResponse{}
-	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[6]
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -600,7 +1907,7 @@ func (x *AcknowledgeDisastersResponse) String() string {
 func (*AcknowledgeDisastersResponse) ProtoMessage() {}
 
 func (x *AcknowledgeDisastersResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[6]
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -613,7 +1920,7 @@ func (x *AcknowledgeDisastersResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AcknowledgeDisastersResponse.ProtoReflect.Descriptor instead.
 func (*AcknowledgeDisastersResponse) Descriptor() ([]byte, []int) {
-	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{6}
+	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *AcknowledgeDisastersResponse) GetAcknowledgedCount() int64 {
@@ -623,13 +1930,141 @@ func (x *AcknowledgeDisastersResponse) GetAcknowledgedCount() int64 {
 	return 0
 }
 
+type StreamGeofenceAlertsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Owner         string                 `protobuf:"bytes,1,opt,name=owner,proto3" json:"owner,omitempty"` // Optional: if set, must be the owner of the stream's token (e.g., "slack")
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamGeofenceAlertsRequest) Reset() {
+	*x = StreamGeofenceAlertsRequest{}
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamGeofenceAlertsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamGeofenceAlertsRequest) ProtoMessage() {}
+
+func (x *StreamGeofenceAlertsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamGeofenceAlertsRequest.ProtoReflect.Descriptor instead.
+func (*StreamGeofenceAlertsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *StreamGeofenceAlertsRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+type GeofenceAlert struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	GeofenceId    string                 `protobuf:"bytes,2,opt,name=geofence_id,json=geofenceId,proto3" json:"geofence_id,omitempty"`
+	GeofenceName  string                 `protobuf:"bytes,3,opt,name=geofence_name,json=geofenceName,proto3" json:"geofence_name,omitempty"`
+	Severity      string                 `protobuf:"bytes,4,opt,name=severity,proto3" json:"severity,omitempty"`                     // LOW, MODERATE, HIGH or CRITICAL
+	CreatedAt     int64                  `protobuf:"varint,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // Unix timestamp
+	Disaster      *Disaster              `protobuf:"bytes,6,opt,name=disaster,proto3" json:"disaster,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GeofenceAlert) Reset() {
+	*x = GeofenceAlert{}
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GeofenceAlert) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GeofenceAlert) ProtoMessage() {}
+
+func (x *GeofenceAlert) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_disasters_v1_disasters_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GeofenceAlert.ProtoReflect.Descriptor instead.
+func (*GeofenceAlert) Descriptor() ([]byte, []int) {
+	return file_proto_disasters_v1_disasters_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GeofenceAlert) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *GeofenceAlert) GetGeofenceId() string {
+	if x != nil {
+		return x.GeofenceId
+	}
+	return ""
+}
+
+func (x *GeofenceAlert) GetGeofenceName() string {
+	if x != nil {
+		return x.GeofenceName
+	}
+	return ""
+}
+
+func (x *GeofenceAlert) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *GeofenceAlert) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *GeofenceAlert) GetDisaster() *Disaster {
+	if x != nil {
+		return x.Disaster
+	}
+	return nil
+}
+
 var File_proto_disasters_v1_disasters_proto protoreflect.FileDescriptor
 
 const file_proto_disasters_v1_disasters_proto_rawDesc = "" +
 	"\n" +
 	"\"proto/disasters/v1/disasters.proto\x12\fdisasters.v1\"$\n" +
 	"\x12GetDisasterRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"\xcf\x03\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xfd\x04\n" +
 	"\bDisaster\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
 	"\x06source\x18\x02 \x01(\tR\x06source\x12.\n" +
@@ -646,40 +2081,153 @@ const file_proto_disasters_v1_disasters_proto_rawDesc = "" +
 	"\x13affected_population\x18\v \x01(\tR\x12affectedPopulation\x12\x1d\n" +
 	"\n" +
 	"report_url\x18\f \x01(\tR\treportUrl\x12:\n" +
-	"\x19affected_population_count\x18\r \x01(\x03R\x17affectedPopulationCount\"\x99\x04\n" +
+	"\x19affected_population_count\x18\r \x01(\x03R\x17affectedPopulationCount\x12\x10\n" +
+	"\x03seq\x18\x0e \x01(\x03R\x03seq\x12)\n" +
+	"\x03gap\x18\x0f \x01(\v2\x17.disasters.v1.StreamGapR\x03gap\x12\x1f\n" +
+	"\vcountry_iso\x18\x10 \x01(\tR\n" +
+	"countryIso\x12$\n" +
+	"\vdistance_km\x18\x11 \x01(\x01H\x00R\n" +
+	"distanceKm\x88\x01\x01\x12\x18\n" +
+	"\asnippet\x18\x12 \x01(\tR\asnippetB\x0e\n" +
+	"\f_distance_km\"\x9d\x01\n" +
+	"\vBoundingBox\x12!\n" +
+	"\fmin_latitude\x18\x01 \x01(\x01R\vminLatitude\x12#\n" +
+	"\rmin_longitude\x18\x02 \x01(\x01R\fminLongitude\x12!\n" +
+	"\fmax_latitude\x18\x03 \x01(\x01R\vmaxLatitude\x12#\n" +
+	"\rmax_longitude\x18\x04 \x01(\x01R\fmaxLongitude\"b\n" +
+	"\tGeoRadius\x12\x1a\n" +
+	"\blatitude\x18\x01 \x01(\x01R\blatitude\x12\x1c\n" +
+	"\tlongitude\x18\x02 \x01(\x01R\tlongitude\x12\x1b\n" +
+	"\tradius_km\x18\x03 \x01(\x01R\bradiusKm\"[\n" +
+	"\tStreamGap\x12\x16\n" +
+	"\x06missed\x18\x01 \x01(\x03R\x06missed\x12\x1b\n" +
+	"\tfirst_seq\x18\x02 \x01(\x03R\bfirstSeq\x12\x19\n" +
+	"\blast_seq\x18\x03 \x01(\x03R\alastSeq\"\"\n" +
+	"\bRejected\x12\x16\n" +
+	"\x06reason\x18\x01 \x01(\tR\x06reason\"\xfc\x02\n" +
+	"\rDisasterEvent\x12+\n" +
+	"\x04kind\x18\x01 \x01(\x0e2\x17.disasters.v1.EventKindR\x04kind\x12\x10\n" +
+	"\x03seq\x18\x02 \x01(\x03R\x03seq\x12$\n" +
+	"\x0eserver_time_ms\x18\x03 \x01(\x03R\fserverTimeMs\x124\n" +
+	"\bdisaster\x18\x04 \x01(\v2\x16.disasters.v1.DisasterH\x00R\bdisaster\x127\n" +
+	"\theartbeat\x18\x05 \x01(\v2\x17.disasters.v1.HeartbeatH\x00R\theartbeat\x12+\n" +
+	"\x03gap\x18\x06 \x01(\v2\x17.disasters.v1.StreamGapH\x00R\x03gap\x124\n" +
+	"\brejected\x18\b \x01(\v2\x16.disasters.v1.RejectedH\x00R\brejected\x12)\n" +
+	"\x10delivery_attempt\x18\a \x01(\x05R\x0fdeliveryAttemptB\t\n" +
+	"\apayload\"\x84\x01\n" +
+	"\x10SubscribeRequest\x12>\n" +
+	"\x06filter\x18\x01 \x01(\v2$.disasters.v1.StreamDisastersRequestH\x00R\x06filter\x12%\n" +
+	"\x03ack\x18\x02 \x01(\v2\x11.disasters.v1.AckH\x00R\x03ackB\t\n" +
+	"\arequest\"\x19\n" +
+	"\x03Ack\x12\x12\n" +
+	"\x04seqs\x18\x01 \x03(\x03R\x04seqs\"6\n" +
+	"\tHeartbeat\x12)\n" +
+	"\x10interval_seconds\x18\x01 \x01(\x03R\x0fintervalSeconds\"\xea\b\n" +
 	"\x14ListDisastersRequest\x12\x14\n" +
 	"\x05limit\x18\x01 \x01(\x05R\x05limit\x123\n" +
 	"\x04type\x18\x02 \x01(\x0e2\x1a.disasters.v1.DisasterTypeH\x00R\x04type\x88\x01\x01\x12(\n" +
 	"\rmin_magnitude\x18\x03 \x01(\x01H\x01R\fminMagnitude\x88\x01\x01\x12>\n" +
 	"\valert_level\x18\x04 \x01(\x0e2\x18.disasters.v1.AlertLevelH\x02R\n" +
 	"alertLevel\x88\x01\x01\x12E\n" +
-	"\x0fmin_alert_level\x18\x05 \x01(\x0e2\x18.disasters.v1.AlertLevelH\x03R\rminAlertLevel\x88\x01\x01\x12&\n" +
-	"\fdiscord_sent\x18\x06 \x01(\bH\x04R\vdiscordSent\x88\x01\x01\x12\x19\n" +
+	"\x0fmin_alert_level\x18\x05 \x01(\x0e2\x18.disasters.v1.AlertLevelH\x03R\rminAlertLevel\x88\x01\x01\x12*\n" +
+	"\fdiscord_sent\x18\x06 \x01(\bB\x02\x18\x01H\x04R\vdiscordSent\x88\x01\x01\x12\x19\n" +
 	"\x05since\x18\a \x01(\x03H\x05R\x05since\x88\x01\x01\x12F\n" +
-	"\x1dmin_affected_population_count\x18\b \x01(\x03H\x06R\x1aminAffectedPopulationCount\x88\x01\x01B\a\n" +
+	"\x1dmin_affected_population_count\x18\b \x01(\x03H\x06R\x1aminAffectedPopulationCount\x88\x01\x01\x12\x1f\n" +
+	"\vconsumer_id\x18\t \x01(\tR\n" +
+	"consumerId\x12!\n" +
+	"\tdelivered\x18\n" +
+	" \x01(\bH\aR\tdelivered\x88\x01\x01\x120\n" +
+	"\x05types\x18\v \x03(\x0e2\x1a.disasters.v1.DisasterTypeR\x05types\x12\x1c\n" +
+	"\tcountries\x18\f \x03(\tR\tcountries\x12-\n" +
+	"\x04bbox\x18\r \x01(\v2\x19.disasters.v1.BoundingBoxR\x04bbox\x12+\n" +
+	"\x04near\x18\x0e \x01(\v2\x17.disasters.v1.GeoRadiusR\x04near\x12\x14\n" +
+	"\x05query\x18\x0f \x01(\tR\x05query\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x10 \x01(\tR\tpageToken\x12\x19\n" +
+	"\x05until\x18\x11 \x01(\x03H\bR\x05until\x88\x01\x01\x12\x18\n" +
+	"\asources\x18\x12 \x03(\tR\asources\x12(\n" +
+	"\rmax_magnitude\x18\x13 \x01(\x01H\tR\fmaxMagnitude\x88\x01\x01\x126\n" +
+	"\n" +
+	"time_field\x18\x14 \x01(\x0e2\x17.disasters.v1.TimeFieldR\ttimeField\x12-\n" +
+	"\asort_by\x18\x15 \x01(\x0e2\x14.disasters.v1.SortByR\x06sortBy\x126\n" +
+	"\n" +
+	"sort_order\x18\x16 \x01(\x0e2\x17.disasters.v1.SortOrderR\tsortOrderB\a\n" +
 	"\x05_typeB\x10\n" +
 	"\x0e_min_magnitudeB\x0e\n" +
 	"\f_alert_levelB\x12\n" +
 	"\x10_min_alert_levelB\x0f\n" +
 	"\r_discord_sentB\b\n" +
 	"\x06_sinceB \n" +
-	"\x1e_min_affected_population_count\"M\n" +
+	"\x1e_min_affected_population_countB\f\n" +
+	"\n" +
+	"_deliveredB\b\n" +
+	"\x06_untilB\x10\n" +
+	"\x0e_max_magnitude\"u\n" +
 	"\x15ListDisastersResponse\x124\n" +
-	"\tdisasters\x18\x01 \x03(\v2\x16.disasters.v1.DisasterR\tdisasters\"\xbd\x02\n" +
+	"\tdisasters\x18\x01 \x03(\v2\x16.disasters.v1.DisasterR\tdisasters\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\x7f\n" +
+	"\x0fGetStatsRequest\x12:\n" +
+	"\x06filter\x18\x01 \x01(\v2\".disasters.v1.ListDisastersRequestR\x06filter\x120\n" +
+	"\x06bucket\x18\x02 \x01(\x0e2\x18.disasters.v1.TimeBucketR\x06bucket\"\x95\x01\n" +
+	"\n" +
+	"StatsGroup\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\x12#\n" +
+	"\rmax_magnitude\x18\x03 \x01(\x01R\fmaxMagnitude\x12:\n" +
+	"\x19total_affected_population\x18\x04 \x01(\x03R\x17totalAffectedPopulation\"\x8a\x03\n" +
+	"\x10GetStatsResponse\x12.\n" +
+	"\x05total\x18\x01 \x01(\v2\x18.disasters.v1.StatsGroupR\x05total\x120\n" +
+	"\x06bucket\x18\x02 \x01(\x0e2\x18.disasters.v1.TimeBucketR\x06bucket\x121\n" +
+	"\aby_type\x18\x03 \x03(\v2\x18.disasters.v1.StatsGroupR\x06byType\x12>\n" +
+	"\x0eby_alert_level\x18\x04 \x03(\v2\x18.disasters.v1.StatsGroupR\fbyAlertLevel\x127\n" +
+	"\n" +
+	"by_country\x18\x05 \x03(\v2\x18.disasters.v1.StatsGroupR\tbyCountry\x125\n" +
+	"\tby_source\x18\x06 \x03(\v2\x18.disasters.v1.StatsGroupR\bbySource\x121\n" +
+	"\aby_time\x18\a \x03(\v2\x18.disasters.v1.StatsGroupR\x06byTime\"\xba\x06\n" +
 	"\x16StreamDisastersRequest\x123\n" +
 	"\x04type\x18\x01 \x01(\x0e2\x1a.disasters.v1.DisasterTypeH\x00R\x04type\x88\x01\x01\x12(\n" +
 	"\rmin_magnitude\x18\x02 \x01(\x01H\x01R\fminMagnitude\x88\x01\x01\x12>\n" +
 	"\valert_level\x18\x03 \x01(\x0e2\x18.disasters.v1.AlertLevelH\x02R\n" +
 	"alertLevel\x88\x01\x01\x12E\n" +
-	"\x0fmin_alert_level\x18\x04 \x01(\x0e2\x18.disasters.v1.AlertLevelH\x03R\rminAlertLevel\x88\x01\x01B\a\n" +
+	"\x0fmin_alert_level\x18\x04 \x01(\x0e2\x18.disasters.v1.AlertLevelH\x03R\rminAlertLevel\x88\x01\x01\x12&\n" +
+	"\fresume_after\x18\x05 \x01(\x03H\x04R\vresumeAfter\x88\x01\x01\x12\x1f\n" +
+	"\vconsumer_id\x18\x06 \x01(\tR\n" +
+	"consumerId\x12\x14\n" +
+	"\x05group\x18\a \x01(\tR\x05group\x120\n" +
+	"\x05types\x18\b \x03(\x0e2\x1a.disasters.v1.DisasterTypeR\x05types\x12\x1c\n" +
+	"\tcountries\x18\t \x03(\tR\tcountries\x12F\n" +
+	"\x1dmin_affected_population_count\x18\n" +
+	" \x01(\x03H\x05R\x1aminAffectedPopulationCount\x88\x01\x01\x12-\n" +
+	"\x04bbox\x18\v \x01(\v2\x19.disasters.v1.BoundingBoxR\x04bbox\x12+\n" +
+	"\x04near\x18\f \x01(\v2\x17.disasters.v1.GeoRadiusR\x04near\x12\x18\n" +
+	"\asources\x18\r \x03(\tR\asources\x12(\n" +
+	"\rmax_magnitude\x18\x0e \x01(\x01H\x06R\fmaxMagnitude\x88\x01\x01\x12\x1f\n" +
+	"\vreport_gaps\x18\x0f \x01(\bR\n" +
+	"reportGapsB\a\n" +
 	"\x05_typeB\x10\n" +
 	"\x0e_min_magnitudeB\x0e\n" +
 	"\f_alert_levelB\x12\n" +
-	"\x10_min_alert_level\"/\n" +
+	"\x10_min_alert_levelB\x0f\n" +
+	"\r_resume_afterB \n" +
+	"\x1e_min_affected_population_countB\x10\n" +
+	"\x0e_max_magnitude\"P\n" +
 	"\x1bAcknowledgeDisastersRequest\x12\x10\n" +
-	"\x03ids\x18\x01 \x03(\tR\x03ids\"M\n" +
+	"\x03ids\x18\x01 \x03(\tR\x03ids\x12\x1f\n" +
+	"\vconsumer_id\x18\x02 \x01(\tR\n" +
+	"consumerId\"M\n" +
 	"\x1cAcknowledgeDisastersResponse\x12-\n" +
-	"\x12acknowledged_count\x18\x01 \x01(\x03R\x11acknowledgedCount*|\n" +
+	"\x12acknowledged_count\x18\x01 \x01(\x03R\x11acknowledgedCount\"3\n" +
+	"\x1bStreamGeofenceAlertsRequest\x12\x14\n" +
+	"\x05owner\x18\x01 \x01(\tR\x05owner\"\xd4\x01\n" +
+	"\rGeofenceAlert\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1f\n" +
+	"\vgeofence_id\x18\x02 \x01(\tR\n" +
+	"geofenceId\x12#\n" +
+	"\rgeofence_name\x18\x03 \x01(\tR\fgeofenceName\x12\x1a\n" +
+	"\bseverity\x18\x04 \x01(\tR\bseverity\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\x03R\tcreatedAt\x122\n" +
+	"\bdisaster\x18\x06 \x01(\v2\x16.disasters.v1.DisasterR\bdisaster*|\n" +
 	"\fDisasterType\x12\x0f\n" +
 	"\vUNSPECIFIED\x10\x00\x12\x0e\n" +
 	"\n" +
@@ -696,12 +2244,47 @@ const file_proto_disasters_v1_disasters_proto_rawDesc = "" +
 	"\x05GREEN\x10\x01\x12\n" +
 	"\n" +
 	"\x06ORANGE\x10\x02\x12\a\n" +
-	"\x03RED\x10\x032\xf6\x02\n" +
+	"\x03RED\x10\x03*\xcf\x01\n" +
+	"\tEventKind\x12\x1a\n" +
+	"\x16EVENT_KIND_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12EVENT_KIND_CREATED\x10\x01\x12\x16\n" +
+	"\x12EVENT_KIND_UPDATED\x10\x02\x12\x18\n" +
+	"\x14EVENT_KIND_ESCALATED\x10\x03\x12\x15\n" +
+	"\x11EVENT_KIND_CLOSED\x10\x04\x12\x18\n" +
+	"\x14EVENT_KIND_HEARTBEAT\x10\x05\x12\x12\n" +
+	"\x0eEVENT_KIND_GAP\x10\x06\x12\x17\n" +
+	"\x13EVENT_KIND_REJECTED\x10\a*U\n" +
+	"\tTimeField\x12\x1a\n" +
+	"\x16TIME_FIELD_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10TIME_FIELD_EVENT\x10\x01\x12\x16\n" +
+	"\x12TIME_FIELD_CREATED\x10\x02*\xa8\x01\n" +
+	"\x06SortBy\x12\x17\n" +
+	"\x13SORT_BY_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fSORT_BY_TIME\x10\x01\x12\x15\n" +
+	"\x11SORT_BY_MAGNITUDE\x10\x02\x12\x17\n" +
+	"\x13SORT_BY_ALERT_LEVEL\x10\x03\x12\x16\n" +
+	"\x12SORT_BY_POPULATION\x10\x04\x12\x14\n" +
+	"\x10SORT_BY_DISTANCE\x10\x05\x12\x15\n" +
+	"\x11SORT_BY_RELEVANCE\x10\x06*P\n" +
+	"\tSortOrder\x12\x1a\n" +
+	"\x16SORT_ORDER_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eSORT_ORDER_ASC\x10\x01\x12\x13\n" +
+	"\x0fSORT_ORDER_DESC\x10\x02*k\n" +
+	"\n" +
+	"TimeBucket\x12\x1b\n" +
+	"\x17TIME_BUCKET_UNSPECIFIED\x10\x00\x12\x13\n" +
+	"\x0fTIME_BUCKET_DAY\x10\x01\x12\x14\n" +
+	"\x10TIME_BUCKET_WEEK\x10\x02\x12\x15\n" +
+	"\x11TIME_BUCKET_MONTH\x10\x032\xce\x05\n" +
 	"\x0fDisasterService\x12G\n" +
 	"\vGetDisaster\x12 .disasters.v1.GetDisasterRequest\x1a\x16.disasters.v1.Disaster\x12X\n" +
 	"\rListDisasters\x12\".disasters.v1.ListDisastersRequest\x1a#.disasters.v1.ListDisastersResponse\x12Q\n" +
-	"\x0fStreamDisasters\x12$.disasters.v1.StreamDisastersRequest\x1a\x16.disasters.v1.Disaster0\x01\x12m\n" +
-	"\x14AcknowledgeDisasters\x12).disasters.v1.AcknowledgeDisastersRequest\x1a*.disasters.v1.AcknowledgeDisastersResponseB6Z4github.com/mr1hm/go-disaster-alerts/gen/disasters/v1b\x06proto3"
+	"\x0fStreamDisasters\x12$.disasters.v1.StreamDisastersRequest\x1a\x16.disasters.v1.Disaster0\x01\x12[\n" +
+	"\x14StreamDisasterEvents\x12$.disasters.v1.StreamDisastersRequest\x1a\x1b.disasters.v1.DisasterEvent0\x01\x12L\n" +
+	"\tSubscribe\x12\x1e.disasters.v1.SubscribeRequest\x1a\x1b.disasters.v1.DisasterEvent(\x010\x01\x12`\n" +
+	"\x14StreamGeofenceAlerts\x12).disasters.v1.StreamGeofenceAlertsRequest\x1a\x1b.disasters.v1.GeofenceAlert0\x01\x12m\n" +
+	"\x14AcknowledgeDisasters\x12).disasters.v1.AcknowledgeDisastersRequest\x1a*.disasters.v1.AcknowledgeDisastersResponse\x12I\n" +
+	"\bGetStats\x12\x1d.disasters.v1.GetStatsRequest\x1a\x1e.disasters.v1.GetStatsResponseB6Z4github.com/mr1hm/go-disaster-alerts/gen/disasters/v1b\x06proto3"
 
 var (
 	file_proto_disasters_v1_disasters_proto_rawDescOnce sync.Once
@@ -715,42 +2298,95 @@ func file_proto_disasters_v1_disasters_proto_rawDescGZIP() []byte {
 	return file_proto_disasters_v1_disasters_proto_rawDescData
 }
 
-var file_proto_disasters_v1_disasters_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_proto_disasters_v1_disasters_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_proto_disasters_v1_disasters_proto_enumTypes = make([]protoimpl.EnumInfo, 7)
+var file_proto_disasters_v1_disasters_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
 var file_proto_disasters_v1_disasters_proto_goTypes = []any{
 	(DisasterType)(0),                    // 0: disasters.v1.DisasterType
 	(AlertLevel)(0),                      // 1: disasters.v1.AlertLevel
-	(*GetDisasterRequest)(nil),           // 2: disasters.v1.GetDisasterRequest
-	(*Disaster)(nil),                     // 3: disasters.v1.Disaster
-	(*ListDisastersRequest)(nil),         // 4: disasters.v1.ListDisastersRequest
-	(*ListDisastersResponse)(nil),        // 5: disasters.v1.ListDisastersResponse
-	(*StreamDisastersRequest)(nil),       // 6: disasters.v1.StreamDisastersRequest
-	(*AcknowledgeDisastersRequest)(nil),  // 7: disasters.v1.AcknowledgeDisastersRequest
-	(*AcknowledgeDisastersResponse)(nil), // 8: disasters.v1.AcknowledgeDisastersResponse
+	(EventKind)(0),                       // 2: disasters.v1.EventKind
+	(TimeField)(0),                       // 3: disasters.v1.TimeField
+	(SortBy)(0),                          // 4: disasters.v1.SortBy
+	(SortOrder)(0),                       // 5: disasters.v1.SortOrder
+	(TimeBucket)(0),                      // 6: disasters.v1.TimeBucket
+	(*GetDisasterRequest)(nil),           // 7: disasters.v1.GetDisasterRequest
+	(*Disaster)(nil),                     // 8: disasters.v1.Disaster
+	(*BoundingBox)(nil),                  // 9: disasters.v1.BoundingBox
+	(*GeoRadius)(nil),                    // 10: disasters.v1.GeoRadius
+	(*StreamGap)(nil),                    // 11: disasters.v1.StreamGap
+	(*Rejected)(nil),                     // 12: disasters.v1.Rejected
+	(*DisasterEvent)(nil),                // 13: disasters.v1.DisasterEvent
+	(*SubscribeRequest)(nil),             // 14: disasters.v1.SubscribeRequest
+	(*Ack)(nil),                          // 15: disasters.v1.Ack
+	(*Heartbeat)(nil),                    // 16: disasters.v1.Heartbeat
+	(*ListDisastersRequest)(nil),         // 17: disasters.v1.ListDisastersRequest
+	(*ListDisastersResponse)(nil),        // 18: disasters.v1.ListDisastersResponse
+	(*GetStatsRequest)(nil),              // 19: disasters.v1.GetStatsRequest
+	(*StatsGroup)(nil),                   // 20: disasters.v1.StatsGroup
+	(*GetStatsResponse)(nil),             // 21: disasters.v1.GetStatsResponse
+	(*StreamDisastersRequest)(nil),       // 22: disasters.v1.StreamDisastersRequest
+	(*AcknowledgeDisastersRequest)(nil),  // 23: disasters.v1.AcknowledgeDisastersRequest
+	(*AcknowledgeDisastersResponse)(nil), // 24: disasters.v1.AcknowledgeDisastersResponse
+	(*StreamGeofenceAlertsRequest)(nil),  // 25: disasters.v1.StreamGeofenceAlertsRequest
+	(*GeofenceAlert)(nil),                // 26: disasters.v1.GeofenceAlert
 }
 var file_proto_disasters_v1_disasters_proto_depIdxs = []int32{
 	0,  // 0: disasters.v1.Disaster.type:type_name -> disasters.v1.DisasterType
 	1,  // 1: disasters.v1.Disaster.alert_level:type_name -> disasters.v1.AlertLevel
-	0,  // 2: disasters.v1.ListDisastersRequest.type:type_name -> disasters.v1.DisasterType
-	1,  // 3: disasters.v1.ListDisastersRequest.alert_level:type_name -> disasters.v1.AlertLevel
-	1,  // 4: disasters.v1.ListDisastersRequest.min_alert_level:type_name -> disasters.v1.AlertLevel
-	3,  // 5: disasters.v1.ListDisastersResponse.disasters:type_name -> disasters.v1.Disaster
-	0,  // 6: disasters.v1.StreamDisastersRequest.type:type_name -> disasters.v1.DisasterType
-	1,  // 7: disasters.v1.StreamDisastersRequest.alert_level:type_name -> disasters.v1.AlertLevel
-	1,  // 8: disasters.v1.StreamDisastersRequest.min_alert_level:type_name -> disasters.v1.AlertLevel
-	2,  // 9: disasters.v1.DisasterService.GetDisaster:input_type -> disasters.v1.GetDisasterRequest
-	4,  // 10: disasters.v1.DisasterService.ListDisasters:input_type -> disasters.v1.ListDisastersRequest
-	6,  // 11: disasters.v1.DisasterService.StreamDisasters:input_type -> disasters.v1.StreamDisastersRequest
-	7,  // 12: disasters.v1.DisasterService.AcknowledgeDisasters:input_type -> disasters.v1.AcknowledgeDisastersRequest
-	3,  // 13: disasters.v1.DisasterService.GetDisaster:output_type -> disasters.v1.Disaster
-	5,  // 14: disasters.v1.DisasterService.ListDisasters:output_type -> disasters.v1.ListDisastersResponse
-	3,  // 15: disasters.v1.DisasterService.StreamDisasters:output_type -> disasters.v1.Disaster
-	8,  // 16: disasters.v1.DisasterService.AcknowledgeDisasters:output_type -> disasters.v1.AcknowledgeDisastersResponse
-	13, // [13:17] is the sub-list for method output_type
-	9,  // [9:13] is the sub-list for method input_type
-	9,  // [9:9] is the sub-list for extension type_name
-	9,  // [9:9] is the sub-list for extension extendee
-	0,  // [0:9] is the sub-list for field type_name
+	11, // 2: disasters.v1.Disaster.gap:type_name -> disasters.v1.StreamGap
+	2,  // 3: disasters.v1.DisasterEvent.kind:type_name -> disasters.v1.EventKind
+	8,  // 4: disasters.v1.DisasterEvent.disaster:type_name -> disasters.v1.Disaster
+	16, // 5: disasters.v1.DisasterEvent.heartbeat:type_name -> disasters.v1.Heartbeat
+	11, // 6: disasters.v1.DisasterEvent.gap:type_name -> disasters.v1.StreamGap
+	12, // 7: disasters.v1.DisasterEvent.rejected:type_name -> disasters.v1.Rejected
+	22, // 8: disasters.v1.SubscribeRequest.filter:type_name -> disasters.v1.StreamDisastersRequest
+	15, // 9: disasters.v1.SubscribeRequest.ack:type_name -> disasters.v1.Ack
+	0,  // 10: disasters.v1.ListDisastersRequest.type:type_name -> disasters.v1.DisasterType
+	1,  // 11: disasters.v1.ListDisastersRequest.alert_level:type_name -> disasters.v1.AlertLevel
+	1,  // 12: disasters.v1.ListDisastersRequest.min_alert_level:type_name -> disasters.v1.AlertLevel
+	0,  // 13: disasters.v1.ListDisastersRequest.types:type_name -> disasters.v1.DisasterType
+	9,  // 14: disasters.v1.ListDisastersRequest.bbox:type_name -> disasters.v1.BoundingBox
+	10, // 15: disasters.v1.ListDisastersRequest.near:type_name -> disasters.v1.GeoRadius
+	3,  // 16: disasters.v1.ListDisastersRequest.time_field:type_name -> disasters.v1.TimeField
+	4,  // 17: disasters.v1.ListDisastersRequest.sort_by:type_name -> disasters.v1.SortBy
+	5,  // 18: disasters.v1.ListDisastersRequest.sort_order:type_name -> disasters.v1.SortOrder
+	8,  // 19: disasters.v1.ListDisastersResponse.disasters:type_name -> disasters.v1.Disaster
+	17, // 20: disasters.v1.GetStatsRequest.filter:type_name -> disasters.v1.ListDisastersRequest
+	6,  // 21: disasters.v1.GetStatsRequest.bucket:type_name -> disasters.v1.TimeBucket
+	20, // 22: disasters.v1.GetStatsResponse.total:type_name -> disasters.v1.StatsGroup
+	6,  // 23: disasters.v1.GetStatsResponse.bucket:type_name -> disasters.v1.TimeBucket
+	20, // 24: disasters.v1.GetStatsResponse.by_type:type_name -> disasters.v1.StatsGroup
+	20, // 25: disasters.v1.GetStatsResponse.by_alert_level:type_name -> disasters.v1.StatsGroup
+	20, // 26: disasters.v1.GetStatsResponse.by_country:type_name -> disasters.v1.StatsGroup
+	20, // 27: disasters.v1.GetStatsResponse.by_source:type_name -> disasters.v1.StatsGroup
+	20, // 28: disasters.v1.GetStatsResponse.by_time:type_name -> disasters.v1.StatsGroup
+	0,  // 29: disasters.v1.StreamDisastersRequest.type:type_name -> disasters.v1.DisasterType
+	1,  // 30: disasters.v1.StreamDisastersRequest.alert_level:type_name -> disasters.v1.AlertLevel
+	1,  // 31: disasters.v1.StreamDisastersRequest.min_alert_level:type_name -> disasters.v1.AlertLevel
+	0,  // 32: disasters.v1.StreamDisastersRequest.types:type_name -> disasters.v1.DisasterType
+	9,  // 33: disasters.v1.StreamDisastersRequest.bbox:type_name -> disasters.v1.BoundingBox
+	10, // 34: disasters.v1.StreamDisastersRequest.near:type_name -> disasters.v1.GeoRadius
+	8,  // 35: disasters.v1.GeofenceAlert.disaster:type_name -> disasters.v1.Disaster
+	7,  // 36: disasters.v1.DisasterService.GetDisaster:input_type -> disasters.v1.GetDisasterRequest
+	17, // 37: disasters.v1.DisasterService.ListDisasters:input_type -> disasters.v1.ListDisastersRequest
+	22, // 38: disasters.v1.DisasterService.StreamDisasters:input_type -> disasters.v1.StreamDisastersRequest
+	22, // 39: disasters.v1.DisasterService.StreamDisasterEvents:input_type -> disasters.v1.StreamDisastersRequest
+	14, // 40: disasters.v1.DisasterService.Subscribe:input_type -> disasters.v1.SubscribeRequest
+	25, // 41: disasters.v1.DisasterService.StreamGeofenceAlerts:input_type -> disasters.v1.StreamGeofenceAlertsRequest
+	23, // 42: disasters.v1.DisasterService.AcknowledgeDisasters:input_type -> disasters.v1.AcknowledgeDisastersRequest
+	19, // 43: disasters.v1.DisasterService.GetStats:input_type -> disasters.v1.GetStatsRequest
+	8,  // 44: disasters.v1.DisasterService.GetDisaster:output_type -> disasters.v1.Disaster
+	18, // 45: disasters.v1.DisasterService.ListDisasters:output_type -> disasters.v1.ListDisastersResponse
+	8,  // 46: disasters.v1.DisasterService.StreamDisasters:output_type -> disasters.v1.Disaster
+	13, // 47: disasters.v1.DisasterService.StreamDisasterEvents:output_type -> disasters.v1.DisasterEvent
+	13, // 48: disasters.v1.DisasterService.Subscribe:output_type -> disasters.v1.DisasterEvent
+	26, // 49: disasters.v1.DisasterService.StreamGeofenceAlerts:output_type -> disasters.v1.GeofenceAlert
+	24, // 50: disasters.v1.DisasterService.AcknowledgeDisasters:output_type -> disasters.v1.AcknowledgeDisastersResponse
+	21, // 51: disasters.v1.DisasterService.GetStats:output_type -> disasters.v1.GetStatsResponse
+	44, // [44:52] is the sub-list for method output_type
+	36, // [36:44] is the sub-list for method input_type
+	36, // [36:36] is the sub-list for extension type_name
+	36, // [36:36] is the sub-list for extension extendee
+	0,  // [0:36] is the sub-list for field type_name
 }
 
 func init() { file_proto_disasters_v1_disasters_proto_init() }
@@ -758,15 +2394,26 @@ func file_proto_disasters_v1_disasters_proto_init() {
 	if File_proto_disasters_v1_disasters_proto != nil {
 		return
 	}
-	file_proto_disasters_v1_disasters_proto_msgTypes[2].OneofWrappers = []any{}
-	file_proto_disasters_v1_disasters_proto_msgTypes[4].OneofWrappers = []any{}
+	file_proto_disasters_v1_disasters_proto_msgTypes[1].OneofWrappers = []any{}
+	file_proto_disasters_v1_disasters_proto_msgTypes[6].OneofWrappers = []any{
+		(*DisasterEvent_Disaster)(nil),
+		(*DisasterEvent_Heartbeat)(nil),
+		(*DisasterEvent_Gap)(nil),
+		(*DisasterEvent_Rejected)(nil),
+	}
+	file_proto_disasters_v1_disasters_proto_msgTypes[7].OneofWrappers = []any{
+		(*SubscribeRequest_Filter)(nil),
+		(*SubscribeRequest_Ack)(nil),
+	}
+	file_proto_disasters_v1_disasters_proto_msgTypes[10].OneofWrappers = []any{}
+	file_proto_disasters_v1_disasters_proto_msgTypes[15].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_disasters_v1_disasters_proto_rawDesc), len(file_proto_disasters_v1_disasters_proto_rawDesc)),
-			NumEnums:      2,
-			NumMessages:   7,
+			NumEnums:      7,
+			NumMessages:   20,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -22,7 +22,11 @@ const (
 	DisasterService_GetDisaster_FullMethodName          = "/disasters.v1.DisasterService/GetDisaster"
 	DisasterService_ListDisasters_FullMethodName        = "/disasters.v1.DisasterService/ListDisasters"
 	DisasterService_StreamDisasters_FullMethodName      = "/disasters.v1.DisasterService/StreamDisasters"
+	DisasterService_StreamDisasterEvents_FullMethodName = "/disasters.v1.DisasterService/StreamDisasterEvents"
+	DisasterService_Subscribe_FullMethodName            = "/disasters.v1.DisasterService/Subscribe"
+	DisasterService_StreamGeofenceAlerts_FullMethodName = "/disasters.v1.DisasterService/StreamGeofenceAlerts"
 	DisasterService_AcknowledgeDisasters_FullMethodName = "/disasters.v1.DisasterService/AcknowledgeDisasters"
+	DisasterService_GetStats_FullMethodName             = "/disasters.v1.DisasterService/GetStats"
 )
 
 // DisasterServiceClient is the client API for DisasterService service.
@@ -37,9 +41,26 @@ type DisasterServiceClient interface {
 	ListDisasters(ctx context.Context, in *ListDisastersRequest, opts ...grpc.CallOption) (*ListDisastersResponse, error)
 	// StreamDisasters opens a server-side stream that pushes new significant disasters in real-time.
 	// Only streams earthquakes >= 5.0 magnitude or other disasters with orange/red alert level.
+	// Set resume_after to the last seq a client received to replay events missed while disconnected.
 	StreamDisasters(ctx context.Context, in *StreamDisastersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Disaster], error)
-	// AcknowledgeDisasters marks disasters as successfully posted to Discord.
+	// StreamDisasterEvents is the v2 stream. It wraps every message in a DisasterEvent envelope carrying the
+	// event kind, sequence number and server time, and sends periodic heartbeats while the stream is idle.
+	// Accepts the same filters and resume_after cursor as StreamDisasters.
+	StreamDisasterEvents(ctx context.Context, in *StreamDisastersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DisasterEvent], error)
+	// Subscribe is a bidirectional event stream. The first client message should be a filter (including
+	// resume_after); later filter messages replace the active filters without reconnecting. Clients ack
+	// each event by seq on the same stream, and unacked events are redelivered after the ack timeout.
+	// Acks are recorded for the filter's consumer_id, as with AcknowledgeDisasters.
+	Subscribe(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[SubscribeRequest, DisasterEvent], error)
+	// StreamGeofenceAlerts streams alerts raised by the owner's geofences as matching disasters are stored.
+	// The owner is authenticated by an "authorization: Bearer <token>" metadata entry (GEOFENCE_TOKENS).
+	// Alerts are also persisted and listed at GET /api/geofences/{id}/alerts for backfill.
+	StreamGeofenceAlerts(ctx context.Context, in *StreamGeofenceAlertsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GeofenceAlert], error)
+	// AcknowledgeDisasters records that a consumer (Discord bot, Slack bot, SMS relay, ...) has delivered disasters.
 	AcknowledgeDisasters(ctx context.Context, in *AcknowledgeDisastersRequest, opts ...grpc.CallOption) (*AcknowledgeDisastersResponse, error)
+	// GetStats aggregates the disasters matching a filter, grouped by type, alert level, country, source
+	// and time bucket, with the count, maximum magnitude and total affected population of each group.
+	GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error)
 }
 
 type disasterServiceClient struct {
@@ -89,6 +110,57 @@ func (c *disasterServiceClient) StreamDisasters(ctx context.Context, in *StreamD
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type DisasterService_StreamDisastersClient = grpc.ServerStreamingClient[Disaster]
 
+func (c *disasterServiceClient) StreamDisasterEvents(ctx context.Context, in *StreamDisastersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DisasterEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DisasterService_ServiceDesc.Streams[1], DisasterService_StreamDisasterEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamDisastersRequest, DisasterEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DisasterService_StreamDisasterEventsClient = grpc.ServerStreamingClient[DisasterEvent]
+
+func (c *disasterServiceClient) Subscribe(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[SubscribeRequest, DisasterEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DisasterService_ServiceDesc.Streams[2], DisasterService_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, DisasterEvent]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DisasterService_SubscribeClient = grpc.BidiStreamingClient[SubscribeRequest, DisasterEvent]
+
+func (c *disasterServiceClient) StreamGeofenceAlerts(ctx context.Context, in *StreamGeofenceAlertsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GeofenceAlert], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DisasterService_ServiceDesc.Streams[3], DisasterService_StreamGeofenceAlerts_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamGeofenceAlertsRequest, GeofenceAlert]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DisasterService_StreamGeofenceAlertsClient = grpc.ServerStreamingClient[GeofenceAlert]
+
 func (c *disasterServiceClient) AcknowledgeDisasters(ctx context.Context, in *AcknowledgeDisastersRequest, opts ...grpc.CallOption) (*AcknowledgeDisastersResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(AcknowledgeDisastersResponse)
@@ -99,6 +171,16 @@ func (c *disasterServiceClient) AcknowledgeDisasters(ctx context.Context, in *Ac
 	return out, nil
 }
 
+func (c *disasterServiceClient) GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetStatsResponse)
+	err := c.cc.Invoke(ctx, DisasterService_GetStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DisasterServiceServer is the server API for DisasterService service.
 // All implementations must embed UnimplementedDisasterServiceServer
 // for forward compatibility.
@@ -111,9 +193,26 @@ type DisasterServiceServer interface {
 	ListDisasters(context.Context, *ListDisastersRequest) (*ListDisastersResponse, error)
 	// StreamDisasters opens a server-side stream that pushes new significant disasters in real-time.
 	// Only streams earthquakes >= 5.0 magnitude or other disasters with orange/red alert level.
+	// Set resume_after to the last seq a client received to replay events missed while disconnected.
 	StreamDisasters(*StreamDisastersRequest, grpc.ServerStreamingServer[Disaster]) error
-	// AcknowledgeDisasters marks disasters as successfully posted to Discord.
+	// StreamDisasterEvents is the v2 stream. It wraps every message in a DisasterEvent envelope carrying the
+	// event kind, sequence number and server time, and sends periodic heartbeats while the stream is idle.
+	// Accepts the same filters and resume_after cursor as StreamDisasters.
+	StreamDisasterEvents(*StreamDisastersRequest, grpc.ServerStreamingServer[DisasterEvent]) error
+	// Subscribe is a bidirectional event stream. The first client message should be a filter (including
+	// resume_after); later filter messages replace the active filters without reconnecting. Clients ack
+	// each event by seq on the same stream, and unacked events are redelivered after the ack timeout.
+	// Acks are recorded for the filter's consumer_id, as with AcknowledgeDisasters.
+	Subscribe(grpc.BidiStreamingServer[SubscribeRequest, DisasterEvent]) error
+	// StreamGeofenceAlerts streams alerts raised by the owner's geofences as matching disasters are stored.
+	// The owner is authenticated by an "authorization: Bearer <token>" metadata entry (GEOFENCE_TOKENS).
+	// Alerts are also persisted and listed at GET /api/geofences/{id}/alerts for backfill.
+	StreamGeofenceAlerts(*StreamGeofenceAlertsRequest, grpc.ServerStreamingServer[GeofenceAlert]) error
+	// AcknowledgeDisasters records that a consumer (Discord bot, Slack bot, SMS relay, ...) has delivered disasters.
 	AcknowledgeDisasters(context.Context, *AcknowledgeDisastersRequest) (*AcknowledgeDisastersResponse, error)
+	// GetStats aggregates the disasters matching a filter, grouped by type, alert level, country, source
+	// and time bucket, with the count, maximum magnitude and total affected population of each group.
+	GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error)
 	mustEmbedUnimplementedDisasterServiceServer()
 }
 
@@ -133,9 +232,21 @@ func (UnimplementedDisasterServiceServer) ListDisasters(context.Context, *ListDi
 func (UnimplementedDisasterServiceServer) StreamDisasters(*StreamDisastersRequest, grpc.ServerStreamingServer[Disaster]) error {
 	return status.Error(codes.Unimplemented, "method StreamDisasters not implemented")
 }
+func (UnimplementedDisasterServiceServer) StreamDisasterEvents(*StreamDisastersRequest, grpc.ServerStreamingServer[DisasterEvent]) error {
+	return status.Error(codes.Unimplemented, "method StreamDisasterEvents not implemented")
+}
+func (UnimplementedDisasterServiceServer) Subscribe(grpc.BidiStreamingServer[SubscribeRequest, DisasterEvent]) error {
+	return status.Error(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedDisasterServiceServer) StreamGeofenceAlerts(*StreamGeofenceAlertsRequest, grpc.ServerStreamingServer[GeofenceAlert]) error {
+	return status.Error(codes.Unimplemented, "method StreamGeofenceAlerts not implemented")
+}
 func (UnimplementedDisasterServiceServer) AcknowledgeDisasters(context.Context, *AcknowledgeDisastersRequest) (*AcknowledgeDisastersResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method AcknowledgeDisasters not implemented")
 }
+func (UnimplementedDisasterServiceServer) GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetStats not implemented")
+}
 func (UnimplementedDisasterServiceServer) mustEmbedUnimplementedDisasterServiceServer() {}
 func (UnimplementedDisasterServiceServer) testEmbeddedByValue()                         {}
 
@@ -204,6 +315,35 @@ func _DisasterService_StreamDisasters_Handler(srv interface{}, stream grpc.Serve
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type DisasterService_StreamDisastersServer = grpc.ServerStreamingServer[Disaster]
 
+func _DisasterService_StreamDisasterEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamDisastersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DisasterServiceServer).StreamDisasterEvents(m, &grpc.GenericServerStream[StreamDisastersRequest, DisasterEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DisasterService_StreamDisasterEventsServer = grpc.ServerStreamingServer[DisasterEvent]
+
+func _DisasterService_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DisasterServiceServer).Subscribe(&grpc.GenericServerStream[SubscribeRequest, DisasterEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DisasterService_SubscribeServer = grpc.BidiStreamingServer[SubscribeRequest, DisasterEvent]
+
+func _DisasterService_StreamGeofenceAlerts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamGeofenceAlertsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DisasterServiceServer).StreamGeofenceAlerts(m, &grpc.GenericServerStream[StreamGeofenceAlertsRequest, GeofenceAlert]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DisasterService_StreamGeofenceAlertsServer = grpc.ServerStreamingServer[GeofenceAlert]
+
 func _DisasterService_AcknowledgeDisasters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(AcknowledgeDisastersRequest)
 	if err := dec(in); err != nil {
@@ -222,6 +362,24 @@ func _DisasterService_AcknowledgeDisasters_Handler(srv interface{}, ctx context.
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DisasterService_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DisasterServiceServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DisasterService_GetStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DisasterServiceServer).GetStats(ctx, req.(*GetStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // DisasterService_ServiceDesc is the grpc.ServiceDesc for DisasterService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -241,6 +399,10 @@ var DisasterService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AcknowledgeDisasters",
 			Handler:    _DisasterService_AcknowledgeDisasters_Handler,
 		},
+		{
+			MethodName: "GetStats",
+			Handler:    _DisasterService_GetStats_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -248,6 +410,22 @@ var DisasterService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _DisasterService_StreamDisasters_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "StreamDisasterEvents",
+			Handler:       _DisasterService_StreamDisasterEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Subscribe",
+			Handler:       _DisasterService_Subscribe_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "StreamGeofenceAlerts",
+			Handler:       _DisasterService_StreamGeofenceAlerts_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "proto/disasters/v1/disasters.proto",
 }
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mr1hm/go-disaster-alerts/internal/backup"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+)
+
+// Backups creates database backups for the admin endpoints
+type Backups interface {
+	Create(ctx context.Context) (*backup.Info, error)
+}
+
+// AdminAuthMiddleware rejects requests without an "Authorization: Bearer <token>" header
+// carrying token
+func AdminAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "invalid or missing admin token",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// ownerKey is the gin context key holding the owner authenticated by OwnerAuthMiddleware
+const ownerKey = "owner"
+
+// OwnerAuthMiddleware rejects requests whose "Authorization: Bearer <token>" header does not carry
+// one of tokens, and stores the token's owner for the handlers
+func OwnerAuthMiddleware(tokens models.OwnerTokens) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		owner, known := tokens.Owner(got)
+		if !ok || !known {
+			c.Header("WWW-Authenticate", `Bearer realm="geofences"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "invalid or missing owner token",
+			})
+			return
+		}
+		c.Set(ownerKey, owner)
+		c.Next()
+	}
+}
+
+func (h *Handler) createBackup(c *gin.Context) {
+	info, err := h.backups.Create(c.Request.Context())
+	if err != nil {
+		slog.Error("backup failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "backup failed",
+		})
+		return
+	}
+	c.JSON(http.StatusCreated, info)
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mr1hm/go-disaster-alerts/internal/backup"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+func TestCreateBackup(t *testing.T) {
+	db, err := repository.NewSQLiteDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	backups := backup.NewManager(db, t.TempDir(), 0)
+	NewHandler(db, nil, WithAdminToken("s3cret"), WithBackups(backups)).RegisterRoutes(router)
+
+	for _, auth := range []string{"", "Bearer wrong", "s3cret", "Basic s3cret"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/admin/backups", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: expected status 401, got %d", auth, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/admin/backups", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var info backup.Info
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if stat, err := os.Stat(info.Path); err != nil || stat.Size() != info.SizeBytes {
+		t.Errorf("expected a backup at %s of %d bytes: %v", info.Path, info.SizeBytes, err)
+	}
+}
+
+func TestAdminRoutes_DisabledWithoutToken(t *testing.T) {
+	db, err := repository.NewSQLiteDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewHandler(db, nil, WithBackups(backup.NewManager(db, t.TempDir(), 0))).RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/admin/backups", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
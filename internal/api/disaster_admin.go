@@ -0,0 +1,389 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+// operatorHeader names the operator making an admin change, recorded in the audit log
+const operatorHeader = "X-Operator"
+
+// defaultOperator is the audit actor when a request has no operatorHeader
+const defaultOperator = "admin"
+
+var countryISOPattern = regexp.MustCompile(`^[A-Za-z]{3}$`)
+
+// GeofenceEvaluator matches disasters created or corrected through the admin API against geofences
+type GeofenceEvaluator interface {
+	Evaluate(ctx context.Context, d *models.Disaster) ([]models.Alert, error)
+}
+
+// disasterEditJSON is the request body for creating and correcting disasters.
+// Omitted fields are left unchanged.
+type disasterEditJSON struct {
+	Type                    *string    `json:"type"`
+	Title                   *string    `json:"title"`
+	Description             *string    `json:"description"`
+	Magnitude               *float64   `json:"magnitude"`
+	AlertLevel              *string    `json:"alert_level"`
+	Latitude                *float64   `json:"latitude"`
+	Longitude               *float64   `json:"longitude"`
+	Timestamp               *time.Time `json:"timestamp"`
+	Country                 *string    `json:"country"`
+	CountryISO              *string    `json:"country_iso"`
+	AffectedPopulation      *string    `json:"affected_population"`
+	AffectedPopulationCount *int64     `json:"affected_population_count"`
+	ReportURL               *string    `json:"report_url"`
+	Closed                  *bool      `json:"closed"`
+}
+
+type adminDisasterJSON struct {
+	ID                      string    `json:"id"`
+	Source                  string    `json:"source"`
+	Type                    string    `json:"type"`
+	Title                   string    `json:"title"`
+	Description             string    `json:"description"`
+	Magnitude               float64   `json:"magnitude"`
+	AlertLevel              string    `json:"alert_level"`
+	Latitude                float64   `json:"latitude"`
+	Longitude               float64   `json:"longitude"`
+	Timestamp               time.Time `json:"timestamp"`
+	Country                 string    `json:"country"`
+	CountryISO              string    `json:"country_iso"`
+	AffectedPopulation      string    `json:"affected_population"`
+	AffectedPopulationCount int64     `json:"affected_population_count"`
+	ReportURL               string    `json:"report_url"`
+	Closed                  bool      `json:"closed"`
+	Corrections             []string  `json:"corrections"`
+	Seq                     int64     `json:"seq"`
+	CreatedAt               time.Time `json:"created_at"`
+	UpdatedAt               time.Time `json:"updated_at"`
+}
+
+type auditEntryJSON struct {
+	ID        int64                `json:"id"`
+	Action    string               `json:"action"`
+	Actor     string               `json:"actor"`
+	Changes   []models.FieldChange `json:"changes"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
+// createDisaster stores a disaster reported by an operator rather than a source
+func (h *Handler) createDisaster(c *gin.Context) {
+	var req disasterEditJSON
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid disaster: " + err.Error(),
+		})
+		return
+	}
+	if req.Type == nil || req.Title == nil || req.Latitude == nil || req.Longitude == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "type, title, latitude and longitude are required",
+		})
+		return
+	}
+
+	now := time.Now()
+	d := &models.Disaster{
+		ID:        newManualDisasterID(),
+		Source:    models.SourceManual,
+		Timestamp: now,
+		CreatedAt: now,
+	}
+	if err := req.apply(d); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	changes := (&models.Disaster{}).Changes(d)
+	for i := range changes {
+		changes[i].Old = nil
+	}
+
+	ctx := c.Request.Context()
+	if err := h.audit.AddAudited(ctx, d, auditEntry(c, d.ID, models.AuditActionCreate, changes)); err != nil {
+		slog.Error("error storing operator disaster", "id", d.ID, "actor", operator(c), "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to store disaster",
+		})
+		return
+	}
+
+	if h.broadcaster != nil {
+		h.broadcaster.Broadcast(d)
+	}
+	slog.Info("operator created disaster", "id", d.ID, "actor", operator(c), "type", d.Type, "alert_level", d.AlertLevel)
+	h.evaluateGeofences(ctx, d)
+
+	c.JSON(http.StatusCreated, adminDisasterResponse(d))
+}
+
+// updateDisaster corrects fields of a stored disaster. Corrected fields are kept when the source
+// reports the disaster again.
+func (h *Handler) updateDisaster(c *gin.Context) {
+	var req disasterEditJSON
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid disaster: " + err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	d, err := h.repo.GetByID(ctx, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch disaster",
+		})
+		return
+	}
+	if d == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "disaster not found",
+		})
+		return
+	}
+
+	before := *d
+	if err := req.apply(d); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	changes := before.Changes(d)
+	if len(changes) == 0 {
+		c.JSON(http.StatusOK, adminDisasterResponse(d))
+		return
+	}
+
+	d.Corrections = slices.Clone(d.Corrections)
+	for _, ch := range changes {
+		if !slices.Contains(d.Corrections, ch.Field) {
+			d.Corrections = append(d.Corrections, ch.Field)
+		}
+	}
+	d.UpdatedAt = time.Now()
+	kind := correctionKind(&before, d)
+
+	// Only applied if nothing else changed the disaster since it was read
+	err = h.audit.UpdateAudited(ctx, d, kind, auditEntry(c, d.ID, models.AuditActionUpdate, changes))
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "disaster not found",
+		})
+		return
+	}
+	if errors.Is(err, repository.ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "disaster changed while it was being corrected, retry",
+		})
+		return
+	}
+	if err != nil {
+		slog.Error("error updating operator disaster", "id", d.ID, "actor", operator(c), "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to update disaster",
+		})
+		return
+	}
+
+	if h.broadcaster != nil {
+		h.broadcaster.Publish(&models.DisasterEvent{
+			Seq:       d.Seq,
+			Kind:      kind,
+			Disaster:  d,
+			CreatedAt: d.UpdatedAt,
+		})
+	}
+	slog.Info("operator corrected disaster", "id", d.ID, "actor", operator(c), "kind", kind, "fields", len(changes))
+	h.evaluateGeofences(ctx, d)
+
+	c.JSON(http.StatusOK, adminDisasterResponse(d))
+}
+
+func (h *Handler) listDisasterAudit(c *gin.Context) {
+	entries, err := h.audit.ListAuditEntries(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch audit entries",
+		})
+		return
+	}
+
+	resp := make([]auditEntryJSON, len(entries))
+	for i, e := range entries {
+		resp[i] = auditEntryJSON{
+			ID:        e.ID,
+			Action:    string(e.Action),
+			Actor:     e.Actor,
+			Changes:   e.Changes,
+			CreatedAt: e.CreatedAt,
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": resp})
+}
+
+// auditEntry records an operator's change; it is stored in the same transaction as the change
+func auditEntry(c *gin.Context, disasterID string, action models.AuditAction, changes []models.FieldChange) *models.AuditEntry {
+	return &models.AuditEntry{
+		DisasterID: disasterID,
+		Action:     action,
+		Actor:      operator(c),
+		Changes:    changes,
+		CreatedAt:  time.Now(),
+	}
+}
+
+// evaluateGeofences logs rather than fails, since the disaster itself was stored and broadcast
+func (h *Handler) evaluateGeofences(ctx context.Context, d *models.Disaster) {
+	if h.evaluator == nil {
+		return
+	}
+	if _, err := h.evaluator.Evaluate(ctx, d); err != nil {
+		slog.Error("error evaluating geofences", "id", d.ID, "error", err)
+	}
+}
+
+// apply validates the fields set in r and copies them to d
+func (r *disasterEditJSON) apply(d *models.Disaster) error {
+	if r.Type != nil {
+		t := parseDisasterType(*r.Type)
+		if t == disastersv1.DisasterType_UNSPECIFIED {
+			return fmt.Errorf("unknown disaster type %q", *r.Type)
+		}
+		d.Type = t
+	}
+	if r.Title != nil {
+		title := strings.TrimSpace(*r.Title)
+		if title == "" {
+			return errors.New("title must not be empty")
+		}
+		d.Title = title
+	}
+	if r.Description != nil {
+		d.Description = strings.TrimSpace(*r.Description)
+	}
+	if r.Magnitude != nil {
+		d.Magnitude = *r.Magnitude
+	}
+	if r.AlertLevel != nil {
+		level := parseAlertLevel(*r.AlertLevel)
+		if level == disastersv1.AlertLevel_UNKNOWN {
+			return fmt.Errorf("unknown alert level %q", *r.AlertLevel)
+		}
+		d.AlertLevel = level
+	}
+	if r.Latitude != nil {
+		if *r.Latitude < -90 || *r.Latitude > 90 {
+			return errors.New("latitude must be between -90 and 90")
+		}
+		d.Latitude = *r.Latitude
+	}
+	if r.Longitude != nil {
+		if *r.Longitude < -180 || *r.Longitude > 180 {
+			return errors.New("longitude must be between -180 and 180")
+		}
+		d.Longitude = *r.Longitude
+	}
+	if r.Timestamp != nil {
+		d.Timestamp = *r.Timestamp
+	}
+	if r.Country != nil {
+		d.Country = strings.TrimSpace(*r.Country)
+	}
+	if r.CountryISO != nil {
+		iso := strings.TrimSpace(*r.CountryISO)
+		if iso != "" && !countryISOPattern.MatchString(iso) {
+			return fmt.Errorf("invalid country_iso %q, expected an ISO 3166-1 alpha-3 code", *r.CountryISO)
+		}
+		d.CountryISO = strings.ToUpper(iso)
+	}
+	if r.AffectedPopulation != nil {
+		d.AffectedPopulation = strings.TrimSpace(*r.AffectedPopulation)
+	}
+	if r.AffectedPopulationCount != nil {
+		if *r.AffectedPopulationCount < 0 {
+			return errors.New("affected_population_count must not be negative")
+		}
+		d.AffectedPopulationCount = *r.AffectedPopulationCount
+	}
+	if r.ReportURL != nil {
+		d.ReportURL = strings.TrimSpace(*r.ReportURL)
+	}
+	if r.Closed != nil {
+		d.Closed = *r.Closed
+	}
+	return nil
+}
+
+// correctionKind is the event kind of an operator's correction, classified like source updates
+func correctionKind(before, after *models.Disaster) disastersv1.EventKind {
+	switch {
+	case after.Closed && !before.Closed:
+		return disastersv1.EventKind_EVENT_KIND_CLOSED
+	case after.AlertLevel > before.AlertLevel:
+		return disastersv1.EventKind_EVENT_KIND_ESCALATED
+	}
+	return disastersv1.EventKind_EVENT_KIND_UPDATED
+}
+
+func adminDisasterResponse(d *models.Disaster) adminDisasterJSON {
+	corrections := d.Corrections
+	if corrections == nil {
+		corrections = []string{}
+	}
+	return adminDisasterJSON{
+		ID:                      d.ID,
+		Source:                  d.Source,
+		Type:                    strings.ToLower(d.Type.String()),
+		Title:                   d.Title,
+		Description:             d.Description,
+		Magnitude:               d.Magnitude,
+		AlertLevel:              strings.ToLower(d.AlertLevel.String()),
+		Latitude:                d.Latitude,
+		Longitude:               d.Longitude,
+		Timestamp:               d.Timestamp,
+		Country:                 d.Country,
+		CountryISO:              d.CountryISO,
+		AffectedPopulation:      d.AffectedPopulation,
+		AffectedPopulationCount: d.AffectedPopulationCount,
+		ReportURL:               d.ReportURL,
+		Closed:                  d.Closed,
+		Corrections:             corrections,
+		Seq:                     d.Seq,
+		CreatedAt:               d.CreatedAt,
+		UpdatedAt:               d.UpdatedAt,
+	}
+}
+
+// operator is the audit actor of a request
+func operator(c *gin.Context) string {
+	if name := strings.TrimSpace(c.GetHeader(operatorHeader)); name != "" {
+		return name
+	}
+	return defaultOperator
+}
+
+func newManualDisasterID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "manual_" + hex.EncodeToString(b)
+}
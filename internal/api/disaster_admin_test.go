@@ -0,0 +1,278 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	internalgrpc "github.com/mr1hm/go-disaster-alerts/internal/grpc"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+type recordingEvaluator struct {
+	ids []string
+}
+
+func (e *recordingEvaluator) Evaluate(ctx context.Context, d *models.Disaster) ([]models.Alert, error) {
+	e.ids = append(e.ids, d.ID)
+	return nil, nil
+}
+
+func setupDisasterAdminRouter(t *testing.T) (*gin.Engine, *repository.SQLiteDB, chan *models.DisasterEvent, *recordingEvaluator) {
+	db, err := repository.NewSQLiteDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	b := internalgrpc.NewBroadcaster()
+	id, events := b.Subscribe()
+	t.Cleanup(func() { b.Unsubscribe(id) })
+	evaluator := &recordingEvaluator{}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewHandler(db, b, WithAdminToken("s3cret"), WithAuditLog(db), WithGeofenceEvaluator(evaluator)).RegisterRoutes(router)
+	return router, db, events, evaluator
+}
+
+func adminRequest(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer s3cret")
+	req.Header.Set("X-Operator", "alice")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateDisaster(t *testing.T) {
+	router, db, events, evaluator := setupDisasterAdminRouter(t)
+
+	w := adminRequest(router, "POST", "/api/admin/disasters", `{
+		"type": "flood",
+		"title": "River flooding in Lyon",
+		"alert_level": "orange",
+		"latitude": 45.76,
+		"longitude": 4.84,
+		"country": "France",
+		"country_iso": "fra"
+	}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created adminDisasterJSON
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if !strings.HasPrefix(created.ID, "manual_") || created.Source != models.SourceManual || created.Type != "flood" || created.CountryISO != "FRA" || created.Seq == 0 {
+		t.Fatalf("unexpected disaster %+v", created)
+	}
+
+	stored, err := db.GetByID(context.Background(), created.ID)
+	if err != nil || stored == nil {
+		t.Fatalf("expected the disaster to be stored: %v", err)
+	}
+	if stored.Timestamp.IsZero() {
+		t.Error("expected the timestamp to default to now")
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Kind != disastersv1.EventKind_EVENT_KIND_CREATED || ev.Disaster.ID != created.ID {
+			t.Errorf("unexpected event %v for %s", ev.Kind, ev.Disaster.ID)
+		}
+	default:
+		t.Error("expected a CREATED event")
+	}
+	if !slices.Equal(evaluator.ids, []string{created.ID}) {
+		t.Errorf("expected geofences evaluated for %s, got %v", created.ID, evaluator.ids)
+	}
+
+	entries, err := db.ListAuditEntries(context.Background(), created.ID)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d: %v", len(entries), err)
+	}
+	if e := entries[0]; e.Action != models.AuditActionCreate || e.Actor != "alice" {
+		t.Errorf("unexpected audit entry %+v", e)
+	}
+	for _, ch := range entries[0].Changes {
+		if ch.Old != nil {
+			t.Errorf("expected no old value for %s on create, got %v", ch.Field, ch.Old)
+		}
+	}
+}
+
+func TestCreateDisaster_Invalid(t *testing.T) {
+	router, _, _, _ := setupDisasterAdminRouter(t)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"missing coordinates", `{"type": "flood", "title": "Flood"}`},
+		{"unknown type", `{"type": "meteor", "title": "Meteor", "latitude": 0, "longitude": 0}`},
+		{"blank title", `{"type": "flood", "title": "  ", "latitude": 0, "longitude": 0}`},
+		{"latitude out of range", `{"type": "flood", "title": "Flood", "latitude": 91, "longitude": 0}`},
+		{"bad country_iso", `{"type": "flood", "title": "Flood", "latitude": 0, "longitude": 0, "country_iso": "FR"}`},
+		{"negative population", `{"type": "flood", "title": "Flood", "latitude": 0, "longitude": 0, "affected_population_count": -1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := adminRequest(router, "POST", "/api/admin/disasters", tt.body); w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestUpdateDisaster(t *testing.T) {
+	router, db, events, evaluator := setupDisasterAdminRouter(t)
+	ctx := context.Background()
+
+	d := &models.Disaster{
+		ID:         "gdacs_EQ_1",
+		Source:     "GDACS",
+		Type:       disastersv1.DisasterType_EARTHQUAKE,
+		Title:      "M6.1 earthquake",
+		AlertLevel: disastersv1.AlertLevel_GREEN,
+		Latitude:   10,
+		Longitude:  20,
+		Timestamp:  time.Now(),
+		Country:    "Unknown",
+		CreatedAt:  time.Now(),
+	}
+	if err := db.Add(ctx, d); err != nil {
+		t.Fatalf("failed to add disaster: %v", err)
+	}
+
+	w := adminRequest(router, "PATCH", "/api/admin/disasters/gdacs_EQ_1", `{"country": "Chile", "latitude": -33.4, "alert_level": "red"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var updated adminDisasterJSON
+	json.Unmarshal(w.Body.Bytes(), &updated)
+	if updated.Country != "Chile" || updated.Latitude != -33.4 || updated.Longitude != 20 || updated.Source != "GDACS" {
+		t.Errorf("unexpected disaster %+v", updated)
+	}
+	if want := []string{"alert_level", "latitude", "country"}; !slices.Equal(updated.Corrections, want) {
+		t.Errorf("expected corrections %v, got %v", want, updated.Corrections)
+	}
+
+	stored, _ := db.GetByID(ctx, "gdacs_EQ_1")
+	if stored.Country != "Chile" || !slices.Equal(stored.Corrections, updated.Corrections) || stored.Seq <= d.Seq {
+		t.Errorf("expected the correction to be stored, got %+v", stored)
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Kind != disastersv1.EventKind_EVENT_KIND_ESCALATED || ev.Seq != stored.Seq {
+			t.Errorf("expected an ESCALATED event at seq %d, got %v at %d", stored.Seq, ev.Kind, ev.Seq)
+		}
+	default:
+		t.Error("expected an event for the correction")
+	}
+	if len(evaluator.ids) != 1 {
+		t.Errorf("expected geofences evaluated once, got %v", evaluator.ids)
+	}
+
+	// Setting the values it already has is not a change
+	if w := adminRequest(router, "PATCH", "/api/admin/disasters/gdacs_EQ_1", `{"country": "Chile"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("expected no event for an unchanged disaster, got %v", ev.Kind)
+	default:
+	}
+
+	w = adminRequest(router, "GET", "/api/admin/disasters/gdacs_EQ_1/audit", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var audit struct {
+		Entries []auditEntryJSON `json:"entries"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &audit)
+	if len(audit.Entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %+v", audit.Entries)
+	}
+	e := audit.Entries[0]
+	if e.Action != "update" || e.Actor != "alice" || len(e.Changes) != 3 {
+		t.Fatalf("unexpected audit entry %+v", e)
+	}
+	if ch := e.Changes[2]; ch.Field != "country" || ch.Old != "Unknown" || ch.New != "Chile" {
+		t.Errorf("unexpected change %+v", ch)
+	}
+}
+
+func TestUpdateDisaster_Errors(t *testing.T) {
+	router, db, _, _ := setupDisasterAdminRouter(t)
+	d := &models.Disaster{ID: "gdacs_FL_1", Source: "GDACS", Type: disastersv1.DisasterType_FLOOD, Title: "Flood", Timestamp: time.Now(), CreatedAt: time.Now()}
+	if err := db.Add(context.Background(), d); err != nil {
+		t.Fatalf("failed to add disaster: %v", err)
+	}
+
+	if w := adminRequest(router, "PATCH", "/api/admin/disasters/missing", `{"country": "Chile"}`); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+	if w := adminRequest(router, "PATCH", "/api/admin/disasters/gdacs_FL_1", `{"longitude": 181}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/api/admin/disasters/gdacs_FL_1", strings.NewReader(`{"country": "Chile"}`))
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 without a token, got %d", w.Code)
+	}
+}
+
+// racingRepo stores a source update right after each read, as an ingest running concurrently would
+type racingRepo struct {
+	*repository.SQLiteDB
+}
+
+func (r racingRepo) GetByID(ctx context.Context, id string) (*models.Disaster, error) {
+	d, err := r.SQLiteDB.GetByID(ctx, id)
+	if err != nil || d == nil {
+		return d, err
+	}
+	update := *d
+	update.Magnitude++
+	update.UpdatedAt = time.Now()
+	return d, r.SQLiteDB.Update(ctx, &update, disastersv1.EventKind_EVENT_KIND_UPDATED)
+}
+
+func TestUpdateDisaster_Conflict(t *testing.T) {
+	db, err := repository.NewSQLiteDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	d := &models.Disaster{ID: "gdacs_EQ_2", Source: "GDACS", Type: disastersv1.DisasterType_EARTHQUAKE, Title: "Earthquake", Timestamp: time.Now(), CreatedAt: time.Now()}
+	if err := db.Add(context.Background(), d); err != nil {
+		t.Fatalf("failed to add disaster: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewHandler(racingRepo{db}, nil, WithAdminToken("s3cret"), WithAuditLog(db)).RegisterRoutes(router)
+
+	w := adminRequest(router, "PATCH", "/api/admin/disasters/gdacs_EQ_2", `{"country": "Chile"}`)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d: %s", w.Code, w.Body.String())
+	}
+	got, _ := db.GetByID(context.Background(), "gdacs_EQ_2")
+	if got.Country != "" || got.Magnitude != 1 {
+		t.Errorf("expected only the concurrent update to be stored, got %+v", got)
+	}
+	if entries, _ := db.ListAuditEntries(context.Background(), "gdacs_EQ_2"); len(entries) != 0 {
+		t.Errorf("expected no audit entry for the rejected correction, got %+v", entries)
+	}
+}
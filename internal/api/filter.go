@@ -0,0 +1,182 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+// maxPageSize caps limit; larger result sets are walked with page_token
+const maxPageSize = 5000
+
+// parseFilter reads the disaster query params shared by the listing endpoints.
+// Malformed attribute filters are ignored, but a malformed bbox, radius or sort is an error,
+// since dropping it would silently return disasters from everywhere or in the wrong order.
+func parseFilter(c *gin.Context) (repository.Filter, error) {
+	filter := repository.Filter{
+		Limit:     20, // Default to 20 disasters if limit param not supplied
+		Query:     c.Query("q"),
+		PageToken: c.Query("page_token"),
+	}
+
+	// type accepts a comma-separated list, e.g. type=earthquake,flood
+	for _, t := range splitList(c.Query("type")) {
+		dt := parseDisasterType(t)
+		if dt != disastersv1.DisasterType_UNSPECIFIED {
+			filter.Types = append(filter.Types, dt)
+		}
+	}
+	if countries := splitList(c.Query("country")); len(countries) > 0 {
+		filter.Countries = countries
+	}
+	if sources := splitList(c.Query("source")); len(sources) > 0 {
+		filter.Sources = sources
+	}
+	if p := c.Query("min_affected_population_count"); p != "" {
+		if pop, err := strconv.ParseInt(p, 10, 64); err == nil {
+			filter.MinAffectedPopulationCount = &pop
+		}
+	}
+	if m := c.Query("min_magnitude"); m != "" {
+		if mag, err := strconv.ParseFloat(m, 64); err == nil {
+			filter.MinMagnitude = &mag
+		}
+	}
+	if m := c.Query("max_magnitude"); m != "" {
+		if mag, err := strconv.ParseFloat(m, 64); err == nil {
+			filter.MaxMagnitude = &mag
+		}
+	}
+	if s := c.Query("since"); s != "" {
+		if t, _, err := parseTime(s); err == nil {
+			filter.Since = &t
+		}
+	}
+	if u := c.Query("until"); u != "" {
+		if t, dateOnly, err := parseTime(u); err == nil {
+			if dateOnly {
+				t = t.AddDate(0, 0, 1) // a date includes the whole day
+			}
+			filter.Until = &t
+		}
+	}
+	if l := c.Query("limit"); l != "" {
+		if lim, err := strconv.Atoi(l); err == nil && lim > 0 && lim <= maxPageSize {
+			filter.Limit = lim
+		}
+	}
+	if al := c.Query("alert_level"); al != "" {
+		level := parseAlertLevel(al)
+		if level != disastersv1.AlertLevel_UNKNOWN {
+			filter.AlertLevel = &level
+		}
+	}
+	if mal := c.Query("min_alert_level"); mal != "" {
+		level := parseAlertLevel(mal)
+		if level != disastersv1.AlertLevel_UNKNOWN {
+			filter.MinAlertLevel = &level
+		}
+	}
+
+	bbox, near, err := parseGeoFilters(c)
+	if err != nil {
+		return filter, err
+	}
+	filter.BBox = bbox
+	filter.Near = near
+
+	switch tf := c.Query("time_field"); tf {
+	case "", "event":
+	case "created":
+		filter.TimeField = repository.TimeFieldCreated
+	default:
+		return filter, fmt.Errorf("invalid time_field %q, expected event or created", tf)
+	}
+	filter.Sort = repository.SortField(c.Query("sort"))
+	switch o := c.Query("order"); o {
+	case "":
+	case "asc":
+		filter.Order = repository.SortOrderAsc
+	case "desc":
+		filter.Order = repository.SortOrderDesc
+	default:
+		return filter, fmt.Errorf("invalid order %q, expected asc or desc", o)
+	}
+	if err := filter.Validate(); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// parseTime reads a date (YYYY-MM-DD) or an RFC 3339 timestamp, reporting which it was
+func parseTime(s string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	return t, false, err
+}
+
+// parseGeoFilters reads bbox=minLon,minLat,maxLon,maxLat (GeoJSON order) and lat, lon, radius_km
+func parseGeoFilters(c *gin.Context) (*geo.BBox, *geo.Circle, error) {
+	var bbox *geo.BBox
+	if b := c.Query("bbox"); b != "" {
+		v, err := parseFloats(b, 4)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid bbox: %w", err)
+		}
+		bbox = &geo.BBox{MinLon: v[0], MinLat: v[1], MaxLon: v[2], MaxLat: v[3]}
+		if err := bbox.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("invalid bbox: %w", err)
+		}
+	}
+
+	var near *geo.Circle
+	lat, lon, radius := c.Query("lat"), c.Query("lon"), c.Query("radius_km")
+	if lat != "" || lon != "" || radius != "" {
+		v, err := parseFloats(lat+","+lon+","+radius, 3)
+		if err != nil {
+			return nil, nil, fmt.Errorf("lat, lon and radius_km must all be numbers: %w", err)
+		}
+		near = &geo.Circle{Lat: v[0], Lon: v[1], RadiusKm: v[2]}
+		if err := near.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("invalid radius filter: %w", err)
+		}
+	}
+
+	return bbox, near, nil
+}
+
+func parseFloats(s string, n int) ([]float64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != n {
+		return nil, fmt.Errorf("expected %d comma-separated numbers", n)
+	}
+	v := make([]float64, n)
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, err
+		}
+		v[i] = f
+	}
+	return v, nil
+}
+
+// splitList splits a comma-separated query value, dropping empty entries
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
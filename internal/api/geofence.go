@@ -0,0 +1,270 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+// GeofenceStore is the storage behind the geofence endpoints
+type GeofenceStore interface {
+	repository.GeofenceRepository
+	repository.AlertRepository
+}
+
+type circleJSON struct {
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	RadiusKm float64 `json:"radius_km"`
+}
+
+type geometryJSON struct {
+	Type        string           `json:"type"`
+	Coordinates geo.MultiPolygon `json:"coordinates"`
+}
+
+// geofenceJSON is the request and response body of the geofence endpoints.
+// A geofence has either a GeoJSON Polygon/MultiPolygon geometry or a circle.
+type geofenceJSON struct {
+	ID                         string          `json:"id,omitempty"`
+	Name                       string          `json:"name"`
+	Owner                      string          `json:"owner"`
+	Geometry                   json.RawMessage `json:"geometry,omitempty"`
+	Circle                     *circleJSON     `json:"circle,omitempty"`
+	Types                      []string        `json:"types,omitempty"`
+	MinMagnitude               *float64        `json:"min_magnitude,omitempty"`
+	MinAlertLevel              string          `json:"min_alert_level,omitempty"`
+	MinAffectedPopulationCount *int64          `json:"min_affected_population_count,omitempty"`
+	CreatedAt                  *time.Time      `json:"created_at,omitempty"`
+}
+
+type alertJSON struct {
+	ID         string    `json:"id"`
+	DisasterID string    `json:"disaster_id"`
+	GeofenceID string    `json:"geofence_id"`
+	Severity   string    `json:"severity"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (h *Handler) createGeofence(c *gin.Context) {
+	var req geofenceJSON
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid geofence: " + err.Error(),
+		})
+		return
+	}
+
+	owner := c.GetString(ownerKey)
+	if req.Owner != "" && strings.TrimSpace(req.Owner) != owner {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "owner does not match the token",
+		})
+		return
+	}
+	req.Owner = owner
+
+	g, err := req.toModel()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	g.ID = newGeofenceID()
+	g.CreatedAt = time.Now()
+
+	if err := h.geofences.AddGeofence(c.Request.Context(), g); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to store geofence",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, geofenceResponse(g))
+}
+
+// listGeofences lists the geofences of the token's owner
+func (h *Handler) listGeofences(c *gin.Context) {
+	geofences, err := h.geofences.ListGeofences(c.Request.Context(), c.GetString(ownerKey))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch geofences",
+		})
+		return
+	}
+
+	resp := make([]geofenceJSON, len(geofences))
+	for i := range geofences {
+		resp[i] = geofenceResponse(&geofences[i])
+	}
+	c.JSON(http.StatusOK, gin.H{"geofences": resp})
+}
+
+func (h *Handler) getGeofence(c *gin.Context) {
+	g, ok := h.ownedGeofence(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, geofenceResponse(g))
+}
+
+// ownedGeofence fetches the :id geofence if it belongs to the token's owner. Otherwise it writes the
+// error response; other owners' geofences are reported as not found.
+func (h *Handler) ownedGeofence(c *gin.Context) (*models.Geofence, bool) {
+	g, err := h.geofences.GetGeofence(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch geofence",
+		})
+		return nil, false
+	}
+	if g == nil || g.Owner != c.GetString(ownerKey) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "geofence not found",
+		})
+		return nil, false
+	}
+	return g, true
+}
+
+func (h *Handler) deleteGeofence(c *gin.Context) {
+	if _, ok := h.ownedGeofence(c); !ok {
+		return
+	}
+	err := h.geofences.DeleteGeofence(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "geofence not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to delete geofence",
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) listGeofenceAlerts(c *gin.Context) {
+	if _, ok := h.ownedGeofence(c); !ok {
+		return
+	}
+	filter := repository.Filter{
+		Limit:      50,
+		GeofenceID: c.Param("id"),
+	}
+	if l := c.Query("limit"); l != "" {
+		if lim, err := strconv.Atoi(l); err == nil && lim > 0 && lim <= 500 {
+			filter.Limit = lim
+		}
+	}
+
+	alerts, err := h.geofences.ListAlerts(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch alerts",
+		})
+		return
+	}
+
+	resp := make([]alertJSON, len(alerts))
+	for i, a := range alerts {
+		resp[i] = alertJSON{
+			ID:         a.ID,
+			DisasterID: a.DisasterID,
+			GeofenceID: a.GeofenceID,
+			Severity:   string(a.Severity),
+			CreatedAt:  a.CreatedAt,
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"alerts": resp})
+}
+
+func (r *geofenceJSON) toModel() (*models.Geofence, error) {
+	g := &models.Geofence{
+		Name:  strings.TrimSpace(r.Name),
+		Owner: strings.TrimSpace(r.Owner),
+		Criteria: models.GeofenceCriteria{
+			MinMagnitude:               r.MinMagnitude,
+			MinAffectedPopulationCount: r.MinAffectedPopulationCount,
+		},
+	}
+
+	if len(r.Geometry) > 0 && string(r.Geometry) != "null" {
+		area, err := geo.ParseGeoJSON(r.Geometry)
+		if err != nil {
+			return nil, err
+		}
+		g.Area = area
+	}
+	if r.Circle != nil {
+		g.Circle = &geo.Circle{Lat: r.Circle.Lat, Lon: r.Circle.Lon, RadiusKm: r.Circle.RadiusKm}
+	}
+
+	for _, t := range r.Types {
+		dt := parseDisasterType(t)
+		if dt == disastersv1.DisasterType_UNSPECIFIED {
+			return nil, fmt.Errorf("unknown disaster type %q", t)
+		}
+		g.Criteria.Types = append(g.Criteria.Types, dt)
+	}
+	if r.MinAlertLevel != "" {
+		level := parseAlertLevel(r.MinAlertLevel)
+		if level == disastersv1.AlertLevel_UNKNOWN {
+			return nil, fmt.Errorf("unknown alert level %q", r.MinAlertLevel)
+		}
+		g.Criteria.MinAlertLevel = &level
+	}
+
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func geofenceResponse(g *models.Geofence) geofenceJSON {
+	resp := geofenceJSON{
+		ID:                         g.ID,
+		Name:                       g.Name,
+		Owner:                      g.Owner,
+		MinMagnitude:               g.Criteria.MinMagnitude,
+		MinAffectedPopulationCount: g.Criteria.MinAffectedPopulationCount,
+		CreatedAt:                  &g.CreatedAt,
+	}
+	if g.Area != nil {
+		resp.Geometry, _ = json.Marshal(geometryJSON{Type: "MultiPolygon", Coordinates: g.Area})
+	}
+	if g.Circle != nil {
+		resp.Circle = &circleJSON{Lat: g.Circle.Lat, Lon: g.Circle.Lon, RadiusKm: g.Circle.RadiusKm}
+	}
+	for _, t := range g.Criteria.Types {
+		resp.Types = append(resp.Types, strings.ToLower(t.String()))
+	}
+	if g.Criteria.MinAlertLevel != nil {
+		resp.MinAlertLevel = strings.ToLower(g.Criteria.MinAlertLevel.String())
+	}
+	return resp
+}
+
+func newGeofenceID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "gf_" + hex.EncodeToString(b)
+}
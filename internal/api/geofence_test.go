@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+func setupGeofenceRouter(t *testing.T) (*gin.Engine, *repository.SQLiteDB) {
+	db, err := repository.NewSQLiteDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	owners := models.OwnerTokens{"sl4ck": "slack", "p4ger": "pager"}
+	NewHandler(db, nil, WithGeofenceStore(db), WithOwnerTokens(owners)).RegisterRoutes(router)
+	return router, db
+}
+
+// geofenceRequest sends a request authenticated with an owner token, or none if token is empty
+func geofenceRequest(router *gin.Engine, method, path, token, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestGeofences_CRUD(t *testing.T) {
+	router, db := setupGeofenceRouter(t)
+
+	body := `{
+		"name": "Luzon",
+		"geometry": {"type": "Polygon", "coordinates": [[[119.5, 13.5], [124.5, 13.5], [122.5, 18.8], [119.5, 13.5]]]},
+		"types": ["earthquake", "tsunami"],
+		"min_alert_level": "orange"
+	}`
+	w := geofenceRequest(router, "POST", "/api/geofences", "sl4ck", body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created geofenceJSON
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if !strings.HasPrefix(created.ID, "gf_") || created.Owner != "slack" || created.MinAlertLevel != "orange" || len(created.Types) != 2 || len(created.Geometry) == 0 {
+		t.Fatalf("unexpected geofence %+v", created)
+	}
+
+	if w := geofenceRequest(router, "GET", "/api/geofences/"+created.ID, "sl4ck", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var list struct {
+		Geofences []geofenceJSON `json:"geofences"`
+	}
+	w = geofenceRequest(router, "GET", "/api/geofences", "sl4ck", "")
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Geofences) != 1 || list.Geofences[0].ID != created.ID {
+		t.Errorf("expected the owner's geofence, got %+v", list.Geofences)
+	}
+
+	alert := &models.Alert{ID: created.ID + ":d1", DisasterID: "d1", GeofenceID: created.ID, Severity: models.AlertSeverityHigh, CreatedAt: time.Now()}
+	if err := db.AddAlert(context.Background(), alert); err != nil {
+		t.Fatalf("AddAlert failed: %v", err)
+	}
+	w = geofenceRequest(router, "GET", "/api/geofences/"+created.ID+"/alerts", "sl4ck", "")
+	var alerts struct {
+		Alerts []alertJSON `json:"alerts"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &alerts)
+	if len(alerts.Alerts) != 1 || alerts.Alerts[0].DisasterID != "d1" || alerts.Alerts[0].Severity != "HIGH" {
+		t.Errorf("unexpected alerts %+v", alerts.Alerts)
+	}
+
+	for _, want := range []int{http.StatusNoContent, http.StatusNotFound} {
+		if w := geofenceRequest(router, "DELETE", "/api/geofences/"+created.ID, "sl4ck", ""); w.Code != want {
+			t.Errorf("expected status %d deleting, got %d", want, w.Code)
+		}
+	}
+}
+
+func TestGeofences_Ownership(t *testing.T) {
+	router, _ := setupGeofenceRouter(t)
+
+	w := geofenceRequest(router, "POST", "/api/geofences", "sl4ck", `{"name": "Tokyo", "circle": {"lat": 35, "lon": 139, "radius_km": 50}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created geofenceJSON
+	json.Unmarshal(w.Body.Bytes(), &created)
+
+	if w := geofenceRequest(router, "GET", "/api/geofences", "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 without a token, got %d", w.Code)
+	}
+	if w := geofenceRequest(router, "GET", "/api/geofences", "guess", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 with an unknown token, got %d", w.Code)
+	}
+	if w := geofenceRequest(router, "POST", "/api/geofences", "p4ger", `{"name": "x", "owner": "slack", "circle": {"lat": 35, "lon": 139, "radius_km": 50}}`); w.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 creating for another owner, got %d", w.Code)
+	}
+
+	// Another owner can neither see nor change the geofence
+	var list struct {
+		Geofences []geofenceJSON `json:"geofences"`
+	}
+	w = geofenceRequest(router, "GET", "/api/geofences", "p4ger", "")
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Geofences) != 0 {
+		t.Errorf("expected no geofences for another owner, got %+v", list.Geofences)
+	}
+	for _, r := range []struct{ method, path string }{
+		{"GET", "/api/geofences/" + created.ID},
+		{"GET", "/api/geofences/" + created.ID + "/alerts"},
+		{"DELETE", "/api/geofences/" + created.ID},
+	} {
+		if w := geofenceRequest(router, r.method, r.path, "p4ger", ""); w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404 for %s %s by another owner, got %d", r.method, r.path, w.Code)
+		}
+	}
+	if w := geofenceRequest(router, "GET", "/api/geofences/"+created.ID, "sl4ck", ""); w.Code != http.StatusOK {
+		t.Errorf("expected the geofence to survive another owner's delete, got %d", w.Code)
+	}
+}
+
+func TestGeofences_Invalid(t *testing.T) {
+	router, _ := setupGeofenceRouter(t)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"no area", `{"name": "x"}`},
+		{"no name", `{"circle": {"lat": 35, "lon": 139, "radius_km": 50}}`},
+		{"both shapes", `{"name": "x", "circle": {"lat": 35, "lon": 139, "radius_km": 50},
+			"geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}}`},
+		{"zero radius", `{"name": "x", "circle": {"lat": 35, "lon": 139, "radius_km": 0}}`},
+		{"unknown type", `{"name": "x", "circle": {"lat": 35, "lon": 139, "radius_km": 50}, "types": ["meteor"]}`},
+		{"bad geometry", `{"name": "x", "geometry": {"type": "Point", "coordinates": [1, 2]}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := geofenceRequest(router, "POST", "/api/geofences", "sl4ck", tt.body)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGeofences_DisabledWithoutStore(t *testing.T) {
+	router := setupTestRouter(newTestRepo(t))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/geofences", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
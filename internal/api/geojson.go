@@ -7,8 +7,9 @@ import (
 )
 
 type FeatureCollection struct {
-	Type     string    `json:"type"`
-	Features []Feature `json:"features"`
+	Type          string    `json:"type"`
+	Features      []Feature `json:"features"`
+	NextPageToken string    `json:"next_page_token,omitempty"` // foreign member: pass as page_token for the next page
 }
 type Feature struct {
 	Type       string         `json:"type"`
@@ -41,6 +42,12 @@ func toGeoJSON(disasters []models.Disaster) FeatureCollection {
 				"timestamp":   d.Timestamp,
 			},
 		}
+		if d.DistanceKm != nil {
+			f.Properties["distance_km"] = *d.DistanceKm
+		}
+		if d.Snippet != "" {
+			f.Properties["snippet"] = d.Snippet
+		}
 		features = append(features, f)
 	}
 
@@ -1,77 +1,177 @@
 package api
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"strconv"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
 	internalgrpc "github.com/mr1hm/go-disaster-alerts/internal/grpc"
 	"github.com/mr1hm/go-disaster-alerts/internal/models"
 	"github.com/mr1hm/go-disaster-alerts/internal/repository"
 )
 
+// maxSearchBodyBytes caps the GeoJSON area accepted by POST /api/disasters/search
+const maxSearchBodyBytes = 1 << 20
+
 type Handler struct {
 	repo        repository.DisasterRepository
 	broadcaster *internalgrpc.Broadcaster
+	geofences   GeofenceStore
+	owners      models.OwnerTokens
+	adminToken  string
+	backups     Backups
+	audit       repository.AuditRepository
+	evaluator   GeofenceEvaluator
+}
+
+type HandlerOption func(*Handler)
+
+// WithGeofenceStore enables the /api/geofences endpoints, together with WithOwnerTokens.
+func WithGeofenceStore(store GeofenceStore) HandlerOption {
+	return func(h *Handler) {
+		h.geofences = store
+	}
+}
+
+// WithOwnerTokens authenticates geofence owners: each request manages the geofences of its token's owner.
+func WithOwnerTokens(tokens models.OwnerTokens) HandlerOption {
+	return func(h *Handler) {
+		h.owners = tokens
+	}
+}
+
+// WithAdminToken enables the /api/admin endpoints for requests bearing token.
+func WithAdminToken(token string) HandlerOption {
+	return func(h *Handler) {
+		h.adminToken = token
+	}
+}
+
+// WithBackups enables POST /api/admin/backups.
+func WithBackups(backups Backups) HandlerOption {
+	return func(h *Handler) {
+		h.backups = backups
+	}
+}
+
+// WithAuditLog enables the /api/admin/disasters endpoints, recording each change in audit.
+func WithAuditLog(audit repository.AuditRepository) HandlerOption {
+	return func(h *Handler) {
+		h.audit = audit
+	}
+}
+
+// WithGeofenceEvaluator evaluates geofences against disasters changed through the admin endpoints.
+func WithGeofenceEvaluator(e GeofenceEvaluator) HandlerOption {
+	return func(h *Handler) {
+		h.evaluator = e
+	}
 }
 
-func NewHandler(repo repository.DisasterRepository, broadcaster *internalgrpc.Broadcaster) *Handler {
-	return &Handler{
+func NewHandler(repo repository.DisasterRepository, broadcaster *internalgrpc.Broadcaster, opts ...HandlerOption) *Handler {
+	h := &Handler{
 		repo:        repo,
 		broadcaster: broadcaster,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 func (h *Handler) RegisterRoutes(r *gin.Engine) {
 	r.GET("/api/disasters", h.getDisasters)
+	r.POST("/api/disasters/search", h.searchDisasters)
+	r.GET("/api/disasters/timeline", h.getTimeline)
+	r.GET("/api/disasters/heatmap", h.getHeatmap)
 	r.GET("/health", h.health)
+	r.GET("/api/metrics", h.metrics)
+	r.GET("/api/stats", h.getStats)
 	r.POST("/api/debug/test-disaster", h.createTestDisaster)
-}
 
-func (h *Handler) getDisasters(c *gin.Context) {
-	filter := repository.Filter{
-		Limit: 20, // Default to 20 disasters if limit param not supplied
+	if h.geofences != nil && len(h.owners) > 0 {
+		geofences := r.Group("/api/geofences", OwnerAuthMiddleware(h.owners))
+		geofences.POST("", h.createGeofence)
+		geofences.GET("", h.listGeofences)
+		geofences.GET("/:id", h.getGeofence)
+		geofences.DELETE("/:id", h.deleteGeofence)
+		geofences.GET("/:id/alerts", h.listGeofenceAlerts)
 	}
 
-	if t := c.Query("type"); t != "" {
-		dt := parseDisasterType(t)
-		if dt != disastersv1.DisasterType_UNSPECIFIED {
-			filter.Type = &dt
+	if h.adminToken != "" {
+		admin := r.Group("/api/admin", AdminAuthMiddleware(h.adminToken))
+		if h.backups != nil {
+			admin.POST("/backups", h.createBackup)
 		}
-	}
-	if m := c.Query("min_magnitude"); m != "" {
-		if mag, err := strconv.ParseFloat(m, 64); err == nil {
-			filter.MinMagnitude = &mag
+		if h.audit != nil {
+			admin.POST("/disasters", h.createDisaster)
+			admin.PATCH("/disasters/:id", h.updateDisaster)
+			admin.GET("/disasters/:id/audit", h.listDisasterAudit)
 		}
 	}
-	if s := c.Query("since"); s != "" {
-		if t, err := time.Parse("2006-01-02", s); err == nil {
-			filter.Since = &t
-		}
+}
+
+func (h *Handler) getDisasters(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
 	}
-	if l := c.Query("limit"); l != "" {
-		if lim, err := strconv.Atoi(l); err == nil && lim > 0 && lim <= 500 {
-			filter.Limit = lim
-		}
+
+	h.listDisasters(c, filter)
+}
+
+// searchDisasters returns disasters inside a GeoJSON Polygon or MultiPolygon sent as the body.
+// The query string takes the same filters as GET /api/disasters.
+func (h *Handler) searchDisasters(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
 	}
-	if al := c.Query("alert_level"); al != "" {
-		level := parseAlertLevel(al)
-		if level != disastersv1.AlertLevel_UNKNOWN {
-			filter.AlertLevel = &level
-		}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxSearchBodyBytes))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "search area too large",
+		})
+		return
 	}
-	if mal := c.Query("min_alert_level"); mal != "" {
-		level := parseAlertLevel(mal)
-		if level != disastersv1.AlertLevel_UNKNOWN {
-			filter.MinAlertLevel = &level
-		}
+	area, err := geo.ParseGeoJSON(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
 	}
+	filter.Area = area
+
+	h.listDisasters(c, filter)
+}
+
+func (h *Handler) listDisasters(c *gin.Context, filter repository.Filter) {
+	// Fetch one extra disaster to learn whether there is a next page
+	limit := filter.Limit
+	filter.Limit++
 
 	disasters, err := h.repo.ListDisasters(c.Request.Context(), filter)
+	if errors.Is(err, repository.ErrInvalidPageToken) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to fetch disasters",
@@ -79,15 +179,36 @@ func (h *Handler) getDisasters(c *gin.Context) {
 		return
 	}
 
+	disasters, next := repository.NextPage(disasters, limit)
 	fc := toGeoJSON(disasters)
+	fc.NextPageToken = next
+	if next != "" && c.Request.Method == http.MethodGet {
+		c.Header("Link", `<`+nextPageURL(c.Request.URL, next)+`>; rel="next"`)
+	}
 	c.Header("Content-Type", "application/geo+json")
 	c.JSON(http.StatusOK, fc)
 }
 
+// nextPageURL is the request URL with page_token replaced, keeping the other filters
+func nextPageURL(u *url.URL, token string) string {
+	q := u.Query()
+	q.Set("page_token", token)
+	next := url.URL{Path: u.Path, RawQuery: q.Encode()}
+	return next.String()
+}
+
 func (h *Handler) health(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
+func (h *Handler) metrics(c *gin.Context) {
+	var stream internalgrpc.BroadcasterMetrics
+	if h.broadcaster != nil {
+		stream = h.broadcaster.Metrics()
+	}
+	c.JSON(http.StatusOK, gin.H{"stream": stream})
+}
+
 func (h *Handler) createTestDisaster(c *gin.Context) {
 	disaster := &models.Disaster{
 		ID:          fmt.Sprintf("test_%d", time.Now().UnixNano()),
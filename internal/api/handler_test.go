@@ -3,102 +3,30 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	internalgrpc "github.com/mr1hm/go-disaster-alerts/internal/grpc"
 	"github.com/mr1hm/go-disaster-alerts/internal/models"
 	"github.com/mr1hm/go-disaster-alerts/internal/repository"
 )
 
-// mockRepo implements repository.DisasterRepository for testing
-type mockRepo struct {
-	disasters []models.Disaster
-}
-
-func (m *mockRepo) Add(ctx context.Context, d *models.Disaster) error {
-	m.disasters = append(m.disasters, *d)
-	return nil
-}
-
-func (m *mockRepo) GetByID(ctx context.Context, id string) (*models.Disaster, error) {
-	for _, d := range m.disasters {
-		if d.ID == id {
-			return &d, nil
+// newTestRepo returns an in-memory repository holding the given disasters
+func newTestRepo(t *testing.T, disasters ...models.Disaster) *repository.MemoryDB {
+	t.Helper()
+	repo := repository.NewMemoryDB()
+	for _, d := range disasters {
+		if err := repo.Add(context.Background(), &d); err != nil {
+			t.Fatalf("Add failed: %v", err)
 		}
 	}
-	return nil, nil
-}
-
-func (m *mockRepo) Exists(ctx context.Context, id string) (bool, error) {
-	for _, d := range m.disasters {
-		if d.ID == id {
-			return true, nil
-		}
-	}
-	return false, nil
-}
-
-func (m *mockRepo) MarkAsSent(ctx context.Context, ids []string) (int64, error) {
-	return int64(len(ids)), nil
-}
-
-func (m *mockRepo) ListDisasters(ctx context.Context, opts repository.Filter) ([]models.Disaster, error) {
-	results := m.disasters
-
-	// Apply type filter
-	if opts.Type != nil {
-		var filtered []models.Disaster
-		for _, d := range results {
-			if d.Type == *opts.Type {
-				filtered = append(filtered, d)
-			}
-		}
-		results = filtered
-	}
-
-	// Apply magnitude filter
-	if opts.MinMagnitude != nil {
-		var filtered []models.Disaster
-		for _, d := range results {
-			if d.Magnitude >= *opts.MinMagnitude {
-				filtered = append(filtered, d)
-			}
-		}
-		results = filtered
-	}
-
-	// Apply alert level filter (exact match)
-	if opts.AlertLevel != nil {
-		var filtered []models.Disaster
-		for _, d := range results {
-			if d.AlertLevel == *opts.AlertLevel {
-				filtered = append(filtered, d)
-			}
-		}
-		results = filtered
-	}
-
-	// Apply min alert level filter (>= match)
-	if opts.MinAlertLevel != nil {
-		var filtered []models.Disaster
-		for _, d := range results {
-			if d.AlertLevel >= *opts.MinAlertLevel {
-				filtered = append(filtered, d)
-			}
-		}
-		results = filtered
-	}
-
-	// Apply limit
-	if opts.Limit > 0 && len(results) > opts.Limit {
-		results = results[:opts.Limit]
-	}
-
-	return results, nil
+	return repo
 }
 
 func setupTestRouter(repo repository.DisasterRepository) *gin.Engine {
@@ -110,20 +38,18 @@ func setupTestRouter(repo repository.DisasterRepository) *gin.Engine {
 }
 
 func TestGetDisasters_ReturnsGeoJSON(t *testing.T) {
-	repo := &mockRepo{
-		disasters: []models.Disaster{
-			{
-				ID:        "test_1",
-				Source:    "test",
-				Type:      disastersv1.DisasterType_EARTHQUAKE,
-				Title:     "Test Quake",
-				Magnitude: 5.5,
-				Latitude:  35.0,
-				Longitude: 139.0,
-				Timestamp: time.Now(),
-			},
+	repo := newTestRepo(t,
+		models.Disaster{
+			ID:        "test_1",
+			Source:    "test",
+			Type:      disastersv1.DisasterType_EARTHQUAKE,
+			Title:     "Test Quake",
+			Magnitude: 5.5,
+			Latitude:  35.0,
+			Longitude: 139.0,
+			Timestamp: time.Now(),
 		},
-	}
+	)
 
 	router := setupTestRouter(repo)
 
@@ -157,13 +83,11 @@ func TestGetDisasters_ReturnsGeoJSON(t *testing.T) {
 }
 
 func TestGetDisasters_TypeFilter(t *testing.T) {
-	repo := &mockRepo{
-		disasters: []models.Disaster{
-			{ID: "eq1", Type: disastersv1.DisasterType_EARTHQUAKE, Timestamp: time.Now()},
-			{ID: "fl1", Type: disastersv1.DisasterType_FLOOD, Timestamp: time.Now()},
-			{ID: "eq2", Type: disastersv1.DisasterType_EARTHQUAKE, Timestamp: time.Now()},
-		},
-	}
+	repo := newTestRepo(t,
+		models.Disaster{ID: "eq1", Type: disastersv1.DisasterType_EARTHQUAKE, Timestamp: time.Now()},
+		models.Disaster{ID: "fl1", Type: disastersv1.DisasterType_FLOOD, Timestamp: time.Now()},
+		models.Disaster{ID: "eq2", Type: disastersv1.DisasterType_EARTHQUAKE, Timestamp: time.Now()},
+	)
 
 	router := setupTestRouter(repo)
 
@@ -180,13 +104,11 @@ func TestGetDisasters_TypeFilter(t *testing.T) {
 }
 
 func TestGetDisasters_MagnitudeFilter(t *testing.T) {
-	repo := &mockRepo{
-		disasters: []models.Disaster{
-			{ID: "d1", Magnitude: 6.0, Timestamp: time.Now()},
-			{ID: "d2", Magnitude: 4.0, Timestamp: time.Now()},
-			{ID: "d3", Magnitude: 7.5, Timestamp: time.Now()},
-		},
-	}
+	repo := newTestRepo(t,
+		models.Disaster{ID: "d1", Magnitude: 6.0, Timestamp: time.Now()},
+		models.Disaster{ID: "d2", Magnitude: 4.0, Timestamp: time.Now()},
+		models.Disaster{ID: "d3", Magnitude: 7.5, Timestamp: time.Now()},
+	)
 
 	router := setupTestRouter(repo)
 
@@ -203,15 +125,13 @@ func TestGetDisasters_MagnitudeFilter(t *testing.T) {
 }
 
 func TestGetDisasters_LimitFilter(t *testing.T) {
-	repo := &mockRepo{
-		disasters: []models.Disaster{
-			{ID: "d1", Timestamp: time.Now()},
-			{ID: "d2", Timestamp: time.Now()},
-			{ID: "d3", Timestamp: time.Now()},
-			{ID: "d4", Timestamp: time.Now()},
-			{ID: "d5", Timestamp: time.Now()},
-		},
-	}
+	repo := newTestRepo(t,
+		models.Disaster{ID: "d1", Timestamp: time.Now()},
+		models.Disaster{ID: "d2", Timestamp: time.Now()},
+		models.Disaster{ID: "d3", Timestamp: time.Now()},
+		models.Disaster{ID: "d4", Timestamp: time.Now()},
+		models.Disaster{ID: "d5", Timestamp: time.Now()},
+	)
 
 	router := setupTestRouter(repo)
 
@@ -228,13 +148,11 @@ func TestGetDisasters_LimitFilter(t *testing.T) {
 }
 
 func TestGetDisasters_AlertLevelFilter(t *testing.T) {
-	repo := &mockRepo{
-		disasters: []models.Disaster{
-			{ID: "g1", AlertLevel: disastersv1.AlertLevel_GREEN, Timestamp: time.Now()},
-			{ID: "o1", AlertLevel: disastersv1.AlertLevel_ORANGE, Timestamp: time.Now()},
-			{ID: "r1", AlertLevel: disastersv1.AlertLevel_RED, Timestamp: time.Now()},
-		},
-	}
+	repo := newTestRepo(t,
+		models.Disaster{ID: "g1", AlertLevel: disastersv1.AlertLevel_GREEN, Timestamp: time.Now()},
+		models.Disaster{ID: "o1", AlertLevel: disastersv1.AlertLevel_ORANGE, Timestamp: time.Now()},
+		models.Disaster{ID: "r1", AlertLevel: disastersv1.AlertLevel_RED, Timestamp: time.Now()},
+	)
 
 	router := setupTestRouter(repo)
 
@@ -251,14 +169,12 @@ func TestGetDisasters_AlertLevelFilter(t *testing.T) {
 }
 
 func TestGetDisasters_MinAlertLevelFilter(t *testing.T) {
-	repo := &mockRepo{
-		disasters: []models.Disaster{
-			{ID: "g1", AlertLevel: disastersv1.AlertLevel_GREEN, Timestamp: time.Now()},
-			{ID: "g2", AlertLevel: disastersv1.AlertLevel_GREEN, Timestamp: time.Now()},
-			{ID: "o1", AlertLevel: disastersv1.AlertLevel_ORANGE, Timestamp: time.Now()},
-			{ID: "r1", AlertLevel: disastersv1.AlertLevel_RED, Timestamp: time.Now()},
-		},
-	}
+	repo := newTestRepo(t,
+		models.Disaster{ID: "g1", AlertLevel: disastersv1.AlertLevel_GREEN, Timestamp: time.Now()},
+		models.Disaster{ID: "g2", AlertLevel: disastersv1.AlertLevel_GREEN, Timestamp: time.Now()},
+		models.Disaster{ID: "o1", AlertLevel: disastersv1.AlertLevel_ORANGE, Timestamp: time.Now()},
+		models.Disaster{ID: "r1", AlertLevel: disastersv1.AlertLevel_RED, Timestamp: time.Now()},
+	)
 
 	router := setupTestRouter(repo)
 
@@ -274,8 +190,135 @@ func TestGetDisasters_MinAlertLevelFilter(t *testing.T) {
 	}
 }
 
+func TestGetDisasters_MultiTypeAndCountryFilter(t *testing.T) {
+	repo := newTestRepo(t,
+		models.Disaster{ID: "eq_jpn", Type: disastersv1.DisasterType_EARTHQUAKE, CountryISO: "JPN", Timestamp: time.Now()},
+		models.Disaster{ID: "fl_phl", Type: disastersv1.DisasterType_FLOOD, CountryISO: "PHL", Timestamp: time.Now()},
+		models.Disaster{ID: "vo_jpn", Type: disastersv1.DisasterType_VOLCANO, CountryISO: "JPN", Timestamp: time.Now()},
+		models.Disaster{ID: "eq_chl", Type: disastersv1.DisasterType_EARTHQUAKE, CountryISO: "CHL", Timestamp: time.Now()},
+	)
+
+	router := setupTestRouter(repo)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/disasters?type=earthquake,flood&country=jpn,PHL", nil)
+	router.ServeHTTP(w, req)
+
+	var fc FeatureCollection
+	json.Unmarshal(w.Body.Bytes(), &fc)
+
+	if len(fc.Features) != 2 {
+		t.Errorf("expected eq_jpn and fl_phl, got %d features", len(fc.Features))
+	}
+}
+
+func TestGetDisasters_Query(t *testing.T) {
+	repo := newTestRepo(t,
+		models.Disaster{ID: "etna", Title: "Volcanic eruption of Etna", Country: "Italy", Timestamp: time.Now()},
+		models.Disaster{ID: "luzon", Title: "Tropical Cyclone", Description: "Landfall over northern Luzon", Timestamp: time.Now()},
+	)
+	router := setupTestRouter(repo)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/disasters?q=LUZON", nil)
+	router.ServeHTTP(w, req)
+
+	var fc FeatureCollection
+	json.Unmarshal(w.Body.Bytes(), &fc)
+	if len(fc.Features) != 1 || fc.Features[0].Properties["id"] != "luzon" {
+		t.Errorf("expected only luzon, got %+v", fc.Features)
+	}
+}
+
+func TestGetDisasters_GeoFilters(t *testing.T) {
+	repo := newTestRepo(t,
+		models.Disaster{ID: "tokyo", Latitude: 35.68, Longitude: 139.69, Timestamp: time.Now()},
+		models.Disaster{ID: "osaka", Latitude: 34.69, Longitude: 135.50, Timestamp: time.Now()},
+		models.Disaster{ID: "fiji", Latitude: -17.7, Longitude: 178.1, Timestamp: time.Now()},
+		models.Disaster{ID: "tonga", Latitude: -21.2, Longitude: -175.2, Timestamp: time.Now()},
+	)
+
+	router := setupTestRouter(repo)
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"radius", "lat=35.68&lon=139.69&radius_km=100", 1},
+		{"larger radius", "lat=35.68&lon=139.69&radius_km=500", 2},
+		{"bbox", "bbox=130,30,145,40", 2},
+		{"antimeridian bbox", "bbox=170,-25,-170,-10", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/api/disasters?"+tt.query, nil)
+			router.ServeHTTP(w, req)
+
+			var fc FeatureCollection
+			json.Unmarshal(w.Body.Bytes(), &fc)
+			if len(fc.Features) != tt.want {
+				t.Errorf("expected %d disasters, got %d", tt.want, len(fc.Features))
+			}
+		})
+	}
+}
+
+func TestGetDisasters_InvalidGeoFilter(t *testing.T) {
+	router := setupTestRouter(newTestRepo(t))
+
+	for _, query := range []string{"bbox=1,2,3", "bbox=0,50,10,40", "lat=35&lon=139", "lat=35&lon=139&radius_km=-5"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/disasters?"+query, nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestSearchDisasters_Polygon(t *testing.T) {
+	repo := newTestRepo(t,
+		models.Disaster{ID: "baguio", Type: disastersv1.DisasterType_EARTHQUAKE, Latitude: 16.41, Longitude: 120.60, Timestamp: time.Now()},
+		models.Disaster{ID: "tuguegarao", Type: disastersv1.DisasterType_FLOOD, Latitude: 17.61, Longitude: 121.73, Timestamp: time.Now()},
+		models.Disaster{ID: "cebu", Type: disastersv1.DisasterType_EARTHQUAKE, Latitude: 10.32, Longitude: 123.89, Timestamp: time.Now()},
+	)
+	router := setupTestRouter(repo)
+
+	area := `{"type": "Polygon", "coordinates": [[[119.5, 13.5], [124.5, 13.5], [122.5, 18.8], [120.0, 18.8], [119.5, 13.5]]]}`
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/disasters/search?type=earthquake", strings.NewReader(area))
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var fc FeatureCollection
+	json.Unmarshal(w.Body.Bytes(), &fc)
+	if len(fc.Features) != 1 || fc.Features[0].Properties["id"] != "baguio" {
+		t.Errorf("expected only baguio, got %+v", fc.Features)
+	}
+}
+
+func TestSearchDisasters_InvalidArea(t *testing.T) {
+	router := setupTestRouter(newTestRepo(t))
+
+	for _, body := range []string{``, `{"type": "Point", "coordinates": [1, 2]}`, `{"type": "Polygon", "coordinates": [[[0, 0]]]}`} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/disasters/search", strings.NewReader(body))
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected status 400, got %d", body, w.Code)
+		}
+	}
+}
+
 func TestCreateTestDisaster(t *testing.T) {
-	repo := &mockRepo{}
+	repo := newTestRepo(t)
 	router := setupTestRouter(repo)
 
 	w := httptest.NewRecorder()
@@ -295,7 +338,7 @@ func TestCreateTestDisaster(t *testing.T) {
 }
 
 func TestHealth(t *testing.T) {
-	router := setupTestRouter(&mockRepo{})
+	router := setupTestRouter(newTestRepo(t))
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/health", nil)
@@ -312,3 +355,152 @@ func TestHealth(t *testing.T) {
 		t.Errorf("expected status ok, got %s", resp["status"])
 	}
 }
+
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	b := internalgrpc.NewBroadcaster()
+	id, _ := b.Subscribe()
+	defer b.Unsubscribe(id)
+	NewHandler(newTestRepo(t), b).RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/metrics", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+
+	var resp struct {
+		Stream internalgrpc.BroadcasterMetrics `json:"stream"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp.Stream.Subscribers != 1 {
+		t.Errorf("expected 1 subscriber, got %d", resp.Stream.Subscribers)
+	}
+}
+
+func TestGetDisasters_Pagination(t *testing.T) {
+	db, err := repository.NewSQLiteDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
+	}
+	defer db.Close()
+	for i := 0; i < 5; i++ {
+		d := &models.Disaster{ID: fmt.Sprintf("d%d", i), Source: "test", Title: "Quake", Type: disastersv1.DisasterType_EARTHQUAKE,
+			Timestamp: time.Now().Add(-time.Duration(i) * time.Hour), CreatedAt: time.Now()}
+		if err := db.Add(context.Background(), d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	router := setupTestRouter(db)
+
+	var ids []any
+	next := "/api/disasters?type=earthquake&limit=2"
+	for pages := 0; next != ""; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination did not terminate")
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", next, nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var fc FeatureCollection
+		json.Unmarshal(w.Body.Bytes(), &fc)
+		for _, f := range fc.Features {
+			ids = append(ids, f.Properties["id"])
+		}
+
+		next = ""
+		if link := w.Header().Get("Link"); link != "" {
+			next = strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`)
+			if !strings.Contains(next, "type=earthquake") || !strings.Contains(next, "page_token="+fc.NextPageToken) {
+				t.Errorf("expected Link to keep filters and carry next_page_token, got %s", link)
+			}
+		} else if fc.NextPageToken != "" {
+			t.Error("expected a Link header alongside next_page_token")
+		}
+	}
+
+	if fmt.Sprint(ids) != "[d0 d1 d2 d3 d4]" {
+		t.Errorf("expected every disaster once in order, got %v", ids)
+	}
+}
+
+func TestGetDisasters_InvalidPageToken(t *testing.T) {
+	db, err := repository.NewSQLiteDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
+	}
+	defer db.Close()
+	router := setupTestRouter(db)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/disasters?page_token=bogus", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestGetDisasters_SortAndRange(t *testing.T) {
+	db, err := repository.NewSQLiteDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
+	}
+	defer db.Close()
+	day := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	for i, mag := range []float64{5.1, 7.3, 6.0, 4.2} {
+		d := &models.Disaster{ID: fmt.Sprintf("d%d", i), Source: "GDACS", Title: "Quake", Magnitude: mag,
+			Timestamp: day.AddDate(0, 0, -i), CreatedAt: time.Now()}
+		if err := db.Add(context.Background(), d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	router := setupTestRouter(db)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/disasters?sort=magnitude&order=asc&since=2026-03-08&until=2026-03-09&max_magnitude=7&source=gdacs", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var fc FeatureCollection
+	json.Unmarshal(w.Body.Bytes(), &fc)
+	var ids []any
+	for _, f := range fc.Features {
+		ids = append(ids, f.Properties["id"])
+	}
+	// until is a whole day, so March 9 is included; d1 is over the max magnitude
+	if fmt.Sprint(ids) != "[d2]" {
+		t.Errorf("expected [d2], got %v", ids)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/disasters?sort=magnitude", nil)
+	router.ServeHTTP(w, req)
+	json.Unmarshal(w.Body.Bytes(), &fc)
+	if len(fc.Features) != 4 || fc.Features[0].Properties["id"] != "d1" || fc.Features[3].Properties["id"] != "d3" {
+		t.Errorf("expected strongest first, got %+v", fc.Features)
+	}
+}
+
+func TestGetDisasters_InvalidSort(t *testing.T) {
+	router := setupTestRouter(newTestRepo(t))
+
+	for _, q := range []string{"sort=depth", "sort=distance", "order=up", "time_field=updated"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/disasters?"+q, nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", q, w.Code)
+		}
+	}
+}
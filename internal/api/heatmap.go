@@ -0,0 +1,110 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+// maxHeatmapZoom is the deepest web map zoom level GET /api/disasters/heatmap accepts
+const maxHeatmapZoom = 20
+
+type heatmapCellJSON struct {
+	Key    string     `json:"key"`
+	Lat    float64    `json:"lat"` // Center of the cell
+	Lon    float64    `json:"lon"`
+	BBox   [4]float64 `json:"bbox"` // minLon,minLat,maxLon,maxLat, like the bbox param
+	Count  int64      `json:"count"`
+	Weight float64    `json:"weight"`
+}
+
+// heatmapJSON is the response body of GET /api/disasters/heatmap
+type heatmapJSON struct {
+	Grid      string            `json:"grid"`
+	Zoom      int               `json:"zoom"`
+	Precision int               `json:"precision,omitempty"` // Geohash length, for the geohash grid
+	CellSize  float64           `json:"cell_size,omitempty"` // Degrees, for the degree grid
+	Weight    string            `json:"weight"`
+	MaxWeight float64           `json:"max_weight"`
+	Cells     []heatmapCellJSON `json:"cells"`
+}
+
+// getHeatmap aggregates the disasters matching the filters of GET /api/disasters into the cells of a
+// grid sized for a web map zoom level, so maps can draw density without fetching every disaster
+func (h *Handler) getHeatmap(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	weight, err := repository.ParseHeatmapWeight(c.Query("weight"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	resp := heatmapJSON{Grid: c.DefaultQuery("grid", "geohash"), Weight: string(weight), Cells: []heatmapCellJSON{}}
+	if z := c.Query("zoom"); z != "" {
+		resp.Zoom, err = strconv.Atoi(z)
+		if err != nil || resp.Zoom < 0 || resp.Zoom > maxHeatmapZoom {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("zoom must be an integer between 0 and %d", maxHeatmapZoom),
+			})
+			return
+		}
+	}
+	var grid geo.Grid
+	switch resp.Grid {
+	case "geohash":
+		resp.Precision = geohashPrecision(resp.Zoom)
+		grid = geo.GeohashGrid{Precision: resp.Precision}
+	case "degree":
+		resp.CellSize = degreeCellSize(resp.Zoom)
+		grid = geo.DegreeGrid{Size: resp.CellSize}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid grid %q, expected geohash or degree", resp.Grid),
+		})
+		return
+	}
+
+	cells, err := h.repo.Heatmap(c.Request.Context(), filter, grid, weight)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to compute heatmap",
+		})
+		return
+	}
+	for _, cell := range cells {
+		b := cell.Bounds
+		resp.Cells = append(resp.Cells, heatmapCellJSON{
+			Key:    cell.Key,
+			Lat:    (b.MinLat + b.MaxLat) / 2,
+			Lon:    (b.MinLon + b.MaxLon) / 2,
+			BBox:   [4]float64{b.MinLon, b.MinLat, b.MaxLon, b.MaxLat},
+			Count:  cell.Count,
+			Weight: cell.Weight,
+		})
+		resp.MaxWeight = max(resp.MaxWeight, cell.Weight)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// degreeCellSize returns cells 1/8 of a 256-pixel map tile across at a zoom level, about 32 pixels
+func degreeCellSize(zoom int) float64 {
+	return 360 / math.Exp2(float64(zoom+3))
+}
+
+// geohashPrecision returns the geohash length whose cells are closest in width to degreeCellSize.
+// A geohash of length n halves the longitude range ceil(5n/2) times.
+func geohashPrecision(zoom int) int {
+	return min(max((2*(zoom+3)+2)/5, 1), geo.MaxGeohashPrecision)
+}
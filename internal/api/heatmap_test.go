@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+)
+
+func TestGetHeatmap(t *testing.T) {
+	now := time.Now()
+	repo := newTestRepo(t,
+		models.Disaster{ID: "tokyo", Source: "GDACS", AlertLevel: disastersv1.AlertLevel_RED, AffectedPopulationCount: 1000,
+			Latitude: 35.7, Longitude: 139.7, Timestamp: now, CreatedAt: now},
+		models.Disaster{ID: "chiba", Source: "GDACS", AlertLevel: disastersv1.AlertLevel_GREEN, AffectedPopulationCount: 50,
+			Latitude: 35.6, Longitude: 139.9, Timestamp: now, CreatedAt: now},
+		models.Disaster{ID: "luzon", Source: "GDACS", AlertLevel: disastersv1.AlertLevel_ORANGE, AffectedPopulationCount: 5000,
+			Latitude: 14.6, Longitude: 121, Timestamp: now, CreatedAt: now},
+	)
+	router := setupTestRouter(repo)
+
+	get := func(query string) (int, heatmapJSON) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/disasters/heatmap?"+query, nil)
+		router.ServeHTTP(w, req)
+		var heatmap heatmapJSON
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &heatmap); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+		}
+		return w.Code, heatmap
+	}
+
+	code, heatmap := get("zoom=2&weight=alert_level")
+	if code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if heatmap.Grid != "geohash" || heatmap.Precision != 2 || heatmap.Weight != "alert_level" || heatmap.MaxWeight != 4 {
+		t.Errorf("unexpected heatmap %+v", heatmap)
+	}
+	if len(heatmap.Cells) != 2 || heatmap.Cells[0].Key != "wd" || heatmap.Cells[1].Key != "xn" || heatmap.Cells[1].Count != 2 || heatmap.Cells[1].Weight != 4 {
+		t.Errorf("expected cells wd and xn, got %+v", heatmap.Cells)
+	}
+	tokyo := heatmap.Cells[1]
+	if tokyo.BBox != [4]float64{135, 33.75, 146.25, 39.375} || tokyo.Lat != 36.5625 || tokyo.Lon != 140.625 {
+		t.Errorf("unexpected bounds of xn: %+v", tokyo)
+	}
+
+	// Filters and the degree grid, 360/2^8 degrees across at zoom 5
+	_, heatmap = get("grid=degree&zoom=5&weight=population&bbox=130,30,145,40")
+	if heatmap.CellSize != 1.40625 || len(heatmap.Cells) != 1 || heatmap.Cells[0].Count != 2 || heatmap.Cells[0].Weight != 1050 {
+		t.Errorf("expected one Tokyo cell weighing 1050, got %+v", heatmap)
+	}
+
+	for _, query := range []string{"zoom=21", "zoom=-1", "zoom=far", "grid=hex", "weight=magnitude", "bbox=1,2,3"} {
+		if code, _ := get(query); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, code)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+type statsGroupJSON struct {
+	Key                     string  `json:"key"`
+	Count                   int64   `json:"count"`
+	MaxMagnitude            float64 `json:"max_magnitude"`
+	TotalAffectedPopulation int64   `json:"total_affected_population"`
+}
+
+type statsTotalJSON struct {
+	Count                   int64   `json:"count"`
+	MaxMagnitude            float64 `json:"max_magnitude"`
+	TotalAffectedPopulation int64   `json:"total_affected_population"`
+}
+
+// statsJSON is the response body of GET /api/stats
+type statsJSON struct {
+	Total        statsTotalJSON   `json:"total"`
+	Bucket       string           `json:"bucket"`
+	ByType       []statsGroupJSON `json:"by_type"`
+	ByAlertLevel []statsGroupJSON `json:"by_alert_level"`
+	ByCountry    []statsGroupJSON `json:"by_country"`
+	BySource     []statsGroupJSON `json:"by_source"`
+	ByTime       []statsGroupJSON `json:"by_time"`
+}
+
+type timelineBucketJSON struct {
+	Start  string           `json:"start"`
+	Count  int64            `json:"count"`
+	ByType map[string]int64 `json:"by_type"`
+}
+
+// timelineJSON is the response body of GET /api/disasters/timeline
+type timelineJSON struct {
+	Bucket  string               `json:"bucket"`
+	Buckets []timelineBucketJSON `json:"buckets"`
+}
+
+// maxTimelineBuckets caps the buckets of a timeline, so a long range needs a larger bucket
+const maxTimelineBuckets = 1000
+
+// getStats aggregates the disasters matching the filters of GET /api/disasters, grouped by type,
+// alert level, country, source and the time bucket given by bucket (day, week or month).
+func (h *Handler) getStats(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	bucket, err := repository.ParseTimeBucket(c.Query("bucket"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	stats, err := h.repo.Stats(c.Request.Context(), filter, bucket)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to compute stats",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, toStatsJSON(stats))
+}
+
+// getTimeline counts the disasters matching the filters of GET /api/disasters per time bucket and
+// per type. Empty buckets between since and until, or between the first and last match, are included.
+func (h *Handler) getTimeline(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	bucket, err := repository.ParseTimeBucket(c.Query("bucket"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	stats, err := h.repo.Stats(c.Request.Context(), filter, bucket)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to compute timeline",
+		})
+		return
+	}
+	timeline, err := toTimelineJSON(stats, filter.Since, filter.Until)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, timeline)
+}
+
+// toTimelineJSON lists every bucket from the one since falls in, or the first in stats, up to the
+// one before until, or the last in stats
+func toTimelineJSON(stats *repository.Stats, since, until *time.Time) (timelineJSON, error) {
+	counts := make(map[string]repository.TimelineBucket, len(stats.Timeline))
+	for _, b := range stats.Timeline {
+		counts[b.Key] = b
+	}
+	var first, last time.Time
+	if n := len(stats.Timeline); n > 0 {
+		first, _ = time.Parse(time.DateOnly, stats.Timeline[0].Key)
+		last, _ = time.Parse(time.DateOnly, stats.Timeline[n-1].Key)
+	}
+	if since != nil {
+		first = stats.Bucket.Start(*since)
+	}
+	if until != nil {
+		last = stats.Bucket.Start(until.Add(-time.Nanosecond)) // until is exclusive
+	}
+
+	timeline := timelineJSON{Bucket: string(stats.Bucket), Buckets: []timelineBucketJSON{}}
+	if first.IsZero() || last.IsZero() {
+		return timeline, nil // no matches and an open range
+	}
+	for start := first; !start.After(last); start = stats.Bucket.Next(start) {
+		if len(timeline.Buckets) == maxTimelineBuckets {
+			return timelineJSON{}, fmt.Errorf("the range spans more than %d buckets, use a larger bucket or a shorter range", maxTimelineBuckets)
+		}
+		key := start.Format(time.DateOnly)
+		b := timelineBucketJSON{Start: key, ByType: map[string]int64{}}
+		if tb, ok := counts[key]; ok {
+			b.Count = tb.Count
+			for _, g := range tb.ByType {
+				b.ByType[strings.ToLower(g.Key)] = g.Count
+			}
+		}
+		timeline.Buckets = append(timeline.Buckets, b)
+	}
+	return timeline, nil
+}
+
+func toStatsJSON(s *repository.Stats) statsJSON {
+	return statsJSON{
+		Total: statsTotalJSON{
+			Count:                   s.Total.Count,
+			MaxMagnitude:            s.Total.MaxMagnitude,
+			TotalAffectedPopulation: s.Total.TotalAffectedPopulation,
+		},
+		Bucket:       string(s.Bucket),
+		ByType:       toStatsGroupsJSON(s.ByType, true),
+		ByAlertLevel: toStatsGroupsJSON(s.ByAlertLevel, true),
+		ByCountry:    toStatsGroupsJSON(s.ByCountry, false),
+		BySource:     toStatsGroupsJSON(s.BySource, false),
+		ByTime:       toStatsGroupsJSON(s.ByTime, false),
+	}
+}
+
+// toStatsGroupsJSON converts groups, lowercasing keys that are enum names as disaster properties do
+func toStatsGroupsJSON(groups []repository.StatsGroup, lower bool) []statsGroupJSON {
+	out := make([]statsGroupJSON, len(groups))
+	for i, g := range groups {
+		out[i] = statsGroupJSON(g)
+		if lower {
+			out[i].Key = strings.ToLower(g.Key)
+		}
+	}
+	return out
+}
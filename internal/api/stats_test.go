@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+)
+
+func TestGetStats(t *testing.T) {
+	day := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	repo := newTestRepo(t,
+		models.Disaster{ID: "q1", Source: "GDACS", Type: disastersv1.DisasterType_EARTHQUAKE, AlertLevel: disastersv1.AlertLevel_RED,
+			Magnitude: 6.8, AffectedPopulationCount: 300, CountryISO: "JPN", Timestamp: day, CreatedAt: day},
+		models.Disaster{ID: "q2", Source: "GDACS", Type: disastersv1.DisasterType_EARTHQUAKE, AlertLevel: disastersv1.AlertLevel_GREEN,
+			Magnitude: 4.9, AffectedPopulationCount: 20, CountryISO: "JPN", Timestamp: day.AddDate(0, 0, 9), CreatedAt: day},
+		models.Disaster{ID: "f1", Source: "GDACS", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_ORANGE,
+			AffectedPopulationCount: 4000, CountryISO: "PHL", Timestamp: day.AddDate(0, 1, 0), CreatedAt: day},
+	)
+	router := setupTestRouter(repo)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/stats?bucket=month&country=JPN", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var stats statsJSON
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if stats.Total != (statsTotalJSON{Count: 2, MaxMagnitude: 6.8, TotalAffectedPopulation: 320}) {
+		t.Errorf("unexpected total %+v", stats.Total)
+	}
+	if stats.Bucket != "month" || len(stats.ByTime) != 1 || stats.ByTime[0].Key != "2026-03-01" || stats.ByTime[0].Count != 2 {
+		t.Errorf("expected one March bucket, got %s %+v", stats.Bucket, stats.ByTime)
+	}
+	if len(stats.ByType) != 1 || stats.ByType[0] != (statsGroupJSON{Key: "earthquake", Count: 2, MaxMagnitude: 6.8, TotalAffectedPopulation: 320}) {
+		t.Errorf("unexpected type groups %+v", stats.ByType)
+	}
+	if len(stats.ByAlertLevel) != 2 || stats.ByAlertLevel[0].Key != "green" || stats.ByAlertLevel[1].Key != "red" {
+		t.Errorf("expected green and red groups, got %+v", stats.ByAlertLevel)
+	}
+
+	// The default limit of listings doesn't apply
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/stats?limit=1", nil)
+	router.ServeHTTP(w, req)
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if stats.Total.Count != 3 || stats.Bucket != "day" || len(stats.ByTime) != 3 {
+		t.Errorf("expected 3 disasters in 3 daily buckets, got %+v", stats)
+	}
+
+	for _, query := range []string{"bucket=year", "sort=distance"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/stats?"+query, nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestGetTimeline(t *testing.T) {
+	day := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	repo := newTestRepo(t,
+		models.Disaster{ID: "q1", Source: "GDACS", Type: disastersv1.DisasterType_EARTHQUAKE, Timestamp: day, CreatedAt: day},
+		models.Disaster{ID: "q2", Source: "GDACS", Type: disastersv1.DisasterType_EARTHQUAKE, Timestamp: day.Add(time.Hour), CreatedAt: day},
+		models.Disaster{ID: "f1", Source: "GDACS", Type: disastersv1.DisasterType_FLOOD, Timestamp: day.AddDate(0, 0, 14), CreatedAt: day},
+	)
+	router := setupTestRouter(repo)
+
+	get := func(query string) (int, timelineJSON) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/disasters/timeline?"+query, nil)
+		router.ServeHTTP(w, req)
+		var timeline timelineJSON
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &timeline); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+		}
+		return w.Code, timeline
+	}
+
+	// Empty weeks between the first and last match are included
+	code, timeline := get("bucket=week")
+	if code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	want := []timelineBucketJSON{
+		{Start: "2026-03-02", Count: 2, ByType: map[string]int64{"earthquake": 2}},
+		{Start: "2026-03-09", Count: 0, ByType: map[string]int64{}},
+		{Start: "2026-03-16", Count: 1, ByType: map[string]int64{"flood": 1}},
+	}
+	if timeline.Bucket != "week" || fmt.Sprint(timeline.Buckets) != fmt.Sprint(want) {
+		t.Errorf("expected weeks %v, got %s %v", want, timeline.Bucket, timeline.Buckets)
+	}
+
+	// The range extends the timeline past the matches, until being exclusive
+	_, timeline = get("since=2026-02-28&until=2026-03-04T00:00:00Z&type=earthquake")
+	if len(timeline.Buckets) != 4 || timeline.Buckets[0].Start != "2026-02-28" || timeline.Buckets[3].Start != "2026-03-03" ||
+		timeline.Buckets[2].Count != 2 {
+		t.Errorf("expected 4 days from Feb 28 with 2 earthquakes on Mar 2, got %v", timeline.Buckets)
+	}
+
+	if _, timeline = get("type=volcano&bucket=month"); timeline.Buckets == nil || len(timeline.Buckets) != 0 {
+		t.Errorf("expected no buckets, got %v", timeline.Buckets)
+	}
+
+	for _, query := range []string{"bucket=year", "since=2000-01-01&until=2026-01-01"} {
+		if code, _ := get(query); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, code)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Package backup writes timestamped database backups to a directory and rotates old ones.
+package backup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+const (
+	filePrefix = "disaster-alerts-"
+	fileSuffix = ".db"
+	timeFormat = "20060102T150405.000Z" // sorts in creation order
+)
+
+// Info describes a backup file
+type Info struct {
+	Path      string    `json:"path"`
+	SizeBytes int64     `json:"size_bytes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Manager creates backups in a directory, keeping only the newest ones
+type Manager struct {
+	db   repository.Backuper
+	dir  string
+	keep int
+	now  func() time.Time
+	mu   sync.Mutex // one backup at a time
+	wg   sync.WaitGroup
+}
+
+// NewManager keeps up to keep backups in dir, all of them if keep is 0
+func NewManager(db repository.Backuper, dir string, keep int) *Manager {
+	return &Manager{db: db, dir: dir, keep: keep, now: time.Now}
+}
+
+// Create writes a new backup and removes those beyond the newest keep
+func (m *Manager) Create(ctx context.Context) (*Info, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return nil, err
+	}
+	createdAt := m.now().UTC()
+	path := filepath.Join(m.dir, filePrefix+createdAt.Format(timeFormat)+fileSuffix)
+	if err := m.db.Backup(ctx, path); err != nil {
+		return nil, fmt.Errorf("error backing up to %s: %w", path, err)
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	info := &Info{Path: path, SizeBytes: stat.Size(), CreatedAt: createdAt}
+
+	if err := m.rotate(); err != nil {
+		slog.Error("error removing old backups", "dir", m.dir, "error", err)
+	}
+	return info, nil
+}
+
+// rotate removes all but the newest keep backups
+func (m *Manager) rotate() error {
+	if m.keep == 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return err
+	}
+	var backups []string
+	for _, e := range entries {
+		if name := e.Name(); !e.IsDir() && strings.HasPrefix(name, filePrefix) && strings.HasSuffix(name, fileSuffix) {
+			backups = append(backups, name)
+		}
+	}
+	slices.Sort(backups)
+	for _, name := range backups[:max(len(backups)-m.keep, 0)] {
+		if err := os.Remove(filepath.Join(m.dir, name)); err != nil {
+			return err
+		}
+		slog.Info("removed old backup", "file", name)
+	}
+	return nil
+}
+
+// Start creates a backup at every interval until ctx is canceled
+func (m *Manager) Start(ctx context.Context, interval time.Duration) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		slog.Info("starting scheduled backups", "dir", m.dir, "interval", interval, "keep", m.keep)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				info, err := m.Create(ctx)
+				if err != nil {
+					if ctx.Err() == nil {
+						slog.Error("scheduled backup failed", "error", err)
+					}
+					continue
+				}
+				slog.Info("backup created", "path", info.Path, "size_bytes", info.SizeBytes)
+			}
+		}
+	}()
+}
+
+// Stop waits for scheduled backups to finish after their context is canceled
+func (m *Manager) Stop() {
+	m.wg.Wait()
+}
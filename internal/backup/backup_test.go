@@ -0,0 +1,91 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+	"go.uber.org/goleak"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+func TestManager_CreateRotates(t *testing.T) {
+	ctx := context.Background()
+	db, err := repository.NewSQLiteDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
+	}
+	defer db.Close()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/notes.txt", []byte("not a backup"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(db, dir, 2)
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time {
+		now = now.Add(time.Hour)
+		return now
+	}
+
+	var created []string
+	for range 3 {
+		info, err := m.Create(ctx)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if info.SizeBytes == 0 || !info.CreatedAt.Equal(now) {
+			t.Errorf("unexpected backup info %+v", info)
+		}
+		created = append(created, info.Path)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, dir+"/"+e.Name())
+	}
+	if want := append(created[1:], dir+"/notes.txt"); !slices.Equal(names, want) {
+		t.Errorf("expected %v, got %v", want, names)
+	}
+
+	// Backups are usable databases
+	restored, err := repository.NewSQLiteDB(created[2])
+	if err != nil {
+		t.Fatalf("failed to open backup: %v", err)
+	}
+	restored.Close()
+}
+
+func TestManager_Start(t *testing.T) {
+	db, err := repository.NewSQLiteDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
+	}
+	defer db.Close()
+
+	dir := t.TempDir()
+	m := NewManager(db, dir, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	m.Start(ctx, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	m.Stop()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected 1 rotated backup, got %d", len(entries))
+	}
+}
@@ -0,0 +1,134 @@
+// Package geo provides the geographic primitives used to filter disasters by location.
+package geo
+
+import (
+	"errors"
+	"math"
+)
+
+// EarthRadiusKm is the mean Earth radius used for haversine distances
+const EarthRadiusKm = 6371.0088
+
+// DistanceKm returns the great-circle distance between two points using the haversine formula
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := radians(lat2 - lat1)
+	dLon := radians(lon2 - lon1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(radians(lat1))*math.Cos(radians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Sqrt(math.Min(1, a)))
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+// BBox is a latitude/longitude rectangle. A box with MinLon > MaxLon crosses the antimeridian,
+// e.g. MinLon 170, MaxLon -170 covers the 20 degrees around longitude 180.
+type BBox struct {
+	MinLat float64
+	MinLon float64
+	MaxLat float64
+	MaxLon float64
+}
+
+// CrossesAntimeridian reports whether the box wraps from MinLon east past 180 to MaxLon
+func (b BBox) CrossesAntimeridian() bool {
+	return b.MinLon > b.MaxLon
+}
+
+func (b BBox) Contains(lat, lon float64) bool {
+	if lat < b.MinLat || lat > b.MaxLat {
+		return false
+	}
+	if b.CrossesAntimeridian() {
+		return lon >= b.MinLon || lon <= b.MaxLon
+	}
+	return lon >= b.MinLon && lon <= b.MaxLon
+}
+
+// Unwrapped returns b as one box, or as its two halves either side of the antimeridian
+// when it crosses it, so each can be queried with plain range comparisons.
+func (b BBox) Unwrapped() []BBox {
+	if !b.CrossesAntimeridian() {
+		return []BBox{b}
+	}
+	return []BBox{
+		{MinLat: b.MinLat, MinLon: b.MinLon, MaxLat: b.MaxLat, MaxLon: 180},
+		{MinLat: b.MinLat, MinLon: -180, MaxLat: b.MaxLat, MaxLon: b.MaxLon},
+	}
+}
+
+func (b BBox) Validate() error {
+	if !validLat(b.MinLat) || !validLat(b.MaxLat) {
+		return errors.New("bbox latitude must be between -90 and 90")
+	}
+	if !validLon(b.MinLon) || !validLon(b.MaxLon) {
+		return errors.New("bbox longitude must be between -180 and 180")
+	}
+	if b.MinLat > b.MaxLat {
+		return errors.New("bbox min latitude must not exceed max latitude")
+	}
+	return nil
+}
+
+// Circle is a center point plus a radius in kilometres
+type Circle struct {
+	Lat      float64
+	Lon      float64
+	RadiusKm float64
+}
+
+func (c Circle) Contains(lat, lon float64) bool {
+	return DistanceKm(c.Lat, c.Lon, lat, lon) <= c.RadiusKm
+}
+
+// Bounds returns the smallest box containing the circle. Circles reaching a pole span every
+// longitude; circles reaching past 180 degrees longitude return a box crossing the antimeridian.
+func (c Circle) Bounds() BBox {
+	angular := c.RadiusKm / EarthRadiusKm
+	dLat := degrees(angular)
+
+	b := BBox{
+		MinLat: math.Max(-90, c.Lat-dLat),
+		MaxLat: math.Min(90, c.Lat+dLat),
+		MinLon: -180,
+		MaxLon: 180,
+	}
+	if c.Lat+dLat >= 90 || c.Lat-dLat <= -90 {
+		return b
+	}
+
+	dLon := degrees(math.Asin(math.Min(1, math.Sin(angular)/math.Cos(radians(c.Lat)))))
+	b.MinLon = c.Lon - dLon
+	b.MaxLon = c.Lon + dLon
+	if b.MinLon < -180 {
+		b.MinLon += 360
+	}
+	if b.MaxLon > 180 {
+		b.MaxLon -= 360
+	}
+	return b
+}
+
+func (c Circle) Validate() error {
+	if !validLat(c.Lat) || !validLon(c.Lon) {
+		return errors.New("center must be a valid latitude/longitude")
+	}
+	if c.RadiusKm <= 0 || math.IsNaN(c.RadiusKm) {
+		return errors.New("radius must be positive")
+	}
+	return nil
+}
+
+func validLat(lat float64) bool {
+	return lat >= -90 && lat <= 90
+}
+
+func validLon(lon float64) bool {
+	return lon >= -180 && lon <= 180
+}
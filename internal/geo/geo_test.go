@@ -0,0 +1,129 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistanceKm(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		want                   float64
+	}{
+		{"same point", 35.68, 139.69, 35.68, 139.69, 0},
+		{"Tokyo to Osaka", 35.6762, 139.6503, 34.6937, 135.5023, 392},
+		{"across the antimeridian", 0, 179.5, 0, -179.5, 111},
+		{"pole to pole", 90, 0, -90, 0, 20015},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DistanceKm(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+			if math.Abs(got-tt.want) > 1 {
+				t.Errorf("DistanceKm = %.1f, want ~%.0f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBBox_Contains(t *testing.T) {
+	japan := BBox{MinLat: 24, MinLon: 122, MaxLat: 46, MaxLon: 146}
+	fiji := BBox{MinLat: -21, MinLon: 176, MaxLat: -12, MaxLon: -178} // crosses the antimeridian
+
+	tests := []struct {
+		name     string
+		box      BBox
+		lat, lon float64
+		want     bool
+	}{
+		{"inside", japan, 35.68, 139.69, true},
+		{"on edge", japan, 24, 122, true},
+		{"outside longitude", japan, 35, 150, false},
+		{"outside latitude", japan, 50, 139, false},
+		{"antimeridian east side", fiji, -17, 178.4, true},
+		{"antimeridian west side", fiji, -17, -179, true},
+		{"antimeridian gap", fiji, -17, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.box.Contains(tt.lat, tt.lon); got != tt.want {
+				t.Errorf("Contains(%v, %v) = %v, want %v", tt.lat, tt.lon, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (BBox{MinLat: 10, MinLon: 0, MaxLat: 5, MaxLon: 10}).Validate(); err == nil {
+		t.Error("expected error for inverted latitude")
+	}
+	if err := (BBox{MinLat: 0, MinLon: 170, MaxLat: 5, MaxLon: -170}).Validate(); err != nil {
+		t.Errorf("expected antimeridian box to be valid, got %v", err)
+	}
+	if err := (Circle{Lat: 0, Lon: 0, RadiusKm: 0}).Validate(); err == nil {
+		t.Error("expected error for zero radius")
+	}
+	if err := (Circle{Lat: 91, Lon: 0, RadiusKm: 10}).Validate(); err == nil {
+		t.Error("expected error for invalid center")
+	}
+}
+
+func TestCircle_Bounds(t *testing.T) {
+	tests := []struct {
+		name   string
+		circle Circle
+		cross  bool
+		full   bool
+	}{
+		{"mid latitude", Circle{Lat: 35.68, Lon: 139.69, RadiusKm: 300}, false, false},
+		{"near antimeridian", Circle{Lat: -17.7, Lon: 178.1, RadiusKm: 500}, true, false},
+		{"near antimeridian west", Circle{Lat: -21.2, Lon: -175.2, RadiusKm: 800}, true, false},
+		{"covers pole", Circle{Lat: 85, Lon: 10, RadiusKm: 1000}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.circle.Bounds()
+			if b.CrossesAntimeridian() != tt.cross {
+				t.Errorf("CrossesAntimeridian = %v, want %v (%+v)", b.CrossesAntimeridian(), tt.cross, b)
+			}
+			if full := b.MinLon == -180 && b.MaxLon == 180; full != tt.full {
+				t.Errorf("full longitude = %v, want %v (%+v)", full, tt.full, b)
+			}
+
+			// Points on the circle's edge must fall inside its bounds
+			for bearing := 0.0; bearing < 360; bearing += 15 {
+				lat, lon := destination(tt.circle, bearing)
+				if !b.Contains(lat, lon) {
+					t.Errorf("edge point (%.3f, %.3f) at bearing %.0f outside bounds %+v", lat, lon, bearing, b)
+				}
+			}
+		})
+	}
+}
+
+func TestBBox_Unwrapped(t *testing.T) {
+	b := BBox{MinLat: -25, MinLon: 170, MaxLat: -10, MaxLon: -170}
+	parts := b.Unwrapped()
+	if len(parts) != 2 {
+		t.Fatalf("expected 2 parts, got %d", len(parts))
+	}
+	if parts[0].MaxLon != 180 || parts[1].MinLon != -180 {
+		t.Errorf("expected split at the antimeridian, got %+v", parts)
+	}
+	if got := (BBox{MinLat: 0, MinLon: 0, MaxLat: 1, MaxLon: 1}).Unwrapped(); len(got) != 1 {
+		t.Errorf("expected a non-crossing box to stay whole, got %d parts", len(got))
+	}
+}
+
+// destination returns the point slightly inside the circle's edge at the given bearing
+func destination(c Circle, bearingDeg float64) (float64, float64) {
+	angular := c.RadiusKm * 0.999 / EarthRadiusKm
+	lat1, lon1, brng := radians(c.Lat), radians(c.Lon), radians(bearingDeg)
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(angular) + math.Cos(lat1)*math.Sin(angular)*math.Cos(brng))
+	lon2 := lon1 + math.Atan2(math.Sin(brng)*math.Sin(angular)*math.Cos(lat1), math.Cos(angular)-math.Sin(lat1)*math.Sin(lat2))
+	lon := math.Mod(degrees(lon2)+540, 360) - 180
+	return degrees(lat2), lon
+}
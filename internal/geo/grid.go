@@ -0,0 +1,80 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// MaxGeohashPrecision is the longest geohash a GeohashGrid produces, cells about 4 cm across
+const MaxGeohashPrecision = 12
+
+// Grid divides the globe into cells that points are aggregated by
+type Grid interface {
+	// Cell returns the key and bounds of the cell containing a point
+	Cell(lat, lon float64) (string, BBox)
+}
+
+// DegreeGrid is a grid of square cells Size degrees across, aligned to -90 latitude and -180 longitude.
+// Cells are keyed "row:col", counted from the south-west corner.
+type DegreeGrid struct {
+	Size float64
+}
+
+func (g DegreeGrid) Cell(lat, lon float64) (string, BBox) {
+	rows, cols := int(math.Ceil(180/g.Size)), int(math.Ceil(360/g.Size))
+	row := min(int(math.Floor((lat+90)/g.Size)), rows-1) // 90 falls in the last row
+	col := int(math.Floor((lon+180)/g.Size)) % cols      // 180 wraps around to -180
+	bounds := BBox{
+		MinLat: -90 + float64(row)*g.Size,
+		MinLon: -180 + float64(col)*g.Size,
+	}
+	bounds.MaxLat = min(bounds.MinLat+g.Size, 90)
+	bounds.MaxLon = min(bounds.MinLon+g.Size, 180)
+	return fmt.Sprintf("%d:%d", row, col), bounds
+}
+
+// GeohashGrid is the grid of geohashes of a precision between 1 and MaxGeohashPrecision,
+// keyed by the geohash.
+type GeohashGrid struct {
+	Precision int
+}
+
+func (g GeohashGrid) Cell(lat, lon float64) (string, BBox) {
+	return Geohash(lat, lon, g.Precision)
+}
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Geohash returns the geohash of a point with the given number of characters, and the bounds of its cell
+func Geohash(lat, lon float64, precision int) (string, BBox) {
+	bounds := BBox{MinLat: -90, MinLon: -180, MaxLat: 90, MaxLon: 180}
+	var hash strings.Builder
+	even := true // bits alternate between longitude and latitude, longitude first
+	for hash.Len() < precision {
+		var ch byte
+		for range 5 {
+			ch <<= 1
+			if even {
+				mid := (bounds.MinLon + bounds.MaxLon) / 2
+				if lon >= mid {
+					ch |= 1
+					bounds.MinLon = mid
+				} else {
+					bounds.MaxLon = mid
+				}
+			} else {
+				mid := (bounds.MinLat + bounds.MaxLat) / 2
+				if lat >= mid {
+					ch |= 1
+					bounds.MinLat = mid
+				} else {
+					bounds.MaxLat = mid
+				}
+			}
+			even = !even
+		}
+		hash.WriteByte(geohashAlphabet[ch])
+	}
+	return hash.String(), bounds
+}
@@ -0,0 +1,60 @@
+package geo
+
+import "testing"
+
+func TestGeohash(t *testing.T) {
+	tests := []struct {
+		lat, lon  float64
+		precision int
+		want      string
+	}{
+		{57.64911, 10.40744, 11, "u4pruydqqvj"},
+		{42.6, -5.6, 5, "ezs42"},
+		{-90, -180, 3, "000"},
+		{90, 180, 3, "zzz"},
+	}
+	for _, tt := range tests {
+		got, bounds := Geohash(tt.lat, tt.lon, tt.precision)
+		if got != tt.want {
+			t.Errorf("Geohash(%g, %g, %d) = %s, want %s", tt.lat, tt.lon, tt.precision, got, tt.want)
+		}
+		if !bounds.Contains(tt.lat, tt.lon) {
+			t.Errorf("%s: bounds %+v do not contain the point", got, bounds)
+		}
+	}
+
+	_, bounds := Geohash(42.6, -5.6, 5)
+	if w, h := bounds.MaxLon-bounds.MinLon, bounds.MaxLat-bounds.MinLat; w != 360.0/8192 || h != 180.0/4096 {
+		t.Errorf("expected a 5-character cell of 360/8192 by 180/4096 degrees, got %g by %g", w, h)
+	}
+}
+
+func TestDegreeGrid_Cell(t *testing.T) {
+	grid := DegreeGrid{Size: 10}
+	tests := []struct {
+		name     string
+		lat, lon float64
+		key      string
+		bounds   BBox
+	}{
+		{"south-west corner", -90, -180, "0:0", BBox{MinLat: -90, MinLon: -180, MaxLat: -80, MaxLon: -170}},
+		{"Tokyo", 35.68, 139.69, "12:31", BBox{MinLat: 30, MinLon: 130, MaxLat: 40, MaxLon: 140}},
+		{"cell edge", 40, 140, "13:32", BBox{MinLat: 40, MinLon: 140, MaxLat: 50, MaxLon: 150}},
+		{"north pole", 90, 0, "17:18", BBox{MinLat: 80, MinLon: 0, MaxLat: 90, MaxLon: 10}},
+		{"antimeridian", 0, 180, "9:0", BBox{MinLat: 0, MinLon: -180, MaxLat: 10, MaxLon: -170}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, bounds := grid.Cell(tt.lat, tt.lon)
+			if key != tt.key || bounds != tt.bounds {
+				t.Errorf("Cell = %s %+v, want %s %+v", key, bounds, tt.key, tt.bounds)
+			}
+		})
+	}
+
+	// Cells along the edges are clipped when the size does not divide the globe
+	_, bounds := DegreeGrid{Size: 7}.Cell(89, 179)
+	if bounds.MaxLat != 90 || bounds.MaxLon != 180 {
+		t.Errorf("expected the last cell clipped to 90, 180, got %+v", bounds)
+	}
+}
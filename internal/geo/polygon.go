@@ -0,0 +1,149 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// maxVertices bounds the size of areas accepted from clients, keeping point-in-polygon checks cheap
+const maxVertices = 10000
+
+// Position is a GeoJSON position: longitude first, then latitude
+type Position [2]float64
+
+// Ring is a closed linear ring. The closing position may be omitted.
+type Ring []Position
+
+// Polygon is an outer ring followed by zero or more holes.
+// Polygons crossing the antimeridian must be split into a MultiPolygon, as RFC 7946 requires.
+type Polygon []Ring
+
+type MultiPolygon []Polygon
+
+// Contains uses ray casting: a point is inside when a ray from it crosses the ring an odd number of times
+func (r Ring) Contains(lat, lon float64) bool {
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		xi, yi := r[i][0], r[i][1]
+		xj, yj := r[j][0], r[j][1]
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Contains reports whether the point is inside the outer ring and outside every hole
+func (p Polygon) Contains(lat, lon float64) bool {
+	if len(p) == 0 || !p[0].Contains(lat, lon) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if hole.Contains(lat, lon) {
+			return false
+		}
+	}
+	return true
+}
+
+// Bounds returns the bounding box of the outer ring
+func (p Polygon) Bounds() BBox {
+	b := BBox{MinLat: math.Inf(1), MinLon: math.Inf(1), MaxLat: math.Inf(-1), MaxLon: math.Inf(-1)}
+	if len(p) == 0 {
+		return b
+	}
+	for _, pos := range p[0] {
+		b.MinLon = math.Min(b.MinLon, pos[0])
+		b.MaxLon = math.Max(b.MaxLon, pos[0])
+		b.MinLat = math.Min(b.MinLat, pos[1])
+		b.MaxLat = math.Max(b.MaxLat, pos[1])
+	}
+	return b
+}
+
+func (m MultiPolygon) Contains(lat, lon float64) bool {
+	for _, p := range m {
+		if p.Contains(lat, lon) {
+			return true
+		}
+	}
+	return false
+}
+
+// Bounds returns one bounding box per polygon, which prefilters far tighter than
+// a single box around polygons on opposite sides of the antimeridian.
+func (m MultiPolygon) Bounds() []BBox {
+	boxes := make([]BBox, len(m))
+	for i, p := range m {
+		boxes[i] = p.Bounds()
+	}
+	return boxes
+}
+
+func (m MultiPolygon) Validate() error {
+	if len(m) == 0 {
+		return errors.New("area must contain at least one polygon")
+	}
+	vertices := 0
+	for _, p := range m {
+		if len(p) == 0 {
+			return errors.New("polygon must have an outer ring")
+		}
+		for _, r := range p {
+			if len(r) < 3 {
+				return errors.New("polygon rings need at least 3 positions")
+			}
+			for _, pos := range r {
+				if !validLon(pos[0]) || !validLat(pos[1]) {
+					return fmt.Errorf("position %v is not a valid [longitude, latitude]", pos)
+				}
+			}
+			vertices += len(r)
+		}
+	}
+	if vertices > maxVertices {
+		return fmt.Errorf("area has %d positions, the limit is %d", vertices, maxVertices)
+	}
+	return nil
+}
+
+// ParseGeoJSON reads a GeoJSON Polygon or MultiPolygon geometry, or a Feature wrapping one,
+// and validates it.
+func ParseGeoJSON(data []byte) (MultiPolygon, error) {
+	var obj struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+		Geometry    json.RawMessage `json:"geometry"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+
+	var area MultiPolygon
+	switch obj.Type {
+	case "Feature":
+		if len(obj.Geometry) == 0 || string(obj.Geometry) == "null" {
+			return nil, errors.New("feature has no geometry")
+		}
+		return ParseGeoJSON(obj.Geometry)
+	case "Polygon":
+		var p Polygon
+		if err := json.Unmarshal(obj.Coordinates, &p); err != nil {
+			return nil, fmt.Errorf("invalid Polygon coordinates: %w", err)
+		}
+		area = MultiPolygon{p}
+	case "MultiPolygon":
+		if err := json.Unmarshal(obj.Coordinates, &area); err != nil {
+			return nil, fmt.Errorf("invalid MultiPolygon coordinates: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported GeoJSON type %q, expected Polygon or MultiPolygon", obj.Type)
+	}
+
+	if err := area.Validate(); err != nil {
+		return nil, err
+	}
+	return area, nil
+}
@@ -0,0 +1,93 @@
+package geo
+
+import (
+	"strings"
+	"testing"
+)
+
+// Luzon, roughly, with a hole around Manila Bay
+const luzonGeoJSON = `{
+	"type": "Polygon",
+	"coordinates": [
+		[[119.5, 13.5], [124.5, 13.5], [122.5, 18.8], [120.0, 18.8], [119.5, 13.5]],
+		[[120.5, 14.4], [121.0, 14.4], [121.0, 14.9], [120.5, 14.9], [120.5, 14.4]]
+	]
+}`
+
+func TestParseGeoJSON_Polygon(t *testing.T) {
+	area, err := ParseGeoJSON([]byte(luzonGeoJSON))
+	if err != nil {
+		t.Fatalf("ParseGeoJSON failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     bool
+	}{
+		{"Baguio", 16.41, 120.60, true},
+		{"inside hole", 14.6, 120.7, false},
+		{"Cebu", 10.32, 123.89, false},
+		{"east of slanted edge", 18.0, 124.0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := area.Contains(tt.lat, tt.lon); got != tt.want {
+				t.Errorf("Contains(%v, %v) = %v, want %v", tt.lat, tt.lon, got, tt.want)
+			}
+		})
+	}
+
+	b := area.Bounds()
+	if len(b) != 1 || b[0].MinLon != 119.5 || b[0].MaxLat != 18.8 {
+		t.Errorf("unexpected bounds %+v", b)
+	}
+}
+
+func TestParseGeoJSON_MultiPolygonAcrossAntimeridian(t *testing.T) {
+	// Fiji split at 180 as RFC 7946 requires, wrapped in a Feature
+	data := `{"type": "Feature", "properties": {}, "geometry": {
+		"type": "MultiPolygon",
+		"coordinates": [
+			[[[177, -19], [180, -19], [180, -16], [177, -16], [177, -19]]],
+			[[[-180, -19], [-178, -19], [-178, -16], [-180, -16], [-180, -19]]]
+		]
+	}}`
+	area, err := ParseGeoJSON([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseGeoJSON failed: %v", err)
+	}
+
+	if !area.Contains(-17.7, 178.1) || !area.Contains(-17, -179) {
+		t.Error("expected points on both sides of the antimeridian to be inside")
+	}
+	if area.Contains(-17, 0) {
+		t.Error("expected point at longitude 0 to be outside")
+	}
+	if len(area.Bounds()) != 2 {
+		t.Errorf("expected one bounding box per polygon, got %d", len(area.Bounds()))
+	}
+}
+
+func TestParseGeoJSON_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"not json", `{`, "invalid GeoJSON"},
+		{"point", `{"type": "Point", "coordinates": [1, 2]}`, "unsupported"},
+		{"short ring", `{"type": "Polygon", "coordinates": [[[0, 0], [1, 1]]]}`, "at least 3"},
+		{"bad latitude", `{"type": "Polygon", "coordinates": [[[0, 0], [1, 95], [2, 0], [0, 0]]]}`, "not a valid"},
+		{"empty multipolygon", `{"type": "MultiPolygon", "coordinates": []}`, "at least one polygon"},
+		{"feature without geometry", `{"type": "Feature", "geometry": null}`, "no geometry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseGeoJSON([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
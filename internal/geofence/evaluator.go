@@ -0,0 +1,120 @@
+// Package geofence matches stored disasters against user-registered geofences and raises alerts.
+package geofence
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+// Notifier delivers a geofence match to the geofence's owner
+type Notifier interface {
+	Notify(m *models.GeofenceMatch)
+}
+
+type Evaluator struct {
+	geofences repository.GeofenceRepository
+	alerts    repository.AlertRepository
+	notifier  Notifier
+}
+
+// NewEvaluator creates an evaluator. notifier may be nil to only persist alerts.
+func NewEvaluator(geofences repository.GeofenceRepository, alerts repository.AlertRepository, notifier Notifier) *Evaluator {
+	return &Evaluator{
+		geofences: geofences,
+		alerts:    alerts,
+		notifier:  notifier,
+	}
+}
+
+// Evaluate checks a stored disaster against every geofence. Each geofence alerts at most once per
+// disaster: the first time the disaster (new or updated) falls inside it and meets its criteria.
+func (e *Evaluator) Evaluate(ctx context.Context, d *models.Disaster) ([]models.Alert, error) {
+	geofences, err := e.geofences.ListGeofences(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("error listing geofences: %w", err)
+	}
+
+	var raised []models.Alert
+	var existing map[string]bool
+	for i := range geofences {
+		g := &geofences[i]
+		if !Matches(g, d) {
+			continue
+		}
+
+		// Loaded lazily, since most disasters match no geofence
+		if existing == nil {
+			if existing, err = e.alertedGeofences(ctx, d.ID); err != nil {
+				return raised, err
+			}
+		}
+		if existing[g.ID] {
+			continue
+		}
+
+		alert := models.Alert{
+			ID:         g.ID + ":" + d.ID,
+			DisasterID: d.ID,
+			GeofenceID: g.ID,
+			Severity:   Severity(d.AlertLevel),
+			CreatedAt:  time.Now(),
+		}
+		if err := e.alerts.AddAlert(ctx, &alert); err != nil {
+			return raised, fmt.Errorf("error storing alert for geofence %s: %w", g.ID, err)
+		}
+		raised = append(raised, alert)
+
+		slog.Info("geofence matched", "geofence_id", g.ID, "owner", g.Owner, "disaster_id", d.ID, "severity", alert.Severity)
+		if e.notifier != nil {
+			e.notifier.Notify(&models.GeofenceMatch{Alert: alert, Geofence: g, Disaster: d})
+		}
+	}
+
+	return raised, nil
+}
+
+func (e *Evaluator) alertedGeofences(ctx context.Context, disasterID string) (map[string]bool, error) {
+	alerts, err := e.alerts.GetByDisasterID(ctx, disasterID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading alerts for disaster %s: %w", disasterID, err)
+	}
+	seen := make(map[string]bool, len(alerts))
+	for _, a := range alerts {
+		seen[a.GeofenceID] = true
+	}
+	return seen, nil
+}
+
+// Matches reports whether d lies inside g and meets its criteria
+func Matches(g *models.Geofence, d *models.Disaster) bool {
+	if !g.Contains(d.Latitude, d.Longitude) {
+		return false
+	}
+	filter := repository.Filter{
+		Types:                      g.Criteria.Types,
+		MinMagnitude:               g.Criteria.MinMagnitude,
+		MinAlertLevel:              g.Criteria.MinAlertLevel,
+		MinAffectedPopulationCount: g.Criteria.MinAffectedPopulationCount,
+	}
+	return filter.Matches(d)
+}
+
+// Severity maps a disaster's alert level to the severity of alerts it raises
+func Severity(level disastersv1.AlertLevel) models.AlertSeverity {
+	switch level {
+	case disastersv1.AlertLevel_RED:
+		return models.AlertSeverityCritical
+	case disastersv1.AlertLevel_ORANGE:
+		return models.AlertSeverityHigh
+	case disastersv1.AlertLevel_GREEN:
+		return models.AlertSeverityLow
+	default:
+		return models.AlertSeverityModerate
+	}
+}
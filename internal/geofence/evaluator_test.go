@@ -0,0 +1,122 @@
+package geofence
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+type recordingNotifier struct {
+	matches []*models.GeofenceMatch
+}
+
+func (n *recordingNotifier) Notify(m *models.GeofenceMatch) {
+	n.matches = append(n.matches, m)
+}
+
+func setupEvaluator(t *testing.T) (*Evaluator, *repository.SQLiteDB, *recordingNotifier) {
+	db, err := repository.NewSQLiteDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	notifier := &recordingNotifier{}
+	return NewEvaluator(db, db, notifier), db, notifier
+}
+
+func TestEvaluator_Evaluate(t *testing.T) {
+	e, db, notifier := setupEvaluator(t)
+	ctx := context.Background()
+
+	minMag := 6.0
+	geofences := []*models.Geofence{
+		{ID: "gf_tokyo", Name: "Tokyo", Owner: "pager", Circle: &geo.Circle{Lat: 35.68, Lon: 139.69, RadiusKm: 150}},
+		{ID: "gf_tokyo_big", Name: "Tokyo, M6+", Owner: "slack", Circle: &geo.Circle{Lat: 35.68, Lon: 139.69, RadiusKm: 150},
+			Criteria: models.GeofenceCriteria{MinMagnitude: &minMag}},
+		{ID: "gf_luzon", Name: "Luzon", Owner: "slack",
+			Area: geo.MultiPolygon{{{{119.5, 13.5}, {124.5, 13.5}, {122.5, 18.8}, {120.0, 18.8}, {119.5, 13.5}}}}},
+	}
+	for _, g := range geofences {
+		if err := db.AddGeofence(ctx, g); err != nil {
+			t.Fatalf("AddGeofence failed: %v", err)
+		}
+	}
+
+	quake := &models.Disaster{ID: "gdacs_1", Type: disastersv1.DisasterType_EARTHQUAKE, Magnitude: 5.2,
+		AlertLevel: disastersv1.AlertLevel_ORANGE, Latitude: 35.3, Longitude: 139.4, Timestamp: time.Now()}
+
+	alerts, err := e.Evaluate(ctx, quake)
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].GeofenceID != "gf_tokyo" || alerts[0].Severity != models.AlertSeverityHigh {
+		t.Fatalf("expected one HIGH alert for gf_tokyo, got %+v", alerts)
+	}
+	if len(notifier.matches) != 1 || notifier.matches[0].Geofence.Owner != "pager" || notifier.matches[0].Disaster.ID != "gdacs_1" {
+		t.Fatalf("expected pager to be notified, got %+v", notifier.matches)
+	}
+
+	// An update that now meets the M6 criteria alerts the second geofence only
+	quake.Magnitude = 6.4
+	alerts, err = e.Evaluate(ctx, quake)
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].GeofenceID != "gf_tokyo_big" {
+		t.Fatalf("expected only gf_tokyo_big to alert on update, got %+v", alerts)
+	}
+
+	// Re-evaluating raises nothing new
+	alerts, err = e.Evaluate(ctx, quake)
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if len(alerts) != 0 || len(notifier.matches) != 2 {
+		t.Errorf("expected no duplicate alerts, got %+v (%d notifications)", alerts, len(notifier.matches))
+	}
+
+	stored, err := db.ListAlerts(ctx, repository.Filter{GeofenceID: "gf_tokyo"})
+	if err != nil || len(stored) != 1 || stored[0].DisasterID != "gdacs_1" {
+		t.Errorf("expected the alert to be persisted, got %+v (err %v)", stored, err)
+	}
+}
+
+func TestEvaluator_NoMatch(t *testing.T) {
+	e, db, notifier := setupEvaluator(t)
+	ctx := context.Background()
+
+	g := &models.Geofence{ID: "gf_luzon", Name: "Luzon", Owner: "slack",
+		Area:     geo.MultiPolygon{{{{119.5, 13.5}, {124.5, 13.5}, {122.5, 18.8}, {120.0, 18.8}, {119.5, 13.5}}}},
+		Criteria: models.GeofenceCriteria{Types: []disastersv1.DisasterType{disastersv1.DisasterType_FLOOD}}}
+	if err := db.AddGeofence(ctx, g); err != nil {
+		t.Fatalf("AddGeofence failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		d    *models.Disaster
+	}{
+		{"wrong type", &models.Disaster{ID: "d1", Type: disastersv1.DisasterType_EARTHQUAKE, Latitude: 16.4, Longitude: 120.6}},
+		{"outside", &models.Disaster{ID: "d2", Type: disastersv1.DisasterType_FLOOD, Latitude: 10.3, Longitude: 123.9}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alerts, err := e.Evaluate(ctx, tt.d)
+			if err != nil {
+				t.Fatalf("Evaluate failed: %v", err)
+			}
+			if len(alerts) != 0 {
+				t.Errorf("expected no alerts, got %+v", alerts)
+			}
+		})
+	}
+	if len(notifier.matches) != 0 {
+		t.Errorf("expected no notifications, got %d", len(notifier.matches))
+	}
+}
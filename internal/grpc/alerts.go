@@ -0,0 +1,87 @@
+package grpc
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+)
+
+// AlertBroadcaster delivers geofence matches to the streams of each geofence's owner.
+// Alerts are persisted before they are published, so a full buffer only drops the live copy.
+type AlertBroadcaster struct {
+	subscribers map[string]map[uint64]chan *models.GeofenceMatch // owner -> subscriber -> channel
+	nextID      atomic.Uint64
+	mu          sync.RWMutex
+}
+
+func NewAlertBroadcaster() *AlertBroadcaster {
+	return &AlertBroadcaster{
+		subscribers: make(map[string]map[uint64]chan *models.GeofenceMatch),
+	}
+}
+
+func (b *AlertBroadcaster) Subscribe(owner string) (uint64, chan *models.GeofenceMatch) {
+	id := b.nextID.Add(1)
+	ch := make(chan *models.GeofenceMatch, subscriberBufferSize)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscribers[owner] == nil {
+		b.subscribers[owner] = make(map[uint64]chan *models.GeofenceMatch)
+	}
+	b.subscribers[owner][id] = ch
+
+	return id, ch
+}
+
+func (b *AlertBroadcaster) Unsubscribe(owner string, id uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs := b.subscribers[owner]
+	if ch, ok := subs[id]; ok {
+		close(ch)
+		delete(subs, id)
+	}
+	if len(subs) == 0 {
+		delete(b.subscribers, owner)
+	}
+}
+
+func (b *AlertBroadcaster) SubscriberCount() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	n := 0
+	for _, subs := range b.subscribers {
+		n += len(subs)
+	}
+	return n
+}
+
+// Notify sends a match to every stream of the geofence's owner
+func (b *AlertBroadcaster) Notify(m *models.GeofenceMatch) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for id, ch := range b.subscribers[m.Geofence.Owner] {
+		select {
+		case ch <- m:
+		default:
+			slog.Warn("alert subscriber buffer full, dropping alert", "subscriber_id", id, "owner", m.Geofence.Owner, "alert_id", m.Alert.ID)
+		}
+	}
+}
+
+// Close closes all subscriber channels, causing streams to exit gracefully
+func (b *AlertBroadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for owner, subs := range b.subscribers {
+		for _, ch := range subs {
+			close(ch)
+		}
+		delete(b.subscribers, owner)
+	}
+}
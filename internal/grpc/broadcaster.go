@@ -1,69 +1,446 @@
 package grpc
 
 import (
+	"log/slog"
+	"sort"
 	"sync"
 	"sync/atomic"
+	"time"
 
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
 	"github.com/mr1hm/go-disaster-alerts/internal/models"
 )
 
+// subscriberBufferSize is the per-subscriber channel capacity (max disasters per poll)
+const subscriberBufferSize = 100
+
+// maxGroupInFlight caps how many unacked events a consumer group holds for reassignment
+const maxGroupInFlight = 10000
+
+// emptyGroupExpiry is how long a consumer group with no members keeps its unacked events
+const emptyGroupExpiry = 5 * time.Minute
+
+// EventFilter reports whether a subscriber wants an event. A nil filter accepts every event.
+type EventFilter func(ev *models.DisasterEvent) bool
+
+// Gap describes a run of events dropped for a subscriber because its buffer was full.
+type Gap struct {
+	Missed   int64
+	FirstSeq int64
+	LastSeq  int64
+}
+
+type subscriber struct {
+	ch          chan *models.DisasterEvent
+	dropped     uint64 // total dropped over the subscription lifetime
+	consecutive int    // drops since the last successful delivery
+	gap         *Gap   // drops not yet reported to the client
+	evicted     bool
+	group       string // empty for subscribers that receive every event
+	filter      EventFilter
+}
+
+func (sub *subscriber) wants(ev *models.DisasterEvent) bool {
+	return sub.filter == nil || sub.filter(ev)
+}
+
+// inFlight is a grouped event handed to a member but not yet acked.
+// member is 0 while the event waits for a live member to join the group.
+type inFlight struct {
+	event  *models.DisasterEvent
+	member uint64
+}
+
+// group load-balances events across its members, delivering each to exactly one
+type group struct {
+	members    []uint64
+	next       int // round-robin position in members
+	inFlight   map[int64]*inFlight
+	emptySince time.Time // when the last member left, zero while the group has members
+}
+
 type Broadcaster struct {
-	subscribers map[uint64]chan *models.Disaster
+	subscribers map[uint64]*subscriber
+	groups      map[string]*group
 	nextID      atomic.Uint64
 	mu          sync.RWMutex
+
+	evictAfter   int // disconnect after this many consecutive drops, 0 = never
+	droppedTotal atomic.Uint64
+	evictions    atomic.Uint64
+}
+
+type BroadcasterOption func(*Broadcaster)
+
+// WithEvictAfter disconnects subscribers that drop n consecutive events.
+func WithEvictAfter(n int) BroadcasterOption {
+	return func(b *Broadcaster) {
+		b.evictAfter = n
+	}
 }
 
-func NewBroadcaster() *Broadcaster {
-	return &Broadcaster{
-		subscribers: make(map[uint64]chan *models.Disaster),
+func NewBroadcaster(opts ...BroadcasterOption) *Broadcaster {
+	b := &Broadcaster{
+		subscribers: make(map[uint64]*subscriber),
+		groups:      make(map[string]*group),
+	}
+	for _, opt := range opts {
+		opt(b)
 	}
+	return b
+}
+
+func (b *Broadcaster) Subscribe() (uint64, chan *models.DisasterEvent) {
+	return b.SubscribeGroup("", nil)
 }
 
-func (b *Broadcaster) Subscribe() (uint64, chan *models.Disaster) {
+// SubscribeGroup joins a consumer group: each event is delivered to only one member of the group
+// whose filter accepts it. An empty name subscribes to every event the filter accepts.
+func (b *Broadcaster) SubscribeGroup(name string, filter EventFilter) (uint64, chan *models.DisasterEvent) {
 	id := b.nextID.Add(1)
-	ch := make(chan *models.Disaster, 100) // Buffer for max disasters per poll
+	ch := make(chan *models.DisasterEvent, subscriberBufferSize)
 
 	b.mu.Lock()
-	b.subscribers[id] = ch
-	b.mu.Unlock()
+	defer b.mu.Unlock()
+
+	b.subscribers[id] = &subscriber{ch: ch, group: name, filter: filter}
+	if name == "" {
+		return id, ch
+	}
+
+	b.expireGroups(time.Now())
+	g, ok := b.groups[name]
+	if !ok {
+		g = &group{inFlight: make(map[int64]*inFlight)}
+		b.groups[name] = g
+	}
+	g.members = append(g.members, id)
+	g.emptySince = time.Time{}
+
+	// Hand over events left behind when the previous members disconnected
+	for _, f := range g.orphans() {
+		b.deliverToGroup(g, f.event)
+	}
 
 	return id, ch
 }
 
+// SetFilter replaces the filter of a subscriber. Events already in its buffer are not re-checked.
+func (b *Broadcaster) SetFilter(id uint64, filter EventFilter) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if sub, ok := b.subscribers[id]; ok {
+		sub.filter = filter
+	}
+}
+
 func (b *Broadcaster) Unsubscribe(id uint64) {
 	b.mu.Lock()
-	if ch, ok := b.subscribers[id]; ok {
-		close(ch)
-		delete(b.subscribers, id)
+	defer b.mu.Unlock()
+
+	sub, ok := b.subscribers[id]
+	if !ok {
+		return
+	}
+	if !sub.evicted {
+		close(sub.ch)
+	}
+	delete(b.subscribers, id)
+
+	if sub.group != "" {
+		b.leaveGroup(id, sub.group)
 	}
-	b.mu.Unlock()
 }
 
+// leaveGroup removes a member and reassigns its unacked events. Must be called with b.mu held.
+func (b *Broadcaster) leaveGroup(id uint64, name string) {
+	g, ok := b.groups[name]
+	if !ok {
+		return
+	}
+	for i, m := range g.members {
+		if m == id {
+			g.members = append(g.members[:i], g.members[i+1:]...)
+			break
+		}
+	}
+
+	var reassign []*inFlight
+	for _, f := range g.inFlight {
+		if f.member == id {
+			f.member = 0
+			reassign = append(reassign, f)
+		}
+	}
+	sort.Slice(reassign, func(i, j int) bool {
+		return reassign[i].event.Seq < reassign[j].event.Seq
+	})
+
+	if len(g.members) == 0 {
+		if len(g.inFlight) == 0 {
+			delete(b.groups, name)
+			return
+		}
+		g.emptySince = time.Now()
+		slog.Info("consumer group has no members, holding unacked events", "group", name, "events", len(g.inFlight), "expires_in", emptyGroupExpiry)
+		return
+	}
+
+	if len(reassign) > 0 {
+		slog.Info("reassigning unacked events", "group", name, "from_subscriber", id, "events", len(reassign))
+	}
+	for _, f := range reassign {
+		b.deliverToGroup(g, f.event)
+	}
+}
+
+// expireGroups drops groups that have had no members for emptyGroupExpiry, along with their
+// unacked events. Must be called with b.mu held.
+func (b *Broadcaster) expireGroups(now time.Time) {
+	for name, g := range b.groups {
+		if len(g.members) == 0 && now.Sub(g.emptySince) >= emptyGroupExpiry {
+			delete(b.groups, name)
+			slog.Warn("consumer group expired without members, dropping unacked events", "group", name, "events", len(g.inFlight))
+		}
+	}
+}
+
+// orphans returns events waiting for a member, oldest seq first
+func (g *group) orphans() []*inFlight {
+	var out []*inFlight
+	for _, f := range g.inFlight {
+		if f.member == 0 {
+			out = append(out, f)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].event.Seq < out[j].event.Seq
+	})
+	return out
+}
+
+// deliverToGroup sends ev to the next member that wants it and has buffer space, round-robin.
+// Events no member wants are not held. Must be called with b.mu held.
+func (b *Broadcaster) deliverToGroup(g *group, ev *models.DisasterEvent) {
+	n := len(g.members)
+	first := -1 // first member in round-robin order that wants ev
+	for i := 0; i < n; i++ {
+		idx := (g.next + i) % n
+		id := g.members[idx]
+		sub := b.subscribers[id]
+		if sub.evicted || !sub.wants(ev) {
+			continue
+		}
+		if first < 0 {
+			first = idx
+		}
+
+		select {
+		case sub.ch <- ev:
+			sub.consecutive = 0
+			g.next = (idx + 1) % n
+			g.track(ev, id)
+			return
+		default:
+		}
+	}
+	delete(g.inFlight, ev.Seq)
+	if first < 0 {
+		return
+	}
+
+	// Every interested member is full: charge the drop to the one whose turn it was
+	g.next = (first + 1) % n
+	id := g.members[first]
+	b.recordDrop(id, b.subscribers[id], ev)
+}
+
+// track records ev as held by member until acked. Unsequenced events cannot be acked and are not tracked.
+func (g *group) track(ev *models.DisasterEvent, member uint64) {
+	if ev.Seq == 0 {
+		return
+	}
+	if _, ok := g.inFlight[ev.Seq]; !ok && len(g.inFlight) >= maxGroupInFlight {
+		oldest := int64(0)
+		for seq := range g.inFlight {
+			if oldest == 0 || seq < oldest {
+				oldest = seq
+			}
+		}
+		delete(g.inFlight, oldest)
+		slog.Warn("consumer group in-flight limit reached, forgetting oldest event", "seq", oldest)
+	}
+	g.inFlight[ev.Seq] = &inFlight{event: ev, member: member}
+}
+
+// Ack marks a grouped event as handled by the subscriber so it is not reassigned when the subscriber leaves.
+// It is a no-op for ungrouped subscribers and for events held by another member.
+func (b *Broadcaster) Ack(id uint64, seq int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub, ok := b.subscribers[id]
+	if !ok || sub.group == "" {
+		return
+	}
+	g := b.groups[sub.group]
+	if f, ok := g.inFlight[seq]; ok && f.member == id {
+		delete(g.inFlight, seq)
+	}
+}
+
+// Broadcast publishes a newly stored disaster as a CREATED event
 func (b *Broadcaster) Broadcast(d *models.Disaster) {
-	b.mu.RLock()
-	defer b.mu.RUnlock()
+	b.Publish(&models.DisasterEvent{
+		Seq:       d.Seq,
+		Kind:      disastersv1.EventKind_EVENT_KIND_CREATED,
+		Disaster:  d,
+		CreatedAt: d.CreatedAt,
+	})
+}
+
+func (b *Broadcaster) Publish(ev *models.DisasterEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for id, sub := range b.subscribers {
+		if sub.evicted || sub.group != "" || !sub.wants(ev) {
+			continue
+		}
 
-	for _, ch := range b.subscribers {
 		select {
-		case ch <- d:
+		case sub.ch <- ev:
+			sub.consecutive = 0
 		default:
-			// Skip slow subscribers
+			b.recordDrop(id, sub, ev)
+		}
+	}
+
+	b.expireGroups(time.Now())
+	for _, g := range b.groups {
+		if len(g.members) > 0 {
+			b.deliverToGroup(g, ev)
 		}
 	}
 }
 
+// recordDrop must be called with b.mu held
+func (b *Broadcaster) recordDrop(id uint64, sub *subscriber, ev *models.DisasterEvent) {
+	sub.dropped++
+	sub.consecutive++
+	b.droppedTotal.Add(1)
+
+	if sub.gap == nil {
+		sub.gap = &Gap{FirstSeq: ev.Seq}
+	}
+	sub.gap.Missed++
+	sub.gap.LastSeq = ev.Seq
+
+	if sub.consecutive == 1 {
+		slog.Warn("subscriber buffer full, dropping events", "subscriber_id", id, "first_seq", ev.Seq)
+	}
+
+	if b.evictAfter > 0 && sub.consecutive >= b.evictAfter {
+		sub.evicted = true
+		close(sub.ch)
+		b.evictions.Add(1)
+		slog.Warn("evicting lagged subscriber", "subscriber_id", id, "consecutive_drops", sub.consecutive)
+	}
+}
+
+// TakeGap returns the events dropped for a subscriber since the last call, or nil if none were dropped.
+func (b *Broadcaster) TakeGap(id uint64) *Gap {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub, ok := b.subscribers[id]
+	if !ok || sub.gap == nil {
+		return nil
+	}
+	gap := sub.gap
+	sub.gap = nil
+	return gap
+}
+
+// Evicted reports whether a subscriber's channel was closed because it stayed lagged.
+func (b *Broadcaster) Evicted(id uint64) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	sub, ok := b.subscribers[id]
+	return ok && sub.evicted
+}
+
 func (b *Broadcaster) SubscriberCount() int {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 	return len(b.subscribers)
 }
 
+type SubscriberMetrics struct {
+	ID       uint64 `json:"id"`
+	Group    string `json:"group,omitempty"`
+	Buffered int    `json:"buffered"`
+	Dropped  uint64 `json:"dropped"`
+	Lagging  bool   `json:"lagging"`
+}
+
+type GroupMetrics struct {
+	Members  int `json:"members"`
+	InFlight int `json:"in_flight"`
+}
+
+type BroadcasterMetrics struct {
+	Subscribers   int                     `json:"subscribers"`
+	DroppedTotal  uint64                  `json:"dropped_total"`
+	Evictions     uint64                  `json:"evictions"`
+	PerSubscriber []SubscriberMetrics     `json:"per_subscriber"`
+	Groups        map[string]GroupMetrics `json:"groups"`
+}
+
+// Metrics returns a snapshot of delivery counters for all current subscribers.
+func (b *Broadcaster) Metrics() BroadcasterMetrics {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	m := BroadcasterMetrics{
+		Subscribers:   len(b.subscribers),
+		DroppedTotal:  b.droppedTotal.Load(),
+		Evictions:     b.evictions.Load(),
+		PerSubscriber: make([]SubscriberMetrics, 0, len(b.subscribers)),
+		Groups:        make(map[string]GroupMetrics, len(b.groups)),
+	}
+	for id, sub := range b.subscribers {
+		sm := SubscriberMetrics{
+			ID:      id,
+			Group:   sub.group,
+			Dropped: sub.dropped,
+			Lagging: sub.consecutive > 0,
+		}
+		if !sub.evicted {
+			sm.Buffered = len(sub.ch)
+		}
+		m.PerSubscriber = append(m.PerSubscriber, sm)
+	}
+	sort.Slice(m.PerSubscriber, func(i, j int) bool {
+		return m.PerSubscriber[i].ID < m.PerSubscriber[j].ID
+	})
+	for name, g := range b.groups {
+		m.Groups[name] = GroupMetrics{Members: len(g.members), InFlight: len(g.inFlight)}
+	}
+	return m
+}
+
 // Close closes all subscriber channels, causing streams to exit gracefully
 func (b *Broadcaster) Close() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	for id, ch := range b.subscribers {
-		close(ch)
+	for id, sub := range b.subscribers {
+		if !sub.evicted {
+			close(sub.ch)
+		}
 		delete(b.subscribers, id)
 	}
+	clear(b.groups)
 }
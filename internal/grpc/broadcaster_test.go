@@ -55,8 +55,11 @@ func TestBroadcaster_Broadcast(t *testing.T) {
 
 	select {
 	case received := <-ch:
-		if received.ID != disaster.ID {
-			t.Errorf("expected ID %s, got %s", disaster.ID, received.ID)
+		if received.Disaster.ID != disaster.ID {
+			t.Errorf("expected ID %s, got %s", disaster.ID, received.Disaster.ID)
+		}
+		if received.Kind != disastersv1.EventKind_EVENT_KIND_CREATED {
+			t.Errorf("expected CREATED event, got %s", received.Kind)
 		}
 	case <-time.After(100 * time.Millisecond):
 		t.Error("timeout waiting for broadcast")
@@ -91,7 +94,7 @@ func TestBroadcaster_ConcurrentBroadcast(t *testing.T) {
 
 	// Create subscribers
 	numSubscribers := 10
-	channels := make([]chan *models.Disaster, numSubscribers)
+	channels := make([]chan *models.DisasterEvent, numSubscribers)
 	ids := make([]uint64, numSubscribers)
 
 	for i := 0; i < numSubscribers; i++ {
@@ -162,7 +165,7 @@ func TestBroadcaster_Close(t *testing.T) {
 	b := NewBroadcaster()
 
 	// Create multiple subscribers
-	var channels []chan *models.Disaster
+	var channels []chan *models.DisasterEvent
 	for i := 0; i < 5; i++ {
 		_, ch := b.Subscribe()
 		channels = append(channels, ch)
@@ -222,3 +225,203 @@ done:
 		t.Errorf("expected 100 buffered messages, got %d", count)
 	}
 }
+
+func TestBroadcaster_DropTracking(t *testing.T) {
+	b := NewBroadcaster()
+
+	id, ch := b.Subscribe()
+	defer b.Unsubscribe(id)
+
+	// Fill the buffer, then drop seq 101-105
+	for i := 1; i <= 105; i++ {
+		b.Broadcast(&models.Disaster{ID: "drop_test", Seq: int64(i)})
+	}
+
+	gap := b.TakeGap(id)
+	if gap == nil {
+		t.Fatal("expected a gap after dropped events")
+	}
+	if gap.Missed != 5 || gap.FirstSeq != 101 || gap.LastSeq != 105 {
+		t.Errorf("expected gap {5 101 105}, got %+v", *gap)
+	}
+	if b.TakeGap(id) != nil {
+		t.Error("expected gap to be cleared after TakeGap")
+	}
+
+	m := b.Metrics()
+	if m.DroppedTotal != 5 {
+		t.Errorf("expected 5 dropped in total, got %d", m.DroppedTotal)
+	}
+	if len(m.PerSubscriber) != 1 || m.PerSubscriber[0].Dropped != 5 || !m.PerSubscriber[0].Lagging {
+		t.Errorf("unexpected subscriber metrics: %+v", m.PerSubscriber)
+	}
+
+	// Draining and delivering again clears the lagging state
+	for len(ch) > 0 {
+		<-ch
+	}
+	b.Broadcast(&models.Disaster{ID: "drop_test", Seq: 106})
+	if m := b.Metrics(); m.PerSubscriber[0].Lagging {
+		t.Error("expected subscriber to no longer be lagging")
+	}
+}
+
+func TestBroadcaster_EvictAfter(t *testing.T) {
+	b := NewBroadcaster(WithEvictAfter(3))
+
+	id, ch := b.Subscribe()
+	defer b.Unsubscribe(id)
+
+	for i := 0; i < subscriberBufferSize+2; i++ {
+		b.Broadcast(&models.Disaster{ID: "evict_test"})
+	}
+	if b.Evicted(id) {
+		t.Fatal("subscriber evicted before reaching the drop limit")
+	}
+
+	b.Broadcast(&models.Disaster{ID: "evict_test"})
+	if !b.Evicted(id) {
+		t.Fatal("expected subscriber to be evicted after 3 consecutive drops")
+	}
+	if m := b.Metrics(); m.Evictions != 1 {
+		t.Errorf("expected 1 eviction, got %d", m.Evictions)
+	}
+
+	// Buffered events remain readable, then the channel reports closed
+	count := 0
+	for range ch {
+		count++
+	}
+	if count != subscriberBufferSize {
+		t.Errorf("expected %d buffered events before close, got %d", subscriberBufferSize, count)
+	}
+
+	// Further broadcasts must not panic on the closed channel
+	b.Broadcast(&models.Disaster{ID: "evict_test"})
+}
+
+func TestBroadcaster_GroupDeliversToOneMember(t *testing.T) {
+	b := NewBroadcaster()
+
+	a, chA := b.SubscribeGroup("bots", nil)
+	defer b.Unsubscribe(a)
+	c, chC := b.SubscribeGroup("bots", nil)
+	defer b.Unsubscribe(c)
+	solo, chSolo := b.Subscribe()
+	defer b.Unsubscribe(solo)
+
+	for i := 1; i <= 4; i++ {
+		b.Broadcast(&models.Disaster{ID: "group_test", Seq: int64(i)})
+	}
+
+	if len(chA) != 2 || len(chC) != 2 {
+		t.Errorf("expected events split 2/2 across the group, got %d/%d", len(chA), len(chC))
+	}
+	if len(chSolo) != 4 {
+		t.Errorf("expected ungrouped subscriber to receive all 4 events, got %d", len(chSolo))
+	}
+
+	m := b.Metrics()
+	if g := m.Groups["bots"]; g.Members != 2 || g.InFlight != 4 {
+		t.Errorf("expected 2 members and 4 in-flight events, got %+v", g)
+	}
+}
+
+func TestBroadcaster_GroupReassignsUnacked(t *testing.T) {
+	b := NewBroadcaster()
+
+	a, chA := b.SubscribeGroup("bots", nil)
+	c, chC := b.SubscribeGroup("bots", nil)
+
+	b.Broadcast(&models.Disaster{ID: "first", Seq: 1})
+	b.Broadcast(&models.Disaster{ID: "second", Seq: 2})
+	if (<-chA).Seq != 1 || (<-chC).Seq != 2 {
+		t.Fatal("expected round-robin delivery")
+	}
+
+	// Acked events stay with their member; unacked ones move on disconnect
+	b.Ack(a, 1)
+	b.Unsubscribe(c)
+
+	select {
+	case ev := <-chA:
+		if ev.Seq != 2 {
+			t.Errorf("expected seq 2 to be reassigned, got %d", ev.Seq)
+		}
+	default:
+		t.Fatal("expected unacked event to be reassigned to the remaining member")
+	}
+
+	// With no members left the event is held for the next one to join
+	b.Unsubscribe(a)
+	if g := b.Metrics().Groups["bots"]; g.Members != 0 || g.InFlight != 1 {
+		t.Fatalf("expected empty group holding 1 event, got %+v", g)
+	}
+
+	d, chD := b.SubscribeGroup("bots", nil)
+	defer b.Unsubscribe(d)
+	select {
+	case ev := <-chD:
+		if ev.Seq != 2 {
+			t.Errorf("expected held seq 2 on join, got %d", ev.Seq)
+		}
+	default:
+		t.Fatal("expected held event to be delivered to the new member")
+	}
+
+	b.Ack(d, 2)
+	if g := b.Metrics().Groups["bots"]; g.InFlight != 0 {
+		t.Errorf("expected nothing in flight after ack, got %d", g.InFlight)
+	}
+}
+
+func TestBroadcaster_GroupSkipsMembersWhoseFilterRejects(t *testing.T) {
+	b := NewBroadcaster()
+
+	floods := func(ev *models.DisasterEvent) bool {
+		return ev.Disaster.Type == disastersv1.DisasterType_FLOOD
+	}
+	a, chA := b.SubscribeGroup("bots", floods)
+	defer b.Unsubscribe(a)
+	c, chC := b.SubscribeGroup("bots", nil)
+	defer b.Unsubscribe(c)
+
+	// Round-robin would hand seq 1 to a, but a only wants floods
+	b.Broadcast(&models.Disaster{ID: "quake", Seq: 1, Type: disastersv1.DisasterType_EARTHQUAKE})
+	b.Broadcast(&models.Disaster{ID: "quake2", Seq: 2, Type: disastersv1.DisasterType_EARTHQUAKE})
+	if len(chA) != 0 || len(chC) != 2 {
+		t.Fatalf("expected both earthquakes to go to the unfiltered member, got %d/%d", len(chA), len(chC))
+	}
+
+	b.SetFilter(c, floods)
+	b.Broadcast(&models.Disaster{ID: "quake3", Seq: 3, Type: disastersv1.DisasterType_EARTHQUAKE})
+	if len(chA) != 0 || len(chC) != 2 {
+		t.Errorf("expected an event no member wants to be skipped, got %d/%d", len(chA), len(chC))
+	}
+	if g := b.Metrics().Groups["bots"]; g.InFlight != 2 {
+		t.Errorf("expected unwanted event not to be held, got %d in flight", g.InFlight)
+	}
+}
+
+func TestBroadcaster_EmptyGroupStopsBuffering(t *testing.T) {
+	b := NewBroadcaster()
+
+	a, chA := b.SubscribeGroup("bots", nil)
+	b.Broadcast(&models.Disaster{ID: "first", Seq: 1})
+	<-chA
+	b.Unsubscribe(a)
+
+	// Events published after the last member left are not held
+	b.Broadcast(&models.Disaster{ID: "second", Seq: 2})
+	if g := b.Metrics().Groups["bots"]; g.InFlight != 1 {
+		t.Fatalf("expected only the unacked event to be held, got %d", g.InFlight)
+	}
+
+	// Held events expire with the group if nobody rejoins
+	b.mu.Lock()
+	b.expireGroups(time.Now().Add(emptyGroupExpiry))
+	b.mu.Unlock()
+	if _, ok := b.Metrics().Groups["bots"]; ok {
+		t.Error("expected empty group to expire")
+	}
+}
@@ -0,0 +1,94 @@
+package grpc
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+)
+
+type pendingDelivery struct {
+	event    *models.DisasterEvent
+	sentAt   time.Time
+	attempts int
+}
+
+// deliveryTracker records events sent on a Subscribe stream until the client acks them.
+// It is owned by a single stream goroutine and is not safe for concurrent use.
+type deliveryTracker struct {
+	timeout     time.Duration
+	maxAttempts int // Deliveries per event before it is given up on
+	maxPending  int // Unacked events allowed before the stream is closed
+	pending     map[int64]*pendingDelivery
+}
+
+func newDeliveryTracker(timeout time.Duration, maxAttempts, maxPending int) *deliveryTracker {
+	return &deliveryTracker{
+		timeout:     timeout,
+		maxAttempts: maxAttempts,
+		maxPending:  maxPending,
+		pending:     make(map[int64]*pendingDelivery),
+	}
+}
+
+// sent records a (re)delivery and returns its attempt number. Unsequenced events are not tracked.
+func (t *deliveryTracker) sent(ev *models.DisasterEvent, now time.Time) int {
+	if ev.Seq == 0 {
+		return 1
+	}
+
+	p, ok := t.pending[ev.Seq]
+	if !ok {
+		p = &pendingDelivery{event: ev}
+		t.pending[ev.Seq] = p
+	}
+	p.sentAt = now
+	p.attempts++
+	return p.attempts
+}
+
+// ack removes acknowledged events and returns the IDs of their disasters. Unknown seqs are ignored.
+func (t *deliveryTracker) ack(seqs []int64) []string {
+	var ids []string
+	for _, seq := range seqs {
+		if p, ok := t.pending[seq]; ok {
+			ids = append(ids, p.event.Disaster.ID)
+			delete(t.pending, seq)
+		}
+	}
+	return ids
+}
+
+// due returns events that have waited longer than the ack timeout, oldest seq first.
+// Events that already used all their attempts are removed and returned as expired instead.
+func (t *deliveryTracker) due(now time.Time) (redeliver, expired []*models.DisasterEvent) {
+	for seq, p := range t.pending {
+		if now.Sub(p.sentAt) < t.timeout {
+			continue
+		}
+		if p.attempts >= t.maxAttempts {
+			expired = append(expired, p.event)
+			delete(t.pending, seq)
+			continue
+		}
+		redeliver = append(redeliver, p.event)
+	}
+	sortBySeq(redeliver)
+	sortBySeq(expired)
+	return redeliver, expired
+}
+
+// full reports whether no more events may be sent until some are acked
+func (t *deliveryTracker) full() bool {
+	return len(t.pending) >= t.maxPending
+}
+
+func (t *deliveryTracker) len() int {
+	return len(t.pending)
+}
+
+func sortBySeq(events []*models.DisasterEvent) {
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Seq < events[j].Seq
+	})
+}
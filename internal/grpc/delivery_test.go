@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+)
+
+func TestDeliveryTracker(t *testing.T) {
+	tracker := newDeliveryTracker(time.Minute, 3, 10)
+	start := time.Now()
+
+	ev1 := &models.DisasterEvent{Seq: 1, Disaster: &models.Disaster{ID: "d1"}}
+	ev2 := &models.DisasterEvent{Seq: 2, Disaster: &models.Disaster{ID: "d2"}}
+
+	if n := tracker.sent(ev2, start); n != 1 {
+		t.Errorf("expected first attempt, got %d", n)
+	}
+	tracker.sent(ev1, start.Add(30*time.Second))
+
+	// Unsequenced events (e.g. debug broadcasts) cannot be acked and are not tracked
+	tracker.sent(&models.DisasterEvent{Disaster: &models.Disaster{ID: "test"}}, start)
+	if tracker.len() != 2 {
+		t.Fatalf("expected 2 pending deliveries, got %d", tracker.len())
+	}
+
+	due, _ := tracker.due(start.Add(time.Minute))
+	if len(due) != 1 || due[0].Seq != 2 {
+		t.Fatalf("expected only seq 2 to be due, got %v", due)
+	}
+	if n := tracker.sent(ev2, start.Add(time.Minute)); n != 2 {
+		t.Errorf("expected second attempt on redelivery, got %d", n)
+	}
+
+	due, _ = tracker.due(start.Add(2 * time.Minute))
+	if len(due) != 2 || due[0].Seq != 1 || due[1].Seq != 2 {
+		t.Fatalf("expected seqs [1 2] due in order, got %v", due)
+	}
+
+	ids := tracker.ack([]int64{2, 99})
+	if len(ids) != 1 || ids[0] != "d2" {
+		t.Errorf("expected ack to return [d2], got %v", ids)
+	}
+	if tracker.len() != 1 {
+		t.Errorf("expected 1 pending delivery after ack, got %d", tracker.len())
+	}
+}
+
+func TestDeliveryTracker_Limits(t *testing.T) {
+	tracker := newDeliveryTracker(time.Minute, 2, 2)
+	start := time.Now()
+
+	ev1 := &models.DisasterEvent{Seq: 1, Disaster: &models.Disaster{ID: "d1"}}
+	ev2 := &models.DisasterEvent{Seq: 2, Disaster: &models.Disaster{ID: "d2"}}
+
+	tracker.sent(ev1, start)
+	if tracker.full() {
+		t.Fatal("expected room for a second event")
+	}
+	tracker.sent(ev2, start.Add(time.Minute))
+	if !tracker.full() {
+		t.Fatal("expected tracker to be full at max pending")
+	}
+
+	// seq 1 is redelivered once more, then given up on
+	tracker.sent(ev1, start.Add(time.Minute))
+	retry, expired := tracker.due(start.Add(2 * time.Minute))
+	if len(retry) != 1 || retry[0].Seq != 2 {
+		t.Fatalf("expected only seq 2 to be retried, got %v", retry)
+	}
+	if len(expired) != 1 || expired[0].Seq != 1 {
+		t.Fatalf("expected seq 1 to expire after max attempts, got %v", expired)
+	}
+	if tracker.len() != 1 || tracker.full() {
+		t.Errorf("expected expired event to free its slot, %d pending", tracker.len())
+	}
+	if ids := tracker.ack([]int64{1}); len(ids) != 0 {
+		t.Errorf("expected late ack of expired event to be ignored, got %v", ids)
+	}
+}
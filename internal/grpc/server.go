@@ -2,31 +2,120 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"io"
 	"log/slog"
 	"net"
+	"strings"
 	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
 	"github.com/mr1hm/go-disaster-alerts/internal/models"
 	"github.com/mr1hm/go-disaster-alerts/internal/repository"
 )
 
+// replayPageSize is how many stored disasters are read per query when resuming a stream
+const replayPageSize = 500
+
+const (
+	// defaultHeartbeatInterval is how long an event stream may stay idle before a heartbeat is sent
+	defaultHeartbeatInterval = 30 * time.Second
+	// defaultAckTimeout is how long Subscribe waits for an ack before redelivering an event
+	defaultAckTimeout = 30 * time.Second
+	// defaultMaxDeliveryAttempts is how many times Subscribe sends an event before giving up on it
+	defaultMaxDeliveryAttempts = 10
+	// defaultMaxUnacked is how many unacked events a Subscribe stream may hold before it is closed
+	defaultMaxUnacked = 1000
+	// defaultConsumerID is used when a request does not name a consumer
+	defaultConsumerID = "discord"
+)
+
 type Server struct {
 	disastersv1.UnimplementedDisasterServiceServer
-	repo        repository.DisasterRepository
-	broadcaster *Broadcaster
-	grpcServer  *grpc.Server
+	repo              repository.DisasterRepository
+	broadcaster       *Broadcaster
+	grpcServer        *grpc.Server
+	heartbeatInterval time.Duration
+	ackTimeout        time.Duration
+	maxAttempts       int
+	maxUnacked        int
+	alerts            *AlertBroadcaster
+	owners            models.OwnerTokens
+}
+
+type ServerOption func(*Server)
+
+// WithHeartbeatInterval sets how often idle StreamDisasterEvents streams receive a heartbeat.
+func WithHeartbeatInterval(d time.Duration) ServerOption {
+	return func(s *Server) {
+		if d > 0 {
+			s.heartbeatInterval = d
+		}
+	}
+}
+
+// WithAckTimeout sets how long Subscribe waits for an ack before redelivering an event.
+func WithAckTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		if d > 0 {
+			s.ackTimeout = d
+		}
+	}
+}
+
+// WithMaxDeliveryAttempts sets how many times Subscribe sends an event before dropping it unacked.
+func WithMaxDeliveryAttempts(n int) ServerOption {
+	return func(s *Server) {
+		if n > 0 {
+			s.maxAttempts = n
+		}
+	}
+}
+
+// WithMaxUnacked sets how many unacked events a Subscribe stream may hold before it is closed.
+func WithMaxUnacked(n int) ServerOption {
+	return func(s *Server) {
+		if n > 0 {
+			s.maxUnacked = n
+		}
+	}
+}
+
+// WithAlertBroadcaster enables StreamGeofenceAlerts, fed by the given broadcaster, together with
+// WithOwnerTokens.
+func WithAlertBroadcaster(alerts *AlertBroadcaster) ServerOption {
+	return func(s *Server) {
+		s.alerts = alerts
+	}
+}
+
+// WithOwnerTokens authenticates StreamGeofenceAlerts clients: each stream receives the alerts of
+// the owner of its "authorization: Bearer <token>" metadata.
+func WithOwnerTokens(tokens models.OwnerTokens) ServerOption {
+	return func(s *Server) {
+		s.owners = tokens
+	}
 }
 
-func NewServer(repo repository.DisasterRepository, broadcaster *Broadcaster) *Server {
-	return &Server{
-		repo:        repo,
-		broadcaster: broadcaster,
+func NewServer(repo repository.DisasterRepository, broadcaster *Broadcaster, opts ...ServerOption) *Server {
+	s := &Server{
+		repo:              repo,
+		broadcaster:       broadcaster,
+		heartbeatInterval: defaultHeartbeatInterval,
+		ackTimeout:        defaultAckTimeout,
+		maxAttempts:       defaultMaxDeliveryAttempts,
+		maxUnacked:        defaultMaxUnacked,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 func (s *Server) Start(addr string) error {
@@ -65,39 +154,26 @@ func (s *Server) GetDisaster(ctx context.Context, req *disastersv1.GetDisasterRe
 }
 
 func (s *Server) ListDisasters(ctx context.Context, req *disastersv1.ListDisastersRequest) (*disastersv1.ListDisastersResponse, error) {
-	filter := repository.Filter{
-		Limit: int(req.Limit),
-	}
-	if req.Type != nil && *req.Type != disastersv1.DisasterType_UNSPECIFIED {
-		filter.Type = req.Type
-	}
-	if req.MinMagnitude != nil {
-		filter.MinMagnitude = req.MinMagnitude
-	}
-	if req.AlertLevel != nil && *req.AlertLevel != disastersv1.AlertLevel_UNKNOWN {
-		filter.AlertLevel = req.AlertLevel
-	}
-	if req.MinAlertLevel != nil && *req.MinAlertLevel != disastersv1.AlertLevel_UNKNOWN {
-		filter.MinAlertLevel = req.MinAlertLevel
-	}
-	if req.DiscordSent != nil {
-		filter.DiscordSent = req.DiscordSent
-	}
-	if req.Since != nil {
-		since := time.Unix(*req.Since, 0)
-		filter.Since = &since
+	filter, err := listFilter(req)
+	if err != nil {
+		return nil, err
 	}
-	if req.MinAffectedPopulationCount != nil {
-		filter.MinAffectedPopulationCount = req.MinAffectedPopulationCount
+	if filter.Limit > 0 {
+		filter.Limit++ // one extra to learn whether there is a next page
 	}
 
 	disasters, err := s.repo.ListDisasters(ctx, filter)
+	if errors.Is(err, repository.ErrInvalidPageToken) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to list disasters: %v", err)
 	}
 
+	disasters, next := repository.NextPage(disasters, int(req.Limit))
 	resp := &disastersv1.ListDisastersResponse{
-		Disasters: make([]*disastersv1.Disaster, len(disasters)),
+		Disasters:     make([]*disastersv1.Disaster, len(disasters)),
+		NextPageToken: next,
 	}
 	for i, d := range disasters {
 		resp.Disasters[i] = toProto(&d)
@@ -106,63 +182,769 @@ func (s *Server) ListDisasters(ctx context.Context, req *disastersv1.ListDisaste
 }
 
 func (s *Server) StreamDisasters(req *disastersv1.StreamDisastersRequest, stream disastersv1.DisasterService_StreamDisastersServer) error {
-	id, ch := s.broadcaster.Subscribe()
+	if err := validateStreamRequest(req); err != nil {
+		return err
+	}
+	filter, err := streamFilter(req)
+	if err != nil {
+		return err
+	}
+
+	// v1 streams only carry newly created disasters
+	wanted := func(ev *models.DisasterEvent) bool {
+		return ev.Kind == disastersv1.EventKind_EVENT_KIND_CREATED && filter.Matches(ev.Disaster)
+	}
+
+	// Subscribe before replaying so events stored during the replay are buffered rather than lost
+	id, ch := s.broadcaster.SubscribeGroup(req.Group, wanted)
 	defer s.broadcaster.Unsubscribe(id)
 
-	slog.Info("client subscribed to disaster stream", "subscriber_id", id)
+	slog.Info("client subscribed to disaster stream", "subscriber_id", id, "group", req.Group)
+
+	var replayedSeq int64
+	if req.ResumeAfter != nil {
+		var err error
+		replayedSeq, err = s.replay(stream.Context(), *req.ResumeAfter, func(ev *models.DisasterEvent) error {
+			if !wanted(ev) {
+				return nil
+			}
+			return stream.Send(eventDisasterProto(ev))
+		})
+		if err != nil {
+			slog.Error("failed to replay disasters to stream", "error", err, "subscriber_id", id)
+			return err
+		}
+		slog.Info("replayed missed disasters", "subscriber_id", id, "resume_after", *req.ResumeAfter, "replayed_seq", replayedSeq)
+	}
 
 	for {
 		select {
 		case <-stream.Context().Done():
 			slog.Info("client disconnected from disaster stream", "subscriber_id", id)
 			return nil
-		case d, ok := <-ch:
+		case ev, ok := <-ch:
 			if !ok {
+				return s.subscriptionClosed(id)
+			}
+
+			// Report drops right away, even if this event is filtered out. Clients that treat every
+			// message as a disaster don't ask for notices, which carry no disaster.
+			if gap := s.broadcaster.TakeGap(id); gap != nil && req.ReportGaps {
+				if err := stream.Send(&disastersv1.Disaster{Gap: gapProto(gap)}); err != nil {
+					slog.Error("failed to send gap to stream", "error", err, "subscriber_id", id)
+					return err
+				}
+			}
+
+			// Skip events already delivered during replay
+			if (ev.Seq == 0 || ev.Seq > replayedSeq) && wanted(ev) {
+				if err := stream.Send(eventDisasterProto(ev)); err != nil {
+					slog.Error("failed to send disaster to stream", "error", err, "subscriber_id", id)
+					return err
+				}
+			}
+			// Server streams have no acks, so a grouped event is done once sent or filtered out
+			s.broadcaster.Ack(id, ev.Seq)
+		}
+	}
+}
+
+func (s *Server) StreamDisasterEvents(req *disastersv1.StreamDisastersRequest, stream disastersv1.DisasterService_StreamDisasterEventsServer) error {
+	if err := validateStreamRequest(req); err != nil {
+		return err
+	}
+	filter, err := streamFilter(req)
+	if err != nil {
+		return err
+	}
+
+	id, ch := s.broadcaster.SubscribeGroup(req.Group, matchDisaster(filter))
+	defer s.broadcaster.Unsubscribe(id)
+
+	slog.Info("client subscribed to disaster event stream", "subscriber_id", id, "group", req.Group)
+
+	var lastSeq int64 // last seq sent, reported in heartbeats
+	send := func(msg *disastersv1.DisasterEvent) error {
+		msg.ServerTimeMs = time.Now().UnixMilli()
+		return stream.Send(msg)
+	}
+	sendGap := func() error {
+		gap := s.broadcaster.TakeGap(id)
+		if gap == nil {
+			return nil
+		}
+		return send(gapEventProto(gap))
+	}
+
+	var replayedSeq int64
+	if req.ResumeAfter != nil {
+		var err error
+		replayedSeq, err = s.replay(stream.Context(), *req.ResumeAfter, func(ev *models.DisasterEvent) error {
+			if !filter.Matches(ev.Disaster) {
 				return nil
 			}
+			lastSeq = ev.Seq
+			return send(eventProto(ev))
+		})
+		if err != nil {
+			slog.Error("failed to replay events to stream", "error", err, "subscriber_id", id)
+			return err
+		}
+		slog.Info("replayed missed events", "subscriber_id", id, "resume_after", *req.ResumeAfter, "replayed_seq", replayedSeq)
+	}
+
+	// Heartbeats are only sent while the stream is idle
+	heartbeat := time.NewTicker(s.heartbeatInterval)
+	defer heartbeat.Stop()
 
-			// Apply filters
-			if req.Type != nil && *req.Type != disastersv1.DisasterType_UNSPECIFIED {
-				if d.Type != *req.Type {
-					continue
+	for {
+		select {
+		case <-stream.Context().Done():
+			slog.Info("client disconnected from disaster event stream", "subscriber_id", id)
+			return nil
+		case <-heartbeat.C:
+			if err := sendGap(); err != nil {
+				return err
+			}
+			if err := send(s.heartbeatProto(lastSeq)); err != nil {
+				slog.Error("failed to send heartbeat to stream", "error", err, "subscriber_id", id)
+				return err
+			}
+		case ev, ok := <-ch:
+			if !ok {
+				return s.subscriptionClosed(id)
+			}
+
+			if err := sendGap(); err != nil {
+				return err
+			}
+			if (ev.Seq == 0 || ev.Seq > replayedSeq) && filter.Matches(ev.Disaster) {
+				if err := send(eventProto(ev)); err != nil {
+					slog.Error("failed to send event to stream", "error", err, "subscriber_id", id)
+					return err
+				}
+				if ev.Seq > lastSeq {
+					lastSeq = ev.Seq
 				}
+				heartbeat.Reset(s.heartbeatInterval)
 			}
-			if req.MinMagnitude != nil && d.Magnitude < *req.MinMagnitude {
-				continue
+			s.broadcaster.Ack(id, ev.Seq)
+		}
+	}
+}
+
+func (s *Server) Subscribe(stream disastersv1.DisasterService_SubscribeServer) error {
+	ctx := stream.Context()
+
+	// The first message carries the initial filters and resume cursor
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	req := first.GetFilter()
+	if req == nil {
+		return status.Error(codes.InvalidArgument, "first message must set filter")
+	}
+
+	if err := validateStreamRequest(req); err != nil {
+		return err
+	}
+	filter, err := streamFilter(req)
+	if err != nil {
+		return err
+	}
+
+	consumerID := consumerOrDefault(req.ConsumerId)
+	groupName := req.Group
+
+	id, ch := s.broadcaster.SubscribeGroup(groupName, matchDisaster(filter))
+	defer s.broadcaster.Unsubscribe(id)
+
+	slog.Info("client subscribed to bidirectional disaster stream", "subscriber_id", id, "consumer_id", consumerID, "group", groupName)
+
+	tracker := newDeliveryTracker(s.ackTimeout, s.maxAttempts, s.maxUnacked)
+	var lastSeq int64
+	send := func(msg *disastersv1.DisasterEvent) error {
+		msg.ServerTimeMs = time.Now().UnixMilli()
+		return stream.Send(msg)
+	}
+	deliver := func(ev *models.DisasterEvent) error {
+		msg := eventProto(ev)
+		msg.DeliveryAttempt = int32(tracker.sent(ev, time.Now()))
+		if ev.Seq > lastSeq {
+			lastSeq = ev.Seq
+		}
+		return send(msg)
+	}
+	// handle applies a client message. A bad filter is reported to the client and the old filter kept.
+	handle := func(msg *disastersv1.SubscribeRequest) error {
+		switch r := msg.Request.(type) {
+		case *disastersv1.SubscribeRequest_Filter:
+			updated, err := streamFilter(r.Filter)
+			if err != nil {
+				slog.Warn("rejected stream filter update", "error", err, "subscriber_id", id)
+				return send(rejectedProto(err))
 			}
-			if req.AlertLevel != nil && *req.AlertLevel != disastersv1.AlertLevel_UNKNOWN {
-				if d.AlertLevel != *req.AlertLevel {
-					continue
+			filter = updated
+			s.broadcaster.SetFilter(id, matchDisaster(updated))
+			if r.Filter.Group != groupName {
+				slog.Warn("ignoring group change on filter update", "subscriber_id", id, "group", groupName)
+			}
+			slog.Info("stream filters updated", "subscriber_id", id)
+		case *disastersv1.SubscribeRequest_Ack:
+			s.ackDeliveries(ctx, id, consumerID, tracker, r.Ack.Seqs)
+		}
+		return nil
+	}
+
+	// Client messages are read on their own goroutine and handled in the send loop
+	incoming := make(chan *disastersv1.SubscribeRequest)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case incoming <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var replayedSeq int64
+	if req.ResumeAfter != nil {
+		replayedSeq, err = s.replay(ctx, *req.ResumeAfter, func(ev *models.DisasterEvent) error {
+			if !filter.Matches(ev.Disaster) {
+				return nil
+			}
+			// A resuming client may be far behind, so replay waits for acks instead of closing the stream
+			if err := s.awaitAcks(ctx, tracker, incoming, recvErr, handle); err != nil {
+				return err
+			}
+			return deliver(ev)
+		})
+		if err == io.EOF {
+			slog.Info("client closed bidirectional disaster stream during replay", "subscriber_id", id, "unacked", tracker.len())
+			return nil
+		}
+		if err != nil {
+			slog.Error("failed to replay events to stream", "error", err, "subscriber_id", id)
+			return err
+		}
+	}
+
+	heartbeat := time.NewTicker(s.heartbeatInterval)
+	defer heartbeat.Stop()
+	redeliver := time.NewTicker(s.ackTimeout / 2)
+	defer redeliver.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("client disconnected from bidirectional disaster stream", "subscriber_id", id, "unacked", tracker.len())
+			return nil
+		case err := <-recvErr:
+			if err == io.EOF {
+				slog.Info("client closed bidirectional disaster stream", "subscriber_id", id, "unacked", tracker.len())
+				return nil
+			}
+			return err
+		case msg := <-incoming:
+			if err := handle(msg); err != nil {
+				return err
+			}
+		case <-redeliver.C:
+			retry, expired := tracker.due(time.Now())
+			for _, ev := range expired {
+				slog.Warn("dropping event unacked after max delivery attempts", "subscriber_id", id, "seq", ev.Seq, "disaster_id", ev.Disaster.ID, "attempts", s.maxAttempts)
+				// Release it from the group too, or the event would be reassigned indefinitely
+				s.broadcaster.Ack(id, ev.Seq)
+			}
+			for _, ev := range retry {
+				if err := deliver(ev); err != nil {
+					return err
+				}
+			}
+		case <-heartbeat.C:
+			if gap := s.broadcaster.TakeGap(id); gap != nil {
+				if err := send(gapEventProto(gap)); err != nil {
+					return err
 				}
 			}
-			if req.MinAlertLevel != nil && *req.MinAlertLevel != disastersv1.AlertLevel_UNKNOWN {
-				if d.AlertLevel < *req.MinAlertLevel {
-					continue
+			if err := send(s.heartbeatProto(lastSeq)); err != nil {
+				return err
+			}
+		case ev, ok := <-ch:
+			if !ok {
+				return s.subscriptionClosed(id)
+			}
+
+			if gap := s.broadcaster.TakeGap(id); gap != nil {
+				if err := send(gapEventProto(gap)); err != nil {
+					return err
 				}
 			}
+			if (ev.Seq != 0 && ev.Seq <= replayedSeq) || !filter.Matches(ev.Disaster) {
+				// Nothing for the client to ack, so release it from the group straight away
+				s.broadcaster.Ack(id, ev.Seq)
+				continue
+			}
+			if ev.Seq != 0 && tracker.full() {
+				slog.Warn("closing bidirectional disaster stream with too many unacked events", "subscriber_id", id, "unacked", tracker.len())
+				return s.tooManyUnacked()
+			}
+
+			if err := deliver(ev); err != nil {
+				slog.Error("failed to send event to stream", "error", err, "subscriber_id", id)
+				return err
+			}
+			heartbeat.Reset(s.heartbeatInterval)
+		}
+	}
+}
 
-			if err := stream.Send(toProto(d)); err != nil {
-				slog.Error("failed to send disaster to stream", "error", err, "subscriber_id", id)
+// awaitAcks handles client messages until the tracker has room for another event.
+// It gives up with ResourceExhausted if nothing is acked within the ack timeout.
+func (s *Server) awaitAcks(ctx context.Context, tracker *deliveryTracker, incoming <-chan *disastersv1.SubscribeRequest, recvErr <-chan error, handle func(*disastersv1.SubscribeRequest) error) error {
+	if !tracker.full() {
+		return nil
+	}
+	timeout := time.NewTimer(s.ackTimeout)
+	defer timeout.Stop()
+	for tracker.full() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-recvErr:
+			return err
+		case msg := <-incoming:
+			if err := handle(msg); err != nil {
 				return err
 			}
+		case <-timeout.C:
+			return s.tooManyUnacked()
+		}
+	}
+	return nil
+}
+
+func (s *Server) tooManyUnacked() error {
+	return status.Errorf(codes.ResourceExhausted, "stream reached %d unacked events", s.maxUnacked)
+}
+
+// ackDeliveries stops redelivery of acked events, releases them from the subscriber's group
+// and records them as delivered for the consumer
+func (s *Server) ackDeliveries(ctx context.Context, subscriberID uint64, consumerID string, tracker *deliveryTracker, seqs []int64) {
+	for _, seq := range seqs {
+		s.broadcaster.Ack(subscriberID, seq)
+	}
+
+	ids := tracker.ack(seqs)
+	if len(ids) == 0 {
+		return
+	}
+	if _, err := s.repo.MarkAsSent(ctx, consumerID, ids); err != nil {
+		slog.Error("failed to mark acked disasters as sent", "error", err, "consumer_id", consumerID, "ids", ids)
+	}
+}
+
+// validateStreamRequest rejects option combinations the stream RPCs cannot honour
+func validateStreamRequest(req *disastersv1.StreamDisastersRequest) error {
+	// Replay is per connection, so every member of a group would receive the same backlog
+	if req.Group != "" && req.ResumeAfter != nil {
+		return status.Error(codes.InvalidArgument, "resume_after cannot be combined with group")
+	}
+	return nil
+}
+
+// consumerOrDefault keeps requests from bots that predate consumer IDs pointed at the Discord consumer
+func consumerOrDefault(consumerID string) string {
+	if consumerID == "" {
+		return defaultConsumerID
+	}
+	return consumerID
+}
+
+// subscriptionClosed maps a closed broadcaster channel to the stream's return value
+func (s *Server) subscriptionClosed(id uint64) error {
+	if s.broadcaster.Evicted(id) {
+		slog.Warn("disconnecting lagged stream subscriber", "subscriber_id", id)
+		return status.Error(codes.ResourceExhausted, "stream disconnected: client fell too far behind")
+	}
+	return nil
+}
+
+// replay passes stored events with seq > after to send, oldest first, and returns the highest seq it read
+func (s *Server) replay(ctx context.Context, after int64, send func(ev *models.DisasterEvent) error) (int64, error) {
+	for {
+		events, err := s.repo.ListEvents(ctx, after, replayPageSize)
+		if err != nil {
+			return after, status.Errorf(codes.Internal, "failed to replay events: %v", err)
+		}
+
+		for i := range events {
+			after = events[i].Seq
+			if err := send(&events[i]); err != nil {
+				return after, err
+			}
+		}
+
+		if len(events) < replayPageSize {
+			return after, nil
 		}
 	}
 }
 
+// listFilter converts the filters of a list request into a repository filter
+func listFilter(req *disastersv1.ListDisastersRequest) (repository.Filter, error) {
+	bbox, near, err := geoFilters(req.Bbox, req.Near)
+	if err != nil {
+		return repository.Filter{}, err
+	}
+	filter := repository.Filter{
+		Limit:     int(req.Limit),
+		Types:     disasterTypes(req.Type, req.Types),
+		Countries: req.Countries,
+		BBox:      bbox,
+		Near:      near,
+		Query:     req.Query,
+		PageToken: req.PageToken,
+		Sources:   req.Sources,
+		TimeField: timeField(req.TimeField),
+		Sort:      sortField(req.SortBy),
+		Order:     sortOrder(req.SortOrder),
+	}
+	if req.MinMagnitude != nil {
+		filter.MinMagnitude = req.MinMagnitude
+	}
+	if req.MaxMagnitude != nil {
+		filter.MaxMagnitude = req.MaxMagnitude
+	}
+	if req.AlertLevel != nil && *req.AlertLevel != disastersv1.AlertLevel_UNKNOWN {
+		filter.AlertLevel = req.AlertLevel
+	}
+	if req.MinAlertLevel != nil && *req.MinAlertLevel != disastersv1.AlertLevel_UNKNOWN {
+		filter.MinAlertLevel = req.MinAlertLevel
+	}
+	filter.ConsumerID = consumerOrDefault(req.ConsumerId)
+	if req.Delivered != nil {
+		filter.Delivered = req.Delivered
+	} else if req.DiscordSent != nil {
+		// Deprecated field, still honoured for bots that predate consumer IDs
+		filter.Delivered = req.DiscordSent
+	}
+	if req.Since != nil {
+		since := time.Unix(*req.Since, 0)
+		filter.Since = &since
+	}
+	if req.Until != nil {
+		until := time.Unix(*req.Until, 0)
+		filter.Until = &until
+	}
+	if req.MinAffectedPopulationCount != nil {
+		filter.MinAffectedPopulationCount = req.MinAffectedPopulationCount
+	}
+	if err := filter.Validate(); err != nil {
+		return repository.Filter{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return filter, nil
+}
+
+// streamFilter converts stream request filters into the repository filter used to match events
+func streamFilter(req *disastersv1.StreamDisastersRequest) (repository.Filter, error) {
+	bbox, near, err := geoFilters(req.Bbox, req.Near)
+	if err != nil {
+		return repository.Filter{}, err
+	}
+	filter := repository.Filter{
+		Types:                      disasterTypes(req.Type, req.Types),
+		Countries:                  req.Countries,
+		Sources:                    req.Sources,
+		MinMagnitude:               req.MinMagnitude,
+		MaxMagnitude:               req.MaxMagnitude,
+		MinAffectedPopulationCount: req.MinAffectedPopulationCount,
+		BBox:                       bbox,
+		Near:                       near,
+	}
+	if req.AlertLevel != nil && *req.AlertLevel != disastersv1.AlertLevel_UNKNOWN {
+		filter.AlertLevel = req.AlertLevel
+	}
+	if req.MinAlertLevel != nil && *req.MinAlertLevel != disastersv1.AlertLevel_UNKNOWN {
+		filter.MinAlertLevel = req.MinAlertLevel
+	}
+	return filter, nil
+}
+
+// matchDisaster adapts a stream filter for the broadcaster, which uses it to pick group members
+func matchDisaster(filter repository.Filter) EventFilter {
+	return func(ev *models.DisasterEvent) bool {
+		return filter.Matches(ev.Disaster)
+	}
+}
+
+func timeField(f disastersv1.TimeField) repository.TimeField {
+	if f == disastersv1.TimeField_TIME_FIELD_CREATED {
+		return repository.TimeFieldCreated
+	}
+	return repository.TimeFieldEvent
+}
+
+var sortFields = map[disastersv1.SortBy]repository.SortField{
+	disastersv1.SortBy_SORT_BY_UNSPECIFIED: repository.SortDefault,
+	disastersv1.SortBy_SORT_BY_TIME:        repository.SortTime,
+	disastersv1.SortBy_SORT_BY_MAGNITUDE:   repository.SortMagnitude,
+	disastersv1.SortBy_SORT_BY_ALERT_LEVEL: repository.SortAlertLevel,
+	disastersv1.SortBy_SORT_BY_POPULATION:  repository.SortPopulation,
+	disastersv1.SortBy_SORT_BY_DISTANCE:    repository.SortDistance,
+	disastersv1.SortBy_SORT_BY_RELEVANCE:   repository.SortRelevance,
+}
+
+// sortField maps the proto sort; unknown values pass through as an invalid field that Validate rejects
+func sortField(s disastersv1.SortBy) repository.SortField {
+	if f, ok := sortFields[s]; ok {
+		return f
+	}
+	return repository.SortField(s.String())
+}
+
+func sortOrder(o disastersv1.SortOrder) repository.SortOrder {
+	switch o {
+	case disastersv1.SortOrder_SORT_ORDER_ASC:
+		return repository.SortOrderAsc
+	case disastersv1.SortOrder_SORT_ORDER_DESC:
+		return repository.SortOrderDesc
+	default:
+		return repository.SortOrderDefault
+	}
+}
+
+// disasterTypes merges the single and repeated type filters, ignoring UNSPECIFIED
+func disasterTypes(single *disastersv1.DisasterType, list []disastersv1.DisasterType) []disastersv1.DisasterType {
+	var types []disastersv1.DisasterType
+	if single != nil && *single != disastersv1.DisasterType_UNSPECIFIED {
+		types = append(types, *single)
+	}
+	for _, t := range list {
+		if t != disastersv1.DisasterType_UNSPECIFIED {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+func geoFilters(bbox *disastersv1.BoundingBox, near *disastersv1.GeoRadius) (*geo.BBox, *geo.Circle, error) {
+	var box *geo.BBox
+	if bbox != nil {
+		box = &geo.BBox{
+			MinLat: bbox.MinLatitude,
+			MinLon: bbox.MinLongitude,
+			MaxLat: bbox.MaxLatitude,
+			MaxLon: bbox.MaxLongitude,
+		}
+		if err := box.Validate(); err != nil {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "invalid bbox: %v", err)
+		}
+	}
+
+	var circle *geo.Circle
+	if near != nil {
+		circle = &geo.Circle{Lat: near.Latitude, Lon: near.Longitude, RadiusKm: near.RadiusKm}
+		if err := circle.Validate(); err != nil {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "invalid near: %v", err)
+		}
+	}
+	return box, circle, nil
+}
+
+func (s *Server) StreamGeofenceAlerts(req *disastersv1.StreamGeofenceAlertsRequest, stream disastersv1.DisasterService_StreamGeofenceAlertsServer) error {
+	if s.alerts == nil || len(s.owners) == 0 {
+		return status.Error(codes.Unimplemented, "geofence alerts are not enabled")
+	}
+	owner, err := s.authenticateOwner(stream.Context())
+	if err != nil {
+		return err
+	}
+	if req.Owner != "" && req.Owner != owner {
+		return status.Error(codes.PermissionDenied, "owner does not match the token")
+	}
+
+	id, ch := s.alerts.Subscribe(owner)
+	defer s.alerts.Unsubscribe(owner, id)
+
+	slog.Info("client subscribed to geofence alerts", "subscriber_id", id, "owner", owner)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			slog.Info("client disconnected from geofence alerts", "subscriber_id", id, "owner", owner)
+			return nil
+		case m, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(geofenceAlertProto(m)); err != nil {
+				slog.Error("failed to send geofence alert", "error", err, "subscriber_id", id)
+				return err
+			}
+		}
+	}
+}
+
+// authenticateOwner returns the owner of the bearer token in the "authorization" metadata
+func (s *Server) authenticateOwner(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(v, "Bearer ")
+		if !ok {
+			continue
+		}
+		if owner, ok := s.owners.Owner(token); ok {
+			return owner, nil
+		}
+	}
+	return "", status.Error(codes.Unauthenticated, "invalid or missing owner token")
+}
+
 func (s *Server) AcknowledgeDisasters(ctx context.Context, req *disastersv1.AcknowledgeDisastersRequest) (*disastersv1.AcknowledgeDisastersResponse, error) {
 	if len(req.Ids) == 0 {
 		return &disastersv1.AcknowledgeDisastersResponse{AcknowledgedCount: 0}, nil
 	}
 
-	count, err := s.repo.MarkAsSent(ctx, req.Ids)
+	consumerID := consumerOrDefault(req.ConsumerId)
+	count, err := s.repo.MarkAsSent(ctx, consumerID, req.Ids)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to acknowledge disasters: %v", err)
 	}
 
-	slog.Info("disasters acknowledged", "count", count, "ids", req.Ids)
+	slog.Info("disasters acknowledged", "consumer_id", consumerID, "count", count, "ids", req.Ids)
 	return &disastersv1.AcknowledgeDisastersResponse{AcknowledgedCount: count}, nil
 }
 
+// GetStats aggregates disasters matching the filter into totals and time buckets
+func (s *Server) GetStats(ctx context.Context, req *disastersv1.GetStatsRequest) (*disastersv1.GetStatsResponse, error) {
+	listReq := req.Filter
+	if listReq == nil {
+		listReq = &disastersv1.ListDisastersRequest{}
+	}
+	filter, err := listFilter(listReq)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.repo.Stats(ctx, filter, timeBucket(req.Bucket))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to compute stats: %v", err)
+	}
+	return statsProto(stats, req.Bucket), nil
+}
+
+func timeBucket(b disastersv1.TimeBucket) repository.TimeBucket {
+	switch b {
+	case disastersv1.TimeBucket_TIME_BUCKET_WEEK:
+		return repository.BucketWeek
+	case disastersv1.TimeBucket_TIME_BUCKET_MONTH:
+		return repository.BucketMonth
+	}
+	return repository.BucketDay
+}
+
+func statsProto(stats *repository.Stats, bucket disastersv1.TimeBucket) *disastersv1.GetStatsResponse {
+	if bucket == disastersv1.TimeBucket_TIME_BUCKET_UNSPECIFIED {
+		bucket = disastersv1.TimeBucket_TIME_BUCKET_DAY
+	}
+	return &disastersv1.GetStatsResponse{
+		Total:        statsGroupProto(stats.Total),
+		Bucket:       bucket,
+		ByType:       statsGroupsProto(stats.ByType),
+		ByAlertLevel: statsGroupsProto(stats.ByAlertLevel),
+		ByCountry:    statsGroupsProto(stats.ByCountry),
+		BySource:     statsGroupsProto(stats.BySource),
+		ByTime:       statsGroupsProto(stats.ByTime),
+	}
+}
+
+func statsGroupsProto(groups []repository.StatsGroup) []*disastersv1.StatsGroup {
+	out := make([]*disastersv1.StatsGroup, len(groups))
+	for i, g := range groups {
+		out[i] = statsGroupProto(g)
+	}
+	return out
+}
+
+func statsGroupProto(g repository.StatsGroup) *disastersv1.StatsGroup {
+	return &disastersv1.StatsGroup{
+		Key:                     g.Key,
+		Count:                   g.Count,
+		MaxMagnitude:            g.MaxMagnitude,
+		TotalAffectedPopulation: g.TotalAffectedPopulation,
+	}
+}
+
+// eventDisasterProto converts an event's disaster, using the event's seq as the stream cursor
+func eventDisasterProto(ev *models.DisasterEvent) *disastersv1.Disaster {
+	pb := toProto(ev.Disaster)
+	pb.Seq = ev.Seq
+	return pb
+}
+
+func eventProto(ev *models.DisasterEvent) *disastersv1.DisasterEvent {
+	return &disastersv1.DisasterEvent{
+		Kind:    ev.Kind,
+		Seq:     ev.Seq,
+		Payload: &disastersv1.DisasterEvent_Disaster{Disaster: eventDisasterProto(ev)},
+	}
+}
+
+func geofenceAlertProto(m *models.GeofenceMatch) *disastersv1.GeofenceAlert {
+	return &disastersv1.GeofenceAlert{
+		Id:           m.Alert.ID,
+		GeofenceId:   m.Geofence.ID,
+		GeofenceName: m.Geofence.Name,
+		Severity:     string(m.Alert.Severity),
+		CreatedAt:    m.Alert.CreatedAt.Unix(),
+		Disaster:     toProto(m.Disaster),
+	}
+}
+
+func gapEventProto(gap *Gap) *disastersv1.DisasterEvent {
+	return &disastersv1.DisasterEvent{
+		Kind:    disastersv1.EventKind_EVENT_KIND_GAP,
+		Payload: &disastersv1.DisasterEvent_Gap{Gap: gapProto(gap)},
+	}
+}
+
+func rejectedProto(err error) *disastersv1.DisasterEvent {
+	return &disastersv1.DisasterEvent{
+		Kind:    disastersv1.EventKind_EVENT_KIND_REJECTED,
+		Payload: &disastersv1.DisasterEvent_Rejected{Rejected: &disastersv1.Rejected{Reason: status.Convert(err).Message()}},
+	}
+}
+
+func (s *Server) heartbeatProto(lastSeq int64) *disastersv1.DisasterEvent {
+	return &disastersv1.DisasterEvent{
+		Kind: disastersv1.EventKind_EVENT_KIND_HEARTBEAT,
+		Seq:  lastSeq,
+		Payload: &disastersv1.DisasterEvent_Heartbeat{Heartbeat: &disastersv1.Heartbeat{
+			IntervalSeconds: int64(s.heartbeatInterval / time.Second),
+		}},
+	}
+}
+
+func gapProto(gap *Gap) *disastersv1.StreamGap {
+	return &disastersv1.StreamGap{
+		Missed:   gap.Missed,
+		FirstSeq: gap.FirstSeq,
+		LastSeq:  gap.LastSeq,
+	}
+}
+
 func toProto(d *models.Disaster) *disastersv1.Disaster {
 	return &disastersv1.Disaster{
 		Id:                      d.ID,
@@ -175,8 +957,12 @@ func toProto(d *models.Disaster) *disastersv1.Disaster {
 		Longitude:               d.Longitude,
 		Timestamp:               d.Timestamp.Unix(),
 		Country:                 d.Country,
+		CountryIso:              d.CountryISO,
 		AffectedPopulation:      d.AffectedPopulation,
 		AffectedPopulationCount: d.AffectedPopulationCount,
 		ReportUrl:               d.ReportURL,
+		Seq:                     d.Seq,
+		DistanceKm:              d.DistanceKm,
+		Snippet:                 d.Snippet,
 	}
 }
@@ -0,0 +1,896 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+// fakeStream implements grpc.ServerStreamingServer[T] for testing
+type fakeStream[T any] struct {
+	grpc.ServerStream
+	ctx     context.Context
+	release chan struct{} // if set, Send blocks until it is closed
+	mu      sync.Mutex
+	sent    []*T
+}
+
+func (f *fakeStream[T]) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeStream[T]) Send(m *T) error {
+	if f.release != nil {
+		<-f.release
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, m)
+	return nil
+}
+
+func (f *fakeStream[T]) messages() []*T {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*T(nil), f.sent...)
+}
+
+func (f *fakeStream[T]) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.sent)
+}
+
+func disasterIDs(msgs []*disastersv1.Disaster) []string {
+	ids := make([]string, len(msgs))
+	for i, d := range msgs {
+		ids[i] = d.Id
+	}
+	return ids
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func setupTestServer(t *testing.T, opts ...BroadcasterOption) (*Server, *repository.SQLiteDB, *Broadcaster) {
+	t.Helper()
+	db, err := repository.NewSQLiteDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	b := NewBroadcaster(opts...)
+	return NewServer(db, b), db, b
+}
+
+func TestServer_StreamDisasters_ResumeAfter(t *testing.T) {
+	srv, db, b := setupTestServer(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	var stored []*models.Disaster
+	for _, id := range []string{"d1", "d2", "d3"} {
+		d := &models.Disaster{ID: id, Source: "test", Type: disastersv1.DisasterType_EARTHQUAKE, Timestamp: now, CreatedAt: now}
+		if err := db.Add(ctx, d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		stored = append(stored, d)
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream := &fakeStream[disastersv1.Disaster]{ctx: streamCtx}
+	resumeAfter := stored[0].Seq
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.StreamDisasters(&disastersv1.StreamDisastersRequest{ResumeAfter: &resumeAfter}, stream)
+	}()
+
+	// Replay should deliver everything after the cursor
+	waitFor(t, func() bool { return stream.count() == 2 })
+
+	// A late broadcast of an already replayed event is not delivered twice
+	b.Broadcast(stored[2])
+
+	live := &models.Disaster{ID: "d4", Source: "test", Type: disastersv1.DisasterType_FLOOD, Timestamp: now, CreatedAt: now}
+	if err := db.Add(ctx, live); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	b.Broadcast(live)
+
+	waitFor(t, func() bool { return stream.count() == 3 })
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("StreamDisasters returned error: %v", err)
+	}
+
+	got := disasterIDs(stream.messages())
+	want := []string{"d2", "d3", "d4"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestServer_StreamDisasters_ReplayAppliesFilters(t *testing.T) {
+	srv, db, _ := setupTestServer(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, d := range []*models.Disaster{
+		{ID: "eq1", Source: "test", Type: disastersv1.DisasterType_EARTHQUAKE, Magnitude: 6.0, Timestamp: now, CreatedAt: now},
+		{ID: "fl1", Source: "test", Type: disastersv1.DisasterType_FLOOD, Timestamp: now, CreatedAt: now},
+		{ID: "eq2", Source: "test", Type: disastersv1.DisasterType_EARTHQUAKE, Magnitude: 4.0, Timestamp: now, CreatedAt: now},
+	} {
+		if err := db.Add(ctx, d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream := &fakeStream[disastersv1.Disaster]{ctx: streamCtx}
+	resumeAfter := int64(0)
+	eqType := disastersv1.DisasterType_EARTHQUAKE
+	minMag := 5.0
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.StreamDisasters(&disastersv1.StreamDisastersRequest{
+			ResumeAfter:  &resumeAfter,
+			Type:         &eqType,
+			MinMagnitude: &minMag,
+		}, stream)
+	}()
+
+	waitFor(t, func() bool { return stream.count() == 1 })
+	cancel()
+	<-done
+
+	if got := disasterIDs(stream.messages()); got[0] != "eq1" {
+		t.Errorf("expected only eq1 to be replayed, got %v", got)
+	}
+}
+
+// streamWithDrops runs StreamDisasters for a client that falls behind until three events are dropped,
+// and returns the messages it was sent. want is the number of messages to wait for.
+func streamWithDrops(t *testing.T, req *disastersv1.StreamDisastersRequest, want int) []*disastersv1.Disaster {
+	t.Helper()
+	srv, _, b := setupTestServer(t)
+
+	streamCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &fakeStream[disastersv1.Disaster]{ctx: streamCtx, release: make(chan struct{})}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.StreamDisasters(req, stream)
+	}()
+	waitFor(t, func() bool { return b.SubscriberCount() == 1 })
+
+	// The first event is picked up and blocks in Send, the next fill the buffer and the rest are dropped
+	total := subscriberBufferSize + 4
+	b.Broadcast(&models.Disaster{ID: "first", Seq: 1})
+	waitFor(t, func() bool { return b.Metrics().PerSubscriber[0].Buffered == 0 })
+	for seq := 2; seq <= total; seq++ {
+		b.Broadcast(&models.Disaster{ID: "next", Seq: int64(seq)})
+	}
+	close(stream.release)
+
+	waitFor(t, func() bool { return stream.count() == want })
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("StreamDisasters returned error: %v", err)
+	}
+	return stream.messages()
+}
+
+func TestServer_StreamDisasters_ReportsGap(t *testing.T) {
+	total := subscriberBufferSize + 4
+	var gaps []*disastersv1.StreamGap
+	for _, m := range streamWithDrops(t, &disastersv1.StreamDisastersRequest{ReportGaps: true}, subscriberBufferSize+2) {
+		if m.Gap != nil {
+			if m.Id != "" {
+				t.Errorf("expected gap as a standalone message, got it on %s", m.Id)
+			}
+			gaps = append(gaps, m.Gap)
+		}
+	}
+	if len(gaps) != 1 {
+		t.Fatalf("expected exactly one gap notice, got %d", len(gaps))
+	}
+	if gaps[0].Missed != 3 || gaps[0].FirstSeq != int64(total-2) || gaps[0].LastSeq != int64(total) {
+		t.Errorf("unexpected gap: %+v", gaps[0])
+	}
+}
+
+func TestServer_StreamDisasters_GapsAreOptIn(t *testing.T) {
+	for _, m := range streamWithDrops(t, &disastersv1.StreamDisastersRequest{}, subscriberBufferSize+1) {
+		if m.Id == "" || m.Gap != nil {
+			t.Errorf("expected only disasters without report_gaps, got %+v", m)
+		}
+	}
+}
+
+func TestServer_StreamDisasters_EvictsLaggedSubscriber(t *testing.T) {
+	srv, _, b := setupTestServer(t, WithEvictAfter(2))
+
+	streamCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &fakeStream[disastersv1.Disaster]{ctx: streamCtx, release: make(chan struct{})}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.StreamDisasters(&disastersv1.StreamDisastersRequest{}, stream)
+	}()
+	waitFor(t, func() bool { return b.SubscriberCount() == 1 })
+
+	b.Broadcast(&models.Disaster{ID: "first", Seq: 1})
+	waitFor(t, func() bool { return b.Metrics().PerSubscriber[0].Buffered == 0 })
+	for seq := 2; seq <= subscriberBufferSize+3; seq++ {
+		b.Broadcast(&models.Disaster{ID: "next", Seq: int64(seq)})
+	}
+	close(stream.release)
+
+	err := <-done
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted after eviction, got %v", err)
+	}
+	if b.SubscriberCount() != 0 {
+		t.Errorf("expected evicted subscriber to be removed, got %d", b.SubscriberCount())
+	}
+}
+
+func TestServer_StreamDisasters_SkipsUpdates(t *testing.T) {
+	srv, _, b := setupTestServer(t)
+
+	streamCtx, cancel := context.WithCancel(context.Background())
+	stream := &fakeStream[disastersv1.Disaster]{ctx: streamCtx}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.StreamDisasters(&disastersv1.StreamDisastersRequest{}, stream)
+	}()
+	waitFor(t, func() bool { return b.SubscriberCount() == 1 })
+
+	d := &models.Disaster{ID: "d1", Seq: 1}
+	b.Publish(&models.DisasterEvent{Seq: 1, Kind: disastersv1.EventKind_EVENT_KIND_CREATED, Disaster: d})
+	b.Publish(&models.DisasterEvent{Seq: 2, Kind: disastersv1.EventKind_EVENT_KIND_ESCALATED, Disaster: d})
+	b.Publish(&models.DisasterEvent{Seq: 3, Kind: disastersv1.EventKind_EVENT_KIND_CREATED, Disaster: &models.Disaster{ID: "d2", Seq: 3}})
+
+	waitFor(t, func() bool { return stream.count() == 2 })
+	cancel()
+	<-done
+
+	got := disasterIDs(stream.messages())
+	if got[0] != "d1" || got[1] != "d2" {
+		t.Errorf("expected only CREATED events [d1 d2], got %v", got)
+	}
+}
+
+func TestServer_StreamDisasterEvents(t *testing.T) {
+	srv, db, b := setupTestServer(t)
+	srv.heartbeatInterval = 20 * time.Millisecond
+	ctx := context.Background()
+	now := time.Now()
+
+	d := &models.Disaster{ID: "d1", Source: "test", Type: disastersv1.DisasterType_CYCLONE, AlertLevel: disastersv1.AlertLevel_GREEN, Timestamp: now, CreatedAt: now}
+	if err := db.Add(ctx, d); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	d.AlertLevel = disastersv1.AlertLevel_ORANGE
+	d.UpdatedAt = now
+	if err := db.Update(ctx, d, disastersv1.EventKind_EVENT_KIND_ESCALATED); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream := &fakeStream[disastersv1.DisasterEvent]{ctx: streamCtx}
+	resumeAfter := int64(0)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.StreamDisasterEvents(&disastersv1.StreamDisastersRequest{ResumeAfter: &resumeAfter}, stream)
+	}()
+
+	// Replay carries the stored kinds, then the idle stream heartbeats
+	waitFor(t, func() bool {
+		msgs := stream.messages()
+		return len(msgs) >= 3 && msgs[len(msgs)-1].Kind == disastersv1.EventKind_EVENT_KIND_HEARTBEAT
+	})
+
+	b.Publish(&models.DisasterEvent{Seq: d.Seq + 1, Kind: disastersv1.EventKind_EVENT_KIND_CLOSED, Disaster: d})
+	waitFor(t, func() bool {
+		for _, m := range stream.messages() {
+			if m.Kind == disastersv1.EventKind_EVENT_KIND_CLOSED {
+				return true
+			}
+		}
+		return false
+	})
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("StreamDisasterEvents returned error: %v", err)
+	}
+
+	msgs := stream.messages()
+	if msgs[0].Kind != disastersv1.EventKind_EVENT_KIND_CREATED || msgs[1].Kind != disastersv1.EventKind_EVENT_KIND_ESCALATED {
+		t.Fatalf("expected CREATED then ESCALATED replay, got %s, %s", msgs[0].Kind, msgs[1].Kind)
+	}
+	if msgs[0].Seq >= msgs[1].Seq {
+		t.Errorf("expected increasing seq, got %d then %d", msgs[0].Seq, msgs[1].Seq)
+	}
+	if msgs[1].GetDisaster().GetAlertLevel() != disastersv1.AlertLevel_ORANGE {
+		t.Errorf("expected replayed disaster payload, got %v", msgs[1].Payload)
+	}
+
+	hb := msgs[2]
+	if hb.Kind != disastersv1.EventKind_EVENT_KIND_HEARTBEAT || hb.GetHeartbeat() == nil {
+		t.Fatalf("expected heartbeat after replay, got %s", hb.Kind)
+	}
+	if hb.Seq != msgs[1].Seq {
+		t.Errorf("expected heartbeat to report last seq %d, got %d", msgs[1].Seq, hb.Seq)
+	}
+	for _, m := range msgs {
+		if m.ServerTimeMs == 0 {
+			t.Errorf("expected server time on every message, missing on %s", m.Kind)
+		}
+	}
+}
+
+// fakeBidiStream implements disastersv1.DisasterService_SubscribeServer for testing
+type fakeBidiStream struct {
+	fakeStream[disastersv1.DisasterEvent]
+	recv chan *disastersv1.SubscribeRequest // closing it ends the client side with io.EOF
+}
+
+func (f *fakeBidiStream) Recv() (*disastersv1.SubscribeRequest, error) {
+	select {
+	case msg, ok := <-f.recv:
+		if !ok {
+			return nil, io.EOF
+		}
+		return msg, nil
+	case <-f.ctx.Done():
+		return nil, f.ctx.Err()
+	}
+}
+
+func (f *fakeBidiStream) countKind(kind disastersv1.EventKind) int {
+	n := 0
+	for _, m := range f.messages() {
+		if m.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+func TestServer_Subscribe(t *testing.T) {
+	srv, db, b := setupTestServer(t)
+	srv.ackTimeout = 40 * time.Millisecond
+	ctx := context.Background()
+	now := time.Now()
+
+	quake := &models.Disaster{ID: "eq1", Source: "test", Type: disastersv1.DisasterType_EARTHQUAKE, Timestamp: now, CreatedAt: now}
+	if err := db.Add(ctx, quake); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream := &fakeBidiStream{
+		fakeStream: fakeStream[disastersv1.DisasterEvent]{ctx: streamCtx},
+		recv:       make(chan *disastersv1.SubscribeRequest, 4),
+	}
+	resumeAfter := int64(0)
+	stream.recv <- &disastersv1.SubscribeRequest{Request: &disastersv1.SubscribeRequest_Filter{
+		Filter: &disastersv1.StreamDisastersRequest{ResumeAfter: &resumeAfter, ConsumerId: "slack"},
+	}}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Subscribe(stream)
+	}()
+
+	// Unacked replayed event is redelivered with an incremented attempt
+	waitFor(t, func() bool {
+		msgs := stream.messages()
+		return len(msgs) >= 2 && msgs[len(msgs)-1].DeliveryAttempt >= 2
+	})
+	first := stream.messages()[0]
+	if first.GetDisaster().GetId() != "eq1" || first.DeliveryAttempt != 1 {
+		t.Fatalf("expected eq1 on first attempt, got %s attempt %d", first.GetDisaster().GetId(), first.DeliveryAttempt)
+	}
+
+	// Ack in-band stops redelivery and records the delivery for the stream's consumer
+	stream.recv <- &disastersv1.SubscribeRequest{Request: &disastersv1.SubscribeRequest_Ack{
+		Ack: &disastersv1.Ack{Seqs: []int64{quake.Seq}},
+	}}
+	sent := true
+	waitFor(t, func() bool {
+		got, _ := db.ListDisasters(ctx, repository.Filter{ConsumerID: "slack", Delivered: &sent})
+		return len(got) == 1
+	})
+	delivered := stream.countKind(disastersv1.EventKind_EVENT_KIND_CREATED)
+	time.Sleep(3 * srv.ackTimeout)
+	if n := stream.countKind(disastersv1.EventKind_EVENT_KIND_CREATED); n != delivered {
+		t.Errorf("expected no redelivery after ack, got %d more", n-delivered)
+	}
+
+	// A bad filter update is rejected without closing the stream
+	stream.recv <- &disastersv1.SubscribeRequest{Request: &disastersv1.SubscribeRequest_Filter{
+		Filter: &disastersv1.StreamDisastersRequest{Near: &disastersv1.GeoRadius{Latitude: 200, RadiusKm: 10}},
+	}}
+	waitFor(t, func() bool { return stream.countKind(disastersv1.EventKind_EVENT_KIND_REJECTED) == 1 })
+	msgs := stream.messages()
+	if reason := msgs[len(msgs)-1].GetRejected().GetReason(); !strings.Contains(reason, "invalid near") {
+		t.Errorf("expected rejection to explain the bad filter, got %q", reason)
+	}
+
+	// Filter update applies without reconnecting
+	flood := disastersv1.DisasterType_FLOOD
+	stream.recv <- &disastersv1.SubscribeRequest{Request: &disastersv1.SubscribeRequest_Filter{
+		Filter: &disastersv1.StreamDisastersRequest{Type: &flood},
+	}}
+	waitFor(t, func() bool { return len(stream.recv) == 0 })
+	time.Sleep(10 * time.Millisecond)
+
+	b.Publish(&models.DisasterEvent{Seq: 10, Kind: disastersv1.EventKind_EVENT_KIND_CREATED, Disaster: &models.Disaster{ID: "eq2", Type: disastersv1.DisasterType_EARTHQUAKE}})
+	b.Publish(&models.DisasterEvent{Seq: 11, Kind: disastersv1.EventKind_EVENT_KIND_CREATED, Disaster: &models.Disaster{ID: "fl1", Type: disastersv1.DisasterType_FLOOD}})
+	waitFor(t, func() bool {
+		msgs := stream.messages()
+		return msgs[len(msgs)-1].GetDisaster().GetId() == "fl1"
+	})
+	for _, m := range stream.messages() {
+		if m.GetDisaster().GetId() == "eq2" {
+			t.Error("expected eq2 to be filtered out after filter update")
+		}
+	}
+
+	close(stream.recv)
+	if err := <-done; err != nil {
+		t.Fatalf("Subscribe returned error: %v", err)
+	}
+}
+
+func TestServer_Subscribe_RequiresFilterFirst(t *testing.T) {
+	srv, _, _ := setupTestServer(t)
+	stream := &fakeBidiStream{
+		fakeStream: fakeStream[disastersv1.DisasterEvent]{ctx: context.Background()},
+		recv:       make(chan *disastersv1.SubscribeRequest, 1),
+	}
+	stream.recv <- &disastersv1.SubscribeRequest{Request: &disastersv1.SubscribeRequest_Ack{Ack: &disastersv1.Ack{}}}
+
+	err := srv.Subscribe(stream)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument when the first message is an ack, got %v", err)
+	}
+}
+
+func TestServer_Subscribe_DeliveryLimits(t *testing.T) {
+	srv, _, b := setupTestServer(t)
+	srv.ackTimeout = 50 * time.Millisecond
+	srv.maxAttempts = 2
+	srv.maxUnacked = 1
+
+	streamCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &fakeBidiStream{
+		fakeStream: fakeStream[disastersv1.DisasterEvent]{ctx: streamCtx},
+		recv:       make(chan *disastersv1.SubscribeRequest, 1),
+	}
+	stream.recv <- &disastersv1.SubscribeRequest{Request: &disastersv1.SubscribeRequest_Filter{
+		Filter: &disastersv1.StreamDisastersRequest{},
+	}}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Subscribe(stream)
+	}()
+	waitFor(t, func() bool { return b.SubscriberCount() == 1 })
+
+	// An event never acked is given up on after max attempts, freeing its slot
+	b.Publish(&models.DisasterEvent{Seq: 1, Kind: disastersv1.EventKind_EVENT_KIND_CREATED, Disaster: &models.Disaster{ID: "d1"}})
+	waitFor(t, func() bool { return stream.countKind(disastersv1.EventKind_EVENT_KIND_CREATED) == 2 })
+	time.Sleep(3 * srv.ackTimeout)
+	if n := stream.countKind(disastersv1.EventKind_EVENT_KIND_CREATED); n != 2 {
+		t.Fatalf("expected delivery to stop after 2 attempts, got %d", n)
+	}
+
+	b.Publish(&models.DisasterEvent{Seq: 2, Kind: disastersv1.EventKind_EVENT_KIND_CREATED, Disaster: &models.Disaster{ID: "d2"}})
+	waitFor(t, func() bool {
+		msgs := stream.messages()
+		return msgs[len(msgs)-1].GetDisaster().GetId() == "d2"
+	})
+
+	// With seq 2 still unacked the stream is at its cap, so the next event closes it
+	b.Publish(&models.DisasterEvent{Seq: 3, Kind: disastersv1.EventKind_EVENT_KIND_CREATED, Disaster: &models.Disaster{ID: "d3"}})
+	select {
+	case err := <-done:
+		if status.Code(err) != codes.ResourceExhausted {
+			t.Fatalf("expected ResourceExhausted at the unacked cap, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected stream to close at the unacked cap")
+	}
+}
+
+func TestServer_AcknowledgeDisasters_PerConsumer(t *testing.T) {
+	srv, db, _ := setupTestServer(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, id := range []string{"d1", "d2"} {
+		if err := db.Add(ctx, &models.Disaster{ID: id, Source: "test", Timestamp: now, CreatedAt: now}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	resp, err := srv.AcknowledgeDisasters(ctx, &disastersv1.AcknowledgeDisastersRequest{Ids: []string{"d1"}, ConsumerId: "sms"})
+	if err != nil {
+		t.Fatalf("AcknowledgeDisasters failed: %v", err)
+	}
+	if resp.AcknowledgedCount != 1 {
+		t.Errorf("expected 1 acknowledged, got %d", resp.AcknowledgedCount)
+	}
+
+	// No consumer_id means the Discord bot, whose acks are independent of the SMS relay
+	if _, err := srv.AcknowledgeDisasters(ctx, &disastersv1.AcknowledgeDisastersRequest{Ids: []string{"d2"}}); err != nil {
+		t.Fatalf("AcknowledgeDisasters failed: %v", err)
+	}
+
+	unsent := false
+	sms, err := srv.ListDisasters(ctx, &disastersv1.ListDisastersRequest{ConsumerId: "sms", Delivered: &unsent})
+	if err != nil {
+		t.Fatalf("ListDisasters failed: %v", err)
+	}
+	if len(sms.Disasters) != 1 || sms.Disasters[0].Id != "d2" {
+		t.Errorf("expected sms to have only d2 unsent, got %v", sms.Disasters)
+	}
+
+	discord, err := srv.ListDisasters(ctx, &disastersv1.ListDisastersRequest{DiscordSent: &unsent})
+	if err != nil {
+		t.Fatalf("ListDisasters failed: %v", err)
+	}
+	if len(discord.Disasters) != 1 || discord.Disasters[0].Id != "d1" {
+		t.Errorf("expected discord to have only d1 unsent via deprecated discord_sent, got %v", discord.Disasters)
+	}
+}
+
+func TestServer_StreamDisasterEvents_Group(t *testing.T) {
+	srv, _, b := setupTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	streams := []*fakeStream[disastersv1.DisasterEvent]{{ctx: ctx}, {ctx: ctx}}
+	done := make(chan error, len(streams))
+	for _, stream := range streams {
+		go func() {
+			done <- srv.StreamDisasterEvents(&disastersv1.StreamDisastersRequest{Group: "bots"}, stream)
+		}()
+	}
+	waitFor(t, func() bool { return b.SubscriberCount() == 2 })
+
+	for i := 1; i <= 4; i++ {
+		b.Broadcast(&models.Disaster{ID: "grouped", Seq: int64(i)})
+	}
+	waitFor(t, func() bool { return streams[0].count()+streams[1].count() == 4 })
+	if streams[0].count() != 2 {
+		t.Errorf("expected events split evenly, got %d and %d", streams[0].count(), streams[1].count())
+	}
+
+	// Sent events count as handled, so nothing is left to reassign
+	waitFor(t, func() bool { return b.Metrics().Groups["bots"].InFlight == 0 })
+
+	cancel()
+	for range streams {
+		if err := <-done; err != nil {
+			t.Fatalf("StreamDisasterEvents returned error: %v", err)
+		}
+	}
+}
+
+func TestServer_StreamDisasters_GroupRejectsResume(t *testing.T) {
+	srv, _, _ := setupTestServer(t)
+	resumeAfter := int64(0)
+	stream := &fakeStream[disastersv1.Disaster]{ctx: context.Background()}
+
+	err := srv.StreamDisasters(&disastersv1.StreamDisastersRequest{Group: "bots", ResumeAfter: &resumeAfter}, stream)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestServer_StreamDisasterEvents_LocationFilters(t *testing.T) {
+	srv, _, b := setupTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stream := &fakeStream[disastersv1.DisasterEvent]{ctx: ctx}
+	req := &disastersv1.StreamDisastersRequest{
+		Countries: []string{"jpn"},
+		Near:      &disastersv1.GeoRadius{Latitude: 35.68, Longitude: 139.69, RadiusKm: 300},
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.StreamDisasterEvents(req, stream)
+	}()
+	waitFor(t, func() bool { return b.SubscriberCount() == 1 })
+
+	b.Broadcast(&models.Disaster{ID: "osaka", CountryISO: "JPN", Latitude: 34.69, Longitude: 135.50, Seq: 1})
+	b.Broadcast(&models.Disaster{ID: "seoul", CountryISO: "KOR", Latitude: 37.57, Longitude: 126.98, Seq: 2})
+	b.Broadcast(&models.Disaster{ID: "yokohama", CountryISO: "JPN", Latitude: 35.44, Longitude: 139.64, Seq: 3})
+
+	waitFor(t, func() bool { return stream.count() == 1 })
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("StreamDisasterEvents returned error: %v", err)
+	}
+
+	msgs := stream.messages()
+	if len(msgs) != 1 || msgs[0].GetDisaster().GetId() != "yokohama" {
+		t.Fatalf("expected only yokohama, got %d messages", len(msgs))
+	}
+	if msgs[0].GetDisaster().GetCountryIso() != "JPN" {
+		t.Errorf("expected country_iso JPN, got %q", msgs[0].GetDisaster().GetCountryIso())
+	}
+}
+
+func TestServer_ListDisasters_InvalidBBox(t *testing.T) {
+	srv, _, _ := setupTestServer(t)
+
+	_, err := srv.ListDisasters(context.Background(), &disastersv1.ListDisastersRequest{
+		Bbox: &disastersv1.BoundingBox{MinLatitude: 50, MaxLatitude: 10},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestServer_ListDisasters_Query(t *testing.T) {
+	srv, db, _ := setupTestServer(t)
+	ctx := context.Background()
+	for _, d := range []*models.Disaster{
+		{ID: "etna", Title: "Volcanic eruption of Etna", Country: "Italy"},
+		{ID: "luzon", Title: "Tropical Cyclone over Luzon", Country: "Philippines"},
+	} {
+		d.Source, d.Timestamp, d.CreatedAt = "test", time.Now(), time.Now()
+		if err := db.Add(ctx, d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	resp, err := srv.ListDisasters(ctx, &disastersv1.ListDisastersRequest{Query: "etna"})
+	if err != nil {
+		t.Fatalf("ListDisasters failed: %v", err)
+	}
+	if len(resp.Disasters) != 1 || resp.Disasters[0].Id != "etna" || resp.Disasters[0].Snippet != "Volcanic eruption of <mark>Etna</mark>" {
+		t.Errorf("expected etna with a highlighted snippet, got %+v", resp.Disasters)
+	}
+}
+
+func TestServer_ListDisasters_Pagination(t *testing.T) {
+	srv, db, _ := setupTestServer(t)
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		d := &models.Disaster{ID: fmt.Sprintf("d%d", i), Source: "test", Title: "Quake",
+			Timestamp: time.Now().Add(-time.Duration(i) * time.Hour), CreatedAt: time.Now()}
+		if err := db.Add(ctx, d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	var ids []string
+	req := &disastersv1.ListDisastersRequest{Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination did not terminate")
+		}
+		resp, err := srv.ListDisasters(ctx, req)
+		if err != nil {
+			t.Fatalf("ListDisasters failed: %v", err)
+		}
+		ids = append(ids, disasterIDs(resp.Disasters)...)
+		if resp.NextPageToken == "" {
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+	if fmt.Sprint(ids) != "[d0 d1 d2 d3 d4]" {
+		t.Errorf("expected every disaster once in order, got %v", ids)
+	}
+
+	_, err := srv.ListDisasters(ctx, &disastersv1.ListDisastersRequest{PageToken: "bogus"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a bad page token, got %v", err)
+	}
+}
+
+func TestServer_GetStats(t *testing.T) {
+	srv, db, _ := setupTestServer(t)
+	ctx := context.Background()
+	day := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	for _, d := range []*models.Disaster{
+		{ID: "q1", Type: disastersv1.DisasterType_EARTHQUAKE, AlertLevel: disastersv1.AlertLevel_RED, Magnitude: 6.5, AffectedPopulationCount: 100, Timestamp: day},
+		{ID: "q2", Type: disastersv1.DisasterType_EARTHQUAKE, AlertLevel: disastersv1.AlertLevel_GREEN, Magnitude: 4.5, Timestamp: day.AddDate(0, 0, 1)},
+		{ID: "f1", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_ORANGE, AffectedPopulationCount: 900, Timestamp: day.AddDate(0, 1, 0)},
+	} {
+		d.Source, d.CreatedAt = "test", time.Now()
+		if err := db.Add(ctx, d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	resp, err := srv.GetStats(ctx, &disastersv1.GetStatsRequest{Bucket: disastersv1.TimeBucket_TIME_BUCKET_MONTH})
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if resp.Total.Count != 3 || resp.Total.MaxMagnitude != 6.5 || resp.Total.TotalAffectedPopulation != 1000 {
+		t.Errorf("unexpected total %+v", resp.Total)
+	}
+	if len(resp.ByType) != 2 || resp.ByType[0].Key != "EARTHQUAKE" || resp.ByType[0].Count != 2 {
+		t.Errorf("expected earthquakes first, got %+v", resp.ByType)
+	}
+	if len(resp.ByTime) != 2 || resp.ByTime[0].Key != "2026-03-01" || resp.ByTime[1].Key != "2026-04-01" {
+		t.Errorf("expected March and April buckets, got %+v", resp.ByTime)
+	}
+
+	orange := disastersv1.AlertLevel_ORANGE
+	resp, err = srv.GetStats(ctx, &disastersv1.GetStatsRequest{Filter: &disastersv1.ListDisastersRequest{MinAlertLevel: &orange, Limit: 1}})
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if resp.Bucket != disastersv1.TimeBucket_TIME_BUCKET_DAY || resp.Total.Count != 2 || len(resp.ByTime) != 2 {
+		t.Errorf("expected 2 disasters in daily buckets, got %+v", resp)
+	}
+
+	_, err = srv.GetStats(ctx, &disastersv1.GetStatsRequest{Filter: &disastersv1.ListDisastersRequest{SortBy: disastersv1.SortBy_SORT_BY_DISTANCE}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestServer_ListDisasters_Sort(t *testing.T) {
+	srv, db, _ := setupTestServer(t)
+	ctx := context.Background()
+	now := time.Now()
+	for i, mag := range []float64{5.1, 7.3, 6.0} {
+		d := &models.Disaster{ID: fmt.Sprintf("d%d", i), Source: "test", Title: "Quake", Magnitude: mag,
+			Timestamp: now.Add(-time.Duration(i) * time.Hour), CreatedAt: now}
+		if err := db.Add(ctx, d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	maxMag := 7.0
+	until := now.Add(-30 * time.Minute).Unix()
+	resp, err := srv.ListDisasters(ctx, &disastersv1.ListDisastersRequest{
+		SortBy:       disastersv1.SortBy_SORT_BY_MAGNITUDE,
+		SortOrder:    disastersv1.SortOrder_SORT_ORDER_ASC,
+		MaxMagnitude: &maxMag,
+		Until:        &until,
+	})
+	if err != nil {
+		t.Fatalf("ListDisasters failed: %v", err)
+	}
+	if ids := disasterIDs(resp.Disasters); fmt.Sprint(ids) != "[d2]" {
+		t.Errorf("expected [d2], got %v", ids)
+	}
+
+	_, err = srv.ListDisasters(ctx, &disastersv1.ListDisastersRequest{SortBy: disastersv1.SortBy_SORT_BY_DISTANCE})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument sorting by distance without near, got %v", err)
+	}
+}
+
+func TestServer_StreamGeofenceAlerts(t *testing.T) {
+	alerts := NewAlertBroadcaster()
+	db, err := repository.NewSQLiteDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	srv := NewServer(db, NewBroadcaster(), WithAlertBroadcaster(alerts), WithOwnerTokens(models.OwnerTokens{"p4ger": "pager"}))
+
+	ctx, cancel := context.WithCancel(ownerContext("p4ger"))
+	stream := &fakeStream[disastersv1.GeofenceAlert]{ctx: ctx}
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.StreamGeofenceAlerts(&disastersv1.StreamGeofenceAlertsRequest{}, stream)
+	}()
+	waitFor(t, func() bool { return alerts.SubscriberCount() == 1 })
+
+	d := &models.Disaster{ID: "gdacs_1", Type: disastersv1.DisasterType_EARTHQUAKE}
+	alerts.Notify(&models.GeofenceMatch{
+		Alert:    models.Alert{ID: "gf_other:gdacs_1", GeofenceID: "gf_other", Severity: models.AlertSeverityLow},
+		Geofence: &models.Geofence{ID: "gf_other", Name: "Other", Owner: "slack"},
+		Disaster: d,
+	})
+	alerts.Notify(&models.GeofenceMatch{
+		Alert:    models.Alert{ID: "gf_tokyo:gdacs_1", GeofenceID: "gf_tokyo", Severity: models.AlertSeverityHigh, CreatedAt: time.Now()},
+		Geofence: &models.Geofence{ID: "gf_tokyo", Name: "Tokyo", Owner: "pager"},
+		Disaster: d,
+	})
+	waitFor(t, func() bool { return stream.count() == 1 })
+
+	got := stream.messages()[0]
+	if got.GeofenceId != "gf_tokyo" || got.GeofenceName != "Tokyo" || got.Severity != "HIGH" || got.Disaster.GetId() != "gdacs_1" {
+		t.Errorf("unexpected alert %+v", got)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("StreamGeofenceAlerts returned error: %v", err)
+	}
+	if alerts.SubscriberCount() != 0 {
+		t.Errorf("expected subscriber to be removed, got %d", alerts.SubscriberCount())
+	}
+}
+
+func TestServer_StreamGeofenceAlerts_Errors(t *testing.T) {
+	srv, _, _ := setupTestServer(t)
+	stream := &fakeStream[disastersv1.GeofenceAlert]{ctx: context.Background()}
+
+	err := srv.StreamGeofenceAlerts(&disastersv1.StreamGeofenceAlertsRequest{Owner: "pager"}, stream)
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("expected Unimplemented without an alert broadcaster, got %v", err)
+	}
+
+	srv = NewServer(srv.repo, srv.broadcaster, WithAlertBroadcaster(NewAlertBroadcaster()))
+	err = srv.StreamGeofenceAlerts(&disastersv1.StreamGeofenceAlertsRequest{Owner: "pager"}, stream)
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("expected Unimplemented without owner tokens, got %v", err)
+	}
+
+	srv = NewServer(srv.repo, srv.broadcaster, WithAlertBroadcaster(NewAlertBroadcaster()), WithOwnerTokens(models.OwnerTokens{"p4ger": "pager"}))
+	tests := []struct {
+		name  string
+		token string
+		owner string
+		want  codes.Code
+	}{
+		{"no token", "", "pager", codes.Unauthenticated},
+		{"unknown token", "guess", "pager", codes.Unauthenticated},
+		{"other owner", "p4ger", "slack", codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.token != "" {
+				ctx = ownerContext(tt.token)
+			}
+			stream := &fakeStream[disastersv1.GeofenceAlert]{ctx: ctx}
+			err := srv.StreamGeofenceAlerts(&disastersv1.StreamGeofenceAlertsRequest{Owner: tt.owner}, stream)
+			if status.Code(err) != tt.want {
+				t.Errorf("expected %s, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// ownerContext is the incoming context of a call carrying an owner bearer token
+func ownerContext(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
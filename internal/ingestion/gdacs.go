@@ -36,7 +36,9 @@ type gdacsItem struct {
 	EventID     string         `xml:"eventid"`    // gdacs:eventid
 	Severity    string         `xml:"severity"`   // gdacs:severity - e.g. "Magnitude 5.6M, Depth:56.4km"
 	Country     string         `xml:"country"`    // gdacs:country
+	ISO3        string         `xml:"iso3"`       // gdacs:iso3
 	Population  populationData `xml:"population"` // gdacs:population with value attribute and text
+	IsCurrent   string         `xml:"iscurrent"`  // gdacs:iscurrent - "false" once the event is over
 }
 
 func (m *Manager) pollGDACS(ctx context.Context, url string) ([]*models.Disaster, error) {
@@ -95,9 +97,11 @@ func (m *Manager) pollGDACS(ctx context.Context, url string) ([]*models.Disaster
 			Longitude:       lon,
 			Timestamp:       timestamp,
 			Country:         item.Country,
+			CountryISO:      strings.ToUpper(strings.TrimSpace(item.ISO3)),
 			AffectedPopulation:      strings.TrimSpace(item.Population.Text),
 			AffectedPopulationCount: item.Population.Value,
 			ReportURL:       item.Link,
+			Closed:          strings.EqualFold(item.IsCurrent, "false"),
 			CreatedAt:       time.Now(),
 		}
 		disasters = append(disasters, d)
@@ -2,10 +2,12 @@ package ingestion
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"sync"
 	"time"
 
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
 	"github.com/mr1hm/go-disaster-alerts/internal/config"
 	internalgrpc "github.com/mr1hm/go-disaster-alerts/internal/grpc"
 	"github.com/mr1hm/go-disaster-alerts/internal/models"
@@ -13,42 +15,82 @@ import (
 	"github.com/mr1hm/go-disaster-alerts/internal/worker"
 )
 
+// GeofenceEvaluator raises alerts for geofences a stored disaster falls inside
+type GeofenceEvaluator interface {
+	Evaluate(ctx context.Context, d *models.Disaster) ([]models.Alert, error)
+}
+
 type Manager struct {
 	cfg         *config.Config
 	repo        repository.DisasterRepository
 	broadcaster *internalgrpc.Broadcaster
+	geofences   GeofenceEvaluator
 	pool        *worker.WorkerPool
+	batcher     *worker.Batcher // new disasters, stored a batch per transaction
 	wg          sync.WaitGroup
 }
 
-func NewManager(cfg *config.Config, repo repository.DisasterRepository, broadcaster *internalgrpc.Broadcaster) *Manager {
-	return &Manager{
+type ManagerOption func(*Manager)
+
+// WithGeofences evaluates every new or changed disaster against geofences once it is stored.
+func WithGeofences(e GeofenceEvaluator) ManagerOption {
+	return func(m *Manager) {
+		m.geofences = e
+	}
+}
+
+func NewManager(cfg *config.Config, repo repository.DisasterRepository, broadcaster *internalgrpc.Broadcaster, opts ...ManagerOption) *Manager {
+	m := &Manager{
 		cfg:         cfg,
 		repo:        repo,
 		broadcaster: broadcaster,
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
-func (m *Manager) Start(ctx context.Context) {
-	processor := func(ctx context.Context, job worker.Job) error {
-		disaster := job.(*models.Disaster)
-
-		if err := m.repo.Add(ctx, disaster); err != nil {
-			slog.Error("error adding disaster", "id", disaster.ID, "error", err)
-			return err
-		}
+// disasterUpdate is a job for a stored disaster whose source data changed
+type disasterUpdate struct {
+	disaster *models.Disaster // polled data with the stored corrections kept
+	kind     disastersv1.EventKind
+	polled   *models.Disaster // polled data as fetched, to merge again if the stored disaster changes
+}
 
-		// Broadcast to gRPC stream subscribers
-		if m.broadcaster != nil {
-			m.broadcaster.Broadcast(disaster)
-		}
+// maxUpdateAttempts bounds how often an update is merged again with a disaster changed meanwhile
+const maxUpdateAttempts = 3
 
-		slog.Info("added disaster", "id", disaster.ID, "type", disaster.Type, "source", disaster.Source, "alert_level", disaster.AlertLevel, "country", disaster.Country, "affected_population_count", disaster.AffectedPopulationCount)
+// newUpdate merges polled data into a stored disaster, keeping operator corrections. It returns
+// nil if nothing the source reports changed.
+func newUpdate(stored, polled *models.Disaster) *disasterUpdate {
+	d := *polled
+	stored.KeepCorrections(&d)
+	kind, changed := classifyChange(stored, &d)
+	if !changed {
 		return nil
 	}
+	d.CreatedAt = stored.CreatedAt
+	d.UpdatedAt = time.Now()
+	d.Seq = stored.Seq
+	return &disasterUpdate{disaster: &d, kind: kind, polled: polled}
+}
+
+func (m *Manager) Start(ctx context.Context) {
+	processor := func(ctx context.Context, job worker.Job) error {
+		switch j := job.(type) {
+		case *disasterUpdate:
+			return m.processUpdate(ctx, j)
+		default:
+			slog.Error("unknown ingestion job", "job", job)
+			return nil
+		}
+	}
 
 	m.pool = worker.NewWorkerPool(m.cfg.Worker.Count, m.cfg.Worker.BufferSize, processor)
 	m.pool.Start(ctx)
+	m.batcher = worker.NewBatcher(m.cfg.Worker.BatchSize, m.cfg.Worker.BatchWait, m.cfg.Worker.BufferSize, m.processNewBatch)
+	m.batcher.Start(ctx)
 
 	// Start GDACS poller if enabled
 	if m.cfg.Sources.GDACSEnabled {
@@ -57,6 +99,108 @@ func (m *Manager) Start(ctx context.Context) {
 	}
 }
 
+// processNewBatch stores a batch of new disasters in one transaction, then broadcasts each. If the
+// batch fails, e.g. because one disaster was stored meanwhile, they are retried one by one so the
+// others are still stored.
+func (m *Manager) processNewBatch(ctx context.Context, jobs []worker.Job) error {
+	disasters := make([]*models.Disaster, 0, len(jobs))
+	for _, job := range jobs {
+		d, ok := job.(*models.Disaster)
+		if !ok {
+			slog.Error("unknown ingestion job", "job", job)
+			continue
+		}
+		disasters = append(disasters, d)
+	}
+
+	if err := m.repo.AddBatch(ctx, disasters); err != nil {
+		slog.Warn("error adding disaster batch, adding one by one", "count", len(disasters), "error", err)
+		var errs []error
+		for _, d := range disasters {
+			if err := m.processNew(ctx, d); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	for _, d := range disasters {
+		m.announceNew(ctx, d)
+	}
+	slog.Debug("added disaster batch", "count", len(disasters))
+	return nil
+}
+
+func (m *Manager) processNew(ctx context.Context, disaster *models.Disaster) error {
+	if err := m.repo.Add(ctx, disaster); err != nil {
+		slog.Error("error adding disaster", "id", disaster.ID, "error", err)
+		return err
+	}
+	m.announceNew(ctx, disaster)
+	return nil
+}
+
+// announceNew broadcasts a stored disaster and evaluates geofences against it
+func (m *Manager) announceNew(ctx context.Context, disaster *models.Disaster) {
+	// Broadcast to gRPC stream subscribers
+	if m.broadcaster != nil {
+		m.broadcaster.Broadcast(disaster)
+	}
+
+	slog.Info("added disaster", "id", disaster.ID, "type", disaster.Type, "source", disaster.Source, "alert_level", disaster.AlertLevel, "country", disaster.Country, "affected_population_count", disaster.AffectedPopulationCount)
+	m.evaluateGeofences(ctx, disaster)
+}
+
+// processUpdate stores an update if the disaster is unchanged since it was polled. Otherwise, e.g.
+// after an operator's correction, the polled data is merged again with the stored disaster.
+func (m *Manager) processUpdate(ctx context.Context, u *disasterUpdate) error {
+	for attempt := 1; ; attempt++ {
+		err := m.repo.UpdateIfUnchanged(ctx, u.disaster, u.kind)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, repository.ErrConflict) || attempt == maxUpdateAttempts {
+			slog.Error("error updating disaster", "id", u.disaster.ID, "error", err)
+			return err
+		}
+		stored, err := m.repo.GetByID(ctx, u.disaster.ID)
+		if err != nil {
+			slog.Error("error rereading disaster", "id", u.disaster.ID, "error", err)
+			return err
+		}
+		if stored == nil {
+			return nil // expired meanwhile
+		}
+		if u = newUpdate(stored, u.polled); u == nil {
+			return nil // already up to date
+		}
+	}
+
+	if m.broadcaster != nil {
+		m.broadcaster.Publish(&models.DisasterEvent{
+			Seq:       u.disaster.Seq,
+			Kind:      u.kind,
+			Disaster:  u.disaster,
+			CreatedAt: u.disaster.UpdatedAt,
+		})
+	}
+
+	slog.Info("updated disaster", "id", u.disaster.ID, "kind", u.kind, "alert_level", u.disaster.AlertLevel, "closed", u.disaster.Closed)
+	m.evaluateGeofences(ctx, u.disaster)
+	return nil
+}
+
+// evaluateGeofences runs after the disaster is stored; a failure is logged rather than failing the job,
+// since the disaster itself was stored and broadcast
+func (m *Manager) evaluateGeofences(ctx context.Context, d *models.Disaster) {
+	if m.geofences == nil {
+		return
+	}
+	if _, err := m.geofences.Evaluate(ctx, d); err != nil {
+		slog.Error("error evaluating geofences", "id", d.ID, "error", err)
+	}
+}
+
 func (m *Manager) runPoller(ctx context.Context, source, url string, interval time.Duration) {
 	defer m.wg.Done()
 	slog.Info("starting poller", "source", source, "interval", interval)
@@ -110,33 +254,66 @@ func (m *Manager) poll(ctx context.Context, source, url string) {
 		return
 	}
 
-	// Filter out existing disasters before submitting
+	// Split into new disasters and stored ones whose source data changed
 	var newDisasters []*models.Disaster
+	var updates []*disasterUpdate
 	for _, d := range disasters {
-		exists, err := m.repo.Exists(ctx, d.ID)
+		existing, err := m.repo.GetByID(ctx, d.ID)
 		if err != nil {
 			slog.Error("error checking existence", "id", d.ID, "error", err)
 			continue
 		}
-		if !exists {
+		if existing == nil {
 			newDisasters = append(newDisasters, d)
+			continue
+		}
+		if u := newUpdate(existing, d); u != nil {
+			updates = append(updates, u)
 		}
 	}
 
-	if len(newDisasters) == 0 {
+	if len(newDisasters) == 0 && len(updates) == 0 {
 		slog.Info("no new disaster alerts found", "source", source)
 		return
 	}
 
 	for _, d := range newDisasters {
-		m.pool.Submit(d)
+		m.batcher.Submit(d)
 	}
+	for _, u := range updates {
+		m.pool.Submit(u)
+	}
+
+	slog.Debug("poll complete", "source", source, "count", len(newDisasters), "updated", len(updates))
+}
 
-	slog.Debug("poll complete", "source", source, "count", len(newDisasters))
+// classifyChange compares a stored disaster with freshly polled data and reports which
+// kind of event the difference represents, if any.
+func classifyChange(stored, polled *models.Disaster) (disastersv1.EventKind, bool) {
+	switch {
+	case polled.Closed && !stored.Closed:
+		return disastersv1.EventKind_EVENT_KIND_CLOSED, true
+	case polled.AlertLevel > stored.AlertLevel:
+		return disastersv1.EventKind_EVENT_KIND_ESCALATED, true
+	case polled.AlertLevel != stored.AlertLevel,
+		polled.Closed != stored.Closed,
+		polled.Magnitude != stored.Magnitude,
+		polled.Latitude != stored.Latitude,
+		polled.Longitude != stored.Longitude,
+		polled.Title != stored.Title,
+		polled.Description != stored.Description,
+		polled.Country != stored.Country,
+		polled.CountryISO != stored.CountryISO,
+		polled.AffectedPopulation != stored.AffectedPopulation,
+		polled.AffectedPopulationCount != stored.AffectedPopulationCount:
+		return disastersv1.EventKind_EVENT_KIND_UPDATED, true
+	}
+	return disastersv1.EventKind_EVENT_KIND_UNSPECIFIED, false
 }
 
 func (m *Manager) Stop() {
 	m.wg.Wait()
+	m.batcher.Stop()
 	m.pool.Stop()
 	slog.Info("ingestion manager stopped")
 }
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"sync"
-	"sync/atomic"
 	"testing"
 	"time"
 
@@ -12,60 +11,34 @@ import (
 
 	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
 	"github.com/mr1hm/go-disaster-alerts/internal/config"
+	internalgrpc "github.com/mr1hm/go-disaster-alerts/internal/grpc"
 	"github.com/mr1hm/go-disaster-alerts/internal/models"
 	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+	"github.com/mr1hm/go-disaster-alerts/internal/worker"
 )
 
 func TestMain(m *testing.M) {
 	goleak.VerifyTestMain(m)
 }
 
-// mockDisasterRepo implements repository.DisasterRepository for testing
-type mockDisasterRepo struct {
-	mu        sync.Mutex
-	disasters map[string]*models.Disaster
-	addCount  atomic.Int64
-}
-
-func newMockRepo() *mockDisasterRepo {
-	return &mockDisasterRepo{
-		disasters: make(map[string]*models.Disaster),
+// countDisasters returns how many disasters repo holds
+func countDisasters(t *testing.T, repo repository.DisasterRepository) int {
+	t.Helper()
+	disasters, err := repo.ListDisasters(context.Background(), repository.Filter{})
+	if err != nil {
+		t.Fatalf("ListDisasters failed: %v", err)
 	}
+	return len(disasters)
 }
 
-func (m *mockDisasterRepo) Add(ctx context.Context, d *models.Disaster) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.disasters[d.ID] = d
-	m.addCount.Add(1)
-	return nil
-}
-
-func (m *mockDisasterRepo) GetByID(ctx context.Context, id string) (*models.Disaster, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	return m.disasters[id], nil
-}
-
-func (m *mockDisasterRepo) Exists(ctx context.Context, id string) (bool, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	_, exists := m.disasters[id]
-	return exists, nil
-}
-
-func (m *mockDisasterRepo) ListDisasters(ctx context.Context, opts repository.Filter) ([]models.Disaster, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	var results []models.Disaster
-	for _, d := range m.disasters {
-		results = append(results, *d)
+// storedAlertLevel returns the alert level repo holds for a disaster
+func storedAlertLevel(t *testing.T, repo repository.DisasterRepository, id string) disastersv1.AlertLevel {
+	t.Helper()
+	d, err := repo.GetByID(context.Background(), id)
+	if err != nil || d == nil {
+		t.Fatalf("GetByID(%s) = %v, %v", id, d, err)
 	}
-	return results, nil
-}
-
-func (m *mockDisasterRepo) MarkAsSent(ctx context.Context, ids []string) (int64, error) {
-	return int64(len(ids)), nil
+	return d.AlertLevel
 }
 
 func TestManager_StartStop(t *testing.T) {
@@ -80,7 +53,7 @@ func TestManager_StartStop(t *testing.T) {
 		},
 	}
 
-	repo := newMockRepo()
+	repo := repository.NewMemoryDB()
 	mgr := NewManager(cfg, repo, nil)
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -109,7 +82,7 @@ func TestManager_ConcurrentSubmit(t *testing.T) {
 		},
 	}
 
-	repo := newMockRepo()
+	repo := repository.NewMemoryDB()
 	mgr := NewManager(cfg, repo, nil)
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -132,7 +105,7 @@ func TestManager_ConcurrentSubmit(t *testing.T) {
 					Timestamp: time.Now(),
 					CreatedAt: time.Now(),
 				}
-				mgr.pool.Submit(d)
+				mgr.batcher.Submit(d)
 			}
 		}(i)
 	}
@@ -147,7 +120,7 @@ func TestManager_ConcurrentSubmit(t *testing.T) {
 
 	// Verify all were processed
 	expected := numGoroutines * numPerGoroutine
-	actual := int(repo.addCount.Load())
+	actual := countDisasters(t, repo)
 	if actual != expected {
 		t.Errorf("expected %d disasters added, got %d", expected, actual)
 	}
@@ -164,7 +137,7 @@ func TestManager_GracefulShutdown(t *testing.T) {
 		},
 	}
 
-	repo := newMockRepo()
+	repo := repository.NewMemoryDB()
 	mgr := NewManager(cfg, repo, nil)
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -179,7 +152,7 @@ func TestManager_GracefulShutdown(t *testing.T) {
 			Timestamp: time.Now(),
 			CreatedAt: time.Now(),
 		}
-		mgr.pool.Submit(d)
+		mgr.batcher.Submit(d)
 	}
 
 	// Immediately cancel
@@ -212,7 +185,7 @@ func TestManager_RaceCondition(t *testing.T) {
 		},
 	}
 
-	repo := newMockRepo()
+	repo := repository.NewMemoryDB()
 	mgr := NewManager(cfg, repo, nil)
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -235,7 +208,7 @@ func TestManager_RaceCondition(t *testing.T) {
 					Timestamp: time.Now(),
 					CreatedAt: time.Now(),
 				}
-				mgr.pool.Submit(d)
+				mgr.batcher.Submit(d)
 			}
 		}(i)
 	}
@@ -248,5 +221,235 @@ func TestManager_RaceCondition(t *testing.T) {
 	mgr.Stop()
 
 	// If we get here without race detector complaining, we're good
-	t.Logf("processed %d disasters without race conditions", repo.addCount.Load())
+	t.Logf("processed %d disasters without race conditions", countDisasters(t, repo))
+}
+
+func TestClassifyChange(t *testing.T) {
+	base := models.Disaster{ID: "gdacs_1", AlertLevel: disastersv1.AlertLevel_ORANGE, Magnitude: 6.1, Title: "M6.1"}
+
+	tests := []struct {
+		name    string
+		mutate  func(d *models.Disaster)
+		kind    disastersv1.EventKind
+		changed bool
+	}{
+		{"unchanged", func(d *models.Disaster) {}, disastersv1.EventKind_EVENT_KIND_UNSPECIFIED, false},
+		{"escalated", func(d *models.Disaster) { d.AlertLevel = disastersv1.AlertLevel_RED }, disastersv1.EventKind_EVENT_KIND_ESCALATED, true},
+		{"downgraded", func(d *models.Disaster) { d.AlertLevel = disastersv1.AlertLevel_GREEN }, disastersv1.EventKind_EVENT_KIND_UPDATED, true},
+		{"magnitude revised", func(d *models.Disaster) { d.Magnitude = 6.3 }, disastersv1.EventKind_EVENT_KIND_UPDATED, true},
+		{"population revised", func(d *models.Disaster) { d.AffectedPopulationCount = 1000 }, disastersv1.EventKind_EVENT_KIND_UPDATED, true},
+		{"closed", func(d *models.Disaster) { d.Closed = true; d.AlertLevel = disastersv1.AlertLevel_RED }, disastersv1.EventKind_EVENT_KIND_CLOSED, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			polled := base
+			tt.mutate(&polled)
+			kind, changed := classifyChange(&base, &polled)
+			if kind != tt.kind || changed != tt.changed {
+				t.Errorf("expected (%s, %v), got (%s, %v)", tt.kind, tt.changed, kind, changed)
+			}
+		})
+	}
+}
+
+func TestClassifyChange_KeepsCorrections(t *testing.T) {
+	stored := models.Disaster{ID: "gdacs_1", Magnitude: 6.1, Country: "Japan", CountryISO: "JPN", Latitude: 35.6,
+		Corrections: []string{"country", "country_iso", "latitude"}}
+	polled := models.Disaster{ID: "gdacs_1", Magnitude: 6.1, Country: "China", CountryISO: "CHN", Latitude: 30.1}
+
+	stored.KeepCorrections(&polled)
+	if _, changed := classifyChange(&stored, &polled); changed {
+		t.Errorf("expected corrected fields to be kept, got %+v", polled)
+	}
+	if len(polled.Corrections) != 3 {
+		t.Errorf("expected the corrections carried over, got %v", polled.Corrections)
+	}
+
+	polled.Magnitude = 6.4
+	if kind, changed := classifyChange(&stored, &polled); !changed || kind != disastersv1.EventKind_EVENT_KIND_UPDATED {
+		t.Errorf("expected other fields to still update, got (%s, %v)", kind, changed)
+	}
+}
+
+func TestManager_ProcessUpdate(t *testing.T) {
+	cfg := &config.Config{
+		Worker: config.WorkerConfig{
+			Count:      1,
+			BufferSize: 10,
+		},
+	}
+
+	repo := repository.NewMemoryDB()
+	stored := &models.Disaster{ID: "gdacs_1", AlertLevel: disastersv1.AlertLevel_GREEN}
+	if err := repo.Add(context.Background(), stored); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	b := internalgrpc.NewBroadcaster()
+	id, ch := b.Subscribe()
+	defer b.Unsubscribe(id)
+
+	mgr := NewManager(cfg, repo, b)
+	ctx, cancel := context.WithCancel(context.Background())
+	mgr.Start(ctx)
+
+	mgr.pool.Submit(newUpdate(stored, &models.Disaster{ID: "gdacs_1", AlertLevel: disastersv1.AlertLevel_RED}))
+
+	select {
+	case ev := <-ch:
+		if ev.Kind != disastersv1.EventKind_EVENT_KIND_ESCALATED || ev.Disaster.ID != "gdacs_1" {
+			t.Errorf("expected ESCALATED gdacs_1, got %s %s", ev.Kind, ev.Disaster.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for update broadcast")
+	}
+
+	cancel()
+	mgr.Stop()
+
+	if got := storedAlertLevel(t, repo, "gdacs_1"); got != disastersv1.AlertLevel_RED {
+		t.Errorf("expected stored alert level RED, got %s", got)
+	}
+}
+
+func TestManager_ProcessUpdateKeepsConcurrentCorrection(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryDB()
+	stored := &models.Disaster{ID: "gdacs_1", Magnitude: 6.1, Country: "China", Timestamp: time.Now(), CreatedAt: time.Now()}
+	if err := repo.Add(ctx, stored); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	mgr := NewManager(&config.Config{}, repo, nil)
+
+	// The poll reads the disaster and queues an update
+	u := newUpdate(stored, &models.Disaster{ID: "gdacs_1", Magnitude: 6.4, Country: "China", Timestamp: stored.Timestamp})
+	if u == nil {
+		t.Fatal("expected an update")
+	}
+
+	// An operator corrects the country before the worker stores the update
+	corrected, err := repo.GetByID(ctx, "gdacs_1")
+	if err != nil || corrected == nil {
+		t.Fatalf("GetByID = %v, %v", corrected, err)
+	}
+	corrected.Country = "Japan"
+	corrected.Corrections = []string{"country"}
+	corrected.UpdatedAt = time.Now()
+	entry := &models.AuditEntry{DisasterID: "gdacs_1", Action: models.AuditActionUpdate, Actor: "op", CreatedAt: time.Now()}
+	if err := repo.UpdateAudited(ctx, corrected, disastersv1.EventKind_EVENT_KIND_UPDATED, entry); err != nil {
+		t.Fatalf("UpdateAudited failed: %v", err)
+	}
+
+	if err := mgr.processUpdate(ctx, u); err != nil {
+		t.Fatalf("processUpdate failed: %v", err)
+	}
+	got, err := repo.GetByID(ctx, "gdacs_1")
+	if err != nil || got == nil {
+		t.Fatalf("GetByID = %v, %v", got, err)
+	}
+	if got.Country != "Japan" || got.Magnitude != 6.4 || len(got.Corrections) != 1 {
+		t.Errorf("expected the correction kept and the polled magnitude stored, got %+v", got)
+	}
+}
+
+type fakeEvaluator struct {
+	evaluated chan string
+}
+
+func (f *fakeEvaluator) Evaluate(ctx context.Context, d *models.Disaster) ([]models.Alert, error) {
+	f.evaluated <- d.ID
+	return nil, fmt.Errorf("geofence store unavailable")
+}
+
+func TestManager_EvaluatesGeofences(t *testing.T) {
+	cfg := &config.Config{
+		Worker: config.WorkerConfig{
+			Count:      1,
+			BufferSize: 10,
+		},
+	}
+
+	repo := repository.NewMemoryDB()
+	stored := &models.Disaster{ID: "gdacs_1", AlertLevel: disastersv1.AlertLevel_GREEN}
+	if err := repo.Add(context.Background(), stored); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	eval := &fakeEvaluator{evaluated: make(chan string, 1)}
+
+	mgr := NewManager(cfg, repo, nil, WithGeofences(eval))
+	ctx, cancel := context.WithCancel(context.Background())
+	mgr.Start(ctx)
+
+	mgr.pool.Submit(newUpdate(stored, &models.Disaster{ID: "gdacs_1", AlertLevel: disastersv1.AlertLevel_RED}))
+
+	select {
+	case id := <-eval.evaluated:
+		if id != "gdacs_1" {
+			t.Errorf("expected gdacs_1 to be evaluated, got %s", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for geofence evaluation")
+	}
+
+	cancel()
+	mgr.Stop()
+
+	// An evaluation error doesn't undo the stored update
+	if got := storedAlertLevel(t, repo, "gdacs_1"); got != disastersv1.AlertLevel_RED {
+		t.Errorf("expected stored alert level RED, got %s", got)
+	}
+}
+
+func TestManager_ProcessNewBatch(t *testing.T) {
+	repo := repository.NewMemoryDB()
+	if err := repo.Add(context.Background(), &models.Disaster{ID: "stored"}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	b := internalgrpc.NewBroadcaster()
+	id, ch := b.Subscribe()
+	defer b.Unsubscribe(id)
+	mgr := NewManager(&config.Config{}, repo, b)
+
+	jobs := func(ids ...string) []worker.Job {
+		var jobs []worker.Job
+		for _, id := range ids {
+			jobs = append(jobs, &models.Disaster{ID: id, Source: "test", CreatedAt: time.Now()})
+		}
+		return jobs
+	}
+	received := func(n int) []string {
+		t.Helper()
+		var ids []string
+		for range n {
+			select {
+			case ev := <-ch:
+				if ev.Kind != disastersv1.EventKind_EVENT_KIND_CREATED || ev.Seq == 0 {
+					t.Errorf("expected a CREATED event with a seq, got %s %d", ev.Kind, ev.Seq)
+				}
+				ids = append(ids, ev.Disaster.ID)
+			case <-time.After(time.Second):
+				t.Fatalf("timeout waiting for broadcast %d of %d", len(ids)+1, n)
+			}
+		}
+		return ids
+	}
+
+	// Every disaster of a batch is broadcast
+	if err := mgr.processNewBatch(context.Background(), jobs("b1", "b2", "b3")); err != nil {
+		t.Fatalf("processNewBatch failed: %v", err)
+	}
+	if got := received(3); fmt.Sprint(got) != "[b1 b2 b3]" {
+		t.Errorf("expected broadcasts for [b1 b2 b3], got %v", got)
+	}
+
+	// A stored disaster fails the batch, but the others are still added one by one
+	if err := mgr.processNewBatch(context.Background(), jobs("b4", "stored", "b5")); err == nil {
+		t.Error("expected an error for the stored disaster")
+	}
+	if got := received(2); fmt.Sprint(got) != "[b4 b5]" {
+		t.Errorf("expected broadcasts for [b4 b5], got %v", got)
+	}
+	if n := countDisasters(t, repo); n != 6 {
+		t.Errorf("expected 6 disasters stored, got %d", n)
+	}
 }
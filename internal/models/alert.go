@@ -14,6 +14,7 @@ const (
 type Alert struct {
 	ID         string
 	DisasterID string
+	GeofenceID string // geofence that raised the alert, empty if none
 	Severity   AlertSeverity
 	CreatedAt  time.Time
 }
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// SourceManual is the source of disasters created by operators rather than ingested
+const SourceManual = "MANUAL"
+
+type AuditAction string
+
+const (
+	AuditActionCreate AuditAction = "create"
+	AuditActionUpdate AuditAction = "update"
+)
+
+// AuditEntry records an operator's change to a disaster
+type AuditEntry struct {
+	ID         int64
+	DisasterID string
+	Action     AuditAction
+	Actor      string        // operator who made the change
+	Changes    []FieldChange // fields set on create, or changed on update
+	CreatedAt  time.Time
+}
+
+// FieldChange is the old and new value of a disaster field, named as in the admin API.
+// Old is nil when the disaster was created.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// correctableField is a disaster field operators can correct. Values are as the admin API shows them.
+type correctableField struct {
+	name  string
+	value func(d *Disaster) any // comparable and JSON-friendly
+	copy  func(dst, src *Disaster)
+}
+
+var correctableFields = []correctableField{
+	{"type", func(d *Disaster) any { return strings.ToLower(d.Type.String()) }, func(dst, src *Disaster) { dst.Type = src.Type }},
+	{"title", func(d *Disaster) any { return d.Title }, func(dst, src *Disaster) { dst.Title = src.Title }},
+	{"description", func(d *Disaster) any { return d.Description }, func(dst, src *Disaster) { dst.Description = src.Description }},
+	{"magnitude", func(d *Disaster) any { return d.Magnitude }, func(dst, src *Disaster) { dst.Magnitude = src.Magnitude }},
+	{"alert_level", func(d *Disaster) any { return strings.ToLower(d.AlertLevel.String()) }, func(dst, src *Disaster) { dst.AlertLevel = src.AlertLevel }},
+	{"latitude", func(d *Disaster) any { return d.Latitude }, func(dst, src *Disaster) { dst.Latitude = src.Latitude }},
+	{"longitude", func(d *Disaster) any { return d.Longitude }, func(dst, src *Disaster) { dst.Longitude = src.Longitude }},
+	{"timestamp", func(d *Disaster) any { return d.Timestamp.UTC().Format(time.RFC3339Nano) }, func(dst, src *Disaster) { dst.Timestamp = src.Timestamp }},
+	{"country", func(d *Disaster) any { return d.Country }, func(dst, src *Disaster) { dst.Country = src.Country }},
+	{"country_iso", func(d *Disaster) any { return d.CountryISO }, func(dst, src *Disaster) { dst.CountryISO = src.CountryISO }},
+	{"affected_population", func(d *Disaster) any { return d.AffectedPopulation }, func(dst, src *Disaster) { dst.AffectedPopulation = src.AffectedPopulation }},
+	{"affected_population_count", func(d *Disaster) any { return d.AffectedPopulationCount }, func(dst, src *Disaster) { dst.AffectedPopulationCount = src.AffectedPopulationCount }},
+	{"report_url", func(d *Disaster) any { return d.ReportURL }, func(dst, src *Disaster) { dst.ReportURL = src.ReportURL }},
+	{"closed", func(d *Disaster) any { return d.Closed }, func(dst, src *Disaster) { dst.Closed = src.Closed }},
+}
+
+// Changes lists the fields operators can correct whose values differ between d and after
+func (d *Disaster) Changes(after *Disaster) []FieldChange {
+	var changes []FieldChange
+	for _, f := range correctableFields {
+		if from, to := f.value(d), f.value(after); from != to {
+			changes = append(changes, FieldChange{Field: f.name, Old: from, New: to})
+		}
+	}
+	return changes
+}
+
+// KeepCorrections copies the fields named in d.Corrections from d to polled, and the list itself,
+// so fresh source data does not undo an operator's corrections
+func (d *Disaster) KeepCorrections(polled *Disaster) {
+	for _, f := range correctableFields {
+		if slices.Contains(d.Corrections, f.name) {
+			f.copy(polled, d)
+		}
+	}
+	polled.Corrections = d.Corrections
+}
@@ -7,22 +7,30 @@ import (
 )
 
 type Disaster struct {
-	ID          string // Unique ID from source (e.g., "gdacs_12345")
-	Source      string // "GDACS"
-	Type        disastersv1.DisasterType
-	Title       string
-	Description string
-	Magnitude   float64 // Richter scale for earthquakes
-	AlertLevel  disastersv1.AlertLevel
-	Latitude    float64
-	Longitude   float64
-	Timestamp   time.Time // when the event occurred
-	Country                 string // Country where disaster occurred
-	AffectedPopulation      string // Affected population text (e.g., "1 thousand (in MMI>=VII)")
-	AffectedPopulationCount int64  // Numeric population value for filtering
-	ReportURL               string // Link to detailed report
-	Raw         []byte    // original JSON/XML for debugging
-	CreatedAt   time.Time // when we ingested it
+	ID                      string // Unique ID from source (e.g., "gdacs_12345")
+	Source                  string // "GDACS"
+	Type                    disastersv1.DisasterType
+	Title                   string
+	Description             string
+	Magnitude               float64 // Richter scale for earthquakes
+	AlertLevel              disastersv1.AlertLevel
+	Latitude                float64
+	Longitude               float64
+	Timestamp               time.Time // when the event occurred
+	Country                 string    // Country where disaster occurred
+	CountryISO              string    // ISO 3166-1 alpha-3 country code (e.g., "JPN")
+	AffectedPopulation      string    // Affected population text (e.g., "1 thousand (in MMI>=VII)")
+	AffectedPopulationCount int64     // Numeric population value for filtering
+	ReportURL               string    // Link to detailed report
+	Raw                     []byte    // original JSON/XML for debugging
+	CreatedAt               time.Time // when we ingested it
+	Seq                     int64     // sequence number of the latest stored event for this disaster
+	Closed                  bool      // source reports the event is no longer current
+	UpdatedAt               time.Time // when we last stored a change, zero if never updated
+	DistanceKm              *float64  // distance from the query's center point, set only by radius queries
+	Snippet                 string    // matching text with search terms wrapped in <mark>, set only by text queries
+	PageToken               string    // opaque position in list results, set only by list queries; pass back to list the disasters after it
+	Corrections             []string  // fields an operator corrected, named as in the admin API; kept when the source reports the disaster again
 }
 
 type Coordinates struct {
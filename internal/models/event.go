@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+// DisasterEvent is a stored change to a disaster, ordered by Seq.
+type DisasterEvent struct {
+	Seq       int64
+	Kind      disastersv1.EventKind
+	Disaster  *Disaster // state of the disaster when the event was recorded
+	CreatedAt time.Time
+}
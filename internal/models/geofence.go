@@ -0,0 +1,100 @@
+package models
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
+)
+
+// Geofence is a named area, either a polygon or a circle, that raises alerts for its owner
+// when a matching disaster is stored inside it.
+type Geofence struct {
+	ID        string
+	Name      string
+	Owner     string           // consumer that receives the geofence's alerts (e.g., "slack")
+	Area      geo.MultiPolygon // set for polygon geofences
+	Circle    *geo.Circle      // set for circle geofences
+	Criteria  GeofenceCriteria
+	CreatedAt time.Time
+}
+
+// GeofenceCriteria narrows which disasters inside a geofence raise alerts. Unset fields match everything.
+type GeofenceCriteria struct {
+	Types                      []disastersv1.DisasterType `json:"types,omitempty"`
+	MinMagnitude               *float64                   `json:"min_magnitude,omitempty"`
+	MinAlertLevel              *disastersv1.AlertLevel    `json:"min_alert_level,omitempty"`
+	MinAffectedPopulationCount *int64                     `json:"min_affected_population_count,omitempty"`
+}
+
+func (g *Geofence) Contains(lat, lon float64) bool {
+	if g.Circle != nil {
+		return g.Circle.Contains(lat, lon)
+	}
+	return g.Area.Contains(lat, lon)
+}
+
+func (g *Geofence) Validate() error {
+	if g.Name == "" {
+		return errors.New("name is required")
+	}
+	if g.Owner == "" {
+		return errors.New("owner is required")
+	}
+	switch {
+	case g.Circle != nil && g.Area != nil:
+		return errors.New("geofence must be either a polygon or a circle, not both")
+	case g.Circle != nil:
+		return g.Circle.Validate()
+	case g.Area != nil:
+		return g.Area.Validate()
+	default:
+		return errors.New("geofence needs a polygon or a circle")
+	}
+}
+
+// GeofenceMatch is an alert raised for a geofence, with the geofence and disaster that caused it
+type GeofenceMatch struct {
+	Alert    Alert
+	Geofence *Geofence
+	Disaster *Disaster
+}
+
+// OwnerTokens maps bearer tokens to the geofence owners they authenticate
+type OwnerTokens map[string]string
+
+// ParseOwnerTokens parses comma-separated owner=token pairs, e.g. "slack=s3cret,sms=t0ken".
+func ParseOwnerTokens(s string) (OwnerTokens, error) {
+	tokens := make(OwnerTokens)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		owner, token, ok := strings.Cut(pair, "=")
+		owner, token = strings.TrimSpace(owner), strings.TrimSpace(token)
+		if !ok || owner == "" || token == "" {
+			return nil, fmt.Errorf("invalid owner token %q, expected owner=token", pair)
+		}
+		if _, dup := tokens[token]; dup {
+			return nil, fmt.Errorf("token for %s is already used by %s", owner, tokens[token])
+		}
+		tokens[token] = owner
+	}
+	return tokens, nil
+}
+
+// Owner returns the owner authenticated by token, comparing against every token in constant time
+func (t OwnerTokens) Owner(token string) (string, bool) {
+	var owner string
+	for candidate, o := range t {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(candidate)) == 1 {
+			owner = o
+		}
+	}
+	return owner, owner != ""
+}
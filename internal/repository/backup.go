@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Backuper copies a consistent snapshot of a live database to a file
+type Backuper interface {
+	Backup(ctx context.Context, path string) error // fails if path exists
+}
+
+// Backup writes a consistent, compacted copy of the database to path with VACUUM INTO, so live
+// databases can be backed up without stopping the service.
+func (s *SQLiteDB) Backup(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("backup %s already exists", path)
+	}
+	if _, err := s.db.ExecContext(ctx, `VACUUM INTO ?`, path); err != nil {
+		os.Remove(path) // a canceled copy leaves a partial file
+		return err
+	}
+	return nil
+}
+
+// PreRestoreSuffix is appended to the path of the database a restore replaces
+const PreRestoreSuffix = ".pre-restore"
+
+// RestoreSQLite replaces the database at path with the backup at backupPath. The backup must pass
+// an integrity check and have a schema version this build can migrate, i.e. not a newer one. The
+// replaced database is kept at path+PreRestoreSuffix. Nothing may have path open during a restore.
+func RestoreSQLite(ctx context.Context, backupPath, path string) (version int, err error) {
+	if _, err := os.Stat(backupPath); err != nil {
+		return 0, err
+	}
+	// Read-only, so none of the journal pragmas OpenSQLiteDB sets
+	db, err := openSQLitePool("file:"+backupPath+"?mode=ro", 1, "busy_timeout(5000)")
+	if err != nil {
+		return 0, err
+	}
+	backup := &SQLiteDB{db: db, read: db}
+	defer backup.Close()
+
+	var integrity string
+	if err := backup.db.QueryRowContext(ctx, `PRAGMA integrity_check`).Scan(&integrity); err != nil {
+		return 0, fmt.Errorf("error checking backup: %w", err)
+	}
+	if integrity != "ok" {
+		return 0, fmt.Errorf("backup failed its integrity check: %s", integrity)
+	}
+	// Read the version without SchemaVersion, which creates schema_migrations if missing
+	err = backup.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	if err != nil && !strings.Contains(err.Error(), "no such table") {
+		return 0, fmt.Errorf("error reading backup schema version: %w", err)
+	}
+	if version == 0 {
+		return 0, errors.New("backup has no schema version")
+	}
+	if version > LatestSchemaVersion() {
+		return 0, fmt.Errorf("backup has schema version %d, newer than the latest known version %d", version, LatestSchemaVersion())
+	}
+
+	// Copy next to the database first, so the swap itself is only renames
+	staged := path + ".restoring"
+	os.Remove(staged)
+	if err := backup.Backup(ctx, staged); err != nil {
+		return 0, fmt.Errorf("error copying backup: %w", err)
+	}
+	// On any failure from here, put back whatever was moved aside
+	var moved []string
+	defer func() {
+		if err == nil {
+			return
+		}
+		os.Remove(staged)
+		for _, suffix := range slices.Backward(moved) {
+			if rerr := os.Rename(path+PreRestoreSuffix+suffix, path+suffix); rerr != nil {
+				err = errors.Join(err, fmt.Errorf("error putting back %s: %w", path+suffix, rerr))
+			}
+		}
+	}()
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		err := os.Rename(path+suffix, path+PreRestoreSuffix+suffix)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("error moving the current database aside: %w", err)
+		}
+		moved = append(moved, suffix)
+	}
+	if err := os.Rename(staged, path); err != nil {
+		return 0, fmt.Errorf("error moving the backup into place: %w", err)
+	}
+	return version, nil
+}
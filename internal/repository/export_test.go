@@ -0,0 +1,8 @@
+package repository
+
+// SetupPostgresDB exposes setupPostgresDB to the conformance tests, which import repositorytest
+// and so must live outside the package
+var (
+	SetupPostgresDB = setupPostgresDB
+	PostgresTestDSN = postgresTestDSN
+)
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
+)
+
+// HeatmapWeight is what each disaster adds to the weight of its heatmap cell
+type HeatmapWeight string
+
+const (
+	WeightCount      HeatmapWeight = "count"       // 1
+	WeightAlertLevel HeatmapWeight = "alert_level" // 1 for GREEN, 2 for ORANGE, 3 for RED, 0 if unknown
+	WeightPopulation HeatmapWeight = "population"  // Affected population count
+)
+
+// ParseHeatmapWeight returns the weight named s, WeightCount if s is empty
+func ParseHeatmapWeight(s string) (HeatmapWeight, error) {
+	switch w := HeatmapWeight(s); w {
+	case "":
+		return WeightCount, nil
+	case WeightCount, WeightAlertLevel, WeightPopulation:
+		return w, nil
+	}
+	return "", fmt.Errorf("invalid heatmap weight %q, expected count, alert_level or population", s)
+}
+
+func (w HeatmapWeight) of(alertLevel disastersv1.AlertLevel, population int64) float64 {
+	switch w {
+	case WeightAlertLevel:
+		return float64(alertLevel)
+	case WeightPopulation:
+		return float64(population)
+	}
+	return 1
+}
+
+// HeatmapCell aggregates the disasters inside a grid cell
+type HeatmapCell struct {
+	Key    string
+	Bounds geo.BBox
+	Count  int64
+	Weight float64
+}
+
+// heatmap accumulates disasters into the cells of a grid
+type heatmap struct {
+	grid   geo.Grid
+	weight HeatmapWeight
+	cells  map[string]*HeatmapCell
+}
+
+func newHeatmap(grid geo.Grid, weight HeatmapWeight) *heatmap {
+	return &heatmap{grid: grid, weight: weight, cells: make(map[string]*HeatmapCell)}
+}
+
+func (h *heatmap) add(lat, lon float64, alertLevel disastersv1.AlertLevel, population int64) {
+	key, bounds := h.grid.Cell(lat, lon)
+	c, ok := h.cells[key]
+	if !ok {
+		c = &HeatmapCell{Key: key, Bounds: bounds}
+		h.cells[key] = c
+	}
+	c.Count++
+	c.Weight += h.weight.of(alertLevel, population)
+}
+
+// result returns the cells ordered by key
+func (h *heatmap) result() []HeatmapCell {
+	out := make([]HeatmapCell, 0, len(h.cells))
+	for _, c := range h.cells {
+		out = append(out, *c)
+	}
+	slices.SortFunc(out, func(a, b HeatmapCell) int { return cmp.Compare(a.Key, b.Key) })
+	return out
+}
+
+// queryHeatmap runs a query returning the latitude, longitude, alert level and affected population
+// of each matching disaster, and aggregates them into the cells of grid
+func queryHeatmap(ctx context.Context, db *sql.DB, area geo.MultiPolygon, grid geo.Grid, weight HeatmapWeight, query string, args ...any) ([]HeatmapCell, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	h := newHeatmap(grid, weight)
+	for rows.Next() {
+		var lat, lon float64
+		var alertLevel int32
+		var population int64
+		if err := rows.Scan(&lat, &lon, &alertLevel, &population); err != nil {
+			return nil, err
+		}
+		if area != nil && !area.Contains(lat, lon) {
+			continue
+		}
+		h.add(lat, lon, disastersv1.AlertLevel(alertLevel), population)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return h.result(), nil
+}
@@ -0,0 +1,673 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+)
+
+// MemoryDB keeps every repository in process memory, for tests and demos. Its filtering, ordering,
+// paging and text search match SQLiteDB's, so results are the same as from a database file holding
+// the same disasters. Nothing is kept after the process exits.
+type MemoryDB struct {
+	mu         sync.RWMutex
+	disasters  map[string]*models.Disaster
+	docs       map[string]*ftsDoc // indexed text of each disaster
+	events     []memoryEvent      // in sequence order, without those of removed disasters
+	lastSeq    int64
+	deliveries map[string]map[string]time.Time // consumer ID -> disaster ID -> acked at
+	alerts     []models.Alert                  // in insertion order
+	geofences  []models.Geofence               // in insertion order
+	audit      []models.AuditEntry             // in insertion order
+	archive    map[string]*models.Disaster     // archived disasters, without raw payloads
+}
+
+type memoryEvent struct {
+	seq        int64
+	disasterID string
+	kind       disastersv1.EventKind
+	createdAt  time.Time
+	snapshot   models.Disaster // state of the disaster the event recorded
+}
+
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{
+		disasters:  make(map[string]*models.Disaster),
+		docs:       make(map[string]*ftsDoc),
+		deliveries: make(map[string]map[string]time.Time),
+		archive:    make(map[string]*models.Disaster),
+	}
+}
+
+// Disaster methods
+
+// Add stores d with a CREATED event and sets d.Seq to the event's sequence number.
+func (m *MemoryDB) Add(ctx context.Context, d *models.Disaster) error {
+	return m.AddBatch(ctx, []*models.Disaster{d})
+}
+
+// AddBatch stores every disaster in ds with its CREATED event and sets each Seq. Nothing is
+// stored if any ID already exists or repeats within ds.
+func (m *MemoryDB) AddBatch(ctx context.Context, ds []*models.Disaster) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.addBatch(ds)
+}
+
+// addBatch is AddBatch. Must be called with m.mu held.
+func (m *MemoryDB) addBatch(ds []*models.Disaster) error {
+	ids := make(map[string]bool, len(ds))
+	for _, d := range ds {
+		if _, ok := m.disasters[d.ID]; ok || ids[d.ID] {
+			return fmt.Errorf("disaster %s already exists", d.ID)
+		}
+		ids[d.ID] = true
+	}
+	for _, d := range ds {
+		d.Seq = m.recordEvent(d, disastersv1.EventKind_EVENT_KIND_CREATED, d.CreatedAt)
+		m.store(d)
+	}
+	return nil
+}
+
+// Update stores the current fields of an existing disaster with an event of the given kind
+// and sets d.Seq to the event's sequence number. CreatedAt is never changed.
+func (m *MemoryDB) Update(ctx context.Context, d *models.Disaster, kind disastersv1.EventKind) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.update(d, kind)
+}
+
+func (m *MemoryDB) UpdateIfUnchanged(ctx context.Context, d *models.Disaster, kind disastersv1.EventKind) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored, ok := m.disasters[d.ID]; ok && stored.Seq != d.Seq {
+		return ErrConflict
+	}
+	return m.update(d, kind)
+}
+
+// update is Update. Must be called with m.mu held.
+func (m *MemoryDB) update(d *models.Disaster, kind disastersv1.EventKind) error {
+	stored, ok := m.disasters[d.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	createdAt := stored.CreatedAt
+	d.Seq = m.recordEvent(d, kind, d.UpdatedAt)
+	m.store(d)
+	m.disasters[d.ID].CreatedAt = createdAt
+	return nil
+}
+
+func (m *MemoryDB) recordEvent(d *models.Disaster, kind disastersv1.EventKind, at time.Time) int64 {
+	m.lastSeq++
+	snapshot := *d
+	snapshot.Seq = m.lastSeq
+	snapshot.Raw = nil
+	snapshot.Corrections = slices.Clone(d.Corrections)
+	snapshot.DistanceKm = nil
+	snapshot.Snippet = ""
+	snapshot.PageToken = ""
+	m.events = append(m.events, memoryEvent{seq: m.lastSeq, disasterID: d.ID, kind: kind, createdAt: at, snapshot: snapshot})
+	return m.lastSeq
+}
+
+// store keeps a copy of d without the fields only list queries set
+func (m *MemoryDB) store(d *models.Disaster) {
+	stored := *d
+	stored.Raw = slices.Clone(d.Raw)
+	stored.Corrections = slices.Clone(d.Corrections)
+	stored.DistanceKm = nil
+	stored.Snippet = ""
+	stored.PageToken = ""
+	m.disasters[d.ID] = &stored
+	m.docs[d.ID] = newFTSDoc(&stored)
+}
+
+// get returns a copy of a stored disaster, or nil
+func (m *MemoryDB) get(id string) *models.Disaster {
+	stored, ok := m.disasters[id]
+	if !ok {
+		return nil
+	}
+	d := *stored
+	d.Raw = slices.Clone(stored.Raw)
+	d.Corrections = slices.Clone(stored.Corrections)
+	return &d
+}
+
+func (m *MemoryDB) GetByID(ctx context.Context, id string) (*models.Disaster, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.get(id), nil
+}
+
+func (m *MemoryDB) Exists(ctx context.Context, id string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.disasters[id]
+	return ok, nil
+}
+
+// memoryResult is a disaster matching a ListDisasters filter, with the values it is ordered by
+type memoryResult struct {
+	d   *models.Disaster
+	key float64
+	t   time.Time
+}
+
+// ListDisasters returns disasters matching opts in the order opts.Sort and opts.Order select, newest first
+// by default. With a Near filter each result's DistanceKm is set to its great-circle distance from the center.
+func (m *MemoryDB) ListDisasters(ctx context.Context, opts Filter) ([]models.Disaster, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	var after *pageToken
+	if opts.PageToken != "" {
+		var err error
+		if after, err = decodePageToken(opts.PageToken); err != nil {
+			return nil, err
+		}
+	}
+
+	if ftsQuery(opts.Query) == "" {
+		opts.Query = "" // nothing searchable, e.g. only punctuation
+	}
+	order := newListOrder(opts, "distance", "rank")
+	var afterResult *memoryResult
+	if after != nil {
+		r, err := order.memoryAfter(after)
+		if err != nil {
+			return nil, err
+		}
+		afterResult = r
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var matches map[string]ftsMatch
+	if opts.Query != "" {
+		matches = ftsSearch(m.docs, ftsPhrases(opts.Query))
+	}
+	attrs := opts
+	attrs.Query = "" // matched against the index above
+
+	var results []memoryResult
+	for id, stored := range m.disasters {
+		if !attrs.Matches(stored) {
+			continue
+		}
+		if opts.Delivered != nil {
+			_, acked := m.deliveries[opts.ConsumerID][id]
+			if acked != *opts.Delivered {
+				continue
+			}
+		}
+		match, ok := matches[id]
+		if opts.Query != "" && !ok {
+			continue
+		}
+
+		r := memoryResult{d: m.get(id), t: stored.Timestamp}
+		if opts.TimeField == TimeFieldCreated {
+			r.t = stored.CreatedAt
+		}
+		if opts.Near != nil {
+			distance := geo.DistanceKm(opts.Near.Lat, opts.Near.Lon, stored.Latitude, stored.Longitude)
+			r.d.DistanceKm = &distance
+		}
+		if opts.Query != "" {
+			r.d.Snippet = match.snippet
+		}
+		switch order.key {
+		case "magnitude":
+			r.key = stored.Magnitude
+		case "alert_level":
+			r.key = float64(stored.AlertLevel)
+		case "affected_population_count":
+			r.key = float64(stored.AffectedPopulationCount)
+		case "distance":
+			r.key = *r.d.DistanceKm
+		case "rank":
+			r.key = match.rank
+		}
+		if afterResult != nil && order.compare(r, *afterResult) <= 0 {
+			continue
+		}
+		results = append(results, r)
+	}
+	slices.SortFunc(results, order.compare)
+
+	var disasters []models.Disaster
+	for _, r := range results {
+		page := pageToken{Sort: order.name, Timestamp: r.t.Format(time.RFC3339Nano), ID: r.d.ID}
+		if order.key != "" {
+			page.Key = &r.key
+		}
+		r.d.PageToken = page.encode()
+		disasters = append(disasters, *r.d)
+	}
+	return paginate(disasters, opts.Limit, opts.Offset), nil
+}
+
+// compare orders results as the SQL clause() does: by key, then time and ID newest first,
+// or by time and ID in the order's direction when there is no key
+func (o listOrder) compare(a, b memoryResult) int {
+	if o.key != "" {
+		if c := cmp.Compare(a.key, b.key); c != 0 {
+			if !o.asc {
+				return -c
+			}
+			return c
+		}
+		if c := b.t.Compare(a.t); c != 0 {
+			return c
+		}
+		return strings.Compare(b.d.ID, a.d.ID)
+	}
+
+	c := a.t.Compare(b.t)
+	if c == 0 {
+		c = strings.Compare(a.d.ID, b.d.ID)
+	}
+	if !o.asc {
+		return -c
+	}
+	return c
+}
+
+// memoryAfter is the position of a page token issued by MemoryDB, for comparing results with
+func (o listOrder) memoryAfter(p *pageToken) (*memoryResult, error) {
+	if p.Sort != o.name || (p.Key != nil) != (o.key != "") {
+		return nil, ErrInvalidPageToken
+	}
+	t, err := time.Parse(time.RFC3339Nano, p.Timestamp)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	r := &memoryResult{d: &models.Disaster{ID: p.ID}, t: t}
+	if p.Key != nil {
+		r.key = *p.Key
+	}
+	return r, nil
+}
+
+func (m *MemoryDB) ListEvents(ctx context.Context, afterSeq int64, limit int) ([]models.DisasterEvent, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	start, _ := slices.BinarySearchFunc(m.events, afterSeq+1, func(ev memoryEvent, seq int64) int {
+		return cmp.Compare(ev.seq, seq)
+	})
+	var events []models.DisasterEvent
+	for _, ev := range m.events[start:] {
+		if limit > 0 && len(events) == limit {
+			break
+		}
+		d := ev.snapshot
+		d.Corrections = slices.Clone(ev.snapshot.Corrections)
+		events = append(events, models.DisasterEvent{
+			Seq:       ev.seq,
+			Kind:      ev.kind,
+			Disaster:  &d,
+			CreatedAt: ev.createdAt,
+		})
+	}
+	return events, nil
+}
+
+// MarkAsSent records that consumerID has delivered the given disasters. Unknown and already
+// acknowledged IDs are ignored, so the count only includes new acknowledgments.
+func (m *MemoryDB) MarkAsSent(ctx context.Context, consumerID string, ids []string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	acked := m.deliveries[consumerID]
+	if acked == nil {
+		acked = make(map[string]time.Time)
+		m.deliveries[consumerID] = acked
+	}
+	now := time.Now()
+	var n int64
+	for _, id := range ids {
+		if _, ok := m.disasters[id]; !ok {
+			continue
+		}
+		if _, ok := acked[id]; ok {
+			continue
+		}
+		acked[id] = now
+		n++
+	}
+	return n, nil
+}
+
+// Stats aggregates the disasters opts matches, ignoring its paging and sort fields
+func (m *MemoryDB) Stats(ctx context.Context, opts Filter, bucket TimeBucket) (*Stats, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	var cells []statsCell
+	m.eachMatch(opts, func(d *models.Disaster) {
+		t := d.Timestamp
+		if opts.TimeField == TimeFieldCreated {
+			t = d.CreatedAt
+		}
+		cells = append(cells, statsCell{
+			typ:          d.Type,
+			alertLevel:   d.AlertLevel,
+			country:      d.CountryISO,
+			source:       d.Source,
+			bucket:       bucket.Start(t).Format(statsDateFormat),
+			count:        1,
+			maxMagnitude: d.Magnitude,
+			population:   d.AffectedPopulationCount,
+		})
+	})
+	return buildStats(cells, bucket), nil
+}
+
+func (m *MemoryDB) Heatmap(ctx context.Context, opts Filter, grid geo.Grid, weight HeatmapWeight) ([]HeatmapCell, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	h := newHeatmap(grid, weight)
+	m.eachMatch(opts, func(d *models.Disaster) {
+		h.add(d.Latitude, d.Longitude, d.AlertLevel, d.AffectedPopulationCount)
+	})
+	return h.result(), nil
+}
+
+// eachMatch calls fn with every disaster opts matches, in no particular order, ignoring paging and sorting
+func (m *MemoryDB) eachMatch(opts Filter, fn func(d *models.Disaster)) {
+	if ftsQuery(opts.Query) == "" {
+		opts.Query = "" // nothing searchable, e.g. only punctuation
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var matches map[string]ftsMatch
+	if opts.Query != "" {
+		matches = ftsSearch(m.docs, ftsPhrases(opts.Query))
+	}
+	attrs := opts
+	attrs.Query = "" // matched against the index above
+
+	for id, d := range m.disasters {
+		if !attrs.Matches(d) {
+			continue
+		}
+		if opts.Delivered != nil {
+			_, acked := m.deliveries[opts.ConsumerID][id]
+			if acked != *opts.Delivered {
+				continue
+			}
+		}
+		if _, ok := matches[id]; opts.Query != "" && !ok {
+			continue
+		}
+		fn(d)
+	}
+}
+
+// Alert methods
+
+func (m *MemoryDB) AddAlert(ctx context.Context, a *models.Alert) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if slices.ContainsFunc(m.alerts, func(stored models.Alert) bool { return stored.ID == a.ID }) {
+		return fmt.Errorf("alert %s already exists", a.ID)
+	}
+	m.alerts = append(m.alerts, *a)
+	return nil
+}
+
+func (m *MemoryDB) GetByDisasterID(ctx context.Context, disasterID string) ([]models.Alert, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var alerts []models.Alert
+	for _, a := range m.alerts {
+		if a.DisasterID == disasterID {
+			alerts = append(alerts, a)
+		}
+	}
+	return alerts, nil
+}
+
+func (m *MemoryDB) ListAlerts(ctx context.Context, opts Filter) ([]models.Alert, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var alerts []models.Alert
+	for _, a := range m.alerts {
+		if opts.Since != nil && a.CreatedAt.Before(*opts.Since) {
+			continue
+		}
+		if opts.GeofenceID != "" && a.GeofenceID != opts.GeofenceID {
+			continue
+		}
+		alerts = append(alerts, a)
+	}
+	slices.SortStableFunc(alerts, func(a, b models.Alert) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	if opts.Offset >= len(alerts) {
+		return nil, nil
+	}
+	alerts = alerts[opts.Offset:]
+	if opts.Limit > 0 && len(alerts) > opts.Limit {
+		alerts = alerts[:opts.Limit]
+	}
+	return alerts, nil
+}
+
+// Geofence methods
+
+func (m *MemoryDB) AddGeofence(ctx context.Context, g *models.Geofence) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if slices.ContainsFunc(m.geofences, func(stored models.Geofence) bool { return stored.ID == g.ID }) {
+		return fmt.Errorf("geofence %s already exists", g.ID)
+	}
+	m.geofences = append(m.geofences, cloneGeofence(*g))
+	return nil
+}
+
+func (m *MemoryDB) GetGeofence(ctx context.Context, id string) (*models.Geofence, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, g := range m.geofences {
+		if g.ID == id {
+			g = cloneGeofence(g)
+			return &g, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MemoryDB) ListGeofences(ctx context.Context, owner string) ([]models.Geofence, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var geofences []models.Geofence
+	for _, g := range m.geofences {
+		if owner == "" || g.Owner == owner {
+			geofences = append(geofences, cloneGeofence(g))
+		}
+	}
+	slices.SortStableFunc(geofences, func(a, b models.Geofence) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return geofences, nil
+}
+
+func (m *MemoryDB) DeleteGeofence(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.geofences, func(g models.Geofence) bool { return g.ID == id })
+	if i < 0 {
+		return ErrNotFound
+	}
+	m.geofences = slices.Delete(m.geofences, i, i+1)
+	return nil
+}
+
+// cloneGeofence copies g so stored geofences can't be changed through the caller's area or criteria
+func cloneGeofence(g models.Geofence) models.Geofence {
+	if g.Area != nil {
+		area := make(geo.MultiPolygon, len(g.Area))
+		for i, polygon := range g.Area {
+			area[i] = make(geo.Polygon, len(polygon))
+			for j, ring := range polygon {
+				area[i][j] = slices.Clone(ring)
+			}
+		}
+		g.Area = area
+	}
+	g.Circle = clonePtr(g.Circle)
+	g.Criteria.Types = slices.Clone(g.Criteria.Types)
+	g.Criteria.MinMagnitude = clonePtr(g.Criteria.MinMagnitude)
+	g.Criteria.MinAlertLevel = clonePtr(g.Criteria.MinAlertLevel)
+	g.Criteria.MinAffectedPopulationCount = clonePtr(g.Criteria.MinAffectedPopulationCount)
+	return g
+}
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// Audit methods
+
+func (m *MemoryDB) AddAuditEntry(ctx context.Context, e *models.AuditEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.addAuditEntry(e)
+	return nil
+}
+
+// AddAudited stores d like Add and e under the same lock, setting d.Seq and e.ID.
+func (m *MemoryDB) AddAudited(ctx context.Context, d *models.Disaster, e *models.AuditEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.addBatch([]*models.Disaster{d}); err != nil {
+		return err
+	}
+	m.addAuditEntry(e)
+	return nil
+}
+
+// UpdateAudited stores d like Update and e under the same lock, if d.Seq is still the stored seq.
+func (m *MemoryDB) UpdateAudited(ctx context.Context, d *models.Disaster, kind disastersv1.EventKind, e *models.AuditEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored, ok := m.disasters[d.ID]; ok && stored.Seq != d.Seq {
+		return ErrConflict
+	}
+	if err := m.update(d, kind); err != nil {
+		return err
+	}
+	m.addAuditEntry(e)
+	return nil
+}
+
+// addAuditEntry must be called with m.mu held
+func (m *MemoryDB) addAuditEntry(e *models.AuditEntry) {
+	e.ID = int64(len(m.audit)) + 1
+	stored := *e
+	stored.Changes = slices.Clone(e.Changes)
+	m.audit = append(m.audit, stored)
+}
+
+func (m *MemoryDB) ListAuditEntries(ctx context.Context, disasterID string) ([]models.AuditEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var entries []models.AuditEntry
+	for _, e := range m.audit {
+		if e.DisasterID == disasterID {
+			e.Changes = slices.Clone(e.Changes)
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// Retention methods
+
+func (m *MemoryDB) ApplyRetention(ctx context.Context, rules []RetentionRule, now time.Time, dryRun bool) (*RetentionReport, error) {
+	cutoff, err := retentionCutoff(rules, now)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var candidates []retentionCandidate
+	for _, d := range m.disasters {
+		if d.Timestamp.Before(cutoff) {
+			candidates = append(candidates, retentionCandidate{
+				id: d.ID, disasterType: d.Type, alertLevel: d.AlertLevel, timestamp: d.Timestamp, hasRaw: d.Raw != nil,
+			})
+		}
+	}
+	slices.SortFunc(candidates, func(a, b retentionCandidate) int {
+		return cmp.Or(a.timestamp.Compare(b.timestamp), strings.Compare(a.id, b.id))
+	})
+
+	report := planRetention(rules, candidates, now, dryRun)
+	if dryRun {
+		return report, nil
+	}
+	removed := make(map[string]bool)
+	for _, e := range report.Disasters {
+		d := m.disasters[e.ID]
+		switch e.Action {
+		case RetentionStripRaw:
+			d.Raw = nil
+			continue
+		case RetentionArchive:
+			d.Raw = nil
+			m.archive[e.ID] = d
+		}
+		removed[e.ID] = true
+		delete(m.disasters, e.ID)
+		delete(m.docs, e.ID)
+		for _, acked := range m.deliveries {
+			delete(acked, e.ID)
+		}
+	}
+	if len(removed) > 0 {
+		m.events = slices.DeleteFunc(m.events, func(ev memoryEvent) bool { return removed[ev.disasterID] })
+		m.alerts = slices.DeleteFunc(m.alerts, func(a models.Alert) bool { return removed[a.DisasterID] })
+	}
+	return report, nil
+}
+
+func (m *MemoryDB) Close() error {
+	return nil
+}
@@ -0,0 +1,385 @@
+package repository
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+	"golang.org/x/text/unicode/norm"
+)
+
+// MemoryDB evaluates text queries the way disasters_fts does: the same tokenizer, BM25 ranking
+// with the weights of ftsSearchSQL, and FTS5's snippet window selection and highlighting.
+
+// ftsWeights are the BM25 weights of the indexed columns, as in ftsSearchSQL
+var ftsWeights = [...]float64{10.0, 1.0, 5.0}
+
+// snippetTokens is the snippet length ftsSearchSQL asks FTS5 for
+const snippetTokens = 12
+
+// ftsToken is a token of indexed text as the unicode61 tokenizer produces it
+type ftsToken struct {
+	text       string // lowercased, diacritics removed
+	start, end int    // byte offsets in the original text
+}
+
+// ftsTokenize splits s into runs of letters and numbers, like unicode61 with remove_diacritics
+func ftsTokenize(s string) []ftsToken {
+	var tokens []ftsToken
+	var b strings.Builder
+	start := -1
+	for i, r := range s {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.In(r, unicode.Co, unicode.Mn) {
+			if start < 0 {
+				start = i
+				b.Reset()
+			}
+			foldRune(&b, r)
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, ftsToken{text: b.String(), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, ftsToken{text: b.String(), start: start, end: len(s)})
+	}
+	return tokens
+}
+
+// foldRune writes r lowercased and without diacritics, e.g. "Ō" as "o"
+func foldRune(b *strings.Builder, r rune) {
+	r = unicode.ToLower(r)
+	if r < unicode.MaxASCII {
+		b.WriteRune(r)
+		return
+	}
+	for _, c := range norm.NFD.String(string(r)) {
+		if !unicode.Is(unicode.Mn, c) {
+			b.WriteRune(c)
+		}
+	}
+}
+
+// ftsPhrase is a quoted term of an FTS5 query: consecutive tokens, the last matching as a prefix
+// for prefix terms
+type ftsPhrase struct {
+	tokens []string
+	prefix bool
+}
+
+// ftsPhrases parses free text as ftsQuery does. Terms without tokens, e.g. "-", are dropped, as
+// FTS5 drops empty phrases; a query of only such terms matches nothing.
+func ftsPhrases(q string) []ftsPhrase {
+	var phrases []ftsPhrase
+	for _, t := range SearchTerms(q) {
+		p := ftsPhrase{prefix: strings.HasSuffix(t, "*")}
+		for _, tok := range ftsTokenize(strings.TrimRight(t, "*")) {
+			p.tokens = append(p.tokens, tok.text)
+		}
+		if len(p.tokens) > 0 {
+			phrases = append(phrases, p)
+		}
+	}
+	return phrases
+}
+
+// matchesAt reports whether the phrase occurs in tokens starting at position i
+func (p ftsPhrase) matchesAt(tokens []ftsToken, i int) bool {
+	if i+len(p.tokens) > len(tokens) {
+		return false
+	}
+	for j, t := range p.tokens {
+		if p.prefix && j == len(p.tokens)-1 {
+			if !strings.HasPrefix(tokens[i+j].text, t) {
+				return false
+			}
+		} else if tokens[i+j].text != t {
+			return false
+		}
+	}
+	return true
+}
+
+// ftsColumn is an indexed column of a disaster
+type ftsColumn struct {
+	text   string
+	tokens []ftsToken
+}
+
+// ftsDoc is a disaster's indexed text, in the column order of disasters_fts
+type ftsDoc [len(ftsWeights)]ftsColumn
+
+func newFTSDoc(d *models.Disaster) *ftsDoc {
+	var doc ftsDoc
+	for i, text := range []string{d.Title, d.Description, d.Country} {
+		doc[i] = ftsColumn{text: text, tokens: ftsTokenize(text)}
+	}
+	return &doc
+}
+
+func (doc *ftsDoc) size() int {
+	n := 0
+	for _, c := range doc {
+		n += len(c.tokens)
+	}
+	return n
+}
+
+// ftsInstance is an occurrence of a query phrase in a document
+type ftsInstance struct {
+	phrase, col, off int
+}
+
+// instances returns every occurrence of the phrases in doc, ordered by column and position
+// like FTS5's xInst
+func (doc *ftsDoc) instances(phrases []ftsPhrase) []ftsInstance {
+	var insts []ftsInstance
+	for c, col := range doc {
+		for off := range col.tokens {
+			for p, phrase := range phrases {
+				if phrase.matchesAt(col.tokens, off) {
+					insts = append(insts, ftsInstance{phrase: p, col: c, off: off})
+				}
+			}
+		}
+	}
+	return insts
+}
+
+// ftsMatch is a document matching a text query, with the rank and snippet ftsSearchSQL selects
+type ftsMatch struct {
+	rank    float64
+	snippet string
+}
+
+// ftsSearch returns the documents containing every phrase, by disaster ID. Ranks are computed
+// as FTS5's bm25() does, over statistics of every document.
+func ftsSearch(docs map[string]*ftsDoc, phrases []ftsPhrase) map[string]ftsMatch {
+	if len(phrases) == 0 {
+		return nil
+	}
+
+	const k1, b = 1.2, 0.75
+	instances := make(map[string][]ftsInstance, len(docs))
+	hits := make([]float64, len(phrases))
+	total := 0
+	for id, doc := range docs {
+		total += doc.size()
+		insts := doc.instances(phrases)
+		seen := make([]bool, len(phrases))
+		for _, in := range insts {
+			if !seen[in.phrase] {
+				seen[in.phrase] = true
+				hits[in.phrase]++
+			}
+		}
+		if !slices.Contains(seen, false) {
+			instances[id] = insts
+		}
+	}
+
+	rows := float64(len(docs))
+	avgdl := float64(total) / rows
+	idf := make([]float64, len(phrases))
+	for i, n := range hits {
+		idf[i] = math.Log((rows - n + 0.5) / (n + 0.5))
+		if idf[i] <= 0 {
+			idf[i] = 1e-6
+		}
+	}
+
+	matches := make(map[string]ftsMatch, len(instances))
+	for id, insts := range instances {
+		doc := docs[id]
+		freq := make([]float64, len(phrases))
+		for _, in := range insts {
+			freq[in.phrase] += ftsWeights[in.col]
+		}
+		size := float64(doc.size())
+		score := 0.0
+		for i := range phrases {
+			score += idf[i] * ((freq[i] * (k1 + 1.0)) / (freq[i] + k1*(1-b+b*size/avgdl)))
+		}
+		matches[id] = ftsMatch{rank: -1.0 * score, snippet: doc.snippet(phrases, insts)}
+	}
+	return matches
+}
+
+// snippet picks the window of snippetTokens tokens covering the most distinct phrases, preferring
+// windows that start a sentence, and highlights the phrases in it, as FTS5's snippet() does
+func (doc *ftsDoc) snippet(phrases []ftsPhrase, insts []ftsInstance) string {
+	bestScore, bestCol, bestStart := 0, 0, 0
+	for c, col := range doc {
+		size := len(col.tokens)
+		starts := col.sentenceStarts()
+		for _, in := range insts {
+			if in.col != c {
+				continue
+			}
+			score, adj := snippetScore(phrases, insts, size, c, in.off)
+			if score > bestScore {
+				bestScore, bestCol, bestStart = score, c, adj
+			}
+
+			if len(starts) > 0 && size > snippetTokens {
+				j := 0
+				for ; j < len(starts)-1; j++ {
+					if starts[j+1] > in.off {
+						break
+					}
+				}
+				if starts[j] < in.off {
+					score, _ := snippetScore(phrases, insts, size, c, starts[j])
+					if starts[j] == 0 {
+						score += 120
+					} else {
+						score += 100
+					}
+					if score > bestScore {
+						bestScore, bestCol, bestStart = score, c, starts[j]
+					}
+				}
+			}
+		}
+	}
+	return doc[bestCol].highlight(phrases, insts, bestCol, bestStart)
+}
+
+// snippetScore scores the window at pos: 1000 for each distinct phrase in it and 1 for each
+// repeat. It also returns the window's start adjusted to center the phrases found.
+func snippetScore(phrases []ftsPhrase, insts []ftsInstance, size, col, pos int) (score, adj int) {
+	seen := make([]bool, len(phrases))
+	first, last := -1, 0
+	for _, in := range insts {
+		if in.col != col || in.off < pos || in.off >= pos+snippetTokens {
+			continue
+		}
+		if seen[in.phrase] {
+			score++
+		} else {
+			score += 1000
+		}
+		seen[in.phrase] = true
+		if first < 0 {
+			first = in.off
+		}
+		last = in.off + len(phrases[in.phrase].tokens)
+	}
+
+	adj = first - (snippetTokens-(last-first))/2
+	if adj+snippetTokens > size {
+		adj = size - snippetTokens
+	}
+	if adj < 0 {
+		adj = 0
+	}
+	return score, adj
+}
+
+// sentenceStarts returns the first token and those following a '.' or ':' and whitespace
+func (col ftsColumn) sentenceStarts() []int {
+	if len(col.tokens) == 0 {
+		return nil
+	}
+	starts := []int{0}
+	for pos, tok := range col.tokens[1:] {
+		i := tok.start - 1
+		var c byte
+		for ; i >= 0; i-- {
+			c = col.text[i]
+			if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+				break
+			}
+		}
+		if i != tok.start-1 && (c == '.' || c == ':') {
+			starts = append(starts, pos+1)
+		}
+	}
+	return starts
+}
+
+// highlight renders the window of the column starting at token start, wrapping phrase occurrences
+// in <mark>. Overlapping occurrences are marked as one, and the window is elided with '…'.
+func (col ftsColumn) highlight(phrases []ftsPhrase, insts []ftsInstance, c, start int) string {
+	type span struct{ start, end int }
+	var spans []span
+	for _, in := range insts {
+		if in.col != c {
+			continue
+		}
+		end := in.off + len(phrases[in.phrase].tokens) - 1
+		if n := len(spans); n > 0 && in.off <= spans[n-1].end {
+			spans[n-1].end = max(spans[n-1].end, end)
+			continue
+		}
+		spans = append(spans, span{in.off, end})
+	}
+	next := func() span {
+		if len(spans) == 0 {
+			return span{-1, -1}
+		}
+		return spans[0]
+	}
+	for len(spans) > 0 && spans[0].start < start {
+		spans = spans[1:]
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	end := start + snippetTokens - 1
+	off, open := 0, false
+	for pos, tok := range col.tokens {
+		if pos < start || pos > end {
+			continue
+		}
+		if start > 0 && pos == start {
+			off = tok.start
+		}
+		if s := next(); open && (pos <= s.start || s.start < 0) && tok.start > off {
+			b.WriteString("</mark>")
+			open = false
+		}
+		if pos == next().start && !open {
+			b.WriteString(col.text[off:tok.start])
+			b.WriteString("<mark>")
+			off, open = tok.start, true
+		}
+		if pos == next().end {
+			if !open {
+				b.WriteString("<mark>")
+				open = true
+			}
+			b.WriteString(col.text[off:tok.end])
+			off = tok.end
+			spans = spans[1:]
+		}
+		if pos == end {
+			if open {
+				// A phrase running past the window is marked up to its end
+				if s := next(); s.start >= 0 && pos >= s.start {
+					b.WriteString(col.text[off:tok.end])
+					off = tok.end
+				}
+				b.WriteString("</mark>")
+				open = false
+			}
+			b.WriteString(col.text[off:tok.end])
+			off = tok.end
+		}
+	}
+	if open {
+		b.WriteString("</mark>")
+	}
+	if end >= len(col.tokens)-1 {
+		b.WriteString(col.text[off:])
+	} else {
+		b.WriteString("…")
+	}
+	return b.String()
+}
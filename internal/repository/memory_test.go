@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+)
+
+// TestMemoryDB_AgreesWithSQLite stores the same disasters in both backends and checks that every
+// filter, sort and text query returns the same results, snippets and pages
+func TestMemoryDB_AgreesWithSQLite(t *testing.T) {
+	ctx := context.Background()
+	sqlite, err := NewSQLiteDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
+	}
+	defer sqlite.Close()
+	memory := NewMemoryDB()
+
+	words := []string{"flood", "Flooding", "earthquake", "cyclone-Luzon", "São", "Paulo", "Tōkyō", "M7.5", "river", "warning", "coast"}
+	countries := []string{"Brazil", "Japan", "Philippines", "Ünïted Státes", ""}
+	isos := []string{"BRA", "JPN", "PHL", "USA", ""}
+	rng := rand.New(rand.NewPCG(1, 2))
+	text := func(n int) string {
+		var parts []string
+		for range n {
+			w := words[rng.IntN(len(words))]
+			if rng.IntN(8) == 0 {
+				w += "."
+			}
+			parts = append(parts, w)
+		}
+		return strings.Join(parts, " ")
+	}
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	var disasters []*models.Disaster
+	for i := range 60 {
+		c := rng.IntN(len(countries))
+		d := &models.Disaster{
+			ID:                      fmt.Sprintf("d%02d", i),
+			Source:                  []string{"GDACS", "usgs"}[rng.IntN(2)],
+			Type:                    disastersv1.DisasterType(rng.IntN(5) + 1),
+			Title:                   text(rng.IntN(6) + 1),
+			Description:             text(rng.IntN(30)),
+			Magnitude:               float64(rng.IntN(8)),
+			AlertLevel:              disastersv1.AlertLevel(rng.IntN(4)),
+			Latitude:                rng.Float64()*160 - 80,
+			Longitude:               rng.Float64()*360 - 180,
+			Timestamp:               base.Add(time.Duration(rng.IntN(10)) * time.Hour),
+			Country:                 countries[c],
+			CountryISO:              isos[c],
+			AffectedPopulationCount: int64(rng.IntN(4)) * 1000,
+			CreatedAt:               base.Add(time.Duration(i) * time.Minute),
+		}
+		disasters = append(disasters, d)
+		for _, db := range []DisasterRepository{sqlite, memory} {
+			copied := *d
+			if err := db.Add(ctx, &copied); err != nil {
+				t.Fatalf("Add failed: %v", err)
+			}
+		}
+	}
+	for _, d := range disasters[:5] {
+		d.Title += " updated flood"
+		d.UpdatedAt = base.Add(24 * time.Hour)
+		for _, db := range []DisasterRepository{sqlite, memory} {
+			copied := *d
+			if err := db.Update(ctx, &copied, disastersv1.EventKind_EVENT_KIND_UPDATED); err != nil {
+				t.Fatalf("Update failed: %v", err)
+			}
+		}
+	}
+	for _, db := range []DisasterRepository{sqlite, memory} {
+		if _, err := db.MarkAsSent(ctx, "discord", []string{"d01", "d02", "d03", "missing"}); err != nil {
+			t.Fatalf("MarkAsSent failed: %v", err)
+		}
+	}
+
+	minMag, maxMag := 3.0, 6.0
+	orange := disastersv1.AlertLevel_ORANGE
+	flood := disastersv1.DisasterType_FLOOD
+	pop := int64(2000)
+	delivered, undelivered := true, false
+	since, until := base.Add(2*time.Hour), base.Add(7*time.Hour)
+	near := &geo.Circle{Lat: 10, Lon: 170, RadiusKm: 4000}
+	area := geo.MultiPolygon{{{{-100, -40}, {0, -40}, {0, 40}, {-100, 40}}}}
+	filters := map[string]Filter{
+		"all":                   {},
+		"limit and offset":      {Limit: 7, Offset: 5},
+		"type":                  {Type: &flood},
+		"magnitude range":       {MinMagnitude: &minMag, MaxMagnitude: &maxMag},
+		"min alert level":       {MinAlertLevel: &orange},
+		"population":            {MinAffectedPopulationCount: &pop},
+		"countries":             {Countries: []string{"jpn", "PHL"}},
+		"sources":               {Sources: []string{"Gdacs"}},
+		"time range":            {Since: &since, Until: &until},
+		"created time":          {Since: &since, TimeField: TimeFieldCreated, Order: SortOrderAsc},
+		"delivered":             {ConsumerID: "discord", Delivered: &delivered},
+		"undelivered":           {ConsumerID: "discord", Delivered: &undelivered, Limit: 10},
+		"bbox":                  {BBox: &geo.BBox{MinLat: -30, MinLon: 150, MaxLat: 60, MaxLon: -120}},
+		"near by distance":      {Near: near},
+		"area":                  {Area: area, Limit: 5, Offset: 2},
+		"query":                 {Query: "flood"},
+		"query prefix":          {Query: "flood*"},
+		"query phrase":          {Query: "cyclone-luzon river"},
+		"query diacritics":      {Query: "sao tokyo"},
+		"query numbers":         {Query: "m7.5"},
+		"query empty phrase":    {Query: "warning -"},
+		"query only empty":      {Query: "- ."},
+		"query sorted by time":  {Query: "coast", Sort: SortTime},
+		"sort magnitude":        {Sort: SortMagnitude},
+		"sort alert level asc":  {Sort: SortAlertLevel, Order: SortOrderAsc},
+		"sort population":       {Sort: SortPopulation, Limit: 9},
+		"sort distance desc":    {Near: near, Sort: SortDistance, Order: SortOrderDesc},
+		"sort relevance desc":   {Query: "flood earth*", Order: SortOrderDesc},
+		"combined with query":   {Query: "paulo", MinAlertLevel: &orange, Countries: []string{"BRA"}},
+		"time ascending":        {Order: SortOrderAsc, Limit: 20},
+		"created time with key": {TimeField: TimeFieldCreated, Sort: SortMagnitude, Order: SortOrderAsc},
+	}
+
+	for name, filter := range filters {
+		t.Run(name, func(t *testing.T) {
+			want, err := sqlite.ListDisasters(ctx, filter)
+			if err != nil {
+				t.Fatalf("SQLite ListDisasters failed: %v", err)
+			}
+			got, err := memory.ListDisasters(ctx, filter)
+			if err != nil {
+				t.Fatalf("MemoryDB ListDisasters failed: %v", err)
+			}
+			compareDisasters(t, want, got)
+
+			// Paging through with each backend's own tokens visits the same disasters
+			if filter.Limit == 0 && filter.Area == nil {
+				filter.Limit = 4
+				compareDisasters(t, listAllPages(t, sqlite, filter), listAllPages(t, memory, filter))
+			}
+		})
+	}
+
+	// Page tokens of another ordering are rejected the same way
+	page, _ := memory.ListDisasters(ctx, Filter{Limit: 1})
+	if _, err := memory.ListDisasters(ctx, Filter{Sort: SortMagnitude, PageToken: page[0].PageToken}); err != ErrInvalidPageToken {
+		t.Errorf("expected ErrInvalidPageToken, got %v", err)
+	}
+}
+
+func listAllPages(t *testing.T, db DisasterRepository, filter Filter) []models.Disaster {
+	t.Helper()
+	var all []models.Disaster
+	for range 100 {
+		page, err := db.ListDisasters(context.Background(), filter)
+		if err != nil {
+			t.Fatalf("ListDisasters failed: %v", err)
+		}
+		all = append(all, page...)
+		if len(page) < filter.Limit {
+			return all
+		}
+		filter.PageToken = page[len(page)-1].PageToken
+	}
+	t.Fatal("paging did not end")
+	return nil
+}
+
+func compareDisasters(t *testing.T, want, got []models.Disaster) {
+	t.Helper()
+	ids := func(ds []models.Disaster) []string {
+		var ids []string
+		for _, d := range ds {
+			ids = append(ids, d.ID)
+		}
+		return ids
+	}
+	if !slices.Equal(ids(want), ids(got)) {
+		t.Fatalf("expected %v, got %v", ids(want), ids(got))
+	}
+	for i := range want {
+		w, g := want[i], got[i]
+		if w.Snippet != g.Snippet {
+			t.Errorf("%s: expected snippet %q, got %q", w.ID, w.Snippet, g.Snippet)
+		}
+		if (w.DistanceKm == nil) != (g.DistanceKm == nil) ||
+			w.DistanceKm != nil && math.Abs(*w.DistanceKm-*g.DistanceKm) > 1e-6 {
+			t.Errorf("%s: expected distance %v, got %v", w.ID, w.DistanceKm, g.DistanceKm)
+		}
+		if w.Title != g.Title || w.Seq != g.Seq || !w.CreatedAt.Equal(g.CreatedAt) || !w.UpdatedAt.Equal(g.UpdatedAt) {
+			t.Errorf("%s: expected %+v, got %+v", w.ID, w, g)
+		}
+	}
+}
+
+func TestMemoryDB_Events(t *testing.T) {
+	db := NewMemoryDB()
+	ctx := context.Background()
+
+	created := time.Now()
+	d := &models.Disaster{ID: "d1", Title: "Quake", CreatedAt: created}
+	if err := db.Add(ctx, d); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := db.Add(ctx, &models.Disaster{ID: "d1"}); err == nil {
+		t.Error("expected an error adding a duplicate")
+	}
+
+	d.Title = "Quake upgraded"
+	d.CreatedAt = created.Add(time.Hour)
+	d.UpdatedAt = created.Add(2 * time.Hour)
+	if err := db.Update(ctx, d, disastersv1.EventKind_EVENT_KIND_UPDATED); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if d.Seq != 2 {
+		t.Errorf("expected seq 2, got %d", d.Seq)
+	}
+	if err := db.Update(ctx, &models.Disaster{ID: "missing"}, disastersv1.EventKind_EVENT_KIND_UPDATED); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	events, err := db.ListEvents(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	if len(events) != 2 || events[0].Kind != disastersv1.EventKind_EVENT_KIND_CREATED || !events[1].CreatedAt.Equal(d.UpdatedAt) {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	// Each event carries the disaster as it was when the event was recorded
+	if events[0].Disaster.Title != "Quake" || events[0].Disaster.Seq != 1 || !events[0].Disaster.CreatedAt.Equal(created) {
+		t.Errorf("unexpected created event disaster %+v", events[0].Disaster)
+	}
+	if events[1].Disaster.Title != "Quake upgraded" || events[1].Disaster.Seq != 2 {
+		t.Errorf("unexpected updated event disaster %+v", events[1].Disaster)
+	}
+	if events, _ := db.ListEvents(ctx, 1, 1); len(events) != 1 || events[0].Seq != 2 {
+		t.Errorf("expected only seq 2, got %+v", events)
+	}
+
+	// Stored disasters don't change with the caller's copy
+	d.Raw = []byte("raw")
+	got, _ := db.GetByID(ctx, "d1")
+	got.Title = "changed"
+	if stored, _ := db.GetByID(ctx, "d1"); stored.Title != "Quake upgraded" || stored.Raw != nil {
+		t.Errorf("stored disaster changed: %+v", stored)
+	}
+}
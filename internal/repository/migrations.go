@@ -0,0 +1,614 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+// migration is one numbered schema change. Each step runs in a transaction together with its
+// schema_migrations row, so a failed step leaves the database at the previous version.
+//
+// Steps must also apply cleanly to databases created before versioning, whose schema may already
+// contain some of their changes: use CREATE ... IF NOT EXISTS and addColumn rather than bare ALTERs.
+type migration struct {
+	version int
+	name    string
+	up      func(ctx context.Context, tx *sql.Tx) error
+	down    func(ctx context.Context, tx *sql.Tx) error
+}
+
+// MigrationStatus describes a migration and when it was applied, nil if pending
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create disasters and alerts",
+		up: func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `
+				CREATE TABLE IF NOT EXISTS disasters (
+					id TEXT PRIMARY KEY,
+					source TEXT NOT NULL,
+					type INTEGER NOT NULL,
+					title TEXT NOT NULL,
+					description TEXT,
+					magnitude REAL,
+					alert_level INTEGER DEFAULT 0,
+					latitude REAL NOT NULL,
+					longitude REAL NOT NULL,
+					timestamp DATETIME NOT NULL,
+					raw BLOB,
+					created_at DATETIME NOT NULL,
+					discord_sent BOOLEAN DEFAULT FALSE
+				);
+
+				CREATE TABLE IF NOT EXISTS alerts (
+					id TEXT PRIMARY KEY,
+					disaster_id TEXT NOT NULL,
+					severity TEXT NOT NULL,
+					created_at DATETIME NOT NULL,
+					FOREIGN KEY (disaster_id) REFERENCES disasters(id)
+				);
+
+				CREATE INDEX IF NOT EXISTS idx_disasters_timestamp ON disasters(timestamp);
+				CREATE INDEX IF NOT EXISTS idx_disasters_type ON disasters(type);
+				CREATE INDEX IF NOT EXISTS idx_disasters_alert_level ON disasters(alert_level);
+				CREATE INDEX IF NOT EXISTS idx_alerts_disaster_id ON alerts(disaster_id);
+			`)
+			if err != nil {
+				return err
+			}
+			// Unversioned databases from after step 5 no longer have the flag
+			hasFlag, err := hasColumn(ctx, tx, "disasters", "discord_sent")
+			if err != nil || !hasFlag {
+				return err
+			}
+			_, err = tx.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS idx_disasters_discord_sent ON disasters(discord_sent)`)
+			return err
+		},
+		down: execSQL(`
+			DROP TABLE alerts;
+			DROP TABLE disasters;
+		`),
+	},
+	{
+		version: 2,
+		name:    "add country, affected population and report URL",
+		up: addColumns("disasters",
+			"country TEXT DEFAULT ''",
+			"affected_population TEXT DEFAULT ''",
+			"affected_population_count INTEGER DEFAULT 0",
+			"report_url TEXT DEFAULT ''",
+		),
+		down: dropColumns("disasters", "country", "affected_population", "affected_population_count", "report_url"),
+	},
+	{
+		version: 3,
+		name:    "add disaster event log",
+		up: func(ctx context.Context, tx *sql.Tx) error {
+			if err := addColumn(ctx, tx, "disasters", "seq INTEGER NOT NULL DEFAULT 0"); err != nil {
+				return err
+			}
+			// Existing disasters get a CREATED event in the order they were stored, so they can be replayed
+			_, err := tx.ExecContext(ctx, `
+				CREATE TABLE IF NOT EXISTS disaster_events (
+					seq INTEGER PRIMARY KEY AUTOINCREMENT,
+					disaster_id TEXT NOT NULL,
+					kind INTEGER NOT NULL,
+					created_at DATETIME NOT NULL,
+					FOREIGN KEY (disaster_id) REFERENCES disasters(id)
+				);
+
+				CREATE INDEX IF NOT EXISTS idx_disasters_seq ON disasters(seq);
+				CREATE INDEX IF NOT EXISTS idx_disaster_events_disaster_id ON disaster_events(disaster_id);
+
+				INSERT INTO disaster_events (disaster_id, kind, created_at)
+				SELECT id, ?, created_at FROM disasters
+				WHERE id NOT IN (SELECT disaster_id FROM disaster_events)
+				ORDER BY created_at, id;
+
+				UPDATE disasters SET seq = (SELECT MAX(seq) FROM disaster_events e WHERE e.disaster_id = disasters.id)
+				WHERE seq = 0;
+			`, int32(disastersv1.EventKind_EVENT_KIND_CREATED))
+			return err
+		},
+		down: func(ctx context.Context, tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, `DROP INDEX IF EXISTS idx_disasters_seq; DROP TABLE disaster_events`); err != nil {
+				return err
+			}
+			return dropColumn(ctx, tx, "disasters", "seq")
+		},
+	},
+	{
+		version: 4,
+		name:    "add closed and updated_at",
+		up:      addColumns("disasters", "closed BOOLEAN DEFAULT FALSE", "updated_at DATETIME"),
+		down:    dropColumns("disasters", "closed", "updated_at"),
+	},
+	{
+		version: 5,
+		name:    "replace discord_sent with per-consumer deliveries",
+		up: func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `
+				CREATE TABLE IF NOT EXISTS deliveries (
+					consumer_id TEXT NOT NULL,
+					disaster_id TEXT NOT NULL,
+					acked_at DATETIME NOT NULL,
+					PRIMARY KEY (consumer_id, disaster_id),
+					FOREIGN KEY (disaster_id) REFERENCES disasters(id)
+				);
+
+				CREATE INDEX IF NOT EXISTS idx_deliveries_disaster_id ON deliveries(disaster_id);
+			`)
+			if err != nil {
+				return err
+			}
+
+			hasFlag, err := hasColumn(ctx, tx, "disasters", "discord_sent")
+			if err != nil || !hasFlag {
+				return err
+			}
+			// The flag only recorded that the Discord bot posted the disaster, not when
+			_, err = tx.ExecContext(ctx, `
+				INSERT OR IGNORE INTO deliveries (consumer_id, disaster_id, acked_at)
+				SELECT 'discord', id, created_at FROM disasters WHERE discord_sent;
+
+				DROP INDEX IF EXISTS idx_disasters_discord_sent;
+			`)
+			if err != nil {
+				return err
+			}
+			return dropColumn(ctx, tx, "disasters", "discord_sent")
+		},
+		down: func(ctx context.Context, tx *sql.Tx) error {
+			if err := addColumn(ctx, tx, "disasters", "discord_sent BOOLEAN DEFAULT FALSE"); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `
+				UPDATE disasters SET discord_sent = TRUE
+				WHERE id IN (SELECT disaster_id FROM deliveries WHERE consumer_id = 'discord');
+
+				CREATE INDEX idx_disasters_discord_sent ON disasters(discord_sent);
+				DROP TABLE deliveries;
+			`)
+			return err
+		},
+	},
+	{
+		version: 6,
+		name:    "add country ISO code",
+		up: func(ctx context.Context, tx *sql.Tx) error {
+			if err := addColumn(ctx, tx, "disasters", "country_iso TEXT DEFAULT ''"); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS idx_disasters_country_iso ON disasters(country_iso)`)
+			return err
+		},
+		down: func(ctx context.Context, tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, `DROP INDEX IF EXISTS idx_disasters_country_iso`); err != nil {
+				return err
+			}
+			return dropColumn(ctx, tx, "disasters", "country_iso")
+		},
+	},
+	{
+		version: 7,
+		name:    "add spatial index",
+		// Keyed by the disaster ID auxiliary column rather than rowid, which VACUUM may renumber
+		// on tables without an INTEGER PRIMARY KEY.
+		up: execSQL(`
+			CREATE VIRTUAL TABLE IF NOT EXISTS disasters_rtree USING rtree(
+				rtree_id, min_lat, max_lat, min_lon, max_lon,
+				+disaster_id TEXT
+			);
+
+			CREATE TRIGGER IF NOT EXISTS disasters_rtree_insert AFTER INSERT ON disasters BEGIN
+				INSERT INTO disasters_rtree (min_lat, max_lat, min_lon, max_lon, disaster_id)
+				VALUES (new.latitude, new.latitude, new.longitude, new.longitude, new.id);
+			END;
+
+			CREATE TRIGGER IF NOT EXISTS disasters_rtree_update AFTER UPDATE OF latitude, longitude ON disasters BEGIN
+				UPDATE disasters_rtree SET min_lat = new.latitude, max_lat = new.latitude, min_lon = new.longitude, max_lon = new.longitude
+				WHERE disaster_id = new.id;
+			END;
+
+			CREATE TRIGGER IF NOT EXISTS disasters_rtree_delete AFTER DELETE ON disasters BEGIN
+				DELETE FROM disasters_rtree WHERE disaster_id = old.id;
+			END;
+
+			INSERT INTO disasters_rtree (min_lat, max_lat, min_lon, max_lon, disaster_id)
+			SELECT latitude, latitude, longitude, longitude, id FROM disasters
+			WHERE id NOT IN (SELECT disaster_id FROM disasters_rtree);
+		`),
+		down: execSQL(`
+			DROP TRIGGER disasters_rtree_insert;
+			DROP TRIGGER disasters_rtree_update;
+			DROP TRIGGER disasters_rtree_delete;
+			DROP TABLE disasters_rtree;
+		`),
+	},
+	{
+		version: 8,
+		name:    "add geofences",
+		up: func(ctx context.Context, tx *sql.Tx) error {
+			if err := addColumn(ctx, tx, "alerts", "geofence_id TEXT DEFAULT ''"); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `
+				CREATE TABLE IF NOT EXISTS geofences (
+					id TEXT PRIMARY KEY,
+					name TEXT NOT NULL,
+					owner TEXT NOT NULL,
+					area TEXT,          -- MultiPolygon coordinates as JSON, NULL for circles
+					center_lat REAL,    -- circle center and radius, NULL for polygons
+					center_lon REAL,
+					radius_km REAL,
+					criteria TEXT NOT NULL DEFAULT '{}',
+					created_at DATETIME NOT NULL
+				);
+
+				CREATE INDEX IF NOT EXISTS idx_alerts_geofence_id ON alerts(geofence_id);
+				CREATE INDEX IF NOT EXISTS idx_geofences_owner ON geofences(owner);
+			`)
+			return err
+		},
+		down: func(ctx context.Context, tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, `DROP INDEX IF EXISTS idx_alerts_geofence_id; DROP TABLE geofences`); err != nil {
+				return err
+			}
+			return dropColumn(ctx, tx, "alerts", "geofence_id")
+		},
+	},
+	{
+		version: 9,
+		name:    "add full-text index",
+		up: execSQL(`
+			CREATE VIRTUAL TABLE IF NOT EXISTS disasters_fts USING fts5(
+				disaster_id UNINDEXED, title, description, country,
+				tokenize = 'unicode61 remove_diacritics 2'
+			);
+
+			CREATE TRIGGER IF NOT EXISTS disasters_fts_insert AFTER INSERT ON disasters BEGIN
+				INSERT INTO disasters_fts (disaster_id, title, description, country)
+				VALUES (new.id, new.title, new.description, new.country);
+			END;
+
+			CREATE TRIGGER IF NOT EXISTS disasters_fts_update AFTER UPDATE OF title, description, country ON disasters BEGIN
+				UPDATE disasters_fts SET title = new.title, description = new.description, country = new.country
+				WHERE disaster_id = new.id;
+			END;
+
+			CREATE TRIGGER IF NOT EXISTS disasters_fts_delete AFTER DELETE ON disasters BEGIN
+				DELETE FROM disasters_fts WHERE disaster_id = old.id;
+			END;
+
+			INSERT INTO disasters_fts (disaster_id, title, description, country)
+			SELECT id, title, description, country FROM disasters
+			WHERE id NOT IN (SELECT disaster_id FROM disasters_fts);
+		`),
+		down: execSQL(`
+			DROP TRIGGER disasters_fts_insert;
+			DROP TRIGGER disasters_fts_update;
+			DROP TRIGGER disasters_fts_delete;
+			DROP TABLE disasters_fts;
+		`),
+	},
+	{
+		version: 10,
+		name:    "add keyset pagination indexes",
+		up: execSQL(`
+			CREATE INDEX IF NOT EXISTS idx_disasters_timestamp_id ON disasters(timestamp, id);
+			CREATE INDEX IF NOT EXISTS idx_disasters_created_at_id ON disasters(created_at, id);
+		`),
+		down: execSQL(`
+			DROP INDEX idx_disasters_timestamp_id;
+			DROP INDEX idx_disasters_created_at_id;
+		`),
+	},
+	{
+		version: 11,
+		name:    "add disasters archive",
+		up: execSQL(`
+			CREATE TABLE IF NOT EXISTS disasters_archive (
+				id TEXT PRIMARY KEY,
+				source TEXT NOT NULL,
+				type INTEGER NOT NULL,
+				title TEXT NOT NULL,
+				description TEXT,
+				magnitude REAL,
+				alert_level INTEGER DEFAULT 0,
+				latitude REAL NOT NULL,
+				longitude REAL NOT NULL,
+				timestamp DATETIME NOT NULL,
+				country TEXT DEFAULT '',
+				affected_population TEXT DEFAULT '',
+				affected_population_count INTEGER DEFAULT 0,
+				report_url TEXT DEFAULT '',
+				created_at DATETIME NOT NULL,
+				closed BOOLEAN DEFAULT FALSE,
+				updated_at DATETIME,
+				country_iso TEXT DEFAULT '',
+				archived_at DATETIME NOT NULL
+			);
+		`),
+		down: execSQL(`DROP TABLE disasters_archive`),
+	},
+	{
+		version: 12,
+		name:    "add operator corrections and audit log",
+		up: func(ctx context.Context, tx *sql.Tx) error {
+			for _, table := range []string{"disasters", "disasters_archive"} {
+				if err := addColumn(ctx, tx, table, "corrections TEXT NOT NULL DEFAULT ''"); err != nil {
+					return err
+				}
+			}
+			_, err := tx.ExecContext(ctx, `
+				CREATE TABLE IF NOT EXISTS disaster_audit (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					disaster_id TEXT NOT NULL,
+					action TEXT NOT NULL,
+					actor TEXT NOT NULL,
+					changes TEXT NOT NULL,
+					created_at DATETIME NOT NULL
+				);
+				CREATE INDEX IF NOT EXISTS idx_disaster_audit_disaster_id ON disaster_audit(disaster_id);
+			`)
+			return err
+		},
+		down: func(ctx context.Context, tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, `DROP TABLE disaster_audit`); err != nil {
+				return err
+			}
+			for _, table := range []string{"disasters_archive", "disasters"} {
+				if err := dropColumn(ctx, tx, table, "corrections"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		version: 13,
+		name:    "add event snapshots",
+		up:      addColumns("disaster_events", "snapshot TEXT"),
+		down:    dropColumns("disaster_events", "snapshot"),
+	},
+}
+
+// LatestSchemaVersion is the version MigrateUp brings a SQLite database to by default
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// SchemaVersion returns the highest applied migration, 0 for an empty or unversioned database
+func (s *SQLiteDB) SchemaVersion(ctx context.Context) (int, error) {
+	return s.migrator().version(ctx)
+}
+
+func (s *SQLiteDB) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	return s.migrator().status(ctx)
+}
+
+// MigrateUp applies pending migrations up to and including target, or all of them when target is 0
+func (s *SQLiteDB) MigrateUp(ctx context.Context, target int) error {
+	return s.migrator().up(ctx, target)
+}
+
+// MigrateDown reverts applied migrations until the database is at version target
+func (s *SQLiteDB) MigrateDown(ctx context.Context, target int) error {
+	return s.migrator().down(ctx, target)
+}
+
+func (s *SQLiteDB) migrator() migrator {
+	return migrator{
+		db:    s.db,
+		steps: migrations,
+		ddl: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at DATETIME NOT NULL
+		)`,
+		bind: func(query string) string { return query },
+	}
+}
+
+// migrator applies a backend's migrations, recording each in its schema_migrations table
+type migrator struct {
+	db    *sql.DB
+	steps []migration
+	ddl   string                    // creates schema_migrations
+	bind  func(query string) string // rewrites ? placeholders for the driver
+	lock  string                    // run first in each step's transaction to serialize migrating processes
+}
+
+func (m migrator) latest() int {
+	return m.steps[len(m.steps)-1].version
+}
+
+func (m migrator) version(ctx context.Context) (int, error) {
+	if _, err := m.db.ExecContext(ctx, m.ddl); err != nil {
+		return 0, err
+	}
+	var version int
+	err := m.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+func (m migrator) status(ctx context.Context) ([]MigrationStatus, error) {
+	if _, err := m.db.ExecContext(ctx, m.ddl); err != nil {
+		return nil, err
+	}
+	rows, err := m.db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, len(m.steps))
+	for i, step := range m.steps {
+		status[i] = MigrationStatus{Version: step.version, Name: step.name}
+		if at, ok := applied[step.version]; ok {
+			status[i].AppliedAt = &at
+		}
+	}
+	return status, nil
+}
+
+func (m migrator) up(ctx context.Context, target int) error {
+	if target == 0 {
+		target = m.latest()
+	}
+	if target < 0 || target > m.latest() {
+		return fmt.Errorf("unknown schema version %d, latest is %d", target, m.latest())
+	}
+	current, err := m.version(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, step := range m.steps {
+		if step.version <= current || step.version > target {
+			continue
+		}
+		err := inTx(ctx, m.db, func(tx *sql.Tx) error {
+			if m.lock != "" {
+				// Another process may have applied the step while this one waited
+				if _, err := tx.ExecContext(ctx, m.lock); err != nil {
+					return err
+				}
+				var applied bool
+				err := tx.QueryRowContext(ctx, m.bind(`SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = ?)`), step.version).Scan(&applied)
+				if err != nil || applied {
+					return err
+				}
+			}
+			if err := step.up(ctx, tx); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, m.bind(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`),
+				step.version, step.name, time.Now())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("error applying migration %d (%s): %w", step.version, step.name, err)
+		}
+		slog.Info("applied migration", "version", step.version, "name", step.name)
+	}
+	return nil
+}
+
+func (m migrator) down(ctx context.Context, target int) error {
+	if target < 0 || target > m.latest() {
+		return fmt.Errorf("unknown schema version %d, latest is %d", target, m.latest())
+	}
+	current, err := m.version(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := len(m.steps) - 1; i >= 0; i-- {
+		step := m.steps[i]
+		if step.version > current || step.version <= target {
+			continue
+		}
+		err := inTx(ctx, m.db, func(tx *sql.Tx) error {
+			if err := step.down(ctx, tx); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, m.bind(`DELETE FROM schema_migrations WHERE version = ?`), step.version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("error reverting migration %d (%s): %w", step.version, step.name, err)
+		}
+		slog.Info("reverted migration", "version", step.version, "name", step.name)
+	}
+	return nil
+}
+
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func execSQL(stmts string) func(ctx context.Context, tx *sql.Tx) error {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, stmts)
+		return err
+	}
+}
+
+func addColumns(table string, defs ...string) func(ctx context.Context, tx *sql.Tx) error {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		for _, def := range defs {
+			if err := addColumn(ctx, tx, table, def); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func dropColumns(table string, columns ...string) func(ctx context.Context, tx *sql.Tx) error {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		for _, column := range columns {
+			if err := dropColumn(ctx, tx, table, column); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// addColumn adds a column given as "name TYPE ..." unless the table already has it
+func addColumn(ctx context.Context, tx *sql.Tx, table, def string) error {
+	name, _, _ := strings.Cut(def, " ")
+	exists, err := hasColumn(ctx, tx, table, name)
+	if err != nil || exists {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table, def))
+	return err
+}
+
+func dropColumn(ctx context.Context, tx *sql.Tx, table, column string) error {
+	_, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, column))
+	return err
+}
+
+func hasColumn(ctx context.Context, tx *sql.Tx, table, column string) (bool, error) {
+	var n int
+	err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&n)
+	return n > 0, err
+}
@@ -0,0 +1,472 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
+)
+
+func openUnmigrated(t *testing.T) *SQLiteDB {
+	t.Helper()
+	db, err := OpenSQLiteDB(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func mustExec(t *testing.T, db *SQLiteDB, query string, args ...any) {
+	t.Helper()
+	if _, err := db.db.Exec(query, args...); err != nil {
+		t.Fatalf("exec failed: %v\n%s", err, query)
+	}
+}
+
+func queryInt(t *testing.T, db *SQLiteDB, query string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := db.db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("query failed: %v\n%s", err, query)
+	}
+	return n
+}
+
+func columnExists(t *testing.T, db *SQLiteDB, table, column string) bool {
+	t.Helper()
+	return queryInt(t, db, `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column) > 0
+}
+
+func schemaObjectExists(t *testing.T, db *SQLiteDB, name string) bool {
+	t.Helper()
+	return queryInt(t, db, `SELECT COUNT(*) FROM sqlite_master WHERE name = ?`, name) > 0
+}
+
+// schemaSnapshot lists every table with its columns, and every index and trigger,
+// excluding SQLite's internal tables and the migrations bookkeeping
+func schemaSnapshot(t *testing.T, db *SQLiteDB) []string {
+	t.Helper()
+	rows, err := db.db.Query(`SELECT type, name FROM sqlite_master
+		WHERE name NOT LIKE 'sqlite_%' AND name != 'schema_migrations' ORDER BY type, name`)
+	if err != nil {
+		t.Fatalf("failed to read schema: %v", err)
+	}
+	type object struct{ typ, name string }
+	var objects []object
+	for rows.Next() {
+		var o object
+		if err := rows.Scan(&o.typ, &o.name); err != nil {
+			t.Fatalf("failed to read schema: %v", err)
+		}
+		objects = append(objects, o)
+	}
+	rows.Close()
+
+	var snapshot []string
+	for _, o := range objects {
+		entry := o.typ + " " + o.name
+		if o.typ == "table" {
+			cols, err := db.db.Query(`SELECT name, type, "notnull", COALESCE(dflt_value, ''), pk FROM pragma_table_info(?)`, o.name)
+			if err != nil {
+				t.Fatalf("failed to read columns: %v", err)
+			}
+			var defs []string
+			for cols.Next() {
+				var name, typ, dflt string
+				var notNull, pk int
+				if err := cols.Scan(&name, &typ, &notNull, &dflt, &pk); err != nil {
+					t.Fatalf("failed to read columns: %v", err)
+				}
+				defs = append(defs, fmt.Sprintf("%s %s notnull=%d default=%s pk=%d", name, typ, notNull, dflt, pk))
+			}
+			cols.Close()
+			// Columns re-added by a down step go at the end; their order doesn't matter
+			slices.Sort(defs)
+			entry += " (" + strings.Join(defs, ", ") + ")"
+		}
+		snapshot = append(snapshot, entry)
+	}
+	return snapshot
+}
+
+// TestMigrations_EachStep applies every migration to a database at the previous version,
+// checks its changes, and checks that reverting it restores the previous schema.
+func TestMigrations_EachStep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	steps := map[int]struct {
+		seed  func(t *testing.T, db *SQLiteDB) // data stored at the previous version
+		check func(t *testing.T, db *SQLiteDB)
+		down  func(t *testing.T, db *SQLiteDB) // data checks after reverting
+	}{
+		1: {
+			check: func(t *testing.T, db *SQLiteDB) {
+				if !schemaObjectExists(t, db, "disasters") || !schemaObjectExists(t, db, "alerts") {
+					t.Error("expected disasters and alerts tables")
+				}
+			},
+		},
+		2: {
+			seed: func(t *testing.T, db *SQLiteDB) {
+				mustExec(t, db, `INSERT INTO disasters (id, source, type, title, latitude, longitude, timestamp, created_at)
+					VALUES ('d1', 'GDACS', 1, 'Quake', 35, 139, ?, ?)`, now, now)
+			},
+			check: func(t *testing.T, db *SQLiteDB) {
+				for _, c := range []string{"country", "affected_population", "affected_population_count", "report_url"} {
+					if !columnExists(t, db, "disasters", c) {
+						t.Errorf("expected column %s", c)
+					}
+				}
+				if n := queryInt(t, db, `SELECT affected_population_count FROM disasters WHERE id = 'd1'`); n != 0 {
+					t.Errorf("expected existing rows to default to 0, got %d", n)
+				}
+			},
+		},
+		3: {
+			seed: func(t *testing.T, db *SQLiteDB) {
+				for i, id := range []string{"d2", "d1"} {
+					mustExec(t, db, `INSERT INTO disasters (id, source, type, title, latitude, longitude, timestamp, created_at)
+						VALUES (?, 'GDACS', 1, 'Quake', 35, 139, ?, ?)`, id, now, now.Add(time.Duration(i)*time.Minute))
+				}
+			},
+			check: func(t *testing.T, db *SQLiteDB) {
+				// Backfilled CREATED events follow the order disasters were stored
+				if n := queryInt(t, db, `SELECT seq FROM disasters WHERE id = 'd2'`); n != 1 {
+					t.Errorf("expected d2 at seq 1, got %d", n)
+				}
+				if n := queryInt(t, db, `SELECT seq FROM disasters WHERE id = 'd1'`); n != 2 {
+					t.Errorf("expected d1 at seq 2, got %d", n)
+				}
+				if n := queryInt(t, db, `SELECT COUNT(*) FROM disaster_events WHERE kind = 1`); n != 2 {
+					t.Errorf("expected 2 CREATED events, got %d", n)
+				}
+			},
+		},
+		4: {
+			check: func(t *testing.T, db *SQLiteDB) {
+				if !columnExists(t, db, "disasters", "closed") || !columnExists(t, db, "disasters", "updated_at") {
+					t.Error("expected closed and updated_at columns")
+				}
+			},
+		},
+		5: {
+			seed: func(t *testing.T, db *SQLiteDB) {
+				for _, id := range []string{"sent", "unsent"} {
+					mustExec(t, db, `INSERT INTO disasters (id, source, type, title, latitude, longitude, timestamp, created_at, discord_sent)
+						VALUES (?, 'GDACS', 1, 'Quake', 35, 139, ?, ?, ?)`, id, now, now, id == "sent")
+				}
+			},
+			check: func(t *testing.T, db *SQLiteDB) {
+				if columnExists(t, db, "disasters", "discord_sent") {
+					t.Error("expected discord_sent to be dropped")
+				}
+				if n := queryInt(t, db, `SELECT COUNT(*) FROM deliveries WHERE consumer_id = 'discord' AND disaster_id = 'sent'`); n != 1 {
+					t.Error("expected the sent flag to become a discord delivery")
+				}
+				if n := queryInt(t, db, `SELECT COUNT(*) FROM deliveries`); n != 1 {
+					t.Errorf("expected 1 delivery, got %d", n)
+				}
+			},
+			down: func(t *testing.T, db *SQLiteDB) {
+				if n := queryInt(t, db, `SELECT COUNT(*) FROM disasters WHERE discord_sent AND id = 'sent'`); n != 1 {
+					t.Error("expected discord deliveries to be restored as the sent flag")
+				}
+			},
+		},
+		6: {
+			check: func(t *testing.T, db *SQLiteDB) {
+				if !columnExists(t, db, "disasters", "country_iso") || !schemaObjectExists(t, db, "idx_disasters_country_iso") {
+					t.Error("expected indexed country_iso column")
+				}
+			},
+		},
+		7: {
+			seed: func(t *testing.T, db *SQLiteDB) {
+				mustExec(t, db, `INSERT INTO disasters (id, source, type, title, latitude, longitude, timestamp, created_at)
+					VALUES ('d1', 'GDACS', 1, 'Quake', 35.5, 139.5, ?, ?)`, now, now)
+			},
+			check: func(t *testing.T, db *SQLiteDB) {
+				if n := queryInt(t, db, `SELECT COUNT(*) FROM disasters_rtree WHERE disaster_id = 'd1' AND min_lat <= 35.5 AND max_lat >= 35.5`); n != 1 {
+					t.Error("expected the existing disaster to be indexed")
+				}
+				mustExec(t, db, `UPDATE disasters SET latitude = 10 WHERE id = 'd1'`)
+				if n := queryInt(t, db, `SELECT COUNT(*) FROM disasters_rtree WHERE disaster_id = 'd1' AND min_lat <= 10 AND max_lat >= 10`); n != 1 {
+					t.Error("expected the index to follow updates")
+				}
+			},
+		},
+		8: {
+			check: func(t *testing.T, db *SQLiteDB) {
+				if !columnExists(t, db, "alerts", "geofence_id") || !schemaObjectExists(t, db, "geofences") {
+					t.Error("expected geofences table and alerts.geofence_id")
+				}
+			},
+		},
+		9: {
+			seed: func(t *testing.T, db *SQLiteDB) {
+				mustExec(t, db, `INSERT INTO disasters (id, source, type, title, latitude, longitude, timestamp, created_at, country)
+					VALUES ('d1', 'GDACS', 5, 'Eruption of Etna', 37.7, 15.0, ?, ?, 'Italy')`, now, now)
+			},
+			check: func(t *testing.T, db *SQLiteDB) {
+				if n := queryInt(t, db, `SELECT COUNT(*) FROM disasters_fts WHERE disasters_fts MATCH 'etna AND italy'`); n != 1 {
+					t.Error("expected the existing disaster to be searchable")
+				}
+			},
+		},
+		10: {
+			check: func(t *testing.T, db *SQLiteDB) {
+				if !schemaObjectExists(t, db, "idx_disasters_timestamp_id") || !schemaObjectExists(t, db, "idx_disasters_created_at_id") {
+					t.Error("expected keyset indexes")
+				}
+			},
+		},
+		11: {
+			check: func(t *testing.T, db *SQLiteDB) {
+				for _, c := range append(strings.Split(archiveColumns, ", "), "archived_at") {
+					if c != "corrections" && !columnExists(t, db, "disasters_archive", c) {
+						t.Errorf("expected archive column %s", c)
+					}
+				}
+				if columnExists(t, db, "disasters_archive", "raw") {
+					t.Error("expected the archive to drop raw payloads")
+				}
+			},
+		},
+		12: {
+			seed: func(t *testing.T, db *SQLiteDB) {
+				mustExec(t, db, `INSERT INTO disasters (id, source, type, title, latitude, longitude, timestamp, created_at)
+					VALUES ('d1', 'GDACS', 1, 'Quake', 35, 139, ?, ?)`, now, now)
+			},
+			check: func(t *testing.T, db *SQLiteDB) {
+				if !schemaObjectExists(t, db, "disaster_audit") {
+					t.Error("expected disaster_audit table")
+				}
+				if n := queryInt(t, db, `SELECT COUNT(*) FROM disasters WHERE corrections = ''`); n != 1 {
+					t.Errorf("expected existing rows to default to no corrections, got %d", n)
+				}
+				if !columnExists(t, db, "disasters_archive", "corrections") {
+					t.Error("expected the archive to keep corrections")
+				}
+			},
+		},
+		13: {
+			seed: func(t *testing.T, db *SQLiteDB) {
+				mustExec(t, db, `INSERT INTO disasters (id, source, type, title, latitude, longitude, timestamp, created_at)
+					VALUES ('d1', 'GDACS', 1, 'Quake', 35, 139, ?, ?)`, now, now)
+				mustExec(t, db, `INSERT INTO disaster_events (disaster_id, kind, created_at) VALUES ('d1', 1, ?)`, now)
+			},
+			check: func(t *testing.T, db *SQLiteDB) {
+				if n := queryInt(t, db, `SELECT COUNT(*) FROM disaster_events WHERE snapshot IS NULL`); n != 1 {
+					t.Errorf("expected existing events to have no snapshot, got %d", n)
+				}
+			},
+		},
+	}
+
+	for _, m := range migrations {
+		t.Run(fmt.Sprintf("%d %s", m.version, m.name), func(t *testing.T) {
+			step, ok := steps[m.version]
+			if !ok {
+				t.Fatalf("migration %d has no test", m.version)
+			}
+
+			db := openUnmigrated(t)
+			if m.version > 1 {
+				if err := db.MigrateUp(ctx, m.version-1); err != nil {
+					t.Fatalf("MigrateUp to %d failed: %v", m.version-1, err)
+				}
+			}
+			if step.seed != nil {
+				step.seed(t, db)
+			}
+			before := schemaSnapshot(t, db)
+
+			if err := db.MigrateUp(ctx, m.version); err != nil {
+				t.Fatalf("MigrateUp failed: %v", err)
+			}
+			if v, _ := db.SchemaVersion(ctx); v != m.version {
+				t.Fatalf("expected version %d, got %d", m.version, v)
+			}
+			step.check(t, db)
+
+			if err := db.MigrateDown(ctx, m.version-1); err != nil {
+				t.Fatalf("MigrateDown failed: %v", err)
+			}
+			if after := schemaSnapshot(t, db); !slices.Equal(before, after) {
+				t.Errorf("down did not restore the schema:\nbefore %v\nafter  %v", before, after)
+			}
+			if step.down != nil {
+				step.down(t, db)
+			}
+		})
+	}
+}
+
+// baselineSchema is the schema created before migrations were versioned
+const baselineSchema = `
+	CREATE TABLE disasters (
+		id TEXT PRIMARY KEY,
+		source TEXT NOT NULL,
+		type INTEGER NOT NULL,
+		title TEXT NOT NULL,
+		description TEXT,
+		magnitude REAL,
+		alert_level INTEGER DEFAULT 0,
+		latitude REAL NOT NULL,
+		longitude REAL NOT NULL,
+		timestamp DATETIME NOT NULL,
+		country TEXT DEFAULT '',
+		affected_population TEXT DEFAULT '',
+		affected_population_count INTEGER DEFAULT 0,
+		report_url TEXT DEFAULT '',
+		raw BLOB,
+		created_at DATETIME NOT NULL,
+		discord_sent BOOLEAN DEFAULT FALSE
+	);
+
+	CREATE TABLE alerts (
+		id TEXT PRIMARY KEY,
+		disaster_id TEXT NOT NULL,
+		severity TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		FOREIGN KEY (disaster_id) REFERENCES disasters(id)
+	);
+
+	CREATE INDEX idx_disasters_timestamp ON disasters(timestamp);
+	CREATE INDEX idx_disasters_type ON disasters(type);
+	CREATE INDEX idx_disasters_alert_level ON disasters(alert_level);
+	CREATE INDEX idx_disasters_discord_sent ON disasters(discord_sent);
+	CREATE INDEX idx_alerts_disaster_id ON alerts(disaster_id);
+`
+
+func TestMigrations_UnversionedBaseline(t *testing.T) {
+	ctx := context.Background()
+	db := openUnmigrated(t)
+	mustExec(t, db, baselineSchema)
+
+	now := time.Now()
+	for i, id := range []string{"posted", "pending"} {
+		mustExec(t, db, `INSERT INTO disasters (id, source, type, title, description, magnitude, latitude, longitude, timestamp, created_at,
+			affected_population_count, country, discord_sent)
+			VALUES (?, 'GDACS', 1, 'Earthquake in Luzon', '', 6.1, 16.4, 120.6, ?, ?, 5000, 'Philippines', ?)`,
+			id, now.Add(-time.Duration(i)*time.Hour), now.Add(time.Duration(i)*time.Minute), id == "posted")
+	}
+
+	if err := db.MigrateUp(ctx, 0); err != nil {
+		t.Fatalf("MigrateUp failed: %v", err)
+	}
+
+	d, err := db.GetByID(ctx, "pending")
+	if err != nil || d == nil || d.Seq != 2 || d.AffectedPopulationCount != 5000 {
+		t.Fatalf("expected migrated disaster with seq 2, got %+v (err %v)", d, err)
+	}
+
+	delivered := false
+	pending, err := db.ListDisasters(ctx, Filter{ConsumerID: "discord", Delivered: &delivered})
+	if err != nil || len(pending) != 1 || pending[0].ID != "pending" {
+		t.Errorf("expected only the unposted disaster to be pending for discord, got %+v (err %v)", pending, err)
+	}
+
+	minPop := int64(1000)
+	found, err := db.ListDisasters(ctx, Filter{
+		Query:                      "luzon",
+		Near:                       &geo.Circle{Lat: 16.4, Lon: 120.6, RadiusKm: 10},
+		MinAffectedPopulationCount: &minPop,
+	})
+	if err != nil || len(found) != 2 {
+		t.Errorf("expected both disasters to be indexed, got %+v (err %v)", found, err)
+	}
+
+	events, err := db.ListEvents(ctx, 0, 10)
+	if err != nil || len(events) != 2 || events[0].Disaster.ID != "posted" {
+		t.Errorf("expected CREATED events in storage order, got %+v (err %v)", events, err)
+	}
+
+	// The migrated database accepts new disasters and updates
+	d.Closed = true
+	d.UpdatedAt = now
+	if err := db.Update(ctx, d, 4); err != nil {
+		t.Errorf("Update failed: %v", err)
+	}
+}
+
+func TestMigrations_UnversionedCurrentSchema(t *testing.T) {
+	ctx := context.Background()
+	db := openUnmigrated(t)
+	if err := db.MigrateUp(ctx, 0); err != nil {
+		t.Fatalf("MigrateUp failed: %v", err)
+	}
+	want := schemaSnapshot(t, db)
+
+	// A database created by the unversioned schema already has every change
+	mustExec(t, db, `DROP TABLE schema_migrations`)
+	if err := db.MigrateUp(ctx, 0); err != nil {
+		t.Fatalf("MigrateUp over the existing schema failed: %v", err)
+	}
+	if got := schemaSnapshot(t, db); !slices.Equal(want, got) {
+		t.Errorf("expected schema to be unchanged:\nwant %v\ngot  %v", want, got)
+	}
+}
+
+func TestMigrations_FailedStepRollsBack(t *testing.T) {
+	ctx := context.Background()
+	db := openUnmigrated(t)
+	if err := db.MigrateUp(ctx, 0); err != nil {
+		t.Fatalf("MigrateUp failed: %v", err)
+	}
+
+	defer func(m []migration) { migrations = m }(migrations)
+	migrations = append(slices.Clone(migrations), migration{
+		version: LatestSchemaVersion() + 1,
+		name:    "broken",
+		up: func(ctx context.Context, tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, `CREATE TABLE half_done (id TEXT)`); err != nil {
+				return err
+			}
+			return errors.New("boom")
+		},
+	})
+
+	if err := db.MigrateUp(ctx, 0); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Fatalf("expected the broken migration to fail, got %v", err)
+	}
+	if schemaObjectExists(t, db, "half_done") {
+		t.Error("expected the failed step to be rolled back")
+	}
+	if v, _ := db.SchemaVersion(ctx); v != LatestSchemaVersion()-1 {
+		t.Errorf("expected version to stay at %d, got %d", LatestSchemaVersion()-1, v)
+	}
+}
+
+func TestMigrations_Status(t *testing.T) {
+	ctx := context.Background()
+	db := openUnmigrated(t)
+	if err := db.MigrateUp(ctx, 3); err != nil {
+		t.Fatalf("MigrateUp failed: %v", err)
+	}
+
+	status, err := db.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatalf("MigrationStatus failed: %v", err)
+	}
+	if len(status) != len(migrations) {
+		t.Fatalf("expected %d migrations, got %d", len(migrations), len(status))
+	}
+	for _, s := range status {
+		if applied := s.AppliedAt != nil; applied != (s.Version <= 3) {
+			t.Errorf("migration %d: applied = %v", s.Version, applied)
+		}
+	}
+
+	if err := db.MigrateUp(ctx, LatestSchemaVersion()+1); err == nil {
+		t.Error("expected an error migrating to an unknown version")
+	}
+}
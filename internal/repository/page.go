@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+)
+
+// ErrInvalidPageToken is returned by ListDisasters for a page token that is malformed or was issued
+// for a different ordering, e.g. a relevance-ranked search.
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// pageToken is the keyset position of a disaster in ListDisasters results: the stored time and
+// ID that break ties, preceded by the primary sort key when results aren't ordered by time.
+type pageToken struct {
+	Sort      string   `json:"s"` // ordering the token was issued for
+	Key       *float64 `json:"k,omitempty"`
+	Timestamp string   `json:"t"` // stored text of the time column, compared exactly as ORDER BY does
+	ID        string   `json:"id"`
+}
+
+func (p pageToken) encode() string {
+	b, _ := json.Marshal(p)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodePageToken(token string) (*pageToken, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	var p pageToken
+	if err := json.Unmarshal(b, &p); err != nil || p.Timestamp == "" || p.ID == "" {
+		return nil, ErrInvalidPageToken
+	}
+	return &p, nil
+}
+
+// NextPage trims results fetched with a limit of limit+1 back to limit and returns the token
+// of the page after them, or an empty token when there is no further page.
+func NextPage(disasters []models.Disaster, limit int) ([]models.Disaster, string) {
+	if limit <= 0 || len(disasters) <= limit {
+		return disasters, ""
+	}
+	disasters = disasters[:limit]
+	return disasters, disasters[limit-1].PageToken
+}
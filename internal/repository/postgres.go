@@ -0,0 +1,727 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+)
+
+// PostgresDB stores disasters in PostgreSQL, with PostGIS geography points for spatial filters
+// and a weighted tsvector column for full-text search. Unlike SQLiteDB it can be shared by
+// several replicas of the service.
+type PostgresDB struct {
+	db *sql.DB
+}
+
+// NewPostgresDB connects to the database at dsn and migrates it to the latest schema version.
+// The PostGIS and unaccent extensions must be available to the server.
+func NewPostgresDB(dsn string) (*PostgresDB, error) {
+	p, err := OpenPostgresDB(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.MigrateUp(context.Background(), 0); err != nil {
+		p.Close()
+		return nil, fmt.Errorf("error while migrating database: %w", err)
+	}
+	return p, nil
+}
+
+// OpenPostgresDB connects to the database at dsn without migrating it, for managing migrations.
+func OpenPostgresDB(dsn string) (*PostgresDB, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error while pinging database: %w", err)
+	}
+	return &PostgresDB{db: db}, nil
+}
+
+// Disaster methods
+
+// Add stores d with a CREATED event and sets d.Seq to the event's sequence number.
+func (p *PostgresDB) Add(ctx context.Context, d *models.Disaster) error {
+	return p.AddBatch(ctx, []*models.Disaster{d})
+}
+
+// AddBatch stores every disaster in ds with its CREATED event in a single transaction, reusing
+// prepared statements, and sets each Seq. Nothing is stored if any insert fails.
+func (p *PostgresDB) AddBatch(ctx context.Context, ds []*models.Disaster) error {
+	return p.addBatch(ctx, ds, nil)
+}
+
+// addBatch is AddBatch, running also (if set) in the same transaction before commit
+func (p *PostgresDB) addBatch(ctx context.Context, ds []*models.Disaster, also func(tx *sql.Tx) error) error {
+	if len(ds) == 0 {
+		return nil
+	}
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// See recordPostgresEvent; taken once for the whole batch
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, eventLogLockID); err != nil {
+		return err
+	}
+	insert, err := tx.PrepareContext(ctx, `
+		INSERT INTO disasters (id, source, type, title, description, magnitude, alert_level, latitude, longitude, timestamp, country, affected_population, affected_population_count, report_url, raw, created_at, closed, country_iso, corrections)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+	`)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	event, err := tx.PrepareContext(ctx, `INSERT INTO disaster_events (disaster_id, kind, created_at, snapshot) VALUES ($1, $2, $3, $4) RETURNING seq`)
+	if err != nil {
+		return err
+	}
+	defer event.Close()
+	setSeq, err := tx.PrepareContext(ctx, `UPDATE disasters SET seq = $1 WHERE id = $2`)
+	if err != nil {
+		return err
+	}
+	defer setSeq.Close()
+
+	seqs := make([]int64, len(ds))
+	for i, d := range ds {
+		_, err := insert.ExecContext(ctx,
+			d.ID, d.Source, int32(d.Type), d.Title, d.Description,
+			d.Magnitude, int32(d.AlertLevel), d.Latitude, d.Longitude, d.Timestamp,
+			d.Country, d.AffectedPopulation, d.AffectedPopulationCount, d.ReportURL, d.Raw, d.CreatedAt, d.Closed, d.CountryISO,
+			strings.Join(d.Corrections, ","),
+		)
+		if err != nil {
+			return fmt.Errorf("error adding disaster %s: %w", d.ID, err)
+		}
+		snapshot, err := eventSnapshot(d)
+		if err != nil {
+			return err
+		}
+		if err := event.QueryRowContext(ctx, d.ID, int32(disastersv1.EventKind_EVENT_KIND_CREATED), d.CreatedAt, snapshot).Scan(&seqs[i]); err != nil {
+			return err
+		}
+		if _, err := setSeq.ExecContext(ctx, seqs[i], d.ID); err != nil {
+			return err
+		}
+	}
+	if also != nil {
+		if err := also(tx); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for i, d := range ds {
+		d.Seq = seqs[i]
+	}
+	return nil
+}
+
+// Update stores the current fields of an existing disaster with an event of the given kind
+// and sets d.Seq to the event's sequence number. CreatedAt is never changed.
+func (p *PostgresDB) Update(ctx context.Context, d *models.Disaster, kind disastersv1.EventKind) error {
+	return p.update(ctx, d, kind, false, nil)
+}
+
+// UpdateIfUnchanged stores d like Update if d.Seq is still the stored seq.
+func (p *PostgresDB) UpdateIfUnchanged(ctx context.Context, d *models.Disaster, kind disastersv1.EventKind) error {
+	return p.update(ctx, d, kind, true, nil)
+}
+
+// update is Update, checking d.Seq first if checkSeq is set and storing audit if not nil.
+func (p *PostgresDB) update(ctx context.Context, d *models.Disaster, kind disastersv1.EventKind, checkSeq bool, audit *models.AuditEntry) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE disasters SET source = $1, type = $2, title = $3, description = $4, magnitude = $5, alert_level = $6, latitude = $7, longitude = $8, timestamp = $9,
+			country = $10, affected_population = $11, affected_population_count = $12, report_url = $13, raw = $14, closed = $15, updated_at = $16, country_iso = $17,
+			corrections = $18
+		WHERE id = $19
+	`
+	args := []any{
+		d.Source, int32(d.Type), d.Title, d.Description, d.Magnitude, int32(d.AlertLevel), d.Latitude, d.Longitude, d.Timestamp,
+		d.Country, d.AffectedPopulation, d.AffectedPopulationCount, d.ReportURL, d.Raw, d.Closed, d.UpdatedAt, d.CountryISO,
+		strings.Join(d.Corrections, ","),
+		d.ID,
+	}
+	if checkSeq {
+		query += ` AND seq = $20`
+		args = append(args, d.Seq)
+	}
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return notUpdated(ctx, tx, rebind, d.ID)
+	}
+
+	seq, err := recordPostgresEvent(ctx, tx, d, kind, d.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if audit != nil {
+		if err := insertAuditEntry(ctx, tx, rebind, audit); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	d.Seq = seq
+	return nil
+}
+
+// eventLogLockID is the advisory lock key serializing writes to the event log
+const eventLogLockID = 7_042_031
+
+// recordPostgresEvent appends to the event log and points the disaster at the new sequence number.
+// Sequence numbers are handed out before commit, so concurrent writers could commit them out of
+// order and a reader replaying from a sequence number would skip the late one. Holding a lock
+// until commit keeps commit order and sequence order the same across replicas.
+func recordPostgresEvent(ctx context.Context, tx *sql.Tx, d *models.Disaster, kind disastersv1.EventKind, at time.Time) (int64, error) {
+	snapshot, err := eventSnapshot(d)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, eventLogLockID); err != nil {
+		return 0, err
+	}
+
+	var seq int64
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO disaster_events (disaster_id, kind, created_at, snapshot) VALUES ($1, $2, $3, $4) RETURNING seq`,
+		d.ID, int32(kind), at, snapshot,
+	).Scan(&seq)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE disasters SET seq = $1 WHERE id = $2`, seq, d.ID)
+	return seq, err
+}
+
+func (p *PostgresDB) GetByID(ctx context.Context, id string) (*models.Disaster, error) {
+	query := `SELECT ` + disasterColumns + ` FROM disasters WHERE id = $1`
+
+	d, err := scanDisaster(p.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (p *PostgresDB) Exists(ctx context.Context, id string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM disasters WHERE id = $1)`
+	err := p.db.QueryRowContext(ctx, query, id).Scan(&exists)
+	return exists, err
+}
+
+// ListDisasters returns disasters matching opts in the order opts.Sort and opts.Order select, newest first
+// by default. With a Near filter each result's DistanceKm is set to its great-circle distance from the center.
+//
+// The query is built with ? placeholders, shared with SQLiteDB's ordering and page tokens, and
+// numbered for Postgres before it runs.
+func (p *PostgresDB) ListDisasters(ctx context.Context, opts Filter) ([]models.Disaster, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	var after *pageToken
+	if opts.PageToken != "" {
+		var err error
+		if after, err = decodePageToken(opts.PageToken); err != nil {
+			return nil, err
+		}
+	}
+
+	match := tsQuery(opts.Query)
+	if match == "" {
+		opts.Query = "" // nothing searchable, e.g. only punctuation
+	}
+	order := newListOrder(opts, pgHaversineSQL, tsRankSQL)
+
+	columns := disasterColumns + ", CAST(" + order.timeColumn + " AS TEXT)"
+	var conditions []string
+	args := []any{}
+
+	if opts.Near != nil {
+		columns += ", " + pgHaversineSQL + " AS distance_km"
+		args = append(args, opts.Near.Lat, opts.Near.Lat, opts.Near.Lon)
+	}
+	if match != "" {
+		columns += ", " + tsHeadlineSQL
+	}
+	if order.key != "" {
+		columns += ", " + order.key + " AS sort_key"
+		args = append(args, order.keyArgs...)
+	}
+	query := `SELECT ` + columns + ` FROM disasters`
+	if match != "" {
+		query += ` CROSS JOIN to_tsquery('simple', disasters_unaccent(?)) AS fts_query`
+		args = append(args, match)
+		conditions = append(conditions, "search @@ fts_query")
+	}
+
+	filterConditions, filterArgs := postgresFilterConditions(opts, order.timeColumn)
+	conditions = append(conditions, filterConditions...)
+	args = append(args, filterArgs...)
+
+	if after != nil {
+		cond, condArgs, err := order.after(after)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, cond)
+		args = append(args, condArgs...)
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query += " ORDER BY " + order.clause()
+
+	if opts.Area != nil {
+		disasters, err := queryDisasters(ctx, p.db, opts, order, rebind(query), args...)
+		if err != nil {
+			return nil, err
+		}
+		return paginate(withinArea(disasters, opts.Area), opts.Limit, opts.Offset), nil
+	}
+
+	if opts.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, opts.Limit)
+	}
+	if opts.Offset > 0 {
+		query += ` OFFSET ?`
+		args = append(args, opts.Offset)
+	}
+
+	return queryDisasters(ctx, p.db, opts, order, rebind(query), args...)
+}
+
+// postgresFilterConditions returns the conditions selecting the disasters opts matches, apart from its
+// query and page token. An Area is only prefiltered on its bounds; callers check points in Go.
+func postgresFilterConditions(opts Filter, timeColumn string) ([]string, []any) {
+	var conditions []string
+	args := []any{}
+
+	if opts.Type != nil {
+		conditions = append(conditions, "type = ?")
+		args = append(args, int32(*opts.Type))
+	}
+	if opts.Since != nil {
+		conditions = append(conditions, timeColumn+" >= ?")
+		args = append(args, *opts.Since)
+	}
+	if opts.Until != nil {
+		conditions = append(conditions, timeColumn+" < ?")
+		args = append(args, *opts.Until)
+	}
+	if opts.MinMagnitude != nil {
+		conditions = append(conditions, "magnitude >= ?")
+		args = append(args, *opts.MinMagnitude)
+	}
+	if opts.MaxMagnitude != nil {
+		conditions = append(conditions, "magnitude <= ?")
+		args = append(args, *opts.MaxMagnitude)
+	}
+	if opts.AlertLevel != nil {
+		conditions = append(conditions, "alert_level = ?")
+		args = append(args, int32(*opts.AlertLevel))
+	}
+	if opts.MinAlertLevel != nil {
+		conditions = append(conditions, "alert_level >= ?")
+		args = append(args, int32(*opts.MinAlertLevel))
+	}
+	if opts.Delivered != nil {
+		delivered := "EXISTS (SELECT 1 FROM deliveries dl WHERE dl.disaster_id = disasters.id AND dl.consumer_id = ?)"
+		if !*opts.Delivered {
+			delivered = "NOT " + delivered
+		}
+		conditions = append(conditions, delivered)
+		args = append(args, opts.ConsumerID)
+	}
+	if opts.MinAffectedPopulationCount != nil {
+		conditions = append(conditions, "affected_population_count >= ?")
+		args = append(args, *opts.MinAffectedPopulationCount)
+	}
+	if len(opts.Types) > 0 {
+		conditions = append(conditions, "type IN ("+placeholderList(len(opts.Types))+")")
+		for _, t := range opts.Types {
+			args = append(args, int32(t))
+		}
+	}
+	if len(opts.Countries) > 0 {
+		conditions = append(conditions, "country_iso IN ("+placeholderList(len(opts.Countries))+")")
+		for _, c := range opts.Countries {
+			args = append(args, strings.ToUpper(c))
+		}
+	}
+	if len(opts.Sources) > 0 {
+		conditions = append(conditions, "lower(source) IN ("+placeholderList(len(opts.Sources))+")")
+		for _, src := range opts.Sources {
+			args = append(args, strings.ToLower(src))
+		}
+	}
+	if opts.BBox != nil {
+		b := opts.BBox
+		cond, condArgs := envelopeCondition(*b)
+		conditions = append(conditions, cond)
+		args = append(args, condArgs...)
+
+		// Exact bounds: the index stores 32-bit floats rounded outward, so it can over-match
+		conditions = append(conditions, "latitude BETWEEN ? AND ?")
+		args = append(args, b.MinLat, b.MaxLat)
+		if b.CrossesAntimeridian() {
+			conditions = append(conditions, "(longitude >= ? OR longitude <= ?)")
+		} else {
+			conditions = append(conditions, "longitude BETWEEN ? AND ?")
+		}
+		args = append(args, b.MinLon, b.MaxLon)
+	}
+	if opts.Near != nil {
+		// Geography index prefilter, then the exact great-circle distance. PostGIS's sphere is
+		// slightly smaller than geo.EarthRadiusKm, so the prefilter gets a metre of slack.
+		conditions = append(conditions, "ST_DWithin(location, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography, ?, false)")
+		args = append(args, opts.Near.Lon, opts.Near.Lat, opts.Near.RadiusKm*1000+1)
+
+		conditions = append(conditions, pgHaversineSQL+" <= ?")
+		args = append(args, opts.Near.Lat, opts.Near.Lat, opts.Near.Lon, opts.Near.RadiusKm)
+	}
+
+	if opts.Area != nil {
+		// Prefilter on the polygons' bounds here and check points in Go, so polygon edges
+		// are treated exactly as geo.MultiPolygon.Contains treats them
+		cond, condArgs := envelopeCondition(opts.Area.Bounds()...)
+		conditions = append(conditions, cond)
+		args = append(args, condArgs...)
+	}
+	return conditions, args
+}
+
+// pgHaversineSQL is haversineSQL for Postgres, where min is only an aggregate
+var pgHaversineSQL = fmt.Sprintf(`(2 * %f * asin(sqrt(least(1,
+	power(sin(radians(latitude - ?) / 2), 2) +
+	cos(radians(?)) * cos(radians(latitude)) * power(sin(radians(longitude - ?) / 2), 2)))))`, geo.EarthRadiusKm)
+
+// tsRankSQL ranks full-text matches, negated so that lower is more relevant as with BM25.
+// The tsvector weights title over country over description.
+const tsRankSQL = `(-ts_rank(search, fts_query))::float8`
+
+// tsHeadlineSQL highlights the best matching fragment of the text fields as the snippet
+const tsHeadlineSQL = `ts_headline('simple', title || ' ' || description || ' ' || country, fts_query,
+	'StartSel=<mark>, StopSel=</mark>, MaxWords=12, MinWords=4')`
+
+// tsQuery turns free text into a to_tsquery expression: every term quoted, so operators and
+// punctuation in user input are matched literally, and ANDed. Empty when the text has no terms.
+func tsQuery(q string) string {
+	terms := SearchTerms(q)
+	for i, t := range terms {
+		prefix := strings.HasSuffix(t, "*")
+		t = strings.ReplaceAll(strings.TrimRight(t, "*"), `\`, `\\`)
+		terms[i] = `'` + strings.ReplaceAll(t, `'`, `''`) + `'`
+		if prefix {
+			terms[i] += ":*"
+		}
+	}
+	return strings.Join(terms, " & ")
+}
+
+// envelopeCondition limits disasters to points whose indexed geometry intersects any of the boxes.
+// Boxes crossing the antimeridian are queried as two halves, since envelopes cannot wrap.
+func envelopeCondition(boxes ...geo.BBox) (string, []any) {
+	var parts []string
+	var args []any
+	for _, b := range boxes {
+		for _, part := range b.Unwrapped() {
+			parts = append(parts, "location::geometry && ST_MakeEnvelope(?, ?, ?, ?, 4326)")
+			args = append(args, part.MinLon, part.MinLat, part.MaxLon, part.MaxLat)
+		}
+	}
+	return "(" + strings.Join(parts, " OR ") + ")", args
+}
+
+// rebind numbers ? placeholders as $1, $2, ... for Postgres
+func rebind(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		n++
+		b.WriteString("$" + strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+func (p *PostgresDB) ListEvents(ctx context.Context, afterSeq int64, limit int) ([]models.DisasterEvent, error) {
+	query := `SELECT ` + qualifiedDisasterColumns("d") + `, e.seq, e.kind, e.created_at, e.snapshot
+		FROM disaster_events e JOIN disasters d ON d.id = e.disaster_id
+		WHERE e.seq > $1 ORDER BY e.seq ASC`
+	args := []any{afterSeq}
+	if limit > 0 {
+		query += ` LIMIT $2`
+		args = append(args, limit)
+	}
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.DisasterEvent
+	for rows.Next() {
+		ev, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+
+	return events, rows.Err()
+}
+
+// MarkAsSent records that consumerID has delivered the given disasters. Unknown and already
+// acknowledged IDs are ignored, so the count only includes new acknowledgments.
+func (p *PostgresDB) MarkAsSent(ctx context.Context, consumerID string, ids []string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	args := []any{consumerID, time.Now()}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO deliveries (consumer_id, disaster_id, acked_at)
+		SELECT ?::text, id, ?::timestamptz FROM disasters WHERE id IN (%s)
+		ON CONFLICT DO NOTHING
+	`, placeholderList(len(ids)))
+	result, err := p.db.ExecContext(ctx, rebind(query), args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// Stats aggregates the disasters opts matches, ignoring its paging and sort fields
+func (p *PostgresDB) Stats(ctx context.Context, opts Filter, bucket TimeBucket) (*Stats, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	timeColumn := filterTimeColumn(opts)
+	unit := "day"
+	switch bucket {
+	case BucketWeek:
+		unit = "week" // ISO weeks, like BucketWeek
+	case BucketMonth:
+		unit = "month"
+	}
+	columns := "type, alert_level, COALESCE(country_iso, ''), source, to_char(date_trunc('" + unit + "', " + timeColumn + " AT TIME ZONE 'UTC'), 'YYYY-MM-DD')"
+	groupBy := "1, 2, 3, 4, 5"
+	if opts.Area != nil {
+		// Points are checked in Go, so group each location apart
+		columns += ", latitude, longitude"
+		groupBy += ", 6, 7"
+	}
+	from, args := postgresFilteredFrom(opts, timeColumn)
+	query := `SELECT ` + columns + `, COUNT(*), COALESCE(MAX(magnitude), 0), COALESCE(SUM(affected_population_count), 0)::bigint` + from + " GROUP BY " + groupBy
+
+	return queryStats(ctx, p.db, opts.Area, bucket, rebind(query), args...)
+}
+
+func (p *PostgresDB) Heatmap(ctx context.Context, opts Filter, grid geo.Grid, weight HeatmapWeight) ([]HeatmapCell, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	from, args := postgresFilteredFrom(opts, filterTimeColumn(opts))
+	query := `SELECT latitude, longitude, alert_level, affected_population_count` + from
+	return queryHeatmap(ctx, p.db, opts.Area, grid, weight, rebind(query), args...)
+}
+
+// postgresFilteredFrom returns the FROM and WHERE clauses selecting the disasters opts matches,
+// ignoring paging and sorting. Placeholders are ? for rebind.
+func postgresFilteredFrom(opts Filter, timeColumn string) (string, []any) {
+	from := " FROM disasters"
+	args := []any{}
+	var conditions []string
+	if match := tsQuery(opts.Query); match != "" {
+		from += ` CROSS JOIN to_tsquery('simple', disasters_unaccent(?)) AS fts_query`
+		args = append(args, match)
+		conditions = append(conditions, "search @@ fts_query")
+	}
+	filterConditions, filterArgs := postgresFilterConditions(opts, timeColumn)
+	conditions = append(conditions, filterConditions...)
+	args = append(args, filterArgs...)
+	if len(conditions) > 0 {
+		from += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	return from, args
+}
+
+// Alert methods
+
+func (p *PostgresDB) AddAlert(ctx context.Context, a *models.Alert) error {
+	query := `INSERT INTO alerts (` + alertColumns + `) VALUES ($1, $2, $3, $4, $5)`
+	_, err := p.db.ExecContext(ctx, query, a.ID, a.DisasterID, a.GeofenceID, a.Severity, a.CreatedAt)
+	return err
+}
+
+func (p *PostgresDB) GetByDisasterID(ctx context.Context, disasterID string) ([]models.Alert, error) {
+	query := `SELECT ` + alertColumns + ` FROM alerts WHERE disaster_id = $1`
+	return queryAlerts(ctx, p.db, query, disasterID)
+}
+
+func (p *PostgresDB) ListAlerts(ctx context.Context, opts Filter) ([]models.Alert, error) {
+	query := `SELECT ` + alertColumns + ` FROM alerts`
+	var conditions []string
+	args := []any{}
+
+	if opts.Since != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, *opts.Since)
+	}
+	if opts.GeofenceID != "" {
+		conditions = append(conditions, "geofence_id = ?")
+		args = append(args, opts.GeofenceID)
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query += ` ORDER BY created_at DESC`
+
+	if opts.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, opts.Limit)
+	}
+	if opts.Offset > 0 {
+		query += ` OFFSET ?`
+		args = append(args, opts.Offset)
+	}
+
+	return queryAlerts(ctx, p.db, rebind(query), args...)
+}
+
+// Geofence methods
+
+func (p *PostgresDB) AddGeofence(ctx context.Context, g *models.Geofence) error {
+	var area sql.NullString
+	if g.Area != nil {
+		b, err := json.Marshal(g.Area)
+		if err != nil {
+			return fmt.Errorf("error encoding geofence area: %w", err)
+		}
+		area = sql.NullString{String: string(b), Valid: true}
+	}
+	var lat, lon, radius sql.NullFloat64
+	if g.Circle != nil {
+		lat = sql.NullFloat64{Float64: g.Circle.Lat, Valid: true}
+		lon = sql.NullFloat64{Float64: g.Circle.Lon, Valid: true}
+		radius = sql.NullFloat64{Float64: g.Circle.RadiusKm, Valid: true}
+	}
+	criteria, err := json.Marshal(g.Criteria)
+	if err != nil {
+		return fmt.Errorf("error encoding geofence criteria: %w", err)
+	}
+
+	query := `INSERT INTO geofences (` + geofenceColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	_, err = p.db.ExecContext(ctx, query, g.ID, g.Name, g.Owner, area, lat, lon, radius, string(criteria), g.CreatedAt)
+	return err
+}
+
+func (p *PostgresDB) GetGeofence(ctx context.Context, id string) (*models.Geofence, error) {
+	geofences, err := queryGeofences(ctx, p.db, `SELECT `+geofenceColumns+` FROM geofences WHERE id = $1`, id)
+	if err != nil || len(geofences) == 0 {
+		return nil, err
+	}
+	return &geofences[0], nil
+}
+
+func (p *PostgresDB) ListGeofences(ctx context.Context, owner string) ([]models.Geofence, error) {
+	if owner == "" {
+		return queryGeofences(ctx, p.db, `SELECT `+geofenceColumns+` FROM geofences ORDER BY created_at`)
+	}
+	return queryGeofences(ctx, p.db, `SELECT `+geofenceColumns+` FROM geofences WHERE owner = $1 ORDER BY created_at`, owner)
+}
+
+func (p *PostgresDB) DeleteGeofence(ctx context.Context, id string) error {
+	result, err := p.db.ExecContext(ctx, `DELETE FROM geofences WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Audit methods
+
+func (p *PostgresDB) AddAuditEntry(ctx context.Context, e *models.AuditEntry) error {
+	return insertAuditEntry(ctx, p.db, rebind, e)
+}
+
+// AddAudited stores d like Add and e in the same transaction, setting d.Seq and e.ID.
+func (p *PostgresDB) AddAudited(ctx context.Context, d *models.Disaster, e *models.AuditEntry) error {
+	return p.addBatch(ctx, []*models.Disaster{d}, func(tx *sql.Tx) error {
+		return insertAuditEntry(ctx, tx, rebind, e)
+	})
+}
+
+// UpdateAudited stores d like Update and e in the same transaction, if d.Seq is still the stored seq.
+func (p *PostgresDB) UpdateAudited(ctx context.Context, d *models.Disaster, kind disastersv1.EventKind, e *models.AuditEntry) error {
+	return p.update(ctx, d, kind, true, e)
+}
+
+func (p *PostgresDB) ListAuditEntries(ctx context.Context, disasterID string) ([]models.AuditEntry, error) {
+	query := `SELECT ` + auditColumns + ` FROM disaster_audit WHERE disaster_id = $1 ORDER BY id`
+	return queryAuditEntries(ctx, p.db, query, disasterID)
+}
+
+// Retention methods
+
+func (p *PostgresDB) ApplyRetention(ctx context.Context, rules []RetentionRule, now time.Time, dryRun bool) (*RetentionReport, error) {
+	return applyRetention(ctx, p.db, rebind, rules, now, dryRun)
+}
+
+func (p *PostgresDB) Close() error {
+	return p.db.Close()
+}
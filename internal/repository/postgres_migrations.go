@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// postgresMigrations are the Postgres schema versions, numbered independently of SQLite's since
+// the Postgres schema started from SQLite's latest.
+var postgresMigrations = []migration{
+	{
+		version: 1,
+		name:    "create schema",
+		up: func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `
+				CREATE EXTENSION IF NOT EXISTS postgis;
+				CREATE EXTENSION IF NOT EXISTS unaccent;
+			`)
+			if err != nil {
+				return err
+			}
+
+			// unaccent is only STABLE, since its dictionary could change, so generated columns need an
+			// IMMUTABLE wrapper. Qualifying the extension's schema keeps it independent of search_path.
+			var schema string
+			err = tx.QueryRowContext(ctx, `
+				SELECT quote_ident(n.nspname) FROM pg_extension e JOIN pg_namespace n ON n.oid = e.extnamespace
+				WHERE e.extname = 'unaccent'
+			`).Scan(&schema)
+			if err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+				CREATE FUNCTION disasters_unaccent(text) RETURNS text
+				LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+				AS $$ SELECT %[1]s.unaccent('%[1]s.unaccent'::regdictionary, $1) $$;
+			`, schema))
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx, `
+				CREATE TABLE disasters (
+					id TEXT PRIMARY KEY,
+					source TEXT NOT NULL,
+					type INTEGER NOT NULL,
+					title TEXT NOT NULL,
+					description TEXT NOT NULL DEFAULT '',
+					magnitude DOUBLE PRECISION NOT NULL DEFAULT 0,
+					alert_level INTEGER NOT NULL DEFAULT 0,
+					latitude DOUBLE PRECISION NOT NULL,
+					longitude DOUBLE PRECISION NOT NULL,
+					location GEOGRAPHY(Point, 4326) GENERATED ALWAYS AS
+						(ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)::geography) STORED,
+					timestamp TIMESTAMPTZ NOT NULL,
+					country TEXT NOT NULL DEFAULT '',
+					country_iso TEXT NOT NULL DEFAULT '',
+					affected_population TEXT NOT NULL DEFAULT '',
+					affected_population_count BIGINT NOT NULL DEFAULT 0,
+					report_url TEXT NOT NULL DEFAULT '',
+					raw BYTEA,
+					created_at TIMESTAMPTZ NOT NULL,
+					seq BIGINT NOT NULL DEFAULT 0,
+					closed BOOLEAN NOT NULL DEFAULT FALSE,
+					updated_at TIMESTAMPTZ,
+					search TSVECTOR GENERATED ALWAYS AS (
+						setweight(to_tsvector('simple', disasters_unaccent(title)), 'A') ||
+						setweight(to_tsvector('simple', disasters_unaccent(country)), 'B') ||
+						setweight(to_tsvector('simple', disasters_unaccent(description)), 'C')
+					) STORED
+				);
+
+				-- location serves radius filters; its planar geometry serves boxes and polygon bounds
+				CREATE INDEX idx_disasters_location ON disasters USING GIST (location);
+				CREATE INDEX idx_disasters_location_geometry ON disasters USING GIST ((location::geometry));
+				CREATE INDEX idx_disasters_search ON disasters USING GIN (search);
+				CREATE INDEX idx_disasters_timestamp_id ON disasters(timestamp, id);
+				CREATE INDEX idx_disasters_created_at_id ON disasters(created_at, id);
+				CREATE INDEX idx_disasters_type ON disasters(type);
+				CREATE INDEX idx_disasters_alert_level ON disasters(alert_level);
+				CREATE INDEX idx_disasters_country_iso ON disasters(country_iso);
+				CREATE INDEX idx_disasters_seq ON disasters(seq);
+
+				CREATE TABLE disaster_events (
+					seq BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+					disaster_id TEXT NOT NULL REFERENCES disasters(id),
+					kind INTEGER NOT NULL,
+					created_at TIMESTAMPTZ NOT NULL
+				);
+
+				CREATE INDEX idx_disaster_events_disaster_id ON disaster_events(disaster_id);
+
+				CREATE TABLE deliveries (
+					consumer_id TEXT NOT NULL,
+					disaster_id TEXT NOT NULL REFERENCES disasters(id),
+					acked_at TIMESTAMPTZ NOT NULL,
+					PRIMARY KEY (consumer_id, disaster_id)
+				);
+
+				CREATE INDEX idx_deliveries_disaster_id ON deliveries(disaster_id);
+
+				CREATE TABLE alerts (
+					id TEXT PRIMARY KEY,
+					disaster_id TEXT NOT NULL REFERENCES disasters(id),
+					geofence_id TEXT NOT NULL DEFAULT '',
+					severity TEXT NOT NULL,
+					created_at TIMESTAMPTZ NOT NULL
+				);
+
+				CREATE INDEX idx_alerts_disaster_id ON alerts(disaster_id);
+				CREATE INDEX idx_alerts_geofence_id ON alerts(geofence_id);
+
+				CREATE TABLE geofences (
+					id TEXT PRIMARY KEY,
+					name TEXT NOT NULL,
+					owner TEXT NOT NULL,
+					area JSONB,                   -- MultiPolygon coordinates, NULL for circles
+					center_lat DOUBLE PRECISION,  -- circle center and radius, NULL for polygons
+					center_lon DOUBLE PRECISION,
+					radius_km DOUBLE PRECISION,
+					criteria JSONB NOT NULL DEFAULT '{}',
+					created_at TIMESTAMPTZ NOT NULL
+				);
+
+				CREATE INDEX idx_geofences_owner ON geofences(owner);
+			`)
+			return err
+		},
+		// The extensions are left installed, since other schemas may use them
+		down: execSQL(`
+			DROP TABLE geofences;
+			DROP TABLE alerts;
+			DROP TABLE deliveries;
+			DROP TABLE disaster_events;
+			DROP TABLE disasters;
+			DROP FUNCTION disasters_unaccent(text);
+		`),
+	},
+	{
+		version: 2,
+		name:    "add disasters archive",
+		up: execSQL(`
+			CREATE TABLE disasters_archive (
+				id TEXT PRIMARY KEY,
+				source TEXT NOT NULL,
+				type INTEGER NOT NULL,
+				title TEXT NOT NULL,
+				description TEXT NOT NULL DEFAULT '',
+				magnitude DOUBLE PRECISION NOT NULL DEFAULT 0,
+				alert_level INTEGER NOT NULL DEFAULT 0,
+				latitude DOUBLE PRECISION NOT NULL,
+				longitude DOUBLE PRECISION NOT NULL,
+				timestamp TIMESTAMPTZ NOT NULL,
+				country TEXT NOT NULL DEFAULT '',
+				country_iso TEXT NOT NULL DEFAULT '',
+				affected_population TEXT NOT NULL DEFAULT '',
+				affected_population_count BIGINT NOT NULL DEFAULT 0,
+				report_url TEXT NOT NULL DEFAULT '',
+				created_at TIMESTAMPTZ NOT NULL,
+				closed BOOLEAN NOT NULL DEFAULT FALSE,
+				updated_at TIMESTAMPTZ,
+				archived_at TIMESTAMPTZ NOT NULL
+			);
+		`),
+		down: execSQL(`DROP TABLE disasters_archive`),
+	},
+	{
+		version: 3,
+		name:    "add operator corrections and audit log",
+		up: execSQL(`
+			ALTER TABLE disasters ADD COLUMN corrections TEXT NOT NULL DEFAULT '';
+			ALTER TABLE disasters_archive ADD COLUMN corrections TEXT NOT NULL DEFAULT '';
+			CREATE TABLE disaster_audit (
+				id BIGSERIAL PRIMARY KEY,
+				disaster_id TEXT NOT NULL,
+				action TEXT NOT NULL,
+				actor TEXT NOT NULL,
+				changes JSONB NOT NULL,
+				created_at TIMESTAMPTZ NOT NULL
+			);
+			CREATE INDEX idx_disaster_audit_disaster_id ON disaster_audit(disaster_id);
+		`),
+		down: execSQL(`
+			DROP TABLE disaster_audit;
+			ALTER TABLE disasters_archive DROP COLUMN corrections;
+			ALTER TABLE disasters DROP COLUMN corrections;
+		`),
+	},
+	{
+		version: 4,
+		name:    "add event snapshots",
+		up:      execSQL(`ALTER TABLE disaster_events ADD COLUMN snapshot JSONB`),
+		down:    execSQL(`ALTER TABLE disaster_events DROP COLUMN snapshot`),
+	},
+}
+
+// migrationLockID is the advisory lock key held while a replica applies a migration
+const migrationLockID = 7_042_030
+
+// SchemaVersion returns the highest applied migration, 0 for an empty database
+func (p *PostgresDB) SchemaVersion(ctx context.Context) (int, error) {
+	return p.migrator().version(ctx)
+}
+
+func (p *PostgresDB) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	return p.migrator().status(ctx)
+}
+
+// MigrateUp applies pending migrations up to and including target, or all of them when target is 0
+func (p *PostgresDB) MigrateUp(ctx context.Context, target int) error {
+	return p.migrator().up(ctx, target)
+}
+
+// MigrateDown reverts applied migrations until the database is at version target
+func (p *PostgresDB) MigrateDown(ctx context.Context, target int) error {
+	return p.migrator().down(ctx, target)
+}
+
+func (p *PostgresDB) migrator() migrator {
+	return migrator{
+		db:    p.db,
+		steps: postgresMigrations,
+		ddl: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL
+		)`,
+		bind: rebind,
+		lock: fmt.Sprintf(`SELECT pg_advisory_xact_lock(%d)`, migrationLockID),
+	}
+}
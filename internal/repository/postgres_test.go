@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+)
+
+// postgresTestDSN returns POSTGRES_TEST_DSN. Without it the test is skipped, except in CI, where a
+// missing server must not pass as a skipped backend.
+func postgresTestDSN(t *testing.T) string {
+	t.Helper()
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		if os.Getenv("CI") != "" {
+			t.Fatal("POSTGRES_TEST_DSN must be set in CI")
+		}
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+	return dsn
+}
+
+// setupPostgresDB migrates a schema of its own on the server at dsn, which needs the PostGIS and
+// unaccent extensions available, and drops it when the test ends
+func setupPostgresDB(t *testing.T, dsn string) *PostgresDB {
+	t.Helper()
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("failed to connect to postgres: %v", err)
+	}
+	defer admin.Close()
+
+	b := make([]byte, 6)
+	rand.Read(b)
+	schema := "test_" + hex.EncodeToString(b)
+	// Extensions go in the default schema, where the test schema's search path finds them
+	_, err = admin.Exec(`
+		CREATE EXTENSION IF NOT EXISTS postgis;
+		CREATE EXTENSION IF NOT EXISTS unaccent;
+		CREATE SCHEMA ` + schema)
+	if err != nil {
+		t.Fatalf("failed to create test schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin, err := sql.Open("pgx", dsn)
+		if err != nil {
+			return
+		}
+		defer admin.Close()
+		admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`)
+	})
+
+	db, err := NewPostgresDB(withSearchPath(t, dsn, schema))
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// withSearchPath adds a search_path runtime parameter to a URL or keyword/value connection string
+func withSearchPath(t *testing.T, dsn, schema string) string {
+	t.Helper()
+	path := schema + ",public"
+	if !strings.HasPrefix(dsn, "postgres://") && !strings.HasPrefix(dsn, "postgresql://") {
+		return dsn + " search_path=" + path
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("invalid POSTGRES_TEST_DSN: %v", err)
+	}
+	q := u.Query()
+	q.Set("search_path", path)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func TestRebind(t *testing.T) {
+	got := rebind(`SELECT a FROM t WHERE b = ? AND c IN (?,?) LIMIT ?`)
+	want := `SELECT a FROM t WHERE b = $1 AND c IN ($2,$3) LIMIT $4`
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestTSQuery(t *testing.T) {
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{"luzon rain", `'luzon' & 'rain'`},
+		{"volc*", `'volc':*`},
+		{`etna OR "luzon`, `'etna' & 'OR' & 'luzon'`},
+		{`o'brien \x`, `'o''brien' & '\\x'`},
+		{`"*"`, ""},
+	}
+	for _, tt := range tests {
+		if got := tsQuery(tt.q); got != tt.want {
+			t.Errorf("tsQuery(%q): expected %q, got %q", tt.q, tt.want, got)
+		}
+	}
+}
+
+func TestPostgresDB_Migrations(t *testing.T) {
+	dsn := postgresTestDSN(t)
+	ctx := context.Background()
+	db := setupPostgresDB(t, dsn)
+
+	now := time.Now()
+	if err := db.Add(ctx, &models.Disaster{ID: "d1", Source: "test", Title: "Quake", Timestamp: now, CreatedAt: now}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	if err := db.MigrateDown(ctx, 0); err != nil {
+		t.Fatalf("MigrateDown failed: %v", err)
+	}
+	if v, _ := db.SchemaVersion(ctx); v != 0 {
+		t.Errorf("expected version 0, got %d", v)
+	}
+	if err := db.MigrateUp(ctx, 0); err != nil {
+		t.Fatalf("MigrateUp failed: %v", err)
+	}
+	status, err := db.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatalf("MigrationStatus failed: %v", err)
+	}
+	for _, s := range status {
+		if s.AppliedAt == nil {
+			t.Errorf("expected migration %d to be applied", s.Version)
+		}
+	}
+	if got, _ := db.GetByID(ctx, "d1"); got != nil {
+		t.Errorf("expected the down migration to drop disasters, got %+v", got)
+	}
+}
@@ -2,30 +2,215 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
 	"github.com/mr1hm/go-disaster-alerts/internal/models"
 )
 
+// ErrNotFound is returned when updating or deleting a record that does not exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict is returned when a disaster changed after it was read.
+var ErrConflict = errors.New("conflict")
+
+// TimeField selects which time Since, Until and time sorting use
+type TimeField int
+
+const (
+	TimeFieldEvent   TimeField = iota // when the disaster occurred (Disaster.Timestamp)
+	TimeFieldCreated                  // when it was first stored (Disaster.CreatedAt)
+)
+
+// SortField orders ListDisasters results. Ties are broken by time, newest first, then by ID.
+type SortField string
+
+const (
+	SortDefault    SortField = ""     // relevance for text queries, otherwise time
+	SortTime       SortField = "time" // by TimeField
+	SortMagnitude  SortField = "magnitude"
+	SortAlertLevel SortField = "alert_level"
+	SortPopulation SortField = "population" // affected population count
+	SortDistance   SortField = "distance"   // from Near's center; requires Near
+	SortRelevance  SortField = "relevance"  // full-text rank; requires Query
+)
+
+// SortOrder is the direction of the sort. The default is ascending for distance and relevance,
+// where the first results are the closest and best matches, and descending otherwise.
+type SortOrder int
+
+const (
+	SortOrderDefault SortOrder = iota
+	SortOrderAsc
+	SortOrderDesc
+)
+
 type Filter struct {
-	Limit                       int
-	Offset                      int
-	Since                       *time.Time
-	Type                        *disastersv1.DisasterType
-	MinMagnitude                *float64
-	AlertLevel                  *disastersv1.AlertLevel
-	MinAlertLevel               *disastersv1.AlertLevel // >= this level (e.g., ORANGE includes ORANGE and RED)
-	DiscordSent                 *bool                   // Filter by discord_sent status
-	MinAffectedPopulationCount  *int64                  // Minimum affected population count
+	Limit                      int
+	Offset                     int
+	Since                      *time.Time // At or after this time
+	Until                      *time.Time // Before this time
+	TimeField                  TimeField  // Time that Since, Until and SortTime use
+	Type                       *disastersv1.DisasterType
+	MinMagnitude               *float64
+	MaxMagnitude               *float64
+	AlertLevel                 *disastersv1.AlertLevel
+	MinAlertLevel              *disastersv1.AlertLevel    // >= this level (e.g., ORANGE includes ORANGE and RED)
+	ConsumerID                 string                     // Consumer that Delivered refers to
+	Delivered                  *bool                      // Filter by whether ConsumerID has acknowledged the disaster
+	MinAffectedPopulationCount *int64                     // Minimum affected population count
+	Types                      []disastersv1.DisasterType // Any of these types (ANDed with Type if both are set)
+	Countries                  []string                   // ISO 3166-1 alpha-3 codes, case-insensitive
+	Sources                    []string                   // Any of these sources (e.g., "GDACS"), case-insensitive
+	BBox                       *geo.BBox
+	Near                       *geo.Circle
+	Area                       geo.MultiPolygon // Point must fall inside one of these polygons
+	Query                      string           // Full-text search over title, description and country; results are ranked by relevance
+	PageToken                  string           // Only disasters after this position, taken from a previous result's PageToken
+	Sort                       SortField
+	Order                      SortOrder
+	GeofenceID                 string // ListAlerts only: alerts raised by this geofence
+}
+
+// Matches reports whether d satisfies the filter's attribute and location criteria.
+// It is the in-process equivalent of ListDisasters' WHERE clause, used for live streams;
+// Delivered, Limit and Offset need stored state and are ignored.
+func (f Filter) Matches(d *models.Disaster) bool {
+	if f.Type != nil && d.Type != *f.Type {
+		return false
+	}
+	if len(f.Types) > 0 && !slices.Contains(f.Types, d.Type) {
+		return false
+	}
+	t := d.Timestamp
+	if f.TimeField == TimeFieldCreated {
+		t = d.CreatedAt
+	}
+	if f.Since != nil && t.Before(*f.Since) {
+		return false
+	}
+	if f.Until != nil && !t.Before(*f.Until) {
+		return false
+	}
+	if f.MinMagnitude != nil && d.Magnitude < *f.MinMagnitude {
+		return false
+	}
+	if f.MaxMagnitude != nil && d.Magnitude > *f.MaxMagnitude {
+		return false
+	}
+	if f.AlertLevel != nil && d.AlertLevel != *f.AlertLevel {
+		return false
+	}
+	if f.MinAlertLevel != nil && d.AlertLevel < *f.MinAlertLevel {
+		return false
+	}
+	if f.MinAffectedPopulationCount != nil && d.AffectedPopulationCount < *f.MinAffectedPopulationCount {
+		return false
+	}
+	if len(f.Countries) > 0 && !slices.ContainsFunc(f.Countries, func(c string) bool {
+		return strings.EqualFold(c, d.CountryISO)
+	}) {
+		return false
+	}
+	if len(f.Sources) > 0 && !slices.ContainsFunc(f.Sources, func(s string) bool {
+		return strings.EqualFold(s, d.Source)
+	}) {
+		return false
+	}
+	if f.BBox != nil && !f.BBox.Contains(d.Latitude, d.Longitude) {
+		return false
+	}
+	if f.Near != nil && !f.Near.Contains(d.Latitude, d.Longitude) {
+		return false
+	}
+	if f.Area != nil && !f.Area.Contains(d.Latitude, d.Longitude) {
+		return false
+	}
+	if f.Query != "" && !matchesText(f.Query, d) {
+		return false
+	}
+	return true
+}
+
+// Validate reports sort options that can't be applied to the filter
+func (f Filter) Validate() error {
+	switch f.Sort {
+	case SortDefault, SortTime, SortMagnitude, SortAlertLevel, SortPopulation:
+	case SortDistance:
+		if f.Near == nil {
+			return errors.New("sorting by distance requires a center point")
+		}
+	case SortRelevance:
+		if len(SearchTerms(f.Query)) == 0 {
+			return errors.New("sorting by relevance requires a text query")
+		}
+	default:
+		return fmt.Errorf("unknown sort field %q", f.Sort)
+	}
+	return nil
+}
+
+// SortKey resolves the default sort field and order
+func (f Filter) SortKey() (SortField, bool) {
+	field := f.Sort
+	if field == SortDefault {
+		field = SortTime
+		if len(SearchTerms(f.Query)) > 0 {
+			field = SortRelevance
+		}
+	}
+	switch f.Order {
+	case SortOrderAsc:
+		return field, true
+	case SortOrderDesc:
+		return field, false
+	default:
+		return field, field == SortDistance || field == SortRelevance
+	}
+}
+
+// SearchTerms splits a free-text query into the terms that must all match. A trailing * marks a prefix term.
+func SearchTerms(q string) []string {
+	var terms []string
+	for _, t := range strings.Fields(q) {
+		t = strings.ReplaceAll(t, `"`, "")
+		if strings.TrimRight(t, "*") != "" {
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+// matchesText approximates the full-text index with case-insensitive substring matching
+func matchesText(q string, d *models.Disaster) bool {
+	text := strings.ToLower(d.Title + " " + d.Description + " " + d.Country)
+	for _, t := range SearchTerms(q) {
+		if !strings.Contains(text, strings.ToLower(strings.TrimRight(t, "*"))) {
+			return false
+		}
+	}
+	return true
 }
 
 type DisasterRepository interface {
 	Add(ctx context.Context, d *models.Disaster) error
+	AddBatch(ctx context.Context, ds []*models.Disaster) error // one transaction, all or nothing
+	Update(ctx context.Context, d *models.Disaster, kind disastersv1.EventKind) error
+	// UpdateIfUnchanged is Update, returning ErrConflict if the disaster's stored seq is no longer
+	// d.Seq, i.e. it changed since d was read.
+	UpdateIfUnchanged(ctx context.Context, d *models.Disaster, kind disastersv1.EventKind) error
 	GetByID(ctx context.Context, id string) (*models.Disaster, error)
 	Exists(ctx context.Context, id string) (bool, error)
 	ListDisasters(ctx context.Context, opts Filter) ([]models.Disaster, error)
-	MarkAsSent(ctx context.Context, ids []string) (int64, error)
+	ListEvents(ctx context.Context, afterSeq int64, limit int) ([]models.DisasterEvent, error) // seq > afterSeq, oldest first
+	MarkAsSent(ctx context.Context, consumerID string, ids []string) (int64, error)            // returns newly acknowledged count
+	Stats(ctx context.Context, opts Filter, bucket TimeBucket) (*Stats, error)
+	Heatmap(ctx context.Context, opts Filter, grid geo.Grid, weight HeatmapWeight) ([]HeatmapCell, error) // non-empty cells, ordered by key
 }
 
 type AlertRepository interface {
@@ -33,3 +218,45 @@ type AlertRepository interface {
 	GetByDisasterID(ctx context.Context, disasterID string) ([]models.Alert, error)
 	ListAlerts(ctx context.Context, opts Filter) ([]models.Alert, error)
 }
+
+type GeofenceRepository interface {
+	AddGeofence(ctx context.Context, g *models.Geofence) error
+	GetGeofence(ctx context.Context, id string) (*models.Geofence, error)       // nil if not found
+	ListGeofences(ctx context.Context, owner string) ([]models.Geofence, error) // all owners if empty
+	DeleteGeofence(ctx context.Context, id string) error                        // ErrNotFound if missing
+}
+
+// AuditRepository keeps the log of operator changes to disasters
+type AuditRepository interface {
+	AddAudited(ctx context.Context, d *models.Disaster, e *models.AuditEntry) error // Add, storing e in the same transaction
+	// UpdateAudited is Update, storing e in the same transaction. It returns ErrConflict if the
+	// disaster's stored seq is no longer d.Seq, i.e. it changed since d was read.
+	UpdateAudited(ctx context.Context, d *models.Disaster, kind disastersv1.EventKind, e *models.AuditEntry) error
+	AddAuditEntry(ctx context.Context, e *models.AuditEntry) error                        // sets e.ID
+	ListAuditEntries(ctx context.Context, disasterID string) ([]models.AuditEntry, error) // oldest first
+}
+
+// RetentionRepository expires old disasters. Archived and deleted disasters are removed with their
+// events, deliveries and alerts.
+type RetentionRepository interface {
+	ApplyRetention(ctx context.Context, rules []RetentionRule, now time.Time, dryRun bool) (*RetentionReport, error) // reports without changes if dryRun
+}
+
+// Store is a storage backend implementing every repository
+type Store interface {
+	DisasterRepository
+	AlertRepository
+	GeofenceRepository
+	AuditRepository
+	RetentionRepository
+	Close() error
+}
+
+// Migrator manages a backend's schema versions
+type Migrator interface {
+	SchemaVersion(ctx context.Context) (int, error)
+	MigrationStatus(ctx context.Context) ([]MigrationStatus, error)
+	MigrateUp(ctx context.Context, target int) error
+	MigrateDown(ctx context.Context, target int) error
+	Close() error
+}
@@ -0,0 +1,33 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository/repositorytest"
+)
+
+func TestSQLiteDB(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repository.Store {
+		db, err := repository.NewSQLiteDB(":memory:")
+		if err != nil {
+			t.Fatalf("failed to create test db: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return db
+	})
+}
+
+func TestMemoryDB(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repository.Store {
+		return repository.NewMemoryDB()
+	})
+}
+
+// TestPostgresDB runs against the server at POSTGRES_TEST_DSN, in a schema of its own per test
+func TestPostgresDB(t *testing.T) {
+	dsn := repository.PostgresTestDSN(t)
+	repositorytest.Run(t, func(t *testing.T) repository.Store {
+		return repository.SetupPostgresDB(t, dsn)
+	})
+}
@@ -0,0 +1,173 @@
+package repositorytest
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+func testAlerts(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	alerts := []models.Alert{
+		{ID: "a1", DisasterID: "d1", Severity: models.AlertSeverityLow, CreatedAt: base},
+		{ID: "a2", DisasterID: "d2", GeofenceID: "gf_1", Severity: models.AlertSeverityHigh, CreatedAt: base.Add(2 * time.Hour)},
+		{ID: "a3", DisasterID: "d1", GeofenceID: "gf_1", Severity: models.AlertSeverityCritical, CreatedAt: base.Add(time.Hour)},
+		{ID: "a4", DisasterID: "d3", Severity: models.AlertSeverityModerate, CreatedAt: base.Add(3 * time.Hour)},
+	}
+	for i := range alerts {
+		if err := db.AddAlert(ctx, &alerts[i]); err != nil {
+			t.Fatalf("AddAlert failed: %v", err)
+		}
+	}
+	if err := db.AddAlert(ctx, &alerts[0]); err == nil {
+		t.Error("expected error for duplicate alert ID, got nil")
+	}
+
+	got, err := db.GetByDisasterID(ctx, "d1")
+	if err != nil {
+		t.Fatalf("GetByDisasterID failed: %v", err)
+	}
+	if ids := alertIDs(got); len(ids) != 2 || !slices.Contains(ids, "a1") || !slices.Contains(ids, "a3") {
+		t.Errorf("expected a1 and a3 for d1, got %v", ids)
+	}
+	for _, a := range got {
+		if a.ID == "a3" && (a.GeofenceID != "gf_1" || a.Severity != models.AlertSeverityCritical || !a.CreatedAt.Equal(alerts[2].CreatedAt)) {
+			t.Errorf("expected a3 to round-trip, got %+v", a)
+		}
+	}
+	if got, err := db.GetByDisasterID(ctx, "missing"); err != nil || len(got) != 0 {
+		t.Errorf("expected no alerts for a missing disaster, got %+v (err %v)", got, err)
+	}
+
+	since := base.Add(time.Hour)
+	tests := []struct {
+		name   string
+		filter repository.Filter
+		want   []string
+	}{
+		{"newest first", repository.Filter{}, []string{"a4", "a2", "a3", "a1"}},
+		{"since", repository.Filter{Since: &since}, []string{"a4", "a2", "a3"}},
+		{"geofence", repository.Filter{GeofenceID: "gf_1"}, []string{"a2", "a3"}},
+		{"since and geofence", repository.Filter{Since: &since, GeofenceID: "gf_1", Limit: 1}, []string{"a2"}},
+		{"limit and offset", repository.Filter{Limit: 2, Offset: 1}, []string{"a2", "a3"}},
+		{"offset without limit", repository.Filter{Offset: 3}, []string{"a1"}},
+		{"offset past the end", repository.Filter{Offset: 4}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.ListAlerts(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("ListAlerts failed: %v", err)
+			}
+			if ids := alertIDs(got); !slices.Equal(ids, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, ids)
+			}
+		})
+	}
+}
+
+func alertIDs(alerts []models.Alert) []string {
+	var ids []string
+	for _, a := range alerts {
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
+
+func testGeofences(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	minMag := 5.0
+	level := disastersv1.AlertLevel_ORANGE
+	polygon := &models.Geofence{
+		ID:    "gf_luzon",
+		Name:  "Luzon",
+		Owner: "slack",
+		Area:  geo.MultiPolygon{{{{119.5, 13.5}, {124.5, 13.5}, {122.5, 18.8}, {119.5, 13.5}}}},
+		Criteria: models.GeofenceCriteria{
+			Types:         []disastersv1.DisasterType{disastersv1.DisasterType_EARTHQUAKE},
+			MinMagnitude:  &minMag,
+			MinAlertLevel: &level,
+		},
+		CreatedAt: time.Now().Add(-time.Minute),
+	}
+	circle := &models.Geofence{
+		ID:        "gf_tokyo",
+		Name:      "Tokyo",
+		Owner:     "pager",
+		Circle:    &geo.Circle{Lat: 35.68, Lon: 139.69, RadiusKm: 100},
+		CreatedAt: time.Now(),
+	}
+	for _, g := range []*models.Geofence{polygon, circle} {
+		if err := db.AddGeofence(ctx, g); err != nil {
+			t.Fatalf("AddGeofence failed: %v", err)
+		}
+	}
+	if err := db.AddGeofence(ctx, circle); err == nil {
+		t.Error("expected error for duplicate geofence ID, got nil")
+	}
+
+	got, err := db.GetGeofence(ctx, "gf_luzon")
+	if err != nil {
+		t.Fatalf("GetGeofence failed: %v", err)
+	}
+	if got == nil || got.Circle != nil || len(got.Area) != 1 || len(got.Area[0][0]) != 4 {
+		t.Fatalf("expected polygon geofence to round-trip, got %+v", got)
+	}
+	if got.Criteria.MinMagnitude == nil || *got.Criteria.MinMagnitude != 5.0 ||
+		got.Criteria.MinAlertLevel == nil || *got.Criteria.MinAlertLevel != level ||
+		len(got.Criteria.Types) != 1 {
+		t.Errorf("expected criteria to round-trip, got %+v", got.Criteria)
+	}
+
+	got, err = db.GetGeofence(ctx, "gf_tokyo")
+	if err != nil || got == nil || got.Area != nil || got.Circle == nil || got.Circle.RadiusKm != 100 {
+		t.Fatalf("expected circle geofence to round-trip, got %+v (err %v)", got, err)
+	}
+
+	all, err := db.ListGeofences(ctx, "")
+	if err != nil || len(all) != 2 || all[0].ID != "gf_luzon" {
+		t.Fatalf("expected both geofences oldest first, got %+v (err %v)", all, err)
+	}
+	owned, err := db.ListGeofences(ctx, "pager")
+	if err != nil || len(owned) != 1 || owned[0].ID != "gf_tokyo" {
+		t.Fatalf("expected only pager's geofence, got %+v (err %v)", owned, err)
+	}
+
+	if err := db.DeleteGeofence(ctx, "gf_tokyo"); err != nil {
+		t.Fatalf("DeleteGeofence failed: %v", err)
+	}
+	if err := db.DeleteGeofence(ctx, "gf_tokyo"); err != repository.ErrNotFound {
+		t.Errorf("expected repository.ErrNotFound deleting twice, got %v", err)
+	}
+	if got, _ := db.GetGeofence(ctx, "gf_tokyo"); got != nil {
+		t.Errorf("expected deleted geofence to be gone, got %+v", got)
+	}
+}
+
+func testListAlertsByGeofence(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	alerts := []models.Alert{
+		{ID: "a1", DisasterID: "d1", GeofenceID: "gf_1", Severity: models.AlertSeverityHigh, CreatedAt: time.Now()},
+		{ID: "a2", DisasterID: "d2", GeofenceID: "gf_2", Severity: models.AlertSeverityLow, CreatedAt: time.Now()},
+	}
+	for i := range alerts {
+		if err := db.AddAlert(ctx, &alerts[i]); err != nil {
+			t.Fatalf("AddAlert failed: %v", err)
+		}
+	}
+
+	got, err := db.ListAlerts(ctx, repository.Filter{GeofenceID: "gf_2"})
+	if err != nil {
+		t.Fatalf("ListAlerts failed: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "a2" || got[0].GeofenceID != "gf_2" {
+		t.Errorf("expected only a2, got %+v", got)
+	}
+}
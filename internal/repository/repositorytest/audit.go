@@ -0,0 +1,146 @@
+package repositorytest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+func testAudit(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	entries := []*models.AuditEntry{
+		{DisasterID: "d1", Action: models.AuditActionCreate, Actor: "alice", CreatedAt: now,
+			Changes: []models.FieldChange{{Field: "title", New: "Landslide"}, {Field: "latitude", New: 35.5}}},
+		{DisasterID: "d2", Action: models.AuditActionUpdate, Actor: "bob", CreatedAt: now,
+			Changes: []models.FieldChange{{Field: "country_iso", Old: "", New: "JPN"}}},
+		{DisasterID: "d1", Action: models.AuditActionUpdate, Actor: "bob", CreatedAt: now.Add(time.Minute),
+			Changes: []models.FieldChange{{Field: "affected_population_count", Old: 0, New: 1200}, {Field: "closed", Old: false, New: true}}},
+	}
+	for _, e := range entries {
+		if err := db.AddAuditEntry(ctx, e); err != nil {
+			t.Fatalf("AddAuditEntry failed: %v", err)
+		}
+	}
+	if entries[0].ID == 0 || entries[1].ID <= entries[0].ID || entries[2].ID <= entries[1].ID {
+		t.Errorf("expected increasing IDs, got %d %d %d", entries[0].ID, entries[1].ID, entries[2].ID)
+	}
+
+	got, err := db.ListAuditEntries(ctx, "d1")
+	if err != nil {
+		t.Fatalf("ListAuditEntries failed: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != entries[0].ID || got[1].ID != entries[2].ID {
+		t.Fatalf("expected d1's two entries oldest first, got %+v", got)
+	}
+	for i, want := range []*models.AuditEntry{entries[0], entries[2]} {
+		e := got[i]
+		if e.DisasterID != want.DisasterID || e.Action != want.Action || e.Actor != want.Actor || !e.CreatedAt.Equal(want.CreatedAt) {
+			t.Errorf("expected %+v, got %+v", want, e)
+		}
+		// Values come back as their JSON equivalents
+		gotJSON, _ := json.Marshal(e.Changes)
+		wantJSON, _ := json.Marshal(want.Changes)
+		if string(gotJSON) != string(wantJSON) {
+			t.Errorf("expected changes %s, got %s", wantJSON, gotJSON)
+		}
+	}
+
+	if got, err := db.ListAuditEntries(ctx, "missing"); err != nil || len(got) != 0 {
+		t.Errorf("expected no entries, got %+v, %v", got, err)
+	}
+}
+
+func testAuditedWrites(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	entry := func(action models.AuditAction) *models.AuditEntry {
+		return &models.AuditEntry{DisasterID: "d1", Action: action, Actor: "alice", CreatedAt: now,
+			Changes: []models.FieldChange{{Field: "title", New: "Flood"}}}
+	}
+
+	d := &models.Disaster{ID: "d1", Source: "manual", Type: disastersv1.DisasterType_FLOOD, Title: "Flood", Timestamp: now, CreatedAt: now}
+	created := entry(models.AuditActionCreate)
+	if err := db.AddAudited(ctx, d, created); err != nil {
+		t.Fatalf("AddAudited failed: %v", err)
+	}
+	if d.Seq == 0 || created.ID == 0 {
+		t.Fatalf("expected seq and audit ID to be set, got %d and %d", d.Seq, created.ID)
+	}
+
+	// A failed add stores neither the disaster nor the entry
+	if err := db.AddAudited(ctx, &models.Disaster{ID: "d1", Timestamp: now, CreatedAt: now}, entry(models.AuditActionCreate)); err == nil {
+		t.Error("expected AddAudited of an existing ID to fail")
+	}
+
+	stale := *d
+	d.Title = "River flood"
+	d.UpdatedAt = now
+	if err := db.UpdateAudited(ctx, d, disastersv1.EventKind_EVENT_KIND_UPDATED, entry(models.AuditActionUpdate)); err != nil {
+		t.Fatalf("UpdateAudited failed: %v", err)
+	}
+	if d.Seq <= stale.Seq {
+		t.Errorf("expected a new seq after update, got %d", d.Seq)
+	}
+
+	// An update based on the old seq conflicts and changes nothing
+	stale.Title = "Lost update"
+	err := db.UpdateAudited(ctx, &stale, disastersv1.EventKind_EVENT_KIND_UPDATED, entry(models.AuditActionUpdate))
+	if !errors.Is(err, repository.ErrConflict) {
+		t.Fatalf("expected ErrConflict for a stale seq, got %v", err)
+	}
+	got, err := db.GetByID(ctx, "d1")
+	if err != nil || got.Title != "River flood" || got.Seq != d.Seq {
+		t.Errorf("expected the conflicting update not to apply, got %+v, %v", got, err)
+	}
+
+	missing := &models.Disaster{ID: "missing", Timestamp: now, UpdatedAt: now}
+	if err := db.UpdateAudited(ctx, missing, disastersv1.EventKind_EVENT_KIND_UPDATED, entry(models.AuditActionUpdate)); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	entries, err := db.ListAuditEntries(ctx, "d1")
+	if err != nil {
+		t.Fatalf("ListAuditEntries failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Action != models.AuditActionCreate || entries[1].Action != models.AuditActionUpdate {
+		t.Errorf("expected only the entries of stored changes, got %+v", entries)
+	}
+}
+
+func testCorrections(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	now := time.Now()
+	d := &models.Disaster{ID: "d1", Source: "GDACS", Type: disastersv1.DisasterType_FLOOD, Title: "Flood",
+		Timestamp: now, CreatedAt: now, Corrections: []string{"country"}}
+	if err := db.Add(ctx, d); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	got, err := db.GetByID(ctx, "d1")
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if !slices.Equal(got.Corrections, []string{"country"}) {
+		t.Errorf("expected corrections [country], got %v", got.Corrections)
+	}
+
+	got.Corrections = append(got.Corrections, "latitude", "longitude")
+	got.UpdatedAt = now
+	if err := db.Update(ctx, got, disastersv1.EventKind_EVENT_KIND_UPDATED); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	list, err := db.ListDisasters(ctx, repository.Filter{})
+	if err != nil {
+		t.Fatalf("ListDisasters failed: %v", err)
+	}
+	if len(list) != 1 || !slices.Equal(list[0].Corrections, []string{"country", "latitude", "longitude"}) {
+		t.Errorf("expected the updated corrections, got %+v", list)
+	}
+}
@@ -0,0 +1,1037 @@
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+func testAddAndGetDisaster(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	disaster := &models.Disaster{
+		ID:        "test_123",
+		Source:    "test",
+		Type:      disastersv1.DisasterType_EARTHQUAKE,
+		Title:     "Test Earthquake",
+		Magnitude: 5.5,
+		Latitude:  35.0,
+		Longitude: 139.0,
+		Timestamp: time.Now(),
+		CreatedAt: time.Now(),
+	}
+
+	// Add
+	err := db.Add(ctx, disaster)
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	// Get
+	got, err := db.GetByID(ctx, "test_123")
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if got.Title != "Test Earthquake" {
+		t.Errorf("expected title 'Test Earthquake', got '%s'", got.Title)
+	}
+}
+
+func testExists(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+
+	exists, err := db.Exists(ctx, "nonexistent")
+	if err != nil {
+		t.Fatalf("Exists failed: %v", err)
+	}
+	if exists {
+		t.Error("expected false for nonexistent ID")
+	}
+
+	// Add one
+	db.Add(ctx, &models.Disaster{
+		ID:        "exists_test",
+		Source:    "test",
+		Type:      disastersv1.DisasterType_FLOOD,
+		Timestamp: time.Now(),
+		CreatedAt: time.Now(),
+	})
+
+	exists, err = db.Exists(ctx, "exists_test")
+	if err != nil {
+		t.Fatalf("Exists failed: %v", err)
+	}
+	if !exists {
+		t.Error("expected true for existing ID")
+	}
+}
+
+func testListDisastersWithFilters(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	now := time.Now()
+
+	// Add test data
+	disasters := []*models.Disaster{
+		{ID: "eq1", Source: "test", Type: disastersv1.DisasterType_EARTHQUAKE, Magnitude: 6.0, Timestamp: now, CreatedAt: now},
+		{ID: "eq2", Source: "test", Type: disastersv1.DisasterType_EARTHQUAKE, Magnitude: 4.0, Timestamp: now, CreatedAt: now},
+		{ID: "fl1", Source: "test", Type: disastersv1.DisasterType_FLOOD, Magnitude: 3.0, Timestamp: now, CreatedAt: now},
+	}
+	for _, d := range disasters {
+		db.Add(ctx, d)
+	}
+
+	// Test type filter
+	eqType := disastersv1.DisasterType_EARTHQUAKE
+	results, err := db.ListDisasters(ctx, repository.Filter{Type: &eqType})
+	if err != nil {
+		t.Fatalf("ListDisasters failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("expected 2 earthquakes, got %d", len(results))
+	}
+
+	// Test magnitude filter
+	minMag := 5.0
+	results, err = db.ListDisasters(ctx, repository.Filter{MinMagnitude: &minMag})
+	if err != nil {
+		t.Fatalf("ListDisasters failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("expected 1 disaster with mag >= 5.0, got %d", len(results))
+	}
+
+	// Test limit
+	results, err = db.ListDisasters(ctx, repository.Filter{Limit: 2})
+	if err != nil {
+		t.Fatalf("ListDisasters failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("expected 2 disasters with limit, got %d", len(results))
+	}
+}
+
+func testListDisastersAlertLevelFilters(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	now := time.Now()
+
+	// Add test data with different alert levels
+	disasters := []*models.Disaster{
+		{ID: "green1", Source: "test", Type: disastersv1.DisasterType_EARTHQUAKE, AlertLevel: disastersv1.AlertLevel_GREEN, Timestamp: now, CreatedAt: now},
+		{ID: "orange1", Source: "test", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_ORANGE, Timestamp: now, CreatedAt: now},
+		{ID: "red1", Source: "test", Type: disastersv1.DisasterType_CYCLONE, AlertLevel: disastersv1.AlertLevel_RED, Timestamp: now, CreatedAt: now},
+		{ID: "green2", Source: "test", Type: disastersv1.DisasterType_EARTHQUAKE, AlertLevel: disastersv1.AlertLevel_GREEN, Timestamp: now, CreatedAt: now},
+	}
+	for _, d := range disasters {
+		db.Add(ctx, d)
+	}
+
+	// Test exact AlertLevel filter
+	orange := disastersv1.AlertLevel_ORANGE
+	results, err := db.ListDisasters(ctx, repository.Filter{AlertLevel: &orange})
+	if err != nil {
+		t.Fatalf("ListDisasters failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("expected 1 orange alert, got %d", len(results))
+	}
+
+	// Test MinAlertLevel filter (>= ORANGE should return ORANGE and RED)
+	results, err = db.ListDisasters(ctx, repository.Filter{MinAlertLevel: &orange})
+	if err != nil {
+		t.Fatalf("ListDisasters failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("expected 2 disasters with alert >= ORANGE, got %d", len(results))
+	}
+
+	// Test MinAlertLevel RED (should return only RED)
+	red := disastersv1.AlertLevel_RED
+	results, err = db.ListDisasters(ctx, repository.Filter{MinAlertLevel: &red})
+	if err != nil {
+		t.Fatalf("ListDisasters failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("expected 1 disaster with alert >= RED, got %d", len(results))
+	}
+}
+
+func testNewFields(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	disaster := &models.Disaster{
+		ID:                 "test_fields",
+		Source:             "GDACS",
+		Type:               disastersv1.DisasterType_CYCLONE,
+		Title:              "Test Cyclone",
+		Magnitude:          150.0,
+		AlertLevel:         disastersv1.AlertLevel_RED,
+		Latitude:           -20.0,
+		Longitude:          45.0,
+		Timestamp:          time.Now(),
+		Country:            "Madagascar",
+		AffectedPopulation: "1.5 million affected",
+		ReportURL:          "https://www.gdacs.org/report.aspx?eventtype=TC&eventid=123",
+		CreatedAt:          time.Now(),
+	}
+
+	err := db.Add(ctx, disaster)
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	got, err := db.GetByID(ctx, "test_fields")
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+
+	if got.Country != "Madagascar" {
+		t.Errorf("expected country 'Madagascar', got '%s'", got.Country)
+	}
+	if got.AffectedPopulation != "1.5 million affected" {
+		t.Errorf("expected affected_population '1.5 million affected', got '%s'", got.AffectedPopulation)
+	}
+	if got.ReportURL != "https://www.gdacs.org/report.aspx?eventtype=TC&eventid=123" {
+		t.Errorf("expected report_url, got '%s'", got.ReportURL)
+	}
+}
+
+func testMarkAsSent(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	now := time.Now()
+
+	// Add test disasters
+	disasters := []*models.Disaster{
+		{ID: "sent1", Source: "test", Type: disastersv1.DisasterType_EARTHQUAKE, Timestamp: now, CreatedAt: now},
+		{ID: "sent2", Source: "test", Type: disastersv1.DisasterType_FLOOD, Timestamp: now, CreatedAt: now},
+		{ID: "sent3", Source: "test", Type: disastersv1.DisasterType_CYCLONE, Timestamp: now, CreatedAt: now},
+	}
+	for _, d := range disasters {
+		db.Add(ctx, d)
+	}
+
+	// Mark two as sent
+	count, err := db.MarkAsSent(ctx, "discord", []string{"sent1", "sent2"})
+	if err != nil {
+		t.Fatalf("MarkAsSent failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 rows affected, got %d", count)
+	}
+
+	// Marking non-existent IDs should return 0
+	count, err = db.MarkAsSent(ctx, "discord", []string{"nonexistent"})
+	if err != nil {
+		t.Fatalf("MarkAsSent failed: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected 0 rows affected for non-existent ID, got %d", count)
+	}
+
+	// Empty slice should return 0
+	count, err = db.MarkAsSent(ctx, "discord", []string{})
+	if err != nil {
+		t.Fatalf("MarkAsSent failed: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected 0 rows affected for empty slice, got %d", count)
+	}
+
+	// Re-acknowledging is a no-op
+	count, err = db.MarkAsSent(ctx, "discord", []string{"sent1"})
+	if err != nil {
+		t.Fatalf("MarkAsSent failed: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected 0 rows affected for already acknowledged ID, got %d", count)
+	}
+
+	// Repeated IDs count once, and consumers acknowledge independently
+	count, err = db.MarkAsSent(ctx, "slack", []string{"sent1", "sent3", "sent3"})
+	if err != nil {
+		t.Fatalf("MarkAsSent failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 rows affected for another consumer, got %d", count)
+	}
+}
+
+func testDeliveredPerConsumer(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, id := range []string{"c1", "c2", "c3"} {
+		db.Add(ctx, &models.Disaster{ID: id, Source: "test", Type: disastersv1.DisasterType_EARTHQUAKE, Timestamp: now, CreatedAt: now})
+	}
+	db.MarkAsSent(ctx, "discord", []string{"c1", "c2"})
+	db.MarkAsSent(ctx, "slack", []string{"c3"})
+
+	unsent, sent := false, true
+	tests := []struct {
+		consumer  string
+		delivered *bool
+		expected  int
+	}{
+		{"discord", &unsent, 1},
+		{"discord", &sent, 2},
+		{"slack", &unsent, 2},
+		{"slack", &sent, 1},
+		{"sms", &unsent, 3},
+		{"sms", nil, 3},
+	}
+	for _, tt := range tests {
+		results, err := db.ListDisasters(ctx, repository.Filter{ConsumerID: tt.consumer, Delivered: tt.delivered})
+		if err != nil {
+			t.Fatalf("ListDisasters failed: %v", err)
+		}
+		if len(results) != tt.expected {
+			t.Errorf("consumer %s delivered=%v: expected %d, got %d", tt.consumer, tt.delivered, tt.expected, len(results))
+		}
+	}
+}
+
+func testDuplicateAdd(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	disaster := &models.Disaster{
+		ID:        "dup_test",
+		Source:    "test",
+		Type:      disastersv1.DisasterType_EARTHQUAKE,
+		Timestamp: time.Now(),
+		CreatedAt: time.Now(),
+	}
+
+	// First add should succeed
+	err := db.Add(ctx, disaster)
+	if err != nil {
+		t.Fatalf("First Add failed: %v", err)
+	}
+
+	// Second add should fail (duplicate primary key)
+	err = db.Add(ctx, disaster)
+	if err == nil {
+		t.Error("expected error for duplicate ID, got nil")
+	}
+}
+
+func testAddBatch(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	now := time.Now()
+	newBatch := func(ids ...string) []*models.Disaster {
+		var ds []*models.Disaster
+		for _, id := range ids {
+			ds = append(ds, &models.Disaster{ID: id, Source: "test", Type: disastersv1.DisasterType_FLOOD, Title: id, Timestamp: now, CreatedAt: now})
+		}
+		return ds
+	}
+
+	if err := db.AddBatch(ctx, nil); err != nil {
+		t.Fatalf("AddBatch of nothing failed: %v", err)
+	}
+	batch := newBatch("b1", "b2", "b3")
+	if err := db.AddBatch(ctx, batch); err != nil {
+		t.Fatalf("AddBatch failed: %v", err)
+	}
+	for i, d := range batch {
+		if d.Seq != int64(i+1) {
+			t.Errorf("expected seq %d for %s, got %d", i+1, d.ID, d.Seq)
+		}
+		got, err := db.GetByID(ctx, d.ID)
+		if err != nil || got == nil || got.Title != d.ID || got.Seq != d.Seq {
+			t.Errorf("expected %s stored with seq %d, got %+v (err %v)", d.ID, d.Seq, got, err)
+		}
+	}
+	events, err := db.ListEvents(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	if len(events) != 3 || events[2].Disaster.ID != "b3" || events[2].Kind != disastersv1.EventKind_EVENT_KIND_CREATED {
+		t.Errorf("expected a CREATED event per disaster, got %v", events)
+	}
+
+	// A duplicate, stored or within the batch, fails the whole batch
+	for _, ids := range [][]string{{"b4", "b2"}, {"b5", "b6", "b5"}} {
+		failed := newBatch(ids...)
+		if err := db.AddBatch(ctx, failed); err == nil {
+			t.Errorf("expected an error adding %v", ids)
+		}
+		for _, d := range failed {
+			if d.Seq != 0 {
+				t.Errorf("expected no seq for %s after a failed batch, got %d", d.ID, d.Seq)
+			}
+		}
+	}
+	for _, id := range []string{"b4", "b5", "b6"} {
+		if exists, err := db.Exists(ctx, id); err != nil || exists {
+			t.Errorf("expected %s not to be stored after a failed batch (err %v)", id, err)
+		}
+	}
+	if events, _ := db.ListEvents(ctx, 3, 0); len(events) != 0 {
+		t.Errorf("expected no events from failed batches, got %v", events)
+	}
+}
+
+func testSeq(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	now := time.Now()
+
+	for i, id := range []string{"seq1", "seq2", "seq3"} {
+		d := &models.Disaster{ID: id, Source: "test", Type: disastersv1.DisasterType_EARTHQUAKE, Timestamp: now, CreatedAt: now}
+		if err := db.Add(ctx, d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		if d.Seq != int64(i+1) {
+			t.Errorf("expected seq %d for %s, got %d", i+1, id, d.Seq)
+		}
+	}
+
+	// Failed insert must not consume a sequence number
+	if err := db.Add(ctx, &models.Disaster{ID: "seq1", Source: "test", Timestamp: now, CreatedAt: now}); err == nil {
+		t.Fatal("expected error for duplicate ID")
+	}
+
+	got, err := db.GetByID(ctx, "seq2")
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if got.Seq != 2 {
+		t.Errorf("expected stored seq 2, got %d", got.Seq)
+	}
+
+	events, err := db.ListEvents(ctx, 1, 0)
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	if len(events) != 2 || events[0].Disaster.ID != "seq2" || events[1].Disaster.ID != "seq3" {
+		t.Errorf("expected [seq2 seq3] oldest first, got %v", events)
+	}
+
+	events, err = db.ListEvents(ctx, 0, 1)
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	if len(events) != 1 || events[0].Disaster.ID != "seq1" || events[0].Kind != disastersv1.EventKind_EVENT_KIND_CREATED {
+		t.Errorf("expected CREATED seq1 with limit 1, got %v", events)
+	}
+
+	d := &models.Disaster{ID: "seq4", Source: "test", Timestamp: now, CreatedAt: now}
+	if err := db.Add(ctx, d); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if d.Seq != 4 {
+		t.Errorf("expected seq 4 after failed insert, got %d", d.Seq)
+	}
+}
+
+func testUpdate(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	created := time.Now().Add(-time.Hour).Truncate(time.Microsecond) // Postgres precision
+	d := &models.Disaster{ID: "upd1", Source: "test", Type: disastersv1.DisasterType_CYCLONE, AlertLevel: disastersv1.AlertLevel_GREEN, Timestamp: created, CreatedAt: created}
+	if err := db.Add(ctx, d); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	updated := *d
+	updated.AlertLevel = disastersv1.AlertLevel_RED
+	updated.Closed = true
+	updated.CreatedAt = time.Now() // must not overwrite the original ingest time
+	updated.UpdatedAt = time.Now()
+	if err := db.Update(ctx, &updated, disastersv1.EventKind_EVENT_KIND_ESCALATED); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if updated.Seq <= d.Seq {
+		t.Errorf("expected update to advance seq past %d, got %d", d.Seq, updated.Seq)
+	}
+
+	got, err := db.GetByID(ctx, "upd1")
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if got.AlertLevel != disastersv1.AlertLevel_RED || !got.Closed {
+		t.Errorf("expected RED and closed, got %s closed=%v", got.AlertLevel, got.Closed)
+	}
+	if !got.CreatedAt.Equal(created) {
+		t.Errorf("expected created_at %v to be preserved, got %v", created, got.CreatedAt)
+	}
+	if got.UpdatedAt.IsZero() || got.Seq != updated.Seq {
+		t.Errorf("expected updated_at and seq %d to be stored, got %v and %d", updated.Seq, got.UpdatedAt, got.Seq)
+	}
+
+	events, err := db.ListEvents(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	if len(events) != 2 || events[0].Kind != disastersv1.EventKind_EVENT_KIND_CREATED || events[1].Kind != disastersv1.EventKind_EVENT_KIND_ESCALATED {
+		t.Fatalf("expected CREATED then ESCALATED events, got %v", events)
+	}
+	// Each event replays the disaster as it was when recorded, not its current row
+	if e := events[0].Disaster; e.AlertLevel != disastersv1.AlertLevel_GREEN || e.Closed || e.Seq != events[0].Seq {
+		t.Errorf("expected the CREATED event to carry the original state, got %s closed=%v seq=%d", e.AlertLevel, e.Closed, e.Seq)
+	}
+	if e := events[1].Disaster; e.AlertLevel != disastersv1.AlertLevel_RED || !e.Closed || e.Seq != events[1].Seq {
+		t.Errorf("expected the ESCALATED event to carry the updated state, got %s closed=%v seq=%d", e.AlertLevel, e.Closed, e.Seq)
+	}
+
+	missing := &models.Disaster{ID: "missing", Source: "test", Timestamp: created, UpdatedAt: time.Now()}
+	if err := db.Update(ctx, missing, disastersv1.EventKind_EVENT_KIND_UPDATED); err != repository.ErrNotFound {
+		t.Errorf("expected repository.ErrNotFound for missing disaster, got %v", err)
+	}
+	if err := db.UpdateIfUnchanged(ctx, missing, disastersv1.EventKind_EVENT_KIND_UPDATED); err != repository.ErrNotFound {
+		t.Errorf("expected repository.ErrNotFound for missing disaster, got %v", err)
+	}
+
+	// A copy read before the last update is stale
+	stale := *d
+	stale.Title = "stale"
+	stale.UpdatedAt = time.Now()
+	if err := db.UpdateIfUnchanged(ctx, &stale, disastersv1.EventKind_EVENT_KIND_UPDATED); err != repository.ErrConflict {
+		t.Errorf("expected repository.ErrConflict for a stale copy, got %v", err)
+	}
+	current := updated
+	current.Title = "current"
+	current.UpdatedAt = time.Now()
+	if err := db.UpdateIfUnchanged(ctx, &current, disastersv1.EventKind_EVENT_KIND_UPDATED); err != nil {
+		t.Errorf("UpdateIfUnchanged failed: %v", err)
+	}
+	if got, _ := db.GetByID(ctx, "upd1"); got == nil || got.Title != "current" || got.Seq != current.Seq {
+		t.Errorf("expected the current copy stored, got %+v", got)
+	}
+}
+
+func testListDisastersAgreesWithMatches(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	now := time.Now()
+	disasters := []*models.Disaster{
+		{ID: "tokyo", Type: disastersv1.DisasterType_EARTHQUAKE, CountryISO: "JPN", Latitude: 35.68, Longitude: 139.69, AffectedPopulationCount: 900000},
+		{ID: "osaka", Type: disastersv1.DisasterType_FLOOD, CountryISO: "JPN", Latitude: 34.69, Longitude: 135.50, AffectedPopulationCount: 1000},
+		{ID: "manila", Type: disastersv1.DisasterType_CYCLONE, CountryISO: "PHL", Latitude: 14.60, Longitude: 120.98, AffectedPopulationCount: 50000},
+		{ID: "fiji", Type: disastersv1.DisasterType_CYCLONE, CountryISO: "FJI", Latitude: -17.7, Longitude: 178.1},
+		{ID: "tonga", Type: disastersv1.DisasterType_TSUNAMI, CountryISO: "TON", Latitude: -21.2, Longitude: -175.2},
+	}
+	for i, d := range disasters {
+		d.Source, d.Title, d.Magnitude = "test", d.ID, float64(i+4)
+		d.Timestamp, d.CreatedAt = now.Add(-time.Duration(i)*24*time.Hour), now.Add(-time.Duration(len(disasters)-i)*time.Hour)
+		if err := db.Add(ctx, d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	disasters[0].Source = "GDACS"
+	if err := db.Update(ctx, disasters[0], disastersv1.EventKind_EVENT_KIND_UPDATED); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	minPop := int64(10000)
+	maxMag := 6.0
+	since, until := now.Add(-3*24*time.Hour), now.Add(-24*time.Hour)
+	createdSince := now.Add(-3 * time.Hour)
+	tests := []struct {
+		name   string
+		filter repository.Filter
+		want   int
+	}{
+		{"types", repository.Filter{Types: []disastersv1.DisasterType{disastersv1.DisasterType_CYCLONE, disastersv1.DisasterType_FLOOD}}, 3},
+		{"countries case-insensitive", repository.Filter{Countries: []string{"jpn", "TON"}}, 3},
+		{"min population", repository.Filter{MinAffectedPopulationCount: &minPop}, 2},
+		{"bbox", repository.Filter{BBox: &geo.BBox{MinLat: 30, MinLon: 130, MaxLat: 40, MaxLon: 145}}, 2},
+		{"antimeridian bbox", repository.Filter{BBox: &geo.BBox{MinLat: -25, MinLon: 170, MaxLat: -10, MaxLon: -170}}, 2},
+		{"radius", repository.Filter{Near: &geo.Circle{Lat: 35.68, Lon: 139.69, RadiusKm: 400}}, 2},
+		{"radius across antimeridian", repository.Filter{Near: &geo.Circle{Lat: -19, Lon: 180, RadiusKm: 600}}, 2},
+		{"combined", repository.Filter{Countries: []string{"JPN"}, Near: &geo.Circle{Lat: 34.69, Lon: 135.50, RadiusKm: 50}}, 1},
+		{"text", repository.Filter{Query: "fij*"}, 1},
+		{"event time range", repository.Filter{Since: &since, Until: &until}, 2},
+		{"created time", repository.Filter{Since: &createdSince, TimeField: repository.TimeFieldCreated}, 3},
+		{"max magnitude", repository.Filter{MaxMagnitude: &maxMag}, 3},
+		{"sources case-insensitive", repository.Filter{Sources: []string{"gdacs"}}, 1},
+		{"types in antimeridian bbox", repository.Filter{Types: []disastersv1.DisasterType{disastersv1.DisasterType_CYCLONE, disastersv1.DisasterType_TSUNAMI}, BBox: &geo.BBox{MinLat: -25, MinLon: 170, MaxLat: -10, MaxLon: -170}}, 2},
+		{"radius, magnitude and population", repository.Filter{Near: &geo.Circle{Lat: 35.68, Lon: 139.69, RadiusKm: 600}, MaxMagnitude: &maxMag, MinAffectedPopulationCount: &minPop}, 1},
+		{"text and countries", repository.Filter{Query: "tok*", Countries: []string{"JPN"}}, 1},
+		{"created time, type and countries", repository.Filter{Since: &createdSince, TimeField: repository.TimeFieldCreated, Types: []disastersv1.DisasterType{disastersv1.DisasterType_CYCLONE}, Countries: []string{"FJI", "TON"}}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.ListDisasters(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("ListDisasters failed: %v", err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("expected %d disasters, got %d", tt.want, len(got))
+			}
+
+			// The in-process matcher used by streams must select the same rows
+			matched := 0
+			for _, d := range disasters {
+				if tt.filter.Matches(d) {
+					matched++
+				}
+			}
+			if matched != len(got) {
+				t.Errorf("Matches selected %d disasters, SQL selected %d", matched, len(got))
+			}
+		})
+	}
+}
+
+func testLocationFilters(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	now := time.Now()
+	add := func(id string, lat, lon float64) *models.Disaster {
+		d := &models.Disaster{ID: id, Source: "test", Title: id, Latitude: lat, Longitude: lon, Timestamp: now, CreatedAt: now}
+		if err := db.Add(ctx, d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		return d
+	}
+	add("tokyo", 35.68, 139.69)
+	add("suva", -18.14, 178.44)
+	moved := add("nuku_alofa", 0, 0)
+
+	// Updates move the indexed point
+	moved.Latitude, moved.Longitude, moved.UpdatedAt = -21.14, -175.20, now
+	if err := db.Update(ctx, moved, disastersv1.EventKind_EVENT_KIND_UPDATED); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	near := &geo.Circle{Lat: -19.5, Lon: 180, RadiusKm: 700}
+	got, err := db.ListDisasters(ctx, repository.Filter{Near: near})
+	if err != nil {
+		t.Fatalf("ListDisasters failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected suva and nuku_alofa across the antimeridian, got %d", len(got))
+	}
+	for _, d := range got {
+		if d.DistanceKm == nil {
+			t.Fatalf("expected distance for %s", d.ID)
+		}
+		want := geo.DistanceKm(near.Lat, near.Lon, d.Latitude, d.Longitude)
+		if diff := *d.DistanceKm - want; diff > 0.001 || diff < -0.001 {
+			t.Errorf("%s: expected distance %.3f, got %.3f", d.ID, want, *d.DistanceKm)
+		}
+	}
+
+	// Without a center point no distance is reported
+	got, err = db.ListDisasters(ctx, repository.Filter{BBox: &geo.BBox{MinLat: 30, MinLon: 130, MaxLat: 40, MaxLon: 145}})
+	if err != nil {
+		t.Fatalf("ListDisasters failed: %v", err)
+	}
+	if len(got) != 1 || got[0].DistanceKm != nil {
+		t.Fatalf("expected tokyo without distance, got %+v", got)
+	}
+}
+
+func testListDisastersArea(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	now := time.Now()
+	points := []struct {
+		id       string
+		lat, lon float64
+	}{
+		{"baguio", 16.41, 120.60},
+		{"tuguegarao", 17.61, 121.73},
+		{"dagupan", 16.04, 120.33},
+		{"bay", 14.6, 120.7}, // inside the hole
+		{"cebu", 10.32, 123.89},
+		{"outside_slant", 18.0, 124.0}, // inside the bounding box, outside the polygon
+	}
+	for i, p := range points {
+		d := &models.Disaster{ID: p.id, Source: "test", Title: p.id, Latitude: p.lat, Longitude: p.lon,
+			Timestamp: now.Add(-time.Duration(i) * time.Hour), CreatedAt: now}
+		if err := db.Add(ctx, d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	area := geo.MultiPolygon{{
+		{{119.5, 13.5}, {124.5, 13.5}, {122.5, 18.8}, {120.0, 18.8}, {119.5, 13.5}},
+		{{120.5, 14.4}, {121.0, 14.4}, {121.0, 14.9}, {120.5, 14.9}, {120.5, 14.4}},
+	}}
+
+	got, err := db.ListDisasters(ctx, repository.Filter{Area: area})
+	if err != nil {
+		t.Fatalf("ListDisasters failed: %v", err)
+	}
+	var ids []string
+	for _, d := range got {
+		ids = append(ids, d.ID)
+	}
+	if len(ids) != 3 || ids[0] != "baguio" || ids[1] != "tuguegarao" || ids[2] != "dagupan" {
+		t.Fatalf("expected [baguio tuguegarao dagupan], got %v", ids)
+	}
+
+	// Limit and offset apply after the point-in-polygon check
+	got, err = db.ListDisasters(ctx, repository.Filter{Area: area, Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("ListDisasters failed: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "tuguegarao" {
+		t.Errorf("expected tuguegarao on the second page, got %+v", got)
+	}
+}
+
+func testListDisastersQuery(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	now := time.Now()
+	disasters := []*models.Disaster{
+		{ID: "etna", Title: "Volcanic eruption of Etna", Description: "Ash plume over eastern Sicily", Country: "Italy", Timestamp: now.Add(-2 * time.Hour)},
+		{ID: "luzon_quake", Title: "Earthquake in Philippines", Description: "Shaking felt across northern Luzon and Metro Manila", Country: "Philippines", Timestamp: now},
+		{ID: "luzon_cyclone", Title: "Tropical Cyclone over Luzon", Description: "Heavy rain expected", Country: "Philippines", Timestamp: now.Add(-time.Hour)},
+		{ID: "sao_paulo", Title: "Flood in São Paulo", Country: "Brazil", Timestamp: now.Add(-3 * time.Hour)},
+	}
+	for _, d := range disasters {
+		d.Source, d.CreatedAt = "test", now
+		if err := db.Add(ctx, d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	got, err := db.ListDisasters(ctx, repository.Filter{Query: "luzon"})
+	if err != nil {
+		t.Fatalf("ListDisasters failed: %v", err)
+	}
+	// A title match outranks a description match, even on an older disaster
+	if len(got) != 2 || got[0].ID != "luzon_cyclone" || got[1].ID != "luzon_quake" {
+		t.Fatalf("expected [luzon_cyclone luzon_quake], got %+v", got)
+	}
+	if !strings.Contains(got[0].Snippet, "<mark>Luzon</mark>") {
+		t.Errorf("expected highlighted snippet, got %q", got[0].Snippet)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"all terms required", "luzon rain", []string{"luzon_cyclone"}},
+		{"country", "italy", []string{"etna"}},
+		{"prefix", "volc*", []string{"etna"}},
+		{"diacritics folded", "sao paulo", []string{"sao_paulo"}},
+		{"operators matched literally", `etna OR "luzon`, nil},
+		{"punctuation only", `"*"`, []string{"luzon_quake", "luzon_cyclone", "etna", "sao_paulo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.ListDisasters(ctx, repository.Filter{Query: tt.query})
+			if err != nil {
+				t.Fatalf("ListDisasters failed: %v", err)
+			}
+			var ids []string
+			for _, d := range got {
+				ids = append(ids, d.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, ids)
+			}
+		})
+	}
+
+	// The index follows updates
+	etna := disasters[0]
+	etna.Title = "Volcanic eruption of Stromboli"
+	if err := db.Update(ctx, etna, disastersv1.EventKind_EVENT_KIND_UPDATED); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got, _ := db.ListDisasters(ctx, repository.Filter{Query: "etna"}); len(got) != 0 {
+		t.Errorf("expected no match for the old title, got %+v", got)
+	}
+	if got, _ := db.ListDisasters(ctx, repository.Filter{Query: "stromboli"}); len(got) != 1 {
+		t.Errorf("expected a match for the new title, got %+v", got)
+	}
+}
+
+func testListDisastersPageToken(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Pairs of disasters share a timestamp, so pages must break ties by ID
+	for i := 0; i < 7; i++ {
+		d := &models.Disaster{ID: fmt.Sprintf("d%d", i), Source: "test", Title: fmt.Sprintf("Flood %d", i),
+			Timestamp: base.Add(time.Duration(i/2) * time.Hour), CreatedAt: time.Now()}
+		if err := db.Add(ctx, d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	walk := func(filter repository.Filter, addWhilePaging bool) []string {
+		t.Helper()
+		var ids []string
+		limit := filter.Limit
+		for page := 0; page < 10; page++ {
+			filter.Limit = limit + 1
+			got, err := db.ListDisasters(ctx, filter)
+			if err != nil {
+				t.Fatalf("ListDisasters failed: %v", err)
+			}
+			got, next := repository.NextPage(got, limit)
+			for _, d := range got {
+				ids = append(ids, d.ID)
+			}
+			if next == "" {
+				return ids
+			}
+			filter.PageToken = next
+
+			// Disasters added while paging land before the first page instead of shifting later ones
+			if page == 0 && addWhilePaging {
+				late := &models.Disaster{ID: "late", Source: "test", Title: "Flood late", Timestamp: base.Add(24 * time.Hour), CreatedAt: time.Now()}
+				if err := db.Add(ctx, late); err != nil {
+					t.Fatalf("Add failed: %v", err)
+				}
+			}
+		}
+		t.Fatal("pagination did not terminate")
+		return nil
+	}
+
+	want := []string{"d6", "d5", "d4", "d3", "d2", "d1", "d0"}
+	if got := walk(repository.Filter{Limit: 3}, true); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Relevance-ranked results page on rank first; every match ranks the same here
+	want = []string{"late", "d6", "d5", "d4", "d3", "d2", "d1", "d0"}
+	if got := walk(repository.Filter{Limit: 2, Query: "flood"}, false); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func testListDisastersInvalidPageToken(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	d := &models.Disaster{ID: "d1", Source: "test", Title: "Flood", Timestamp: time.Now(), CreatedAt: time.Now()}
+	if err := db.Add(ctx, d); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	got, err := db.ListDisasters(ctx, repository.Filter{})
+	if err != nil || len(got) != 1 || got[0].PageToken == "" {
+		t.Fatalf("expected a page token on results, got %+v (err %v)", got, err)
+	}
+
+	tests := []struct {
+		name   string
+		filter repository.Filter
+	}{
+		{"garbage", repository.Filter{PageToken: "not-a-token"}},
+		{"empty json", repository.Filter{PageToken: "e30"}},
+		{"time token with a text query", repository.Filter{PageToken: got[0].PageToken, Query: "flood"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := db.ListDisasters(ctx, tt.filter); err != repository.ErrInvalidPageToken {
+				t.Errorf("expected repository.ErrInvalidPageToken, got %v", err)
+			}
+		})
+	}
+}
+
+func testListDisastersSort(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	now := time.Now()
+	disasters := []*models.Disaster{
+		{ID: "a", Magnitude: 5.0, AlertLevel: disastersv1.AlertLevel_RED, AffectedPopulationCount: 10, Latitude: 35.68, Longitude: 139.69},
+		{ID: "b", Magnitude: 7.1, AlertLevel: disastersv1.AlertLevel_GREEN, AffectedPopulationCount: 5000, Latitude: 34.69, Longitude: 135.50},
+		{ID: "c", Magnitude: 6.2, AlertLevel: disastersv1.AlertLevel_ORANGE, AffectedPopulationCount: 300, Latitude: 43.06, Longitude: 141.35},
+		{ID: "d", Magnitude: 6.2, AlertLevel: disastersv1.AlertLevel_GREEN, AffectedPopulationCount: 0, Latitude: 33.59, Longitude: 130.40},
+	}
+	for i, d := range disasters {
+		// Stored in reverse order of event time, so created_at order differs from event order
+		d.Source, d.Title = "test", "Quake "+d.ID
+		d.Timestamp, d.CreatedAt = now.Add(-time.Duration(i)*time.Hour), now.Add(time.Duration(i)*time.Minute)
+		if err := db.Add(ctx, d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	tokyo := &geo.Circle{Lat: 35.68, Lon: 139.69, RadiusKm: 2000}
+
+	tests := []struct {
+		name   string
+		filter repository.Filter
+		want   []string
+	}{
+		{"default newest first", repository.Filter{}, []string{"a", "b", "c", "d"}},
+		{"time ascending", repository.Filter{Order: repository.SortOrderAsc}, []string{"d", "c", "b", "a"}},
+		{"created_at", repository.Filter{TimeField: repository.TimeFieldCreated}, []string{"d", "c", "b", "a"}},
+		{"magnitude, ties newest first", repository.Filter{Sort: repository.SortMagnitude}, []string{"b", "c", "d", "a"}},
+		{"magnitude ascending", repository.Filter{Sort: repository.SortMagnitude, Order: repository.SortOrderAsc}, []string{"a", "c", "d", "b"}},
+		{"alert level", repository.Filter{Sort: repository.SortAlertLevel}, []string{"a", "c", "b", "d"}},
+		{"population", repository.Filter{Sort: repository.SortPopulation}, []string{"b", "c", "a", "d"}},
+		{"distance defaults to nearest", repository.Filter{Sort: repository.SortDistance, Near: tokyo}, []string{"a", "b", "c", "d"}},
+		{"distance descending", repository.Filter{Sort: repository.SortDistance, Order: repository.SortOrderDesc, Near: tokyo}, []string{"d", "c", "b", "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Walk one disaster per page, so every position goes through a page token
+			var ids []string
+			filter := tt.filter
+			for len(ids) <= len(disasters) {
+				filter.Limit = 2
+				got, err := db.ListDisasters(ctx, filter)
+				if err != nil {
+					t.Fatalf("ListDisasters failed: %v", err)
+				}
+				got, next := repository.NextPage(got, 1)
+				for _, d := range got {
+					ids = append(ids, d.ID)
+				}
+				if next == "" {
+					break
+				}
+				filter.PageToken = next
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, ids)
+			}
+		})
+	}
+
+	// A token only continues the ordering it was issued for
+	first, err := db.ListDisasters(ctx, repository.Filter{Sort: repository.SortMagnitude, Limit: 1})
+	if err != nil || len(first) != 1 {
+		t.Fatalf("ListDisasters failed: %v", err)
+	}
+	for _, f := range []repository.Filter{
+		{PageToken: first[0].PageToken},
+		{PageToken: first[0].PageToken, Sort: repository.SortMagnitude, Order: repository.SortOrderAsc},
+		{PageToken: first[0].PageToken, Sort: repository.SortMagnitude, TimeField: repository.TimeFieldCreated},
+	} {
+		if _, err := db.ListDisasters(ctx, f); err != repository.ErrInvalidPageToken {
+			t.Errorf("expected repository.ErrInvalidPageToken for %+v, got %v", f, err)
+		}
+	}
+}
+
+func testListDisastersLimitOffset(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		d := &models.Disaster{ID: fmt.Sprintf("d%d", i), Source: "test", Magnitude: float64(i % 2),
+			Timestamp: base.Add(time.Duration(i) * time.Hour), CreatedAt: base}
+		if err := db.Add(ctx, d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	minMag := 1.0
+	tests := []struct {
+		name   string
+		filter repository.Filter
+		want   []string
+	}{
+		{"limit", repository.Filter{Limit: 2}, []string{"d4", "d3"}},
+		{"limit and offset", repository.Filter{Limit: 2, Offset: 2}, []string{"d2", "d1"}},
+		{"offset without limit", repository.Filter{Offset: 3}, []string{"d1", "d0"}},
+		{"offset past the end", repository.Filter{Limit: 2, Offset: 5}, nil},
+		{"limit past the end", repository.Filter{Limit: 10, Offset: 4}, []string{"d0"}},
+		{"offset after filtering", repository.Filter{MinMagnitude: &minMag, Offset: 1}, []string{"d1"}},
+		{"offset with a sort key", repository.Filter{Sort: repository.SortMagnitude, Order: repository.SortOrderAsc, Limit: 2, Offset: 2}, []string{"d0", "d3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.ListDisasters(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("ListDisasters failed: %v", err)
+			}
+			if ids := disasterIDs(got); !slices.Equal(ids, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, ids)
+			}
+		})
+	}
+}
+
+func testConcurrentAccess(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	const writers, perWriter = 8, 25
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*perWriter*3)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				d := &models.Disaster{ID: fmt.Sprintf("w%d_%d", w, i), Source: "test", Title: "Concurrent flood",
+					Timestamp: now, CreatedAt: now}
+				if err := db.Add(ctx, d); err != nil {
+					errs <- fmt.Errorf("Add %s: %w", d.ID, err)
+					continue
+				}
+				if i%5 == 0 {
+					d.Magnitude, d.UpdatedAt = 6.5, time.Now()
+					if err := db.Update(ctx, d, disastersv1.EventKind_EVENT_KIND_UPDATED); err != nil {
+						errs <- fmt.Errorf("Update %s: %w", d.ID, err)
+					}
+				}
+				if _, err := db.ListDisasters(ctx, repository.Filter{Query: "flood", Limit: 5}); err != nil {
+					errs <- fmt.Errorf("ListDisasters: %w", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	all, err := db.ListDisasters(ctx, repository.Filter{})
+	if err != nil {
+		t.Fatalf("ListDisasters failed: %v", err)
+	}
+	if len(all) != writers*perWriter {
+		t.Fatalf("expected %d disasters, got %d", writers*perWriter, len(all))
+	}
+
+	// Every write got its own sequence number, and each disaster points at its latest event
+	events, err := db.ListEvents(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	updates := writers * ((perWriter + 4) / 5)
+	if len(events) != writers*perWriter+updates {
+		t.Fatalf("expected %d events, got %d", writers*perWriter+updates, len(events))
+	}
+	latest := make(map[string]int64)
+	for i, ev := range events {
+		if i > 0 && ev.Seq <= events[i-1].Seq {
+			t.Fatalf("events out of order: %d after %d", ev.Seq, events[i-1].Seq)
+		}
+		latest[ev.Disaster.ID] = ev.Seq
+	}
+	for _, d := range all {
+		if d.Seq != latest[d.ID] {
+			t.Errorf("%s: expected seq %d, got %d", d.ID, latest[d.ID], d.Seq)
+		}
+	}
+
+	// Consumers racing to acknowledge the same disasters count each one once
+	ids := disasterIDs(all)
+	var acked atomic.Int64
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := db.MarkAsSent(ctx, "discord", ids)
+			if err != nil {
+				t.Errorf("MarkAsSent failed: %v", err)
+			}
+			acked.Add(n)
+		}()
+	}
+	wg.Wait()
+	if acked.Load() != int64(len(ids)) {
+		t.Errorf("expected %d acknowledgments in total, got %d", len(ids), acked.Load())
+	}
+}
+
+func disasterIDs(disasters []models.Disaster) []string {
+	var ids []string
+	for _, d := range disasters {
+		ids = append(ids, d.ID)
+	}
+	return ids
+}
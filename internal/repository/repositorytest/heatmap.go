@@ -0,0 +1,81 @@
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+func testHeatmap(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	now := time.Now()
+	for _, d := range []*models.Disaster{
+		{ID: "tokyo", Type: disastersv1.DisasterType_EARTHQUAKE, AlertLevel: disastersv1.AlertLevel_RED, Title: "Earthquake near Tokyo",
+			AffectedPopulationCount: 1000, Latitude: 35.7, Longitude: 139.7},
+		{ID: "chiba", Type: disastersv1.DisasterType_EARTHQUAKE, AlertLevel: disastersv1.AlertLevel_GREEN, Title: "Earthquake off Chiba",
+			AffectedPopulationCount: 50, Latitude: 35.6, Longitude: 139.9},
+		{ID: "luzon", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_ORANGE, Title: "Flooding in Luzon",
+			AffectedPopulationCount: 5000, Latitude: 14.6, Longitude: 121},
+		{ID: "fiji", Type: disastersv1.DisasterType_CYCLONE, Title: "Cyclone near Fiji",
+			Latitude: -17.7, Longitude: 178},
+	} {
+		d.Source, d.Timestamp, d.CreatedAt = "GDACS", now, now
+		if err := db.Add(ctx, d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter repository.Filter
+		grid   geo.Grid
+		weight repository.HeatmapWeight
+		want   string
+	}{
+		{"count", repository.Filter{}, geo.DegreeGrid{Size: 10}, repository.WeightCount, "10:30:1 12:31:2 7:35:1"},
+		{"alert level", repository.Filter{}, geo.DegreeGrid{Size: 10}, repository.WeightAlertLevel, "10:30:1:2 12:31:2:4 7:35:1:0"},
+		{"population", repository.Filter{}, geo.DegreeGrid{Size: 10}, repository.WeightPopulation, "10:30:1:5000 12:31:2:1050 7:35:1:0"},
+		{"geohash", repository.Filter{}, geo.GeohashGrid{Precision: 2}, repository.WeightCount, "ru:1 wd:1 xn:2"},
+		{"geohash splits nearby points", repository.Filter{}, geo.GeohashGrid{Precision: 4}, repository.WeightCount, "ruye:1 wdw5:1 xn76:1 xn77:1"},
+		{"bbox", repository.Filter{BBox: &geo.BBox{MinLat: 0, MinLon: 100, MaxLat: 50, MaxLon: 150}}, geo.DegreeGrid{Size: 10}, repository.WeightCount, "10:30:1 12:31:2"},
+		{"area", repository.Filter{Area: geo.MultiPolygon{{{{130, 30}, {145, 30}, {145, 40}, {130, 40}, {130, 30}}}}}, geo.DegreeGrid{Size: 90}, repository.WeightPopulation, "1:3:2:1050"},
+		{"query", repository.Filter{Query: "earthquake"}, geo.DegreeGrid{Size: 90}, repository.WeightCount, "1:3:2"},
+		{"nothing matches", repository.Filter{Query: "volcano"}, geo.DegreeGrid{Size: 10}, repository.WeightCount, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cells, err := db.Heatmap(ctx, tt.filter, tt.grid, tt.weight)
+			if err != nil {
+				t.Fatalf("Heatmap failed: %v", err)
+			}
+			if got := formatCells(cells); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+			for _, c := range cells {
+				if key, bounds := tt.grid.Cell((c.Bounds.MinLat+c.Bounds.MaxLat)/2, (c.Bounds.MinLon+c.Bounds.MaxLon)/2); key != c.Key || bounds != c.Bounds {
+					t.Errorf("cell %s: bounds %+v do not match the grid", c.Key, c.Bounds)
+				}
+			}
+		})
+	}
+}
+
+// formatCells writes each cell as key:count, followed by :weight when it differs from the count
+func formatCells(cells []repository.HeatmapCell) string {
+	var parts []string
+	for _, c := range cells {
+		part := fmt.Sprintf("%s:%d", c.Key, c.Count)
+		if c.Weight != float64(c.Count) {
+			part += fmt.Sprintf(":%g", c.Weight)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,56 @@
+// Package repositorytest checks a storage backend against the behavior the repository interfaces
+// document. Every backend runs the same suite, so they can be swapped without changing results.
+package repositorytest
+
+import (
+	"testing"
+
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+// Factory returns an empty store for a single test. It is responsible for closing the store when
+// the test ends, e.g. with t.Cleanup.
+type Factory func(t *testing.T) repository.Store
+
+// Run runs the suite with a fresh store from newStore for each test
+func Run(t *testing.T, newStore Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, db repository.Store)
+	}{
+		{"AddAndGetDisaster", testAddAndGetDisaster},
+		{"NewFields", testNewFields},
+		{"Exists", testExists},
+		{"DuplicateAdd", testDuplicateAdd},
+		{"AddBatch", testAddBatch},
+		{"Seq", testSeq},
+		{"Update", testUpdate},
+		{"MarkAsSent", testMarkAsSent},
+		{"DeliveredPerConsumer", testDeliveredPerConsumer},
+		{"ListDisasters/WithFilters", testListDisastersWithFilters},
+		{"ListDisasters/AlertLevelFilters", testListDisastersAlertLevelFilters},
+		{"ListDisasters/AgreesWithMatches", testListDisastersAgreesWithMatches},
+		{"ListDisasters/LimitOffset", testListDisastersLimitOffset},
+		{"ListDisasters/LocationFilters", testLocationFilters},
+		{"ListDisasters/Area", testListDisastersArea},
+		{"ListDisasters/Query", testListDisastersQuery},
+		{"ListDisasters/Sort", testListDisastersSort},
+		{"ListDisasters/PageToken", testListDisastersPageToken},
+		{"ListDisasters/InvalidPageToken", testListDisastersInvalidPageToken},
+		{"Stats", testStats},
+		{"Heatmap", testHeatmap},
+		{"Corrections", testCorrections},
+		{"Audit", testAudit},
+		{"AuditedWrites", testAuditedWrites},
+		{"ConcurrentAccess", testConcurrentAccess},
+		{"Alerts", testAlerts},
+		{"ListAlerts/ByGeofence", testListAlertsByGeofence},
+		{"Geofences", testGeofences},
+		{"ApplyRetention", testApplyRetention},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package repositorytest
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+func testApplyRetention(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	green, orange, red := disastersv1.AlertLevel_GREEN, disastersv1.AlertLevel_ORANGE, disastersv1.AlertLevel_RED
+	flood, quake := disastersv1.DisasterType_FLOOD, disastersv1.DisasterType_EARTHQUAKE
+
+	disasters := []struct {
+		id         string
+		t          disastersv1.DisasterType
+		level      disastersv1.AlertLevel
+		age        time.Duration
+		raw        []byte
+		wantAction repository.RetentionAction // "" if kept as is
+	}{
+		{"old-green-flood", flood, green, 40 * day, []byte("raw"), repository.RetentionDelete},
+		{"old-orange-quake", quake, orange, 50 * day, []byte("raw"), repository.RetentionArchive},
+		{"old-red-flood", flood, red, 45 * day, []byte("raw"), repository.RetentionStripRaw},
+		{"old-red-no-raw", flood, red, 45 * day, nil, ""},
+		{"recent-green", flood, green, 20 * day, []byte("raw"), repository.RetentionStripRaw},
+		{"new-green", flood, green, day, []byte("raw"), ""},
+	}
+	for _, d := range disasters {
+		err := db.Add(ctx, &models.Disaster{
+			ID: d.id, Source: "GDACS", Type: d.t, AlertLevel: d.level, Title: d.id,
+			Timestamp: now.Add(-d.age), CreatedAt: now.Add(-d.age), Raw: d.raw,
+		})
+		if err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		if err := db.AddAlert(ctx, &models.Alert{ID: "a-" + d.id, DisasterID: d.id, Severity: models.AlertSeverityLow, CreatedAt: now}); err != nil {
+			t.Fatalf("AddAlert failed: %v", err)
+		}
+	}
+	if _, err := db.MarkAsSent(ctx, "discord", []string{"old-green-flood", "old-red-flood"}); err != nil {
+		t.Fatalf("MarkAsSent failed: %v", err)
+	}
+
+	// Rules stack: every disaster gets the most destructive action of the rules expiring it
+	rules := []repository.RetentionRule{
+		{MaxAge: 14 * day, Action: repository.RetentionStripRaw},
+		{AlertLevels: []disastersv1.AlertLevel{green}, MaxAge: 30 * day, Action: repository.RetentionDelete},
+		{Types: []disastersv1.DisasterType{quake}, MaxAge: 30 * day, Action: repository.RetentionArchive},
+	}
+	want := make(map[string]repository.RetentionAction)
+	for _, d := range disasters {
+		if d.wantAction != "" {
+			want[d.id] = d.wantAction
+		}
+	}
+	// Reports list disasters oldest first
+	wantIDs := []string{"old-orange-quake", "old-red-flood", "old-green-flood", "recent-green"}
+
+	checkReport := func(t *testing.T, report *repository.RetentionReport, dryRun bool) {
+		t.Helper()
+		if report.DryRun != dryRun {
+			t.Errorf("expected DryRun %v, got %v", dryRun, report.DryRun)
+		}
+		var ids []string
+		for _, e := range report.Disasters {
+			ids = append(ids, e.ID)
+			if e.Action != want[e.ID] {
+				t.Errorf("%s: expected action %q, got %q", e.ID, want[e.ID], e.Action)
+			}
+		}
+		if !slices.Equal(ids, wantIDs) {
+			t.Errorf("expected %v, got %v", wantIDs, ids)
+		}
+		if n := report.Count(repository.RetentionStripRaw); n != 2 {
+			t.Errorf("expected 2 stripped, got %d", n)
+		}
+	}
+
+	// A dry run reports without changing anything
+	report, err := db.ApplyRetention(ctx, rules, now, true)
+	if err != nil {
+		t.Fatalf("ApplyRetention dry run failed: %v", err)
+	}
+	checkReport(t, report, true)
+	for _, d := range disasters {
+		got, err := db.GetByID(ctx, d.id)
+		if err != nil || got == nil || string(got.Raw) != string(d.raw) {
+			t.Errorf("%s: expected dry run to keep it unchanged, got %+v (%v)", d.id, got, err)
+		}
+	}
+
+	report, err = db.ApplyRetention(ctx, rules, now, false)
+	if err != nil {
+		t.Fatalf("ApplyRetention failed: %v", err)
+	}
+	checkReport(t, report, false)
+
+	for _, d := range disasters {
+		got, err := db.GetByID(ctx, d.id)
+		if err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		alerts, err := db.GetByDisasterID(ctx, d.id)
+		if err != nil {
+			t.Fatalf("GetByDisasterID failed: %v", err)
+		}
+		switch d.wantAction {
+		case repository.RetentionArchive, repository.RetentionDelete:
+			if got != nil || len(alerts) != 0 {
+				t.Errorf("%s: expected it removed with its alerts, got %+v and %d alerts", d.id, got, len(alerts))
+			}
+		case repository.RetentionStripRaw:
+			if got == nil || got.Raw != nil || got.Title != d.id || len(alerts) != 1 {
+				t.Errorf("%s: expected it kept without raw, got %+v and %d alerts", d.id, got, len(alerts))
+			}
+		default:
+			if got == nil || string(got.Raw) != string(d.raw) {
+				t.Errorf("%s: expected it unchanged, got %+v", d.id, got)
+			}
+		}
+	}
+
+	// Events of removed disasters are gone; those of kept ones keep their sequence numbers
+	events, err := db.ListEvents(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	var eventIDs []string
+	for _, e := range events {
+		eventIDs = append(eventIDs, e.Disaster.ID)
+	}
+	if wantEvents := []string{"old-red-flood", "old-red-no-raw", "recent-green", "new-green"}; !slices.Equal(eventIDs, wantEvents) {
+		t.Errorf("expected events of %v, got %v", wantEvents, eventIDs)
+	}
+	if events, _ := db.ListEvents(ctx, 4, 1); len(events) != 1 || events[0].Seq != 5 {
+		t.Errorf("expected seq 5 after 4, got %+v", events)
+	}
+
+	delivered := true
+	sent, err := db.ListDisasters(ctx, repository.Filter{ConsumerID: "discord", Delivered: &delivered})
+	if err != nil {
+		t.Fatalf("ListDisasters failed: %v", err)
+	}
+	if ids := disasterIDs(sent); !slices.Equal(ids, []string{"old-red-flood"}) {
+		t.Errorf("expected only old-red-flood delivered, got %v", ids)
+	}
+
+	// Applying the rules again has nothing left to do
+	if report, err := db.ApplyRetention(ctx, rules, now, false); err != nil || len(report.Disasters) != 0 {
+		t.Errorf("expected an empty second run, got %+v (%v)", report, err)
+	}
+
+	// A disaster ingested again after it was archived can be archived again
+	again := &models.Disaster{ID: "old-orange-quake", Source: "GDACS", Type: quake, AlertLevel: red, Title: "reingested",
+		Timestamp: now.Add(-50 * day), CreatedAt: now}
+	if err := db.Add(ctx, again); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if report, err := db.ApplyRetention(ctx, rules, now, false); err != nil || report.Count(repository.RetentionArchive) != 1 {
+		t.Fatalf("expected the disaster archived again, got %+v (%v)", report, err)
+	}
+	if got, err := db.GetByID(ctx, again.ID); err != nil || got != nil {
+		t.Errorf("expected the re-archived disaster removed, got %+v (%v)", got, err)
+	}
+
+	for _, invalid := range [][]repository.RetentionRule{
+		nil,
+		{{MaxAge: 0, Action: repository.RetentionDelete}},
+		{{MaxAge: day, Action: "shred"}},
+	} {
+		if _, err := db.ApplyRetention(ctx, invalid, now, true); err == nil {
+			t.Errorf("expected an error for rules %+v", invalid)
+		}
+	}
+}
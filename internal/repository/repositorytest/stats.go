@@ -0,0 +1,129 @@
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+func testStats(t *testing.T, db repository.Store) {
+	ctx := context.Background()
+	created := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	// Local dates that differ from the UTC dates buckets use
+	mountain := time.FixedZone("MST", -7*60*60)
+	tokyo := time.FixedZone("JST", 9*60*60)
+	disasters := []*models.Disaster{
+		{ID: "q1", Source: "GDACS", Type: disastersv1.DisasterType_EARTHQUAKE, AlertLevel: disastersv1.AlertLevel_RED, Title: "Earthquake near Tokyo",
+			Magnitude: 7.1, AffectedPopulationCount: 1000, CountryISO: "JPN", Latitude: 35, Longitude: 139,
+			Timestamp: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)},
+		{ID: "q2", Source: "GDACS", Type: disastersv1.DisasterType_EARTHQUAKE, AlertLevel: disastersv1.AlertLevel_ORANGE, Title: "Earthquake off Chiba",
+			Magnitude: 5.5, AffectedPopulationCount: 200, CountryISO: "JPN", Latitude: 36, Longitude: 140,
+			Timestamp: time.Date(2026, 3, 1, 23, 30, 0, 0, mountain)},
+		{ID: "f1", Source: "GDACS", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_ORANGE, Title: "Flooding in Luzon",
+			AffectedPopulationCount: 5000, CountryISO: "PHL", Latitude: 14, Longitude: 121,
+			Timestamp: time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)},
+		{ID: "c1", Source: "USGS", Type: disastersv1.DisasterType_CYCLONE, AlertLevel: disastersv1.AlertLevel_GREEN, Title: "Cyclone Alpha",
+			Latitude: 25, Longitude: -80,
+			Timestamp: time.Date(2026, 4, 15, 8, 0, 0, 123456789, tokyo)},
+	}
+	for _, d := range disasters {
+		d.CreatedAt = created
+		if err := db.Add(ctx, d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	stats, err := db.Stats(ctx, repository.Filter{}, repository.BucketDay)
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if want := (repository.StatsGroup{Count: 4, MaxMagnitude: 7.1, TotalAffectedPopulation: 6200}); stats.Total != want {
+		t.Errorf("expected total %+v, got %+v", want, stats.Total)
+	}
+	if stats.Bucket != repository.BucketDay {
+		t.Errorf("expected bucket day, got %s", stats.Bucket)
+	}
+	for _, tt := range []struct {
+		name   string
+		groups []repository.StatsGroup
+		want   string
+	}{
+		{"type", stats.ByType, "EARTHQUAKE:2:7.1:1200 CYCLONE:1:0:0 FLOOD:1:0:5000"},
+		{"alert level", stats.ByAlertLevel, "ORANGE:2:5.5:5200 GREEN:1:0:0 RED:1:7.1:1000"},
+		{"country", stats.ByCountry, "JPN:2:7.1:1200 :1:0:0 PHL:1:0:5000"},
+		{"source", stats.BySource, "GDACS:3:7.1:6200 USGS:1:0:0"},
+		{"day", stats.ByTime, "2026-03-02:2:7.1:1200 2026-03-08:1:0:5000 2026-04-14:1:0:0"},
+	} {
+		if got := formatGroups(tt.groups); got != tt.want {
+			t.Errorf("by %s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+
+	if len(stats.Timeline) != len(stats.ByTime) {
+		t.Fatalf("expected a timeline bucket per time group, got %+v", stats.Timeline)
+	}
+	for i, b := range stats.Timeline {
+		if b.StatsGroup != stats.ByTime[i] {
+			t.Errorf("timeline bucket %d: expected %+v, got %+v", i, stats.ByTime[i], b.StatsGroup)
+		}
+	}
+	monthly, err := db.Stats(ctx, repository.Filter{}, repository.BucketMonth)
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if got, want := formatGroups(monthly.Timeline[0].ByType), "EARTHQUAKE:2:7.1:1200 FLOOD:1:0:5000"; got != want {
+		t.Errorf("expected %s in %s, got %s", want, monthly.Timeline[0].Key, got)
+	}
+
+	orange := disastersv1.AlertLevel_ORANGE
+	japan := geo.MultiPolygon{{{{130, 30}, {145, 30}, {145, 40}, {130, 40}, {130, 30}}}}
+	tests := []struct {
+		name   string
+		filter repository.Filter
+		bucket repository.TimeBucket
+		want   string
+	}{
+		{"week", repository.Filter{}, repository.BucketWeek, "2026-03-02:3:7.1:6200 2026-04-13:1:0:0"},
+		{"month", repository.Filter{}, repository.BucketMonth, "2026-03-01:3:7.1:6200 2026-04-01:1:0:0"},
+		{"created time", repository.Filter{TimeField: repository.TimeFieldCreated}, repository.BucketDay, "2026-05-01:4:7.1:6200"},
+		{"attributes", repository.Filter{MinAlertLevel: &orange, Countries: []string{"jpn"}}, repository.BucketMonth, "2026-03-01:2:7.1:1200"},
+		{"area", repository.Filter{Area: japan}, repository.BucketDay, "2026-03-02:2:7.1:1200"},
+		{"query", repository.Filter{Query: "earthquake"}, repository.BucketMonth, "2026-03-01:2:7.1:1200"},
+		{"paging ignored", repository.Filter{Limit: 1, Offset: 2, Sort: repository.SortMagnitude}, repository.BucketMonth, "2026-03-01:3:7.1:6200 2026-04-01:1:0:0"},
+		{"nothing matches", repository.Filter{Query: "volcano"}, repository.BucketDay, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := db.Stats(ctx, tt.filter, tt.bucket)
+			if err != nil {
+				t.Fatalf("Stats failed: %v", err)
+			}
+			if got := formatGroups(stats.ByTime); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+			var total int64
+			for _, g := range stats.ByType {
+				total += g.Count
+			}
+			if total != stats.Total.Count {
+				t.Errorf("expected type groups to add up to %d, got %d", stats.Total.Count, total)
+			}
+		})
+	}
+}
+
+// formatGroups writes each group as key:count:max_magnitude:total_population
+func formatGroups(groups []repository.StatsGroup) string {
+	var parts []string
+	for _, g := range groups {
+		parts = append(parts, fmt.Sprintf("%s:%d:%g:%d", g.Key, g.Count, g.MaxMagnitude, g.TotalAffectedPopulation))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+// RetentionAction is what happens to a disaster once a retention rule expires it
+type RetentionAction string
+
+const (
+	RetentionStripRaw RetentionAction = "strip_raw" // keep the disaster without its raw source payload
+	RetentionArchive  RetentionAction = "archive"   // move it to the archive without its raw payload
+	RetentionDelete   RetentionAction = "delete"
+)
+
+// severity orders actions from least to most destructive
+func (a RetentionAction) severity() int {
+	return slices.Index([]RetentionAction{RetentionStripRaw, RetentionArchive, RetentionDelete}, a)
+}
+
+// RetentionRule expires disasters of the given types and alert levels once their event time is
+// older than MaxAge. A disaster expired by several rules gets the most destructive of their actions,
+// so e.g. stripping raw payloads after a week and deleting green alerts after a month combine.
+type RetentionRule struct {
+	Types       []disastersv1.DisasterType // any type if empty
+	AlertLevels []disastersv1.AlertLevel   // any alert level if empty
+	MaxAge      time.Duration
+	Action      RetentionAction
+}
+
+func (r RetentionRule) Validate() error {
+	if r.MaxAge <= 0 {
+		return fmt.Errorf("retention age must be positive, got %s", r.MaxAge)
+	}
+	if r.Action.severity() < 0 {
+		return fmt.Errorf("unknown retention action %q", r.Action)
+	}
+	return nil
+}
+
+func (r RetentionRule) matches(c retentionCandidate, now time.Time) bool {
+	return c.timestamp.Before(now.Add(-r.MaxAge)) &&
+		(len(r.Types) == 0 || slices.Contains(r.Types, c.disasterType)) &&
+		(len(r.AlertLevels) == 0 || slices.Contains(r.AlertLevels, c.alertLevel))
+}
+
+// ExpiredDisaster is a disaster a retention run stripped, archived or deleted
+type ExpiredDisaster struct {
+	ID         string
+	Type       disastersv1.DisasterType
+	AlertLevel disastersv1.AlertLevel
+	Timestamp  time.Time
+	Action     RetentionAction
+}
+
+// RetentionReport lists the disasters a retention run expired, oldest first, or would have in a dry run
+type RetentionReport struct {
+	DryRun    bool
+	Disasters []ExpiredDisaster
+}
+
+// Count returns how many disasters the run expired with action
+func (r *RetentionReport) Count(action RetentionAction) int {
+	n := 0
+	for _, d := range r.Disasters {
+		if d.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// ids returns the IDs of disasters expired with action
+func (r *RetentionReport) ids(action RetentionAction) []string {
+	var ids []string
+	for _, d := range r.Disasters {
+		if d.Action == action {
+			ids = append(ids, d.ID)
+		}
+	}
+	return ids
+}
+
+// retentionCandidate is the part of a stored disaster retention rules look at
+type retentionCandidate struct {
+	id           string
+	disasterType disastersv1.DisasterType
+	alertLevel   disastersv1.AlertLevel
+	timestamp    time.Time
+	hasRaw       bool
+}
+
+// planRetention picks the action for each candidate, skipping those with nothing left to strip
+func planRetention(rules []RetentionRule, candidates []retentionCandidate, now time.Time, dryRun bool) *RetentionReport {
+	report := &RetentionReport{DryRun: dryRun}
+	for _, c := range candidates {
+		action := RetentionAction("")
+		for _, r := range rules {
+			if r.matches(c, now) && r.Action.severity() > action.severity() {
+				action = r.Action
+			}
+		}
+		if action == "" || (action == RetentionStripRaw && !c.hasRaw) {
+			continue
+		}
+		report.Disasters = append(report.Disasters, ExpiredDisaster{
+			ID: c.id, Type: c.disasterType, AlertLevel: c.alertLevel, Timestamp: c.timestamp, Action: action,
+		})
+	}
+	return report
+}
+
+// retentionCutoff is the event time before which any rule can expire a disaster
+func retentionCutoff(rules []RetentionRule, now time.Time) (time.Time, error) {
+	if len(rules) == 0 {
+		return time.Time{}, fmt.Errorf("no retention rules")
+	}
+	var minAge time.Duration
+	for i, r := range rules {
+		if err := r.Validate(); err != nil {
+			return time.Time{}, fmt.Errorf("retention rule %d: %w", i+1, err)
+		}
+		if i == 0 || r.MaxAge < minAge {
+			minAge = r.MaxAge
+		}
+	}
+	return now.Add(-minAge), nil
+}
+
+// archiveColumns are the disaster columns kept in disasters_archive
+var archiveColumns = strings.Join(slices.DeleteFunc(slices.Clone(disasterColumnNames), func(c string) bool {
+	return c == "raw" || c == "seq"
+}), ", ")
+
+// archiveUpsert replaces the archived copy of a disaster archived before, e.g. one ingested again
+// after it expired
+var archiveUpsert = func() string {
+	var set []string
+	for c := range strings.SplitSeq(archiveColumns+", archived_at", ", ") {
+		if c != "id" {
+			set = append(set, c+" = excluded."+c)
+		}
+	}
+	return "ON CONFLICT (id) DO UPDATE SET " + strings.Join(set, ", ")
+}()
+
+// retentionBatchSize bounds the IDs bound to each statement, below SQLite's variable limit
+const retentionBatchSize = 500
+
+// applyRetention expires disasters in a SQL database, binding ? placeholders with bind. Archived
+// and deleted disasters lose their events, deliveries and alerts along with them.
+func applyRetention(ctx context.Context, db *sql.DB, bind func(string) string, rules []RetentionRule, now time.Time, dryRun bool) (*RetentionReport, error) {
+	cutoff, err := retentionCutoff(rules, now)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, bind(`SELECT id, type, alert_level, timestamp, raw IS NOT NULL FROM disasters
+		WHERE timestamp < ? ORDER BY timestamp, id`), cutoff)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []retentionCandidate
+	for rows.Next() {
+		var c retentionCandidate
+		var typeInt, alertLevelInt int32
+		if err := rows.Scan(&c.id, &typeInt, &alertLevelInt, &c.timestamp, &c.hasRaw); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		c.disasterType = disastersv1.DisasterType(typeInt)
+		c.alertLevel = disastersv1.AlertLevel(alertLevelInt)
+		candidates = append(candidates, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report := planRetention(rules, candidates, now, dryRun)
+	if dryRun || len(report.Disasters) == 0 {
+		return report, nil
+	}
+
+	err = inTx(ctx, db, func(tx *sql.Tx) error {
+		exec := func(query string, ids []string, args ...any) error {
+			for chunk := range slices.Chunk(ids, retentionBatchSize) {
+				chunkArgs := slices.Clone(args)
+				for _, id := range chunk {
+					chunkArgs = append(chunkArgs, id)
+				}
+				q := strings.Replace(query, "(?...)", "("+placeholderList(len(chunk))+")", 1)
+				if _, err := tx.ExecContext(ctx, bind(q), chunkArgs...); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		if err := exec(`UPDATE disasters SET raw = NULL WHERE id IN (?...)`, report.ids(RetentionStripRaw)); err != nil {
+			return err
+		}
+		archived := report.ids(RetentionArchive)
+		err := exec(`INSERT INTO disasters_archive (`+archiveColumns+`, archived_at)
+			SELECT `+archiveColumns+`, ? FROM disasters WHERE id IN (?...) `+archiveUpsert, archived, now)
+		if err != nil {
+			return err
+		}
+
+		removed := append(archived, report.ids(RetentionDelete)...)
+		for _, table := range []string{"alerts", "deliveries", "disaster_events"} {
+			if err := exec(`DELETE FROM `+table+` WHERE disaster_id IN (?...)`, removed); err != nil {
+				return err
+			}
+		}
+		return exec(`DELETE FROM disasters WHERE id IN (?...)`, removed)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
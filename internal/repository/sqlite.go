@@ -3,134 +3,444 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"net/url"
+	"runtime"
 	"strings"
+	"time"
 
 	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
 	"github.com/mr1hm/go-disaster-alerts/internal/models"
 	_ "modernc.org/sqlite"
 )
 
 type SQLiteDB struct {
-	db *sql.DB
+	db   *sql.DB // the single writer connection, also used by migrations
+	read *sql.DB // read-only connections, so queries don't wait behind writes
 }
 
+// NewSQLiteDB opens the database at path and migrates it to the latest schema version.
 func NewSQLiteDB(path string) (*SQLiteDB, error) {
-	db, err := sql.Open("sqlite", path)
+	s, err := OpenSQLiteDB(path)
 	if err != nil {
-		return nil, fmt.Errorf("error opening database: %w", err)
+		return nil, err
+	}
+	if err := s.MigrateUp(context.Background(), 0); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("error while migrating database: %w", err)
 	}
+	return s, nil
+}
+
+// sqlitePragmas are applied to every connection through the DSN, so a connection the pool opens
+// later gets them too and opening fails if one can't be set. busy_timeout waits 5s on locks.
+var sqlitePragmas = []string{"busy_timeout(5000)", "journal_mode(WAL)", "synchronous(NORMAL)"}
 
-	// SQLite optimizations
-	db.SetMaxOpenConns(1) // Prevent concurrent write locks
-	db.Exec("PRAGMA journal_mode=WAL")
-	db.Exec("PRAGMA synchronous=NORMAL")
-	db.Exec("PRAGMA busy_timeout=5000") // Wait 5s on locks
+// OpenSQLiteDB opens the database at path without migrating it, for managing migrations.
+// Writes go through a single connection, since SQLite allows one writer at a time, while reads
+// use a pool of read-only connections that WAL mode lets run alongside the writer.
+func OpenSQLiteDB(path string) (*SQLiteDB, error) {
+	db, err := openSQLitePool(path, 1, sqlitePragmas...)
+	if err != nil {
+		return nil, err
+	}
 
+	// In-memory databases exist per connection, so they can only be read through the writer
+	if path == ":memory:" || strings.Contains(path, "mode=memory") {
+		return &SQLiteDB{db: db, read: db}, nil
+	}
+	read, err := openSQLitePool(path, max(4, runtime.NumCPU()), "busy_timeout(5000)", "query_only(1)")
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteDB{db: db, read: read}, nil
+}
+
+func openSQLitePool(path string, maxConns int, pragmas ...string) (*sql.DB, error) {
+	params := url.Values{"_pragma": pragmas}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	db, err := sql.Open("sqlite", path+sep+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+	db.SetMaxOpenConns(maxConns)
+	db.SetMaxIdleConns(maxConns)
+
+	// Opening the first connection applies the pragmas
 	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("error while pinging database: %w", err)
+		db.Close()
+		return nil, fmt.Errorf("error while opening database: %w", err)
+	}
+	return db, nil
+}
+
+// Disaster methods
+
+var disasterColumnNames = []string{
+	"id", "source", "type", "title", "description", "magnitude", "alert_level", "latitude", "longitude", "timestamp",
+	"country", "affected_population", "affected_population_count", "report_url", "raw", "created_at", "seq", "closed", "updated_at",
+	"country_iso", "corrections",
+}
+
+var disasterColumns = strings.Join(disasterColumnNames, ", ")
+
+// qualifiedDisasterColumns returns the disaster columns prefixed with a table alias for joins
+func qualifiedDisasterColumns(alias string) string {
+	cols := make([]string, len(disasterColumnNames))
+	for i, c := range disasterColumnNames {
+		cols[i] = alias + "." + c
 	}
+	return strings.Join(cols, ", ")
+}
 
-	s := &SQLiteDB{
-		db: db,
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanDisaster scans the disaster columns followed by any extra selected columns
+func scanDisaster(row rowScanner, extra ...any) (models.Disaster, error) {
+	var d models.Disaster
+	var typeInt, alertLevelInt int32
+	var updatedAt sql.NullTime
+	var corrections string
+	dest := []any{
+		&d.ID, &d.Source, &typeInt, &d.Title, &d.Description,
+		&d.Magnitude, &alertLevelInt, &d.Latitude, &d.Longitude, &d.Timestamp,
+		&d.Country, &d.AffectedPopulation, &d.AffectedPopulationCount, &d.ReportURL, &d.Raw, &d.CreatedAt,
+		&d.Seq, &d.Closed, &updatedAt, &d.CountryISO, &corrections,
 	}
-	if err := s.migrate(); err != nil {
-		return nil, fmt.Errorf("error while migrating to database: %w", err)
+	err := row.Scan(append(dest, extra...)...)
+	d.Type = disastersv1.DisasterType(typeInt)
+	d.AlertLevel = disastersv1.AlertLevel(alertLevelInt)
+	d.UpdatedAt = updatedAt.Time
+	if corrections != "" {
+		d.Corrections = strings.Split(corrections, ",")
 	}
+	return d, err
+}
 
-	return s, nil
+// Add stores d with a CREATED event and sets d.Seq to the event's sequence number.
+func (s *SQLiteDB) Add(ctx context.Context, d *models.Disaster) error {
+	return s.AddBatch(ctx, []*models.Disaster{d})
 }
 
-func (s *SQLiteDB) migrate() error {
-	schema := `
-		CREATE TABLE IF NOT EXISTS disasters (
-			id TEXT PRIMARY KEY,
-			source TEXT NOT NULL,
-			type INTEGER NOT NULL,
-			title TEXT NOT NULL,
-			description TEXT,
-			magnitude REAL,
-			alert_level INTEGER DEFAULT 0,
-			latitude REAL NOT NULL,
-			longitude REAL NOT NULL,
-			timestamp DATETIME NOT NULL,
-			country TEXT DEFAULT '',
-			affected_population TEXT DEFAULT '',
-			affected_population_count INTEGER DEFAULT 0,
-			report_url TEXT DEFAULT '',
-			raw BLOB,
-			created_at DATETIME NOT NULL,
-			discord_sent BOOLEAN DEFAULT FALSE
-		);
-
-		CREATE TABLE IF NOT EXISTS alerts (
-			id TEXT PRIMARY KEY,
-			disaster_id TEXT NOT NULL,
-			severity TEXT NOT NULL,
-			created_at DATETIME NOT NULL,
-			FOREIGN KEY (disaster_id) REFERENCES disasters(id)
-		);
-
-		CREATE INDEX IF NOT EXISTS idx_disasters_timestamp ON disasters(timestamp);
-		CREATE INDEX IF NOT EXISTS idx_disasters_type ON disasters(type);
-		CREATE INDEX IF NOT EXISTS idx_disasters_alert_level ON disasters(alert_level);
-		CREATE INDEX IF NOT EXISTS idx_disasters_discord_sent ON disasters(discord_sent);
-		CREATE INDEX IF NOT EXISTS idx_alerts_disaster_id ON alerts(disaster_id);
-  	`
-
-	_, err := s.db.Exec(schema)
+// AddBatch stores every disaster in ds with its CREATED event in a single transaction, reusing
+// prepared statements, and sets each Seq. Nothing is stored if any insert fails.
+func (s *SQLiteDB) AddBatch(ctx context.Context, ds []*models.Disaster) error {
+	return s.addBatch(ctx, ds, nil)
+}
+
+// addBatch is AddBatch, running also (if set) in the same transaction before commit
+func (s *SQLiteDB) addBatch(ctx context.Context, ds []*models.Disaster, also func(tx *sql.Tx) error) error {
+	if len(ds) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
+	insert, err := tx.PrepareContext(ctx, `
+		INSERT INTO disasters (id, source, type, title, description, magnitude, alert_level, latitude, longitude, timestamp, country, affected_population, affected_population_count, report_url, raw, created_at, closed, country_iso, corrections)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	event, err := tx.PrepareContext(ctx, `INSERT INTO disaster_events (disaster_id, kind, created_at, snapshot) VALUES (?, ?, ?, ?) RETURNING seq`)
+	if err != nil {
+		return err
+	}
+	defer event.Close()
+	setSeq, err := tx.PrepareContext(ctx, `UPDATE disasters SET seq = ? WHERE id = ?`)
+	if err != nil {
+		return err
+	}
+	defer setSeq.Close()
+
+	seqs := make([]int64, len(ds))
+	for i, d := range ds {
+		_, err := insert.ExecContext(ctx,
+			d.ID, d.Source, int32(d.Type), d.Title, d.Description,
+			d.Magnitude, int32(d.AlertLevel), d.Latitude, d.Longitude, d.Timestamp,
+			d.Country, d.AffectedPopulation, d.AffectedPopulationCount, d.ReportURL, d.Raw, d.CreatedAt, d.Closed, d.CountryISO,
+			strings.Join(d.Corrections, ","),
+		)
+		if err != nil {
+			return fmt.Errorf("error adding disaster %s: %w", d.ID, err)
+		}
+		snapshot, err := eventSnapshot(d)
+		if err != nil {
+			return err
+		}
+		if err := event.QueryRowContext(ctx, d.ID, int32(disastersv1.EventKind_EVENT_KIND_CREATED), d.CreatedAt, snapshot).Scan(&seqs[i]); err != nil {
+			return err
+		}
+		if _, err := setSeq.ExecContext(ctx, seqs[i], d.ID); err != nil {
+			return err
+		}
+	}
+	if also != nil {
+		if err := also(tx); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for i, d := range ds {
+		d.Seq = seqs[i]
+	}
 	return nil
 }
 
-// Disaster methods
+// Update stores the current fields of an existing disaster with an event of the given kind
+// and sets d.Seq to the event's sequence number. CreatedAt is never changed.
+func (s *SQLiteDB) Update(ctx context.Context, d *models.Disaster, kind disastersv1.EventKind) error {
+	return s.update(ctx, d, kind, false, nil)
+}
+
+// UpdateIfUnchanged stores d like Update if d.Seq is still the stored seq.
+func (s *SQLiteDB) UpdateIfUnchanged(ctx context.Context, d *models.Disaster, kind disastersv1.EventKind) error {
+	return s.update(ctx, d, kind, true, nil)
+}
+
+// update is Update, checking d.Seq first if checkSeq is set and storing audit if not nil.
+func (s *SQLiteDB) update(ctx context.Context, d *models.Disaster, kind disastersv1.EventKind, checkSeq bool, audit *models.AuditEntry) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-func (s *SQLiteDB) Add(ctx context.Context, d *models.Disaster) error {
 	query := `
-		INSERT INTO disasters (id, source, type, title, description, magnitude, alert_level, latitude, longitude, timestamp, country, affected_population, affected_population_count, report_url, raw, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		UPDATE disasters SET source = ?, type = ?, title = ?, description = ?, magnitude = ?, alert_level = ?, latitude = ?, longitude = ?, timestamp = ?,
+			country = ?, affected_population = ?, affected_population_count = ?, report_url = ?, raw = ?, closed = ?, updated_at = ?, country_iso = ?,
+			corrections = ?
+		WHERE id = ?
 	`
-	_, err := s.db.ExecContext(ctx, query,
-		d.ID, d.Source, int32(d.Type), d.Title, d.Description,
-		d.Magnitude, int32(d.AlertLevel), d.Latitude, d.Longitude, d.Timestamp,
-		d.Country, d.AffectedPopulation, d.AffectedPopulationCount, d.ReportURL, d.Raw, d.CreatedAt,
-	)
-	return err
+	args := []any{
+		d.Source, int32(d.Type), d.Title, d.Description, d.Magnitude, int32(d.AlertLevel), d.Latitude, d.Longitude, d.Timestamp,
+		d.Country, d.AffectedPopulation, d.AffectedPopulationCount, d.ReportURL, d.Raw, d.Closed, d.UpdatedAt, d.CountryISO,
+		strings.Join(d.Corrections, ","),
+		d.ID,
+	}
+	if checkSeq {
+		query += ` AND seq = ?`
+		args = append(args, d.Seq)
+	}
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return notUpdated(ctx, tx, identity, d.ID)
+	}
+
+	seq, err := recordEvent(ctx, tx, d, kind, d.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if audit != nil {
+		if err := insertAuditEntry(ctx, tx, identity, audit); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	d.Seq = seq
+	return nil
+}
+
+// notUpdated explains why an UPDATE matched no rows: ErrNotFound if the disaster is missing,
+// otherwise ErrConflict because its seq no longer matched
+func notUpdated(ctx context.Context, tx *sql.Tx, bind func(string) string, id string) error {
+	var exists bool
+	err := tx.QueryRowContext(ctx, bind(`SELECT EXISTS (SELECT 1 FROM disasters WHERE id = ?)`), id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return ErrConflict
+}
+
+func identity(query string) string {
+	return query
+}
+
+// recordEvent appends d's new state to the event log and points the disaster at the new sequence number
+func recordEvent(ctx context.Context, tx *sql.Tx, d *models.Disaster, kind disastersv1.EventKind, at time.Time) (int64, error) {
+	snapshot, err := eventSnapshot(d)
+	if err != nil {
+		return 0, err
+	}
+	var seq int64
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO disaster_events (disaster_id, kind, created_at, snapshot) VALUES (?, ?, ?, ?) RETURNING seq`,
+		d.ID, int32(kind), at, snapshot,
+	).Scan(&seq)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE disasters SET seq = ? WHERE id = ?`, seq, d.ID)
+	return seq, err
+}
+
+// eventSnapshot encodes the state of d an event records, without the raw source payload
+func eventSnapshot(d *models.Disaster) (string, error) {
+	s := *d
+	s.Raw = nil
+	s.DistanceKm = nil
+	s.Snippet = ""
+	s.PageToken = ""
+	b, err := json.Marshal(&s)
+	return string(b), err
+}
+
+// scanEvent scans a disaster's current columns followed by the seq, kind, created_at and snapshot of
+// one of its events. The event carries the snapshot if it has one; events recorded before snapshots
+// were kept carry the current state.
+func scanEvent(row rowScanner) (models.DisasterEvent, error) {
+	var ev models.DisasterEvent
+	var kindInt int32
+	var snapshot sql.NullString
+	d, err := scanDisaster(row, &ev.Seq, &kindInt, &ev.CreatedAt, &snapshot)
+	if err != nil {
+		return ev, err
+	}
+	if snapshot.Valid {
+		d = models.Disaster{}
+		if err := json.Unmarshal([]byte(snapshot.String), &d); err != nil {
+			return ev, fmt.Errorf("invalid snapshot in event %d: %w", ev.Seq, err)
+		}
+		d.Seq = ev.Seq
+	}
+	ev.Kind = disastersv1.EventKind(kindInt)
+	ev.Disaster = &d
+	return ev, nil
 }
 
 func (s *SQLiteDB) GetByID(ctx context.Context, id string) (*models.Disaster, error) {
-	query := `SELECT id, source, type, title, description, magnitude, alert_level, latitude, longitude, timestamp, country, affected_population, affected_population_count, report_url, raw, created_at FROM disasters WHERE id = ?`
+	query := `SELECT ` + disasterColumns + ` FROM disasters WHERE id = ?`
 
-	var d models.Disaster
-	var typeInt, alertLevelInt int32
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
-		&d.ID, &d.Source, &typeInt, &d.Title, &d.Description,
-		&d.Magnitude, &alertLevelInt, &d.Latitude, &d.Longitude, &d.Timestamp,
-		&d.Country, &d.AffectedPopulation, &d.AffectedPopulationCount, &d.ReportURL, &d.Raw, &d.CreatedAt,
-	)
+	d, err := scanDisaster(s.read.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	d.Type = disastersv1.DisasterType(typeInt)
-	d.AlertLevel = disastersv1.AlertLevel(alertLevelInt)
 	return &d, nil
 }
 
 func (s *SQLiteDB) Exists(ctx context.Context, id string) (bool, error) {
 	var exists bool
 	query := `SELECT EXISTS(SELECT 1 FROM disasters WHERE id = ?)`
-	err := s.db.QueryRowContext(ctx, query, id).Scan(&exists)
+	err := s.read.QueryRowContext(ctx, query, id).Scan(&exists)
 	return exists, err
 }
 
+// ListDisasters returns disasters matching opts in the order opts.Sort and opts.Order select, newest first
+// by default. With a Near filter each result's DistanceKm is set to its great-circle distance from the center.
 func (s *SQLiteDB) ListDisasters(ctx context.Context, opts Filter) ([]models.Disaster, error) {
-	query := `SELECT id, source, type, title, description, magnitude, alert_level, latitude, longitude, timestamp, country, affected_population, affected_population_count, report_url, raw, created_at FROM disasters`
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	var after *pageToken
+	if opts.PageToken != "" {
+		var err error
+		if after, err = decodePageToken(opts.PageToken); err != nil {
+			return nil, err
+		}
+	}
+
+	match := ftsQuery(opts.Query)
+	if match == "" {
+		opts.Query = "" // nothing searchable, e.g. only punctuation
+	}
+	order := newListOrder(opts, haversineSQL, "fts.rank")
+
+	columns := disasterColumns + ", CAST(" + order.timeColumn + " AS TEXT)"
+	var conditions []string
+	args := []any{}
+
+	if opts.Near != nil {
+		columns += ", " + haversineSQL + " AS distance_km"
+		args = append(args, opts.Near.Lat, opts.Near.Lat, opts.Near.Lon)
+	}
+	if match != "" {
+		columns += ", fts.snippet"
+	}
+	if order.key != "" {
+		columns += ", " + order.key + " AS sort_key"
+		args = append(args, order.keyArgs...)
+	}
+	query := `SELECT ` + columns + ` FROM disasters`
+	if match != "" {
+		query += ` JOIN (` + ftsSearchSQL + `) fts ON fts.disaster_id = disasters.id`
+		args = append(args, match)
+	}
+
+	filterConditions, filterArgs := sqliteFilterConditions(opts, order.timeColumn)
+	conditions = append(conditions, filterConditions...)
+	args = append(args, filterArgs...)
+
+	if after != nil {
+		cond, condArgs, err := order.after(after)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, cond)
+		args = append(args, condArgs...)
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query += " ORDER BY " + order.clause()
+
+	if opts.Area != nil {
+		disasters, err := queryDisasters(ctx, s.read, opts, order, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		return paginate(withinArea(disasters, opts.Area), opts.Limit, opts.Offset), nil
+	}
+
+	if opts.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, opts.Limit)
+	} else if opts.Offset > 0 {
+		query += ` LIMIT -1` // SQLite only takes OFFSET after a LIMIT
+	}
+	if opts.Offset > 0 {
+		query += ` OFFSET ?`
+		args = append(args, opts.Offset)
+	}
+
+	return queryDisasters(ctx, s.read, opts, order, query, args...)
+}
+
+// sqliteFilterConditions returns the conditions selecting the disasters opts matches, apart from its
+// query and page token. An Area is only prefiltered on its bounds; callers check points in Go.
+func sqliteFilterConditions(opts Filter, timeColumn string) ([]string, []any) {
 	var conditions []string
 	args := []any{}
 
@@ -139,13 +449,21 @@ func (s *SQLiteDB) ListDisasters(ctx context.Context, opts Filter) ([]models.Dis
 		args = append(args, int32(*opts.Type))
 	}
 	if opts.Since != nil {
-		conditions = append(conditions, "timestamp >= ?")
+		conditions = append(conditions, timeColumn+" >= ?")
 		args = append(args, *opts.Since)
 	}
+	if opts.Until != nil {
+		conditions = append(conditions, timeColumn+" < ?")
+		args = append(args, *opts.Until)
+	}
 	if opts.MinMagnitude != nil {
 		conditions = append(conditions, "magnitude >= ?")
 		args = append(args, *opts.MinMagnitude)
 	}
+	if opts.MaxMagnitude != nil {
+		conditions = append(conditions, "magnitude <= ?")
+		args = append(args, *opts.MaxMagnitude)
+	}
 	if opts.AlertLevel != nil {
 		conditions = append(conditions, "alert_level = ?")
 		args = append(args, int32(*opts.AlertLevel))
@@ -154,31 +472,260 @@ func (s *SQLiteDB) ListDisasters(ctx context.Context, opts Filter) ([]models.Dis
 		conditions = append(conditions, "alert_level >= ?")
 		args = append(args, int32(*opts.MinAlertLevel))
 	}
-	if opts.DiscordSent != nil {
-		conditions = append(conditions, "discord_sent = ?")
-		args = append(args, *opts.DiscordSent)
+	if opts.Delivered != nil {
+		delivered := "EXISTS (SELECT 1 FROM deliveries dl WHERE dl.disaster_id = disasters.id AND dl.consumer_id = ?)"
+		if !*opts.Delivered {
+			delivered = "NOT " + delivered
+		}
+		conditions = append(conditions, delivered)
+		args = append(args, opts.ConsumerID)
 	}
 	if opts.MinAffectedPopulationCount != nil {
 		conditions = append(conditions, "affected_population_count >= ?")
 		args = append(args, *opts.MinAffectedPopulationCount)
 	}
+	if len(opts.Types) > 0 {
+		conditions = append(conditions, "type IN ("+placeholderList(len(opts.Types))+")")
+		for _, t := range opts.Types {
+			args = append(args, int32(t))
+		}
+	}
+	if len(opts.Countries) > 0 {
+		conditions = append(conditions, "country_iso IN ("+placeholderList(len(opts.Countries))+")")
+		for _, c := range opts.Countries {
+			args = append(args, strings.ToUpper(c))
+		}
+	}
+	if len(opts.Sources) > 0 {
+		conditions = append(conditions, "source COLLATE NOCASE IN ("+placeholderList(len(opts.Sources))+")")
+		for _, src := range opts.Sources {
+			args = append(args, src)
+		}
+	}
+	if opts.BBox != nil {
+		b := opts.BBox
+		cond, condArgs := rtreeCondition(*b)
+		conditions = append(conditions, cond)
+		args = append(args, condArgs...)
 
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
+		// Exact bounds: the R*Tree stores 32-bit floats rounded outward, so it can over-match
+		conditions = append(conditions, "latitude BETWEEN ? AND ?")
+		args = append(args, b.MinLat, b.MaxLat)
+		if b.CrossesAntimeridian() {
+			conditions = append(conditions, "(longitude >= ? OR longitude <= ?)")
+		} else {
+			conditions = append(conditions, "longitude BETWEEN ? AND ?")
+		}
+		args = append(args, b.MinLon, b.MaxLon)
 	}
+	if opts.Near != nil {
+		// R*Tree prefilter on the circle's bounding box, then the exact great-circle distance
+		cond, condArgs := rtreeCondition(opts.Near.Bounds())
+		conditions = append(conditions, cond)
+		args = append(args, condArgs...)
 
-	query += ` ORDER BY timestamp DESC`
+		conditions = append(conditions, haversineSQL+" <= ?")
+		args = append(args, opts.Near.Lat, opts.Near.Lat, opts.Near.Lon, opts.Near.RadiusKm)
+	}
 
-	if opts.Limit > 0 {
+	if opts.Area != nil {
+		// Polygons can't be tested in SQL: prefilter on their bounds here, callers check points in Go
+		cond, condArgs := rtreeCondition(opts.Area.Bounds()...)
+		conditions = append(conditions, cond)
+		args = append(args, condArgs...)
+	}
+	return conditions, args
+}
+
+// listOrder is the ORDER BY of a ListDisasters query: an optional primary sort key, then time and ID
+type listOrder struct {
+	name       string // sort field and direction, recorded in page tokens
+	key        string // SQL expression of the primary sort key, empty when sorting by time
+	keyArgs    []any
+	asc        bool // direction of the primary key, or of time and ID when there is none
+	timeColumn string
+}
+
+// filterTimeColumn is the column of the time opts.TimeField selects
+func filterTimeColumn(opts Filter) string {
+	if opts.TimeField == TimeFieldCreated {
+		return "created_at"
+	}
+	return "timestamp"
+}
+
+// newListOrder takes the backend's SQL for the distance from (?, ?) as (lat, lat, lon) args,
+// and for a full-text rank where lower is more relevant
+func newListOrder(opts Filter, distanceSQL, rankSQL string) listOrder {
+	field, asc := opts.SortKey()
+	o := listOrder{asc: asc, timeColumn: filterTimeColumn(opts)}
+
+	switch field {
+	case SortMagnitude:
+		o.key = "magnitude"
+	case SortAlertLevel:
+		o.key = "alert_level"
+	case SortPopulation:
+		o.key = "affected_population_count"
+	case SortDistance:
+		o.key = distanceSQL
+		o.keyArgs = []any{opts.Near.Lat, opts.Near.Lat, opts.Near.Lon}
+	case SortRelevance:
+		o.key = rankSQL
+	}
+
+	o.name = fmt.Sprintf("%s:%s", field, o.timeColumn)
+	if asc {
+		o.name += ":asc"
+	}
+	return o
+}
+
+func (o listOrder) direction() string {
+	if o.asc {
+		return "ASC"
+	}
+	return "DESC"
+}
+
+func (o listOrder) clause() string {
+	if o.key == "" {
+		return o.timeColumn + " " + o.direction() + ", disasters.id " + o.direction()
+	}
+	return "sort_key " + o.direction() + ", " + o.timeColumn + " DESC, disasters.id DESC"
+}
+
+// after is the keyset condition selecting rows that follow the token in this order,
+// so pages don't shift as disasters are added
+func (o listOrder) after(p *pageToken) (string, []any, error) {
+	if p.Sort != o.name || (p.Key != nil) != (o.key != "") {
+		return "", nil, ErrInvalidPageToken
+	}
+
+	cmp := "<"
+	if o.asc {
+		cmp = ">"
+	}
+	if o.key == "" {
+		return fmt.Sprintf("(%s, disasters.id) %s (?, ?)", o.timeColumn, cmp), []any{p.Timestamp, p.ID}, nil
+	}
+
+	cond := fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND (%[3]s, disasters.id) < (?, ?)))", o.key, cmp, o.timeColumn)
+	var args []any
+	args = append(args, o.keyArgs...)
+	args = append(args, *p.Key)
+	args = append(args, o.keyArgs...)
+	args = append(args, *p.Key, p.Timestamp, p.ID)
+	return cond, args, nil
+}
+
+// ftsSearchSQL ranks full-text matches with BM25, weighting title over country over description,
+// and picks the best matching fragment of any column as the snippet
+const ftsSearchSQL = `SELECT disaster_id,
+	bm25(disasters_fts, 0, 10.0, 1.0, 5.0) AS rank,
+	snippet(disasters_fts, -1, '<mark>', '</mark>', '…', 12) AS snippet
+	FROM disasters_fts WHERE disasters_fts MATCH ?`
+
+// ftsQuery turns free text into an FTS5 query: every term quoted, so operators and punctuation in
+// user input are matched literally, and ANDed. Empty when the text has no terms.
+func ftsQuery(q string) string {
+	terms := SearchTerms(q)
+	for i, t := range terms {
+		prefix := strings.HasSuffix(t, "*")
+		terms[i] = `"` + strings.TrimRight(t, "*") + `"`
+		if prefix {
+			terms[i] += "*"
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+func withinArea(disasters []models.Disaster, area geo.MultiPolygon) []models.Disaster {
+	kept := disasters[:0]
+	for _, d := range disasters {
+		if area.Contains(d.Latitude, d.Longitude) {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+// paginate applies offset and limit to results filtered after the query
+func paginate(disasters []models.Disaster, limit, offset int) []models.Disaster {
+	if offset >= len(disasters) {
+		return nil
+	}
+	disasters = disasters[offset:]
+	if limit > 0 && len(disasters) > limit {
+		disasters = disasters[:limit]
+	}
+	return disasters
+}
+
+// haversineSQL is the great-circle distance in km from (?, ?) taken as (lat, lat, lon) args, matching geo.DistanceKm
+var haversineSQL = fmt.Sprintf(`(2 * %f * asin(sqrt(min(1,
+	pow(sin(radians(latitude - ?) / 2), 2) +
+	cos(radians(?)) * cos(radians(latitude)) * pow(sin(radians(longitude - ?) / 2), 2)))))`, geo.EarthRadiusKm)
+
+// rtreeCondition limits disasters to R*Tree entries intersecting any of the boxes. Boxes crossing
+// the antimeridian are queried as two halves, since R*Tree ranges cannot wrap.
+func rtreeCondition(boxes ...geo.BBox) (string, []any) {
+	var parts []geo.BBox
+	for _, b := range boxes {
+		parts = append(parts, b.Unwrapped()...)
+	}
+
+	var selects []string
+	var args []any
+	for _, part := range parts {
+		selects = append(selects, `SELECT disaster_id FROM disasters_rtree WHERE max_lat >= ? AND min_lat <= ? AND max_lon >= ? AND min_lon <= ?`)
+		args = append(args, part.MinLat, part.MaxLat, part.MinLon, part.MaxLon)
+	}
+	return "id IN (" + strings.Join(selects, " UNION ALL ") + ")", args
+}
+
+// placeholderList returns n comma-separated "?" placeholders for an IN clause
+func placeholderList(n int) string {
+	placeholders := make([]string, n)
+	for i := range placeholders {
+		placeholders[i] = "?"
+	}
+	return strings.Join(placeholders, ",")
+}
+
+func (s *SQLiteDB) ListEvents(ctx context.Context, afterSeq int64, limit int) ([]models.DisasterEvent, error) {
+	query := `SELECT ` + qualifiedDisasterColumns("d") + `, e.seq, e.kind, e.created_at, e.snapshot
+		FROM disaster_events e JOIN disasters d ON d.id = e.disaster_id
+		WHERE e.seq > ? ORDER BY e.seq ASC`
+	args := []any{afterSeq}
+	if limit > 0 {
 		query += ` LIMIT ?`
-		args = append(args, opts.Limit)
+		args = append(args, limit)
 	}
-	if opts.Offset > 0 {
-		query += ` OFFSET ?`
-		args = append(args, opts.Offset)
+
+	rows, err := s.read.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	var events []models.DisasterEvent
+	for rows.Next() {
+		ev, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+
+	return events, rows.Err()
+}
+
+// queryDisasters runs a ListDisasters query selecting the disaster columns and the stored text of the
+// order's time column, followed by distance_km for radius filters, the snippet for text queries
+// and the order's sort key
+func queryDisasters(ctx context.Context, db *sql.DB, opts Filter, order listOrder, query string, args ...any) ([]models.Disaster, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -186,54 +733,118 @@ func (s *SQLiteDB) ListDisasters(ctx context.Context, opts Filter) ([]models.Dis
 
 	var disasters []models.Disaster
 	for rows.Next() {
-		var d models.Disaster
-		var typeInt, alertLevelInt int32
-		if err := rows.Scan(
-			&d.ID, &d.Source, &typeInt, &d.Title, &d.Description,
-			&d.Magnitude, &alertLevelInt, &d.Latitude, &d.Longitude, &d.Timestamp,
-			&d.Country, &d.AffectedPopulation, &d.AffectedPopulationCount, &d.ReportURL, &d.Raw, &d.CreatedAt,
-		); err != nil {
+		page := pageToken{Sort: order.name}
+		var distance, key float64
+		var snippet string
+		extra := []any{&page.Timestamp}
+		if opts.Near != nil {
+			extra = append(extra, &distance)
+		}
+		if opts.Query != "" {
+			extra = append(extra, &snippet)
+		}
+		if order.key != "" {
+			extra = append(extra, &key)
+			page.Key = &key
+		}
+		d, err := scanDisaster(rows, extra...)
+		if err != nil {
 			return nil, err
 		}
-		d.Type = disastersv1.DisasterType(typeInt)
-		d.AlertLevel = disastersv1.AlertLevel(alertLevelInt)
+		if opts.Near != nil {
+			d.DistanceKm = &distance
+		}
+		if opts.Query != "" {
+			d.Snippet = snippet
+		}
+		page.ID = d.ID
+		d.PageToken = page.encode()
 		disasters = append(disasters, d)
 	}
 
 	return disasters, rows.Err()
 }
 
-// Alert methods
+// Stats aggregates the disasters opts matches, ignoring its paging and sort fields
+func (s *SQLiteDB) Stats(ctx context.Context, opts Filter, bucket TimeBucket) (*Stats, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	timeColumn := filterTimeColumn(opts)
+	columns := "type, alert_level, COALESCE(country_iso, ''), source, " + sqliteBucketSQL(bucket, timeColumn)
+	groupBy := "1, 2, 3, 4, 5"
+	if opts.Area != nil {
+		// Points are checked in Go, so group each location apart
+		columns += ", latitude, longitude"
+		groupBy += ", 6, 7"
+	}
+	from, args := sqliteFilteredFrom(opts, timeColumn)
+	query := `SELECT ` + columns + `, COUNT(*), COALESCE(MAX(magnitude), 0), COALESCE(SUM(affected_population_count), 0)` + from + " GROUP BY " + groupBy
 
-func (s *SQLiteDB) AddAlert(ctx context.Context, a *models.Alert) error {
-	query := `INSERT INTO alerts (id, disaster_id, severity, created_at) VALUES (?, ?, ?, ?)`
-	_, err := s.db.ExecContext(ctx, query, a.ID, a.DisasterID, a.Severity, a.CreatedAt)
-	return err
+	return queryStats(ctx, s.read, opts.Area, bucket, query, args...)
 }
 
-func (s *SQLiteDB) GetByDisasterID(ctx context.Context, disasterID string) ([]models.Alert, error) {
-	query := `SELECT id, disaster_id, severity, created_at FROM alerts WHERE disaster_id = ?`
-
-	rows, err := s.db.QueryContext(ctx, query, disasterID)
-	if err != nil {
+func (s *SQLiteDB) Heatmap(ctx context.Context, opts Filter, grid geo.Grid, weight HeatmapWeight) ([]HeatmapCell, error) {
+	if err := opts.Validate(); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	from, args := sqliteFilteredFrom(opts, filterTimeColumn(opts))
+	query := `SELECT latitude, longitude, alert_level, affected_population_count` + from
+	return queryHeatmap(ctx, s.read, opts.Area, grid, weight, query, args...)
+}
 
-	var alerts []models.Alert
-	for rows.Next() {
-		var a models.Alert
-		if err := rows.Scan(&a.ID, &a.DisasterID, &a.Severity, &a.CreatedAt); err != nil {
-			return nil, err
-		}
-		alerts = append(alerts, a)
+// sqliteFilteredFrom returns the FROM and WHERE clauses selecting the disasters opts matches,
+// ignoring paging and sorting
+func sqliteFilteredFrom(opts Filter, timeColumn string) (string, []any) {
+	from := " FROM disasters"
+	args := []any{}
+	if match := ftsQuery(opts.Query); match != "" {
+		from += ` JOIN (` + ftsSearchSQL + `) fts ON fts.disaster_id = disasters.id`
+		args = append(args, match)
 	}
+	conditions, condArgs := sqliteFilterConditions(opts, timeColumn)
+	args = append(args, condArgs...)
+	if len(conditions) > 0 {
+		from += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	return from, args
+}
 
-	return alerts, rows.Err()
+// sqliteUTC converts a time column to a UTC datetime. The driver stores times as time.Time.String()
+// text in the time's own zone, e.g. "2026-03-01 05:00:00.5 -0700 MST".
+func sqliteUTC(column string) string {
+	offset := "substr(" + column + ", 20 + instr(substr(" + column + ", 20), ' '), 5)" // -0700
+	return "datetime(substr(" + column + ", 1, 19) || substr(" + offset + ", 1, 3) || ':' || substr(" + offset + ", 4, 2))"
+}
+
+// sqliteBucketSQL returns the first day of the bucket a time column falls in, formatted 2006-01-02
+func sqliteBucketSQL(b TimeBucket, column string) string {
+	switch b {
+	case BucketWeek:
+		return "date(" + sqliteUTC(column) + ", 'weekday 0', '-6 days')"
+	case BucketMonth:
+		return "date(" + sqliteUTC(column) + ", 'start of month')"
+	}
+	return "date(" + sqliteUTC(column) + ")"
+}
+
+// Alert methods
+
+const alertColumns = `id, disaster_id, geofence_id, severity, created_at`
+
+func (s *SQLiteDB) AddAlert(ctx context.Context, a *models.Alert) error {
+	query := `INSERT INTO alerts (` + alertColumns + `) VALUES (?, ?, ?, ?, ?)`
+	_, err := s.db.ExecContext(ctx, query, a.ID, a.DisasterID, a.GeofenceID, a.Severity, a.CreatedAt)
+	return err
+}
+
+func (s *SQLiteDB) GetByDisasterID(ctx context.Context, disasterID string) ([]models.Alert, error) {
+	query := `SELECT ` + alertColumns + ` FROM alerts WHERE disaster_id = ?`
+	return queryAlerts(ctx, s.read, query, disasterID)
 }
 
 func (s *SQLiteDB) ListAlerts(ctx context.Context, opts Filter) ([]models.Alert, error) {
-	query := `SELECT id, disaster_id, severity, created_at FROM alerts`
+	query := `SELECT ` + alertColumns + ` FROM alerts`
 	var conditions []string
 	args := []any{}
 
@@ -241,6 +852,10 @@ func (s *SQLiteDB) ListAlerts(ctx context.Context, opts Filter) ([]models.Alert,
 		conditions = append(conditions, "created_at >= ?")
 		args = append(args, *opts.Since)
 	}
+	if opts.GeofenceID != "" {
+		conditions = append(conditions, "geofence_id = ?")
+		args = append(args, opts.GeofenceID)
+	}
 
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
@@ -251,13 +866,19 @@ func (s *SQLiteDB) ListAlerts(ctx context.Context, opts Filter) ([]models.Alert,
 	if opts.Limit > 0 {
 		query += ` LIMIT ?`
 		args = append(args, opts.Limit)
+	} else if opts.Offset > 0 {
+		query += ` LIMIT -1` // SQLite only takes OFFSET after a LIMIT
 	}
 	if opts.Offset > 0 {
 		query += ` OFFSET ?`
 		args = append(args, opts.Offset)
 	}
 
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	return queryAlerts(ctx, s.read, query, args...)
+}
+
+func queryAlerts(ctx context.Context, db *sql.DB, query string, args ...any) ([]models.Alert, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -266,7 +887,7 @@ func (s *SQLiteDB) ListAlerts(ctx context.Context, opts Filter) ([]models.Alert,
 	var alerts []models.Alert
 	for rows.Next() {
 		var a models.Alert
-		if err := rows.Scan(&a.ID, &a.DisasterID, &a.Severity, &a.CreatedAt); err != nil {
+		if err := rows.Scan(&a.ID, &a.DisasterID, &a.GeofenceID, &a.Severity, &a.CreatedAt); err != nil {
 			return nil, err
 		}
 		alerts = append(alerts, a)
@@ -275,19 +896,112 @@ func (s *SQLiteDB) ListAlerts(ctx context.Context, opts Filter) ([]models.Alert,
 	return alerts, rows.Err()
 }
 
-func (s *SQLiteDB) MarkAsSent(ctx context.Context, ids []string) (int64, error) {
+// Geofence methods
+
+const geofenceColumns = `id, name, owner, area, center_lat, center_lon, radius_km, criteria, created_at`
+
+func (s *SQLiteDB) AddGeofence(ctx context.Context, g *models.Geofence) error {
+	var area sql.NullString
+	if g.Area != nil {
+		b, err := json.Marshal(g.Area)
+		if err != nil {
+			return fmt.Errorf("error encoding geofence area: %w", err)
+		}
+		area = sql.NullString{String: string(b), Valid: true}
+	}
+	var lat, lon, radius sql.NullFloat64
+	if g.Circle != nil {
+		lat = sql.NullFloat64{Float64: g.Circle.Lat, Valid: true}
+		lon = sql.NullFloat64{Float64: g.Circle.Lon, Valid: true}
+		radius = sql.NullFloat64{Float64: g.Circle.RadiusKm, Valid: true}
+	}
+	criteria, err := json.Marshal(g.Criteria)
+	if err != nil {
+		return fmt.Errorf("error encoding geofence criteria: %w", err)
+	}
+
+	query := `INSERT INTO geofences (` + geofenceColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = s.db.ExecContext(ctx, query, g.ID, g.Name, g.Owner, area, lat, lon, radius, string(criteria), g.CreatedAt)
+	return err
+}
+
+func (s *SQLiteDB) GetGeofence(ctx context.Context, id string) (*models.Geofence, error) {
+	geofences, err := queryGeofences(ctx, s.read, `SELECT `+geofenceColumns+` FROM geofences WHERE id = ?`, id)
+	if err != nil || len(geofences) == 0 {
+		return nil, err
+	}
+	return &geofences[0], nil
+}
+
+func (s *SQLiteDB) ListGeofences(ctx context.Context, owner string) ([]models.Geofence, error) {
+	if owner == "" {
+		return queryGeofences(ctx, s.read, `SELECT `+geofenceColumns+` FROM geofences ORDER BY created_at`)
+	}
+	return queryGeofences(ctx, s.read, `SELECT `+geofenceColumns+` FROM geofences WHERE owner = ? ORDER BY created_at`, owner)
+}
+
+func (s *SQLiteDB) DeleteGeofence(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM geofences WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func queryGeofences(ctx context.Context, db *sql.DB, query string, args ...any) ([]models.Geofence, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var geofences []models.Geofence
+	for rows.Next() {
+		var g models.Geofence
+		var area sql.NullString
+		var lat, lon, radius sql.NullFloat64
+		var criteria string
+		if err := rows.Scan(&g.ID, &g.Name, &g.Owner, &area, &lat, &lon, &radius, &criteria, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		if area.Valid {
+			if err := json.Unmarshal([]byte(area.String), &g.Area); err != nil {
+				return nil, fmt.Errorf("error decoding area of geofence %s: %w", g.ID, err)
+			}
+		}
+		if radius.Valid {
+			g.Circle = &geo.Circle{Lat: lat.Float64, Lon: lon.Float64, RadiusKm: radius.Float64}
+		}
+		if err := json.Unmarshal([]byte(criteria), &g.Criteria); err != nil {
+			return nil, fmt.Errorf("error decoding criteria of geofence %s: %w", g.ID, err)
+		}
+		geofences = append(geofences, g)
+	}
+
+	return geofences, rows.Err()
+}
+
+// MarkAsSent records that consumerID has delivered the given disasters. Unknown and already
+// acknowledged IDs are ignored, so the count only includes new acknowledgments.
+func (s *SQLiteDB) MarkAsSent(ctx context.Context, consumerID string, ids []string) (int64, error) {
 	if len(ids) == 0 {
 		return 0, nil
 	}
 
-	placeholders := make([]string, len(ids))
-	args := make([]any, len(ids))
-	for i, id := range ids {
-		placeholders[i] = "?"
-		args[i] = id
+	args := []any{consumerID, time.Now()}
+	for _, id := range ids {
+		args = append(args, id)
 	}
 
-	query := fmt.Sprintf("UPDATE disasters SET discord_sent = TRUE WHERE id IN (%s)", strings.Join(placeholders, ","))
+	query := fmt.Sprintf(`
+		INSERT OR IGNORE INTO deliveries (consumer_id, disaster_id, acked_at)
+		SELECT ?, id, ? FROM disasters WHERE id IN (%s)
+	`, placeholderList(len(ids)))
 	result, err := s.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		return 0, err
@@ -296,6 +1010,78 @@ func (s *SQLiteDB) MarkAsSent(ctx context.Context, ids []string) (int64, error)
 	return result.RowsAffected()
 }
 
+// Audit methods
+
+const auditColumns = `id, disaster_id, action, actor, changes, created_at`
+
+func (s *SQLiteDB) AddAuditEntry(ctx context.Context, e *models.AuditEntry) error {
+	return insertAuditEntry(ctx, s.db, identity, e)
+}
+
+// AddAudited stores d like Add and e in the same transaction, setting d.Seq and e.ID.
+func (s *SQLiteDB) AddAudited(ctx context.Context, d *models.Disaster, e *models.AuditEntry) error {
+	return s.addBatch(ctx, []*models.Disaster{d}, func(tx *sql.Tx) error {
+		return insertAuditEntry(ctx, tx, identity, e)
+	})
+}
+
+// UpdateAudited stores d like Update and e in the same transaction, if d.Seq is still the stored seq.
+func (s *SQLiteDB) UpdateAudited(ctx context.Context, d *models.Disaster, kind disastersv1.EventKind, e *models.AuditEntry) error {
+	return s.update(ctx, d, kind, true, e)
+}
+
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// insertAuditEntry stores e and sets e.ID. bind adapts the ? placeholders to the backend.
+func insertAuditEntry(ctx context.Context, q rowQuerier, bind func(string) string, e *models.AuditEntry) error {
+	changes, err := json.Marshal(e.Changes)
+	if err != nil {
+		return err
+	}
+	return q.QueryRowContext(ctx,
+		bind(`INSERT INTO disaster_audit (disaster_id, action, actor, changes, created_at) VALUES (?, ?, ?, ?, ?) RETURNING id`),
+		e.DisasterID, e.Action, e.Actor, string(changes), e.CreatedAt,
+	).Scan(&e.ID)
+}
+
+func (s *SQLiteDB) ListAuditEntries(ctx context.Context, disasterID string) ([]models.AuditEntry, error) {
+	query := `SELECT ` + auditColumns + ` FROM disaster_audit WHERE disaster_id = ? ORDER BY id`
+	return queryAuditEntries(ctx, s.read, query, disasterID)
+}
+
+func queryAuditEntries(ctx context.Context, db *sql.DB, query string, args ...any) ([]models.AuditEntry, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var e models.AuditEntry
+		var changes string
+		if err := rows.Scan(&e.ID, &e.DisasterID, &e.Action, &e.Actor, &changes, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(changes), &e.Changes); err != nil {
+			return nil, fmt.Errorf("invalid changes in audit entry %d: %w", e.ID, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Retention methods
+
+func (s *SQLiteDB) ApplyRetention(ctx context.Context, rules []RetentionRule, now time.Time, dryRun bool) (*RetentionReport, error) {
+	return applyRetention(ctx, s.db, identity, rules, now, dryRun)
+}
+
 func (s *SQLiteDB) Close() error {
+	if s.read != s.db {
+		s.read.Close()
+	}
 	return s.db.Close()
 }
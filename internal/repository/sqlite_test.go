@@ -2,290 +2,338 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 
 	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
 	"github.com/mr1hm/go-disaster-alerts/internal/models"
 )
 
-func setupTestDB(t *testing.T) *SQLiteDB {
+// TestSQLiteDB_SpatialIndexBackfill checks that disasters stored before the R*Tree index existed
+// are indexed when it is created
+func TestSQLiteDB_SpatialIndexBackfill(t *testing.T) {
 	db, err := NewSQLiteDB(":memory:")
 	if err != nil {
 		t.Fatalf("failed to create test db: %v", err)
 	}
-	return db
-}
-
-func TestSQLiteDB_AddAndGetDisaster(t *testing.T) {
-	db := setupTestDB(t)
 	defer db.Close()
 
 	ctx := context.Background()
-	disaster := &models.Disaster{
-		ID:        "test_123",
-		Source:    "test",
-		Type:      disastersv1.DisasterType_EARTHQUAKE,
-		Title:     "Test Earthquake",
-		Magnitude: 5.5,
-		Latitude:  35.0,
-		Longitude: 139.0,
-		Timestamp: time.Now(),
-		CreatedAt: time.Now(),
-	}
-
-	// Add
-	err := db.Add(ctx, disaster)
-	if err != nil {
-		t.Fatalf("Add failed: %v", err)
+	now := time.Now()
+	for _, d := range []*models.Disaster{
+		{ID: "tokyo", Latitude: 35.68, Longitude: 139.69},
+		{ID: "suva", Latitude: -18.14, Longitude: 178.44},
+		{ID: "nuku_alofa", Latitude: -21.14, Longitude: -175.20},
+	} {
+		d.Source, d.Title, d.Timestamp, d.CreatedAt = "test", d.ID, now, now
+		if err := db.Add(ctx, d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
 	}
 
-	// Get
-	got, err := db.GetByID(ctx, "test_123")
-	if err != nil {
-		t.Fatalf("GetByID failed: %v", err)
+	if err := db.MigrateDown(ctx, 6); err != nil {
+		t.Fatalf("MigrateDown failed: %v", err)
 	}
-	if got.Title != "Test Earthquake" {
-		t.Errorf("expected title 'Test Earthquake', got '%s'", got.Title)
+	if err := db.MigrateUp(ctx, 0); err != nil {
+		t.Fatalf("MigrateUp failed: %v", err)
 	}
-}
-
-func TestSQLiteDB_Exists(t *testing.T) {
-	db := setupTestDB(t)
-	defer db.Close()
-
-	ctx := context.Background()
-
-	exists, err := db.Exists(ctx, "nonexistent")
+	got, err := db.ListDisasters(ctx, Filter{Near: &geo.Circle{Lat: -19.5, Lon: 180, RadiusKm: 700}})
 	if err != nil {
-		t.Fatalf("Exists failed: %v", err)
+		t.Fatalf("ListDisasters failed: %v", err)
 	}
-	if exists {
-		t.Error("expected false for nonexistent ID")
+	if len(got) != 2 {
+		t.Errorf("expected backfilled index to find 2 disasters, got %d", len(got))
 	}
+}
 
-	// Add one
-	db.Add(ctx, &models.Disaster{
-		ID:        "exists_test",
-		Source:    "test",
-		Type:      disastersv1.DisasterType_FLOOD,
-		Timestamp: time.Now(),
-		CreatedAt: time.Now(),
-	})
-
-	exists, err = db.Exists(ctx, "exists_test")
+// TestSQLiteDB_ApplyRetentionArchives checks that archived disasters are kept in disasters_archive
+// without their raw payloads, and out of the search and spatial indexes
+func TestSQLiteDB_ApplyRetentionArchives(t *testing.T) {
+	db, err := NewSQLiteDB(":memory:")
 	if err != nil {
-		t.Fatalf("Exists failed: %v", err)
-	}
-	if !exists {
-		t.Error("expected true for existing ID")
+		t.Fatalf("failed to create test db: %v", err)
 	}
-}
-
-func TestSQLiteDB_ListDisasters_WithFilters(t *testing.T) {
-	db := setupTestDB(t)
 	defer db.Close()
 
 	ctx := context.Background()
 	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+	d := &models.Disaster{ID: "etna", Source: "GDACS", Title: "Eruption of Etna", Latitude: 37.7, Longitude: 15.0,
+		Country: "Italy", Timestamp: old, CreatedAt: old, Raw: []byte("<item/>"), Corrections: []string{"country"}}
+	if err := db.Add(ctx, d); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
 
-	// Add test data
-	disasters := []*models.Disaster{
-		{ID: "eq1", Source: "test", Type: disastersv1.DisasterType_EARTHQUAKE, Magnitude: 6.0, Timestamp: now, CreatedAt: now},
-		{ID: "eq2", Source: "test", Type: disastersv1.DisasterType_EARTHQUAKE, Magnitude: 4.0, Timestamp: now, CreatedAt: now},
-		{ID: "fl1", Source: "test", Type: disastersv1.DisasterType_FLOOD, Magnitude: 3.0, Timestamp: now, CreatedAt: now},
+	rules := []RetentionRule{{MaxAge: 24 * time.Hour, Action: RetentionArchive}}
+	if _, err := db.ApplyRetention(ctx, rules, now, false); err != nil {
+		t.Fatalf("ApplyRetention failed: %v", err)
+	}
+	if n := queryInt(t, db, `SELECT COUNT(*) FROM disasters_archive WHERE id = 'etna' AND title = 'Eruption of Etna' AND country = 'Italy' AND corrections = 'country'`); n != 1 {
+		t.Error("expected the disaster in the archive")
 	}
-	for _, d := range disasters {
-		db.Add(ctx, d)
+	for _, table := range []string{"disasters", "disasters_fts", "disasters_rtree", "disaster_events"} {
+		column := "disaster_id"
+		if table == "disasters" {
+			column = "id"
+		}
+		if n := queryInt(t, db, `SELECT COUNT(*) FROM `+table+` WHERE `+column+` = 'etna'`); n != 0 {
+			t.Errorf("expected the disaster removed from %s", table)
+		}
 	}
+}
 
-	// Test type filter
-	eqType := disastersv1.DisasterType_EARTHQUAKE
-	results, err := db.ListDisasters(ctx, Filter{Type: &eqType})
+func TestSQLiteDB_EventsWithoutSnapshot(t *testing.T) {
+	db, err := NewSQLiteDB(":memory:")
 	if err != nil {
-		t.Fatalf("ListDisasters failed: %v", err)
-	}
-	if len(results) != 2 {
-		t.Errorf("expected 2 earthquakes, got %d", len(results))
+		t.Fatalf("failed to create test db: %v", err)
 	}
+	defer db.Close()
 
-	// Test magnitude filter
-	minMag := 5.0
-	results, err = db.ListDisasters(ctx, Filter{MinMagnitude: &minMag})
-	if err != nil {
-		t.Fatalf("ListDisasters failed: %v", err)
+	ctx := context.Background()
+	d := &models.Disaster{ID: "d1", Source: "GDACS", Title: "Quake", Timestamp: time.Now(), CreatedAt: time.Now()}
+	if err := db.Add(ctx, d); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	// Events recorded before snapshots were kept fall back to the current row
+	if _, err := db.db.ExecContext(ctx, `UPDATE disaster_events SET snapshot = NULL`); err != nil {
+		t.Fatalf("clearing snapshots failed: %v", err)
 	}
-	if len(results) != 1 {
-		t.Errorf("expected 1 disaster with mag >= 5.0, got %d", len(results))
+	if _, err := db.db.ExecContext(ctx, `UPDATE disasters SET title = 'Quake upgraded'`); err != nil {
+		t.Fatalf("updating title failed: %v", err)
 	}
 
-	// Test limit
-	results, err = db.ListDisasters(ctx, Filter{Limit: 2})
+	events, err := db.ListEvents(ctx, 0, 0)
 	if err != nil {
-		t.Fatalf("ListDisasters failed: %v", err)
+		t.Fatalf("ListEvents failed: %v", err)
 	}
-	if len(results) != 2 {
-		t.Errorf("expected 2 disasters with limit, got %d", len(results))
+	if len(events) != 1 || events[0].Disaster.Title != "Quake upgraded" {
+		t.Errorf("expected the current row for an event without a snapshot, got %+v", events)
 	}
 }
 
-func TestSQLiteDB_ListDisasters_AlertLevelFilters(t *testing.T) {
-	db := setupTestDB(t)
-	defer db.Close()
-
+func TestSQLiteDB_BackupAndRestore(t *testing.T) {
 	ctx := context.Background()
-	now := time.Now()
-
-	// Add test data with different alert levels
-	disasters := []*models.Disaster{
-		{ID: "green1", Source: "test", Type: disastersv1.DisasterType_EARTHQUAKE, AlertLevel: disastersv1.AlertLevel_GREEN, Timestamp: now, CreatedAt: now},
-		{ID: "orange1", Source: "test", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_ORANGE, Timestamp: now, CreatedAt: now},
-		{ID: "red1", Source: "test", Type: disastersv1.DisasterType_CYCLONE, AlertLevel: disastersv1.AlertLevel_RED, Timestamp: now, CreatedAt: now},
-		{ID: "green2", Source: "test", Type: disastersv1.DisasterType_EARTHQUAKE, AlertLevel: disastersv1.AlertLevel_GREEN, Timestamp: now, CreatedAt: now},
-	}
-	for _, d := range disasters {
-		db.Add(ctx, d)
-	}
-
-	// Test exact AlertLevel filter
-	orange := disastersv1.AlertLevel_ORANGE
-	results, err := db.ListDisasters(ctx, Filter{AlertLevel: &orange})
+	dir := t.TempDir()
+	path := filepath.Join(dir, "live.db")
+	db, err := NewSQLiteDB(path)
 	if err != nil {
-		t.Fatalf("ListDisasters failed: %v", err)
+		t.Fatalf("failed to create test db: %v", err)
 	}
-	if len(results) != 1 {
-		t.Errorf("expected 1 orange alert, got %d", len(results))
+	now := time.Now()
+	add := func(id string) {
+		t.Helper()
+		if err := db.Add(ctx, &models.Disaster{ID: id, Source: "test", Title: id, Timestamp: now, CreatedAt: now}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
 	}
+	add("before")
 
-	// Test MinAlertLevel filter (>= ORANGE should return ORANGE and RED)
-	results, err = db.ListDisasters(ctx, Filter{MinAlertLevel: &orange})
-	if err != nil {
-		t.Fatalf("ListDisasters failed: %v", err)
+	backupPath := filepath.Join(dir, "backup.db")
+	if err := db.Backup(ctx, backupPath); err != nil {
+		t.Fatalf("Backup failed: %v", err)
 	}
-	if len(results) != 2 {
-		t.Errorf("expected 2 disasters with alert >= ORANGE, got %d", len(results))
+	if err := db.Backup(ctx, backupPath); err == nil {
+		t.Error("expected an error overwriting a backup")
 	}
+	add("after")
+	db.Close()
 
-	// Test MinAlertLevel RED (should return only RED)
-	red := disastersv1.AlertLevel_RED
-	results, err = db.ListDisasters(ctx, Filter{MinAlertLevel: &red})
+	version, err := RestoreSQLite(ctx, backupPath, path)
 	if err != nil {
-		t.Fatalf("ListDisasters failed: %v", err)
+		t.Fatalf("RestoreSQLite failed: %v", err)
+	}
+	if version != LatestSchemaVersion() {
+		t.Errorf("expected version %d, got %d", LatestSchemaVersion(), version)
+	}
+
+	for file, want := range map[string][]string{path: {"before"}, path + PreRestoreSuffix: {"after", "before"}} {
+		restored, err := NewSQLiteDB(file)
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", file, err)
+		}
+		got, err := restored.ListDisasters(ctx, Filter{})
+		restored.Close()
+		if err != nil {
+			t.Fatalf("ListDisasters failed: %v", err)
+		}
+		var ids []string
+		for _, d := range got {
+			ids = append(ids, d.ID)
+		}
+		slices.Sort(ids)
+		if !slices.Equal(ids, want) {
+			t.Errorf("%s: expected %v, got %v", filepath.Base(file), want, ids)
+		}
+	}
+
+	// Backups the build can't migrate are rejected without touching the database
+	newer := filepath.Join(dir, "newer.db")
+	future, err := NewSQLiteDB(newer)
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
 	}
-	if len(results) != 1 {
-		t.Errorf("expected 1 disaster with alert >= RED, got %d", len(results))
+	mustExec(t, future, `INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, 'from the future', ?)`, LatestSchemaVersion()+1, now)
+	future.Close()
+	unversioned := filepath.Join(dir, "unversioned.db")
+	if err := os.WriteFile(unversioned, nil, 0o644); err != nil {
+		t.Fatal(err)
 	}
-}
-
-func TestSQLiteDB_NewFields(t *testing.T) {
-	db := setupTestDB(t)
-	defer db.Close()
-
-	ctx := context.Background()
-	disaster := &models.Disaster{
-		ID:         "test_fields",
-		Source:     "GDACS",
-		Type:       disastersv1.DisasterType_CYCLONE,
-		Title:      "Test Cyclone",
-		Magnitude:  150.0,
-		AlertLevel: disastersv1.AlertLevel_RED,
-		Latitude:   -20.0,
-		Longitude:  45.0,
-		Timestamp:  time.Now(),
-		Country:            "Madagascar",
-		AffectedPopulation: "1.5 million affected",
-		ReportURL:          "https://www.gdacs.org/report.aspx?eventtype=TC&eventid=123",
-		CreatedAt:  time.Now(),
+	for _, bad := range []string{newer, unversioned, filepath.Join(dir, "missing.db")} {
+		if _, err := RestoreSQLite(ctx, bad, path); err == nil {
+			t.Errorf("expected restoring %s to fail", filepath.Base(bad))
+		}
 	}
-
-	err := db.Add(ctx, disaster)
-	if err != nil {
-		t.Fatalf("Add failed: %v", err)
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected the database to stay in place: %v", err)
 	}
 
-	got, err := db.GetByID(ctx, "test_fields")
-	if err != nil {
-		t.Fatalf("GetByID failed: %v", err)
+	// A swap that fails partway puts back the files already moved aside
+	if err := os.WriteFile(path+"-wal", []byte("wal"), 0o644); err != nil {
+		t.Fatal(err)
 	}
-
-	if got.Country != "Madagascar" {
-		t.Errorf("expected country 'Madagascar', got '%s'", got.Country)
+	if err := os.MkdirAll(filepath.Join(path+PreRestoreSuffix+"-wal", "blocker"), 0o755); err != nil {
+		t.Fatal(err)
 	}
-	if got.AffectedPopulation != "1.5 million affected" {
-		t.Errorf("expected affected_population '1.5 million affected', got '%s'", got.AffectedPopulation)
+	if _, err := RestoreSQLite(ctx, backupPath, path); err == nil {
+		t.Fatal("expected the swap to fail")
 	}
-	if got.ReportURL != "https://www.gdacs.org/report.aspx?eventtype=TC&eventid=123" {
-		t.Errorf("expected report_url, got '%s'", got.ReportURL)
+	for _, file := range []string{path, path + "-wal"} {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("expected %s to be put back: %v", filepath.Base(file), err)
+		}
+	}
+	if _, err := os.Stat(path + ".restoring"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the staged copy to be removed, got %v", err)
 	}
 }
 
-func TestSQLiteDB_MarkAsSent(t *testing.T) {
-	db := setupTestDB(t)
-	defer db.Close()
-
+func TestSQLiteDB_ReadsDuringWrite(t *testing.T) {
 	ctx := context.Background()
-	now := time.Now()
-
-	// Add test disasters
-	disasters := []*models.Disaster{
-		{ID: "sent1", Source: "test", Type: disastersv1.DisasterType_EARTHQUAKE, Timestamp: now, CreatedAt: now},
-		{ID: "sent2", Source: "test", Type: disastersv1.DisasterType_FLOOD, Timestamp: now, CreatedAt: now},
-		{ID: "sent3", Source: "test", Type: disastersv1.DisasterType_CYCLONE, Timestamp: now, CreatedAt: now},
+	db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
 	}
-	for _, d := range disasters {
-		db.Add(ctx, d)
+	defer db.Close()
+	now := time.Now()
+	if err := db.Add(ctx, &models.Disaster{ID: "committed", Source: "test", Timestamp: now, CreatedAt: now}); err != nil {
+		t.Fatalf("Add failed: %v", err)
 	}
 
-	// Mark two as sent
-	count, err := db.MarkAsSent(ctx, []string{"sent1", "sent2"})
+	// Hold the only writer connection in an open transaction
+	tx, err := db.db.BeginTx(ctx, nil)
 	if err != nil {
-		t.Fatalf("MarkAsSent failed: %v", err)
+		t.Fatalf("BeginTx failed: %v", err)
 	}
-	if count != 2 {
-		t.Errorf("expected 2 rows affected, got %d", count)
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `UPDATE disasters SET title = 'pending'`); err != nil {
+		t.Fatalf("update failed: %v", err)
 	}
 
-	// Marking non-existent IDs should return 0
-	count, err = db.MarkAsSent(ctx, []string{"nonexistent"})
+	readCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	d, err := db.GetByID(readCtx, "committed")
 	if err != nil {
-		t.Fatalf("MarkAsSent failed: %v", err)
+		t.Fatalf("GetByID failed while a write was open: %v", err)
 	}
-	if count != 0 {
-		t.Errorf("expected 0 rows affected for non-existent ID, got %d", count)
+	if d.Title != "" {
+		t.Errorf("expected the committed title, got %q", d.Title)
 	}
 
-	// Empty slice should return 0
-	count, err = db.MarkAsSent(ctx, []string{})
-	if err != nil {
-		t.Fatalf("MarkAsSent failed: %v", err)
+	if _, err := db.read.ExecContext(ctx, `DELETE FROM disasters`); err == nil {
+		t.Error("expected the read pool to reject writes")
 	}
-	if count != 0 {
-		t.Errorf("expected 0 rows affected for empty slice, got %d", count)
+	tx.Rollback()
+	var mode string
+	if err := db.db.QueryRowContext(ctx, `PRAGMA journal_mode`).Scan(&mode); err != nil || mode != "wal" {
+		t.Errorf("expected WAL journal mode, got %q: %v", mode, err)
 	}
 }
 
-func TestSQLiteDB_DuplicateAdd(t *testing.T) {
-	db := setupTestDB(t)
-	defer db.Close()
-
-	ctx := context.Background()
-	disaster := &models.Disaster{
-		ID:        "dup_test",
-		Source:    "test",
-		Type:      disastersv1.DisasterType_EARTHQUAKE,
-		Timestamp: time.Now(),
-		CreatedAt: time.Now(),
+func TestFilter_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  Filter
+		wantErr bool
+	}{
+		{"default", Filter{}, false},
+		{"magnitude", Filter{Sort: SortMagnitude}, false},
+		{"distance without center", Filter{Sort: SortDistance}, true},
+		{"distance", Filter{Sort: SortDistance, Near: &geo.Circle{Lat: 1, Lon: 1, RadiusKm: 1}}, false},
+		{"relevance without query", Filter{Sort: SortRelevance, Query: "  "}, true},
+		{"relevance", Filter{Sort: SortRelevance, Query: "etna"}, false},
+		{"unknown", Filter{Sort: "depth"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.filter.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
 	}
+}
 
-	// First add should succeed
-	err := db.Add(ctx, disaster)
+// BenchmarkSQLiteDB_ListDisastersUnderLoad measures list latency for parallel readers while a
+// writer keeps updating disasters, as ingestion does alongside REST and gRPC clients
+func BenchmarkSQLiteDB_ListDisastersUnderLoad(b *testing.B) {
+	ctx := context.Background()
+	db, err := NewSQLiteDB(filepath.Join(b.TempDir(), "bench.db"))
 	if err != nil {
-		t.Fatalf("First Add failed: %v", err)
+		b.Fatalf("failed to create db: %v", err)
 	}
+	defer db.Close()
 
-	// Second add should fail (duplicate primary key)
-	err = db.Add(ctx, disaster)
-	if err == nil {
-		t.Error("expected error for duplicate ID, got nil")
-	}
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	disasters := make([]*models.Disaster, 2000)
+	for i := range disasters {
+		disasters[i] = &models.Disaster{
+			ID: fmt.Sprintf("d%04d", i), Source: "GDACS", Type: disastersv1.DisasterType(i%7 + 1),
+			Title: fmt.Sprintf("Event %d", i), Magnitude: float64(i % 9), AlertLevel: disastersv1.AlertLevel(i % 4),
+			Latitude: float64(i%160) - 80, Longitude: float64(i%360) - 180,
+			Timestamp: base.Add(time.Duration(i) * time.Minute), CreatedAt: base, Raw: make([]byte, 2048),
+		}
+		if err := db.Add(ctx, disasters[i]); err != nil {
+			b.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			d := *disasters[i%len(disasters)]
+			d.Magnitude += 0.1
+			d.UpdatedAt = base.Add(time.Duration(i) * time.Second)
+			if err := db.Update(ctx, &d, disastersv1.EventKind_EVENT_KIND_UPDATED); err != nil {
+				b.Errorf("Update failed: %v", err)
+				return
+			}
+		}
+	}()
+
+	orange := disastersv1.AlertLevel_ORANGE
+	filter := Filter{Limit: 50, MinAlertLevel: &orange}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := db.ListDisasters(ctx, filter); err != nil {
+				b.Errorf("ListDisasters failed: %v", err)
+				return
+			}
+		}
+	})
+	b.StopTimer()
+	close(stop)
+	<-done
 }
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+)
+
+func TestSQLiteDB_UpgradesExistingDatabase(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "disasters.db")
+
+	// A database created before disasters had a sequence number
+	old, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = old.Exec(`
+		CREATE TABLE disasters (
+			id TEXT PRIMARY KEY,
+			source TEXT NOT NULL,
+			type INTEGER NOT NULL,
+			title TEXT NOT NULL,
+			description TEXT,
+			magnitude REAL,
+			alert_level INTEGER DEFAULT 0,
+			latitude REAL NOT NULL,
+			longitude REAL NOT NULL,
+			timestamp DATETIME NOT NULL,
+			country TEXT DEFAULT '',
+			affected_population TEXT DEFAULT '',
+			affected_population_count INTEGER DEFAULT 0,
+			report_url TEXT DEFAULT '',
+			raw BLOB,
+			created_at DATETIME NOT NULL,
+			discord_sent BOOLEAN DEFAULT FALSE
+		);
+		CREATE TABLE alerts (
+			id TEXT PRIMARY KEY,
+			disaster_id TEXT NOT NULL,
+			severity TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			FOREIGN KEY (disaster_id) REFERENCES disasters(id)
+		);
+		CREATE INDEX idx_disasters_discord_sent ON disasters(discord_sent);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for i, id := range []string{"newer", "older"} {
+		_, err := old.Exec(`INSERT INTO disasters (id, source, type, title, description, magnitude, latitude, longitude, timestamp, created_at, discord_sent)
+			VALUES (?, 'GDACS', 1, 'Earthquake', '', 0, 0, 0, ?, ?, ?)`, id, now, now.Add(-time.Duration(i)*time.Hour), id == "older")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	old.Close()
+
+	db, err := NewSQLiteDB(path)
+	if err != nil {
+		t.Fatalf("failed to open existing database: %v", err)
+	}
+	defer db.Close()
+
+	for id, want := range map[string]int64{"older": 1, "newer": 2} {
+		d, err := db.GetByID(ctx, id)
+		if err != nil || d == nil || d.Seq != want {
+			t.Fatalf("expected %s numbered %d in storage order, got %+v (err %v)", id, want, d, err)
+		}
+	}
+
+	d := &models.Disaster{ID: "new", Source: "test", Timestamp: now, CreatedAt: now}
+	if err := db.Add(ctx, d); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if d.Seq != 3 {
+		t.Errorf("expected seq 3 after the existing disasters, got %d", d.Seq)
+	}
+
+	events, err := db.ListEvents(ctx, 0, 0)
+	if err != nil || len(events) != 3 || events[0].Disaster.ID != "older" || events[2].Seq != 3 {
+		t.Errorf("expected a CREATED event for each disaster in storage order, got %+v (err %v)", events, err)
+	}
+
+	d.Closed = true
+	d.UpdatedAt = now
+	if err := db.Update(ctx, d, disastersv1.EventKind_EVENT_KIND_CLOSED); err != nil || d.Seq != 4 {
+		t.Errorf("expected the update recorded at seq 4, got %d (err %v)", d.Seq, err)
+	}
+
+	// Disasters the Discord bot already posted are delivered to it
+	unsent := false
+	pending, err := db.ListDisasters(ctx, Filter{ConsumerID: "discord", Delivered: &unsent})
+	if err != nil || len(pending) != 2 || pending[0].ID == "older" || pending[1].ID == "older" {
+		t.Errorf("expected only the disasters not flagged discord_sent pending, got %+v (err %v)", pending, err)
+	}
+
+	// Opening again does not renumber
+	db.Close()
+	db, err = NewSQLiteDB(path)
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer db.Close()
+	if got, _ := db.GetByID(ctx, "newer"); got == nil || got.Seq != 2 {
+		t.Errorf("expected seq 2 kept on reopen, got %+v", got)
+	}
+}
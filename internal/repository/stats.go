@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/geo"
+)
+
+// TimeBucket is the period Stats groups disasters by over time
+type TimeBucket string
+
+const (
+	BucketDay   TimeBucket = "day"
+	BucketWeek  TimeBucket = "week" // ISO weeks, starting on Monday
+	BucketMonth TimeBucket = "month"
+)
+
+// ParseTimeBucket returns the bucket named s, BucketDay if s is empty
+func ParseTimeBucket(s string) (TimeBucket, error) {
+	switch b := TimeBucket(s); b {
+	case "":
+		return BucketDay, nil
+	case BucketDay, BucketWeek, BucketMonth:
+		return b, nil
+	}
+	return "", fmt.Errorf("invalid time bucket %q, expected day, week or month", s)
+}
+
+// Start returns the start of the bucket t falls in, in UTC
+func (b TimeBucket) Start(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	switch b {
+	case BucketWeek:
+		day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case BucketMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Next returns the start of the bucket after the one starting at start
+func (b TimeBucket) Next(start time.Time) time.Time {
+	switch b {
+	case BucketWeek:
+		return start.AddDate(0, 0, 7)
+	case BucketMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// StatsGroup aggregates the disasters sharing a key
+type StatsGroup struct {
+	Key                     string
+	Count                   int64
+	MaxMagnitude            float64
+	TotalAffectedPopulation int64
+}
+
+// Stats summarizes the disasters a filter matches, in total and grouped by each attribute. Groups
+// are ordered by count, largest first, except ByTime, which is oldest first and omits empty buckets.
+type Stats struct {
+	Total        StatsGroup   // with an empty key
+	Bucket       TimeBucket   // of ByTime
+	ByType       []StatsGroup // keyed by DisasterType name
+	ByAlertLevel []StatsGroup // keyed by AlertLevel name
+	ByCountry    []StatsGroup // keyed by ISO 3166-1 alpha-3 code, empty if unknown
+	BySource     []StatsGroup
+	ByTime       []StatsGroup // keyed by the bucket's first day in UTC, formatted 2006-01-02
+	Timeline     []TimelineBucket
+}
+
+// TimelineBucket is a group of Stats.ByTime broken down by type
+type TimelineBucket struct {
+	StatsGroup
+	ByType []StatsGroup // keyed by DisasterType name, ordered by count
+}
+
+// statsDateFormat formats the keys of Stats.ByTime
+const statsDateFormat = time.DateOnly
+
+// statsCell aggregates the disasters sharing every grouping key. Backends group in their query,
+// then buildStats rolls the cells up into each grouping.
+type statsCell struct {
+	typ          disastersv1.DisasterType
+	alertLevel   disastersv1.AlertLevel
+	country      string
+	source       string
+	bucket       string
+	count        int64
+	maxMagnitude float64
+	population   int64
+}
+
+func (g *StatsGroup) add(c statsCell) {
+	if g.Count == 0 || c.maxMagnitude > g.MaxMagnitude {
+		g.MaxMagnitude = c.maxMagnitude
+	}
+	g.Count += c.count
+	g.TotalAffectedPopulation += c.population
+}
+
+func statsTypeKey(c statsCell) string { return c.typ.String() }
+
+func buildStats(cells []statsCell, bucket TimeBucket) *Stats {
+	stats := &Stats{Bucket: bucket}
+	groupings := []struct {
+		dst *[]StatsGroup
+		key func(statsCell) string
+	}{
+		{&stats.ByType, statsTypeKey},
+		{&stats.ByAlertLevel, func(c statsCell) string { return c.alertLevel.String() }},
+		{&stats.ByCountry, func(c statsCell) string { return c.country }},
+		{&stats.BySource, func(c statsCell) string { return c.source }},
+		{&stats.ByTime, func(c statsCell) string { return c.bucket }},
+	}
+	for _, grouping := range groupings {
+		*grouping.dst = groupStats(cells, grouping.key)
+	}
+	slices.SortFunc(stats.ByTime, func(a, b StatsGroup) int { return cmp.Compare(a.Key, b.Key) })
+
+	byBucket := make(map[string][]statsCell)
+	for _, c := range cells {
+		byBucket[c.bucket] = append(byBucket[c.bucket], c)
+	}
+	stats.Timeline = make([]TimelineBucket, len(stats.ByTime))
+	for i, g := range stats.ByTime {
+		stats.Timeline[i] = TimelineBucket{StatsGroup: g, ByType: groupStats(byBucket[g.Key], statsTypeKey)}
+	}
+
+	for _, c := range cells {
+		stats.Total.add(c)
+	}
+	return stats
+}
+
+// groupStats rolls cells up by key, ordered by count, largest first, then by key
+func groupStats(cells []statsCell, key func(statsCell) string) []StatsGroup {
+	groups := make(map[string]*StatsGroup)
+	for _, c := range cells {
+		k := key(c)
+		g, ok := groups[k]
+		if !ok {
+			g = &StatsGroup{Key: k}
+			groups[k] = g
+		}
+		g.add(c)
+	}
+	out := make([]StatsGroup, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, *g)
+	}
+	slices.SortFunc(out, func(a, b StatsGroup) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Key, b.Key))
+	})
+	return out
+}
+
+// queryStats runs a query returning the grouping keys of statsCell followed, if area is set, by the
+// location of the cell, then its count, maximum magnitude and total affected population
+func queryStats(ctx context.Context, db *sql.DB, area geo.MultiPolygon, bucket TimeBucket, query string, args ...any) (*Stats, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cells []statsCell
+	for rows.Next() {
+		var c statsCell
+		var typ, alertLevel int32
+		var lat, lon float64
+		dest := []any{&typ, &alertLevel, &c.country, &c.source, &c.bucket}
+		if area != nil {
+			dest = append(dest, &lat, &lon)
+		}
+		dest = append(dest, &c.count, &c.maxMagnitude, &c.population)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		if area != nil && !area.Contains(lat, lon) {
+			continue
+		}
+		c.typ, c.alertLevel = disastersv1.DisasterType(typ), disastersv1.AlertLevel(alertLevel)
+		cells = append(cells, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return buildStats(cells, bucket), nil
+}
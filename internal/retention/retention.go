@@ -0,0 +1,163 @@
+// Package retention prunes old disasters on a schedule according to configured retention rules.
+package retention
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+// ParseRules parses rules separated by ';', each a comma-separated list of key=value settings:
+//
+//	type=flood|earthquake  disaster types the rule applies to, all if omitted
+//	level=green|orange     alert levels the rule applies to, all if omitted
+//	age=30d                event age after which the rule applies, in days or as a Go duration
+//	action=delete          strip_raw, archive or delete
+//
+// e.g. "level=green,age=30d,action=delete; age=90d,action=strip_raw"
+func ParseRules(s string) ([]repository.RetentionRule, error) {
+	var rules []repository.RetentionRule
+	for i, spec := range strings.Split(s, ";") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		rule, err := parseRule(spec)
+		if err != nil {
+			return nil, fmt.Errorf("retention rule %d: %w", i+1, err)
+		}
+		rules = append(rules, rule)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("no retention rules in %q", s)
+	}
+	return rules, nil
+}
+
+func parseRule(spec string) (repository.RetentionRule, error) {
+	var rule repository.RetentionRule
+	for _, setting := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(setting), "=")
+		if !ok {
+			return rule, fmt.Errorf("expected key=value, got %q", setting)
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "type":
+			for _, name := range strings.Split(value, "|") {
+				t, ok := disastersv1.DisasterType_value[strings.ToUpper(strings.TrimSpace(name))]
+				if !ok {
+					return rule, fmt.Errorf("unknown disaster type %q", name)
+				}
+				rule.Types = append(rule.Types, disastersv1.DisasterType(t))
+			}
+		case "level":
+			for _, name := range strings.Split(value, "|") {
+				l, ok := disastersv1.AlertLevel_value[strings.ToUpper(strings.TrimSpace(name))]
+				if !ok {
+					return rule, fmt.Errorf("unknown alert level %q", name)
+				}
+				rule.AlertLevels = append(rule.AlertLevels, disastersv1.AlertLevel(l))
+			}
+		case "age":
+			age, err := parseAge(value)
+			if err != nil {
+				return rule, err
+			}
+			rule.MaxAge = age
+		case "action":
+			rule.Action = repository.RetentionAction(value)
+		default:
+			return rule, fmt.Errorf("unknown setting %q", key)
+		}
+	}
+	if rule.MaxAge == 0 || rule.Action == "" {
+		return rule, fmt.Errorf("age and action are required")
+	}
+	return rule, rule.Validate()
+}
+
+// parseAge parses a number of days such as "30d", or a Go duration such as "36h"
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return d, nil
+}
+
+// Job applies retention rules when started and then at every interval. In dry-run mode it only
+// logs what the rules would expire.
+type Job struct {
+	repo     repository.RetentionRepository
+	rules    []repository.RetentionRule
+	interval time.Duration
+	dryRun   bool
+	now      func() time.Time
+	wg       sync.WaitGroup
+}
+
+func NewJob(repo repository.RetentionRepository, rules []repository.RetentionRule, interval time.Duration, dryRun bool) *Job {
+	return &Job{repo: repo, rules: rules, interval: interval, dryRun: dryRun, now: time.Now}
+}
+
+// Start runs the job until ctx is canceled
+func (j *Job) Start(ctx context.Context) {
+	j.wg.Add(1)
+	go j.run(ctx)
+}
+
+// Stop waits for the job to finish after its context is canceled
+func (j *Job) Stop() {
+	j.wg.Wait()
+	slog.Info("retention job stopped")
+}
+
+func (j *Job) run(ctx context.Context) {
+	defer j.wg.Done()
+	slog.Info("starting retention job", "rules", len(j.rules), "interval", j.interval, "dry_run", j.dryRun)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.RunOnce(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce applies the rules once and logs a summary of the report
+func (j *Job) RunOnce(ctx context.Context) (*repository.RetentionReport, error) {
+	report, err := j.repo.ApplyRetention(ctx, j.rules, j.now(), j.dryRun)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Error("retention failed", "error", err)
+		}
+		return nil, err
+	}
+	slog.Info("retention applied",
+		"dry_run", report.DryRun,
+		"stripped", report.Count(repository.RetentionStripRaw),
+		"archived", report.Count(repository.RetentionArchive),
+		"deleted", report.Count(repository.RetentionDelete),
+	)
+	return report, nil
+}
@@ -0,0 +1,116 @@
+package retention
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+	"go.uber.org/goleak"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+func TestParseRules(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		name    string
+		input   string
+		want    []repository.RetentionRule
+		wantErr bool
+	}{
+		{
+			name:  "single",
+			input: "age=30d,action=delete",
+			want:  []repository.RetentionRule{{MaxAge: 30 * day, Action: repository.RetentionDelete}},
+		},
+		{
+			name:  "filters and several rules",
+			input: " type=flood|Earthquake , level=green, age=30d, action=archive ; age=36h,action=strip_raw;",
+			want: []repository.RetentionRule{
+				{
+					Types:       []disastersv1.DisasterType{disastersv1.DisasterType_FLOOD, disastersv1.DisasterType_EARTHQUAKE},
+					AlertLevels: []disastersv1.AlertLevel{disastersv1.AlertLevel_GREEN},
+					MaxAge:      30 * day,
+					Action:      repository.RetentionArchive,
+				},
+				{MaxAge: 36 * time.Hour, Action: repository.RetentionStripRaw},
+			},
+		},
+		{name: "empty", input: " ; ", wantErr: true},
+		{name: "missing action", input: "age=30d", wantErr: true},
+		{name: "missing age", input: "action=delete", wantErr: true},
+		{name: "unknown action", input: "age=30d,action=shred", wantErr: true},
+		{name: "unknown type", input: "type=meteor,age=30d,action=delete", wantErr: true},
+		{name: "unknown level", input: "level=purple,age=30d,action=delete", wantErr: true},
+		{name: "invalid age", input: "age=thirty,action=delete", wantErr: true},
+		{name: "negative age", input: "age=-1d,action=delete", wantErr: true},
+		{name: "unknown setting", input: "age=30d,action=delete,source=gdacs", wantErr: true},
+		{name: "not key=value", input: "age=30d,delete", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRules(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRules(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRules(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJob(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryDB()
+	now := time.Now()
+	for _, d := range []*models.Disaster{
+		{ID: "old", AlertLevel: disastersv1.AlertLevel_GREEN, Timestamp: now.Add(-48 * time.Hour)},
+		{ID: "new", AlertLevel: disastersv1.AlertLevel_GREEN, Timestamp: now},
+	} {
+		if err := repo.Add(ctx, d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	rules := []repository.RetentionRule{{MaxAge: 24 * time.Hour, Action: repository.RetentionDelete}}
+
+	// A dry run only reports
+	report, err := NewJob(repo, rules, time.Hour, true).RunOnce(ctx)
+	if err != nil {
+		t.Fatalf("RunOnce failed: %v", err)
+	}
+	if report.Count(repository.RetentionDelete) != 1 {
+		t.Errorf("expected 1 disaster to delete, got %+v", report)
+	}
+	if exists, _ := repo.Exists(ctx, "old"); !exists {
+		t.Error("expected the dry run to keep the disaster")
+	}
+
+	// Starting the job applies the rules right away
+	jobCtx, cancel := context.WithCancel(ctx)
+	job := NewJob(repo, rules, time.Hour, false)
+	job.Start(jobCtx)
+	deadline := time.Now().Add(time.Second)
+	for {
+		if exists, _ := repo.Exists(ctx, "old"); !exists {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the job to delete the old disaster")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	job.Stop()
+
+	if exists, _ := repo.Exists(ctx, "new"); !exists {
+		t.Error("expected the recent disaster to be kept")
+	}
+}
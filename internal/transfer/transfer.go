@@ -0,0 +1,375 @@
+// Package transfer streams disasters between a repository and NDJSON or CSV files, for moving
+// data between deployments and seeding databases.
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+// Format is a file format of exported disasters
+type Format string
+
+const (
+	FormatNDJSON Format = "ndjson" // one JSON record per line
+	FormatCSV    Format = "csv"    // a header row of columns, then one record per row
+)
+
+// ParseFormat returns the named format, or the one matching path's extension if name is empty.
+// Files without a .csv extension default to NDJSON.
+func ParseFormat(name, path string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "":
+		if strings.EqualFold(filepath.Ext(path), ".csv") {
+			return FormatCSV, nil
+		}
+		return FormatNDJSON, nil
+	case "ndjson", "jsonl":
+		return FormatNDJSON, nil
+	case "csv":
+		return FormatCSV, nil
+	}
+	return "", fmt.Errorf("unknown format %q, expected ndjson or csv", name)
+}
+
+// record is a disaster as exported. Enums are written by name and times in RFC 3339, so files
+// stay readable and independent of the proto numbering; fields only list queries set are left out.
+type record struct {
+	ID                      string    `json:"id"`
+	Source                  string    `json:"source"`
+	Type                    string    `json:"type"`
+	Title                   string    `json:"title"`
+	Description             string    `json:"description"`
+	Magnitude               float64   `json:"magnitude"`
+	AlertLevel              string    `json:"alert_level"`
+	Latitude                float64   `json:"latitude"`
+	Longitude               float64   `json:"longitude"`
+	Timestamp               time.Time `json:"timestamp"`
+	Country                 string    `json:"country"`
+	CountryISO              string    `json:"country_iso"`
+	AffectedPopulation      string    `json:"affected_population"`
+	AffectedPopulationCount int64     `json:"affected_population_count"`
+	ReportURL               string    `json:"report_url"`
+	Closed                  bool      `json:"closed"`
+	Corrections             []string  `json:"corrections,omitempty"` // fields an operator corrected
+	CreatedAt               time.Time `json:"created_at"`
+	UpdatedAt               time.Time `json:"updated_at,omitzero"`
+	Raw                     []byte    `json:"raw,omitempty"` // base64
+}
+
+// csvColumns is the CSV header, in the field order of record
+var csvColumns = []string{
+	"id", "source", "type", "title", "description", "magnitude", "alert_level", "latitude", "longitude",
+	"timestamp", "country", "country_iso", "affected_population", "affected_population_count", "report_url",
+	"closed", "corrections", "created_at", "updated_at", "raw",
+}
+
+func toRecord(d *models.Disaster) record {
+	return record{
+		ID: d.ID, Source: d.Source, Type: d.Type.String(), Title: d.Title, Description: d.Description,
+		Magnitude: d.Magnitude, AlertLevel: d.AlertLevel.String(), Latitude: d.Latitude, Longitude: d.Longitude,
+		Timestamp: d.Timestamp, Country: d.Country, CountryISO: d.CountryISO,
+		AffectedPopulation: d.AffectedPopulation, AffectedPopulationCount: d.AffectedPopulationCount,
+		ReportURL: d.ReportURL, Closed: d.Closed, Corrections: d.Corrections,
+		CreatedAt: d.CreatedAt, UpdatedAt: d.UpdatedAt, Raw: d.Raw,
+	}
+}
+
+func (r record) toModel() (*models.Disaster, error) {
+	if r.ID == "" {
+		return nil, errors.New("id is required")
+	}
+	t, ok := enumValue(disastersv1.DisasterType_value, r.Type)
+	if !ok {
+		return nil, fmt.Errorf("unknown disaster type %q", r.Type)
+	}
+	level, ok := enumValue(disastersv1.AlertLevel_value, r.AlertLevel)
+	if !ok {
+		return nil, fmt.Errorf("unknown alert level %q", r.AlertLevel)
+	}
+	return &models.Disaster{
+		ID: r.ID, Source: r.Source, Type: disastersv1.DisasterType(t), Title: r.Title, Description: r.Description,
+		Magnitude: r.Magnitude, AlertLevel: disastersv1.AlertLevel(level), Latitude: r.Latitude, Longitude: r.Longitude,
+		Timestamp: r.Timestamp, Country: r.Country, CountryISO: r.CountryISO,
+		AffectedPopulation: r.AffectedPopulation, AffectedPopulationCount: r.AffectedPopulationCount,
+		ReportURL: r.ReportURL, Closed: r.Closed, Corrections: r.Corrections,
+		CreatedAt: r.CreatedAt, UpdatedAt: r.UpdatedAt, Raw: r.Raw,
+	}, nil
+}
+
+// enumValue looks up a proto enum value by case-insensitive name, the zero value if name is empty
+func enumValue(values map[string]int32, name string) (int32, bool) {
+	if name == "" {
+		return 0, true
+	}
+	v, ok := values[strings.ToUpper(name)]
+	return v, ok
+}
+
+func (r record) csvRow() []string {
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339Nano)
+	}
+	return []string{
+		r.ID, r.Source, r.Type, r.Title, r.Description, strconv.FormatFloat(r.Magnitude, 'g', -1, 64), r.AlertLevel,
+		strconv.FormatFloat(r.Latitude, 'g', -1, 64), strconv.FormatFloat(r.Longitude, 'g', -1, 64),
+		formatTime(r.Timestamp), r.Country, r.CountryISO, r.AffectedPopulation,
+		strconv.FormatInt(r.AffectedPopulationCount, 10), r.ReportURL, strconv.FormatBool(r.Closed),
+		strings.Join(r.Corrections, ","), formatTime(r.CreatedAt), formatTime(r.UpdatedAt), base64.StdEncoding.EncodeToString(r.Raw),
+	}
+}
+
+// parseCSVRow reads a row whose columns are named by header. Missing columns keep their zero value.
+func parseCSVRow(header, row []string) (record, error) {
+	var r record
+	var err error
+	for i, col := range header {
+		v := row[i]
+		if v == "" {
+			continue
+		}
+		switch col {
+		case "id":
+			r.ID = v
+		case "source":
+			r.Source = v
+		case "type":
+			r.Type = v
+		case "title":
+			r.Title = v
+		case "description":
+			r.Description = v
+		case "magnitude":
+			r.Magnitude, err = strconv.ParseFloat(v, 64)
+		case "alert_level":
+			r.AlertLevel = v
+		case "latitude":
+			r.Latitude, err = strconv.ParseFloat(v, 64)
+		case "longitude":
+			r.Longitude, err = strconv.ParseFloat(v, 64)
+		case "timestamp":
+			r.Timestamp, err = time.Parse(time.RFC3339Nano, v)
+		case "country":
+			r.Country = v
+		case "country_iso":
+			r.CountryISO = v
+		case "affected_population":
+			r.AffectedPopulation = v
+		case "affected_population_count":
+			r.AffectedPopulationCount, err = strconv.ParseInt(v, 10, 64)
+		case "report_url":
+			r.ReportURL = v
+		case "closed":
+			r.Closed, err = strconv.ParseBool(v)
+		case "corrections":
+			r.Corrections = strings.Split(v, ",")
+		case "created_at":
+			r.CreatedAt, err = time.Parse(time.RFC3339Nano, v)
+		case "updated_at":
+			r.UpdatedAt, err = time.Parse(time.RFC3339Nano, v)
+		case "raw":
+			r.Raw, err = base64.StdEncoding.DecodeString(v)
+		}
+		if err != nil {
+			return r, fmt.Errorf("invalid %s: %w", col, err)
+		}
+	}
+	return r, nil
+}
+
+// exportPageSize is the number of disasters Export reads per query
+const exportPageSize = 500
+
+// Export writes the disasters matching filter to w in filter's order and returns how many it
+// wrote. Filter.Limit and Offset bound the whole export rather than a page; 0 exports every match.
+func Export(ctx context.Context, repo repository.DisasterRepository, filter repository.Filter, w io.Writer, format Format) (int, error) {
+	var write func(r record) error
+	var flush func() error
+	switch format {
+	case FormatNDJSON:
+		bw := bufio.NewWriter(w)
+		enc := json.NewEncoder(bw)
+		write = func(r record) error { return enc.Encode(r) }
+		flush = bw.Flush
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvColumns); err != nil {
+			return 0, err
+		}
+		write = func(r record) error { return cw.Write(r.csvRow()) }
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	default:
+		return 0, fmt.Errorf("unknown format %q", format)
+	}
+
+	total := filter.Limit
+	n := 0
+	for {
+		page := filter
+		page.Limit = exportPageSize
+		if total > 0 {
+			page.Limit = min(total-n, exportPageSize)
+		}
+		disasters, err := repo.ListDisasters(ctx, page)
+		if err != nil {
+			return n, err
+		}
+		for i := range disasters {
+			if err := write(toRecord(&disasters[i])); err != nil {
+				return n, err
+			}
+			n++
+		}
+		if len(disasters) < page.Limit || n == total {
+			break
+		}
+		// Later pages continue after the last disaster, so the offset only skips the first
+		filter.PageToken = disasters[len(disasters)-1].PageToken
+		filter.Offset = 0
+	}
+	return n, flush()
+}
+
+// ImportResult counts what Import did with the records it read
+type ImportResult struct {
+	Created   int
+	Updated   int
+	Unchanged int
+}
+
+// ImportRepository is the storage Import upserts into
+type ImportRepository interface {
+	Add(ctx context.Context, d *models.Disaster) error
+	Update(ctx context.Context, d *models.Disaster, kind disastersv1.EventKind) error
+	GetByID(ctx context.Context, id string) (*models.Disaster, error)
+}
+
+// Import upserts the disasters read from r. New disasters are added and changed ones updated with
+// the event kind ingestion would record; records matching the stored disaster are skipped, so
+// importing a file again changes nothing. Records without a creation time are stamped with now.
+func Import(ctx context.Context, repo ImportRepository, r io.Reader, format Format, now time.Time) (ImportResult, error) {
+	var result ImportResult
+	var next func() (record, error) // io.EOF after the last record
+	line := 0
+	switch format {
+	case FormatNDJSON:
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		next = func() (record, error) {
+			var rec record
+			err := dec.Decode(&rec)
+			return rec, err
+		}
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		header, err := cr.Read()
+		if err != nil {
+			return result, fmt.Errorf("error reading CSV header: %w", err)
+		}
+		for _, col := range header {
+			if !slices.Contains(csvColumns, col) {
+				return result, fmt.Errorf("unknown CSV column %q", col)
+			}
+		}
+		if !slices.Contains(header, "id") {
+			return result, errors.New("CSV header has no id column")
+		}
+		line = 1
+		next = func() (record, error) {
+			row, err := cr.Read()
+			if err != nil {
+				return record{}, err
+			}
+			return parseCSVRow(header, row)
+		}
+	default:
+		return result, fmt.Errorf("unknown format %q", format)
+	}
+
+	for {
+		rec, err := next()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		line++
+		if err != nil {
+			return result, fmt.Errorf("record %d: %w", line, err)
+		}
+		d, err := rec.toModel()
+		if err != nil {
+			return result, fmt.Errorf("record %d: %w", line, err)
+		}
+		if err := upsert(ctx, repo, d, now, &result); err != nil {
+			return result, fmt.Errorf("record %d (%s): %w", line, d.ID, err)
+		}
+	}
+}
+
+func upsert(ctx context.Context, repo ImportRepository, d *models.Disaster, now time.Time, result *ImportResult) error {
+	stored, err := repo.GetByID(ctx, d.ID)
+	if err != nil {
+		return err
+	}
+	if stored == nil {
+		if d.CreatedAt.IsZero() {
+			d.CreatedAt = now
+		}
+		if err := repo.Add(ctx, d); err != nil {
+			return err
+		}
+		result.Created++
+		return nil
+	}
+
+	if sameContent(stored, d) {
+		result.Unchanged++
+		return nil
+	}
+	kind := disastersv1.EventKind_EVENT_KIND_UPDATED
+	switch {
+	case d.Closed && !stored.Closed:
+		kind = disastersv1.EventKind_EVENT_KIND_CLOSED
+	case d.AlertLevel > stored.AlertLevel:
+		kind = disastersv1.EventKind_EVENT_KIND_ESCALATED
+	}
+	if d.UpdatedAt.IsZero() || !d.UpdatedAt.After(stored.UpdatedAt) {
+		d.UpdatedAt = now
+	}
+	if err := repo.Update(ctx, d, kind); err != nil {
+		return err
+	}
+	result.Updated++
+	return nil
+}
+
+// sameContent reports whether two versions of a disaster differ in anything but their store times
+func sameContent(a, b *models.Disaster) bool {
+	return a.Source == b.Source && a.Type == b.Type && a.Title == b.Title && a.Description == b.Description &&
+		a.Magnitude == b.Magnitude && a.AlertLevel == b.AlertLevel && a.Latitude == b.Latitude &&
+		a.Longitude == b.Longitude && a.Timestamp.Equal(b.Timestamp) && a.Country == b.Country &&
+		a.CountryISO == b.CountryISO && a.AffectedPopulation == b.AffectedPopulation &&
+		a.AffectedPopulationCount == b.AffectedPopulationCount && a.ReportURL == b.ReportURL &&
+		a.Closed == b.Closed && slices.Equal(a.Corrections, b.Corrections) && bytes.Equal(a.Raw, b.Raw)
+}
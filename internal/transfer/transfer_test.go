@@ -0,0 +1,235 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/go-disaster-alerts/internal/models"
+	"github.com/mr1hm/go-disaster-alerts/internal/repository"
+)
+
+func seedRepo(t *testing.T, n int) *repository.MemoryDB {
+	t.Helper()
+	repo := repository.NewMemoryDB()
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := range n {
+		d := &models.Disaster{
+			ID:                      fmt.Sprintf("gdacs_%04d", i),
+			Source:                  "GDACS",
+			Type:                    disastersv1.DisasterType(i%7 + 1),
+			Title:                   fmt.Sprintf("Event %d, \"quoted\"\nover lines", i),
+			Magnitude:               float64(i%9) + 0.1,
+			AlertLevel:              disastersv1.AlertLevel(i % 4),
+			Latitude:                float64(i%90) - 45.5,
+			Longitude:               float64(i%180) - 90.25,
+			Timestamp:               base.Add(time.Duration(i) * time.Hour),
+			Country:                 "São Tomé",
+			CountryISO:              "STP",
+			AffectedPopulationCount: int64(i) * 100,
+			Closed:                  i%5 == 0,
+			CreatedAt:               base.Add(time.Duration(i)*time.Hour + time.Minute),
+		}
+		if i%3 == 0 {
+			d.Corrections = []string{"latitude", "country"}
+		}
+		if i%2 == 0 {
+			d.Raw = []byte(fmt.Sprintf("<item id=%q/>", d.ID))
+		}
+		if err := repo.Add(context.Background(), d); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	return repo
+}
+
+func TestExportImport_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := seedRepo(t, 1234)
+	want, err := src.ListDisasters(ctx, repository.Filter{})
+	if err != nil {
+		t.Fatalf("ListDisasters failed: %v", err)
+	}
+
+	for _, format := range []Format{FormatNDJSON, FormatCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := Export(ctx, src, repository.Filter{}, &buf, format)
+			if err != nil {
+				t.Fatalf("Export failed: %v", err)
+			}
+			if n != len(want) {
+				t.Fatalf("expected %d exported, got %d", len(want), n)
+			}
+
+			dst := repository.NewMemoryDB()
+			data := buf.Bytes()
+			result, err := Import(ctx, dst, bytes.NewReader(data), format, time.Now())
+			if err != nil {
+				t.Fatalf("Import failed: %v", err)
+			}
+			if result != (ImportResult{Created: len(want)}) {
+				t.Errorf("unexpected result %+v", result)
+			}
+			got, err := dst.ListDisasters(ctx, repository.Filter{})
+			if err != nil {
+				t.Fatalf("ListDisasters failed: %v", err)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("expected %d imported, got %d", len(want), len(got))
+			}
+			for i := range want {
+				w, g := want[i], got[i]
+				if !sameContent(&w, &g) || !w.CreatedAt.Equal(g.CreatedAt) {
+					t.Fatalf("expected %+v, got %+v", w, g)
+				}
+			}
+
+			// Importing again is a no-op
+			result, err = Import(ctx, dst, bytes.NewReader(data), format, time.Now())
+			if err != nil {
+				t.Fatalf("second Import failed: %v", err)
+			}
+			if result != (ImportResult{Unchanged: len(want)}) {
+				t.Errorf("expected every record unchanged, got %+v", result)
+			}
+			if events, _ := dst.ListEvents(ctx, 0, 0); len(events) != len(want) {
+				t.Errorf("expected only %d creation events, got %d", len(want), len(events))
+			}
+		})
+	}
+}
+
+func TestExport_Filter(t *testing.T) {
+	ctx := context.Background()
+	repo := seedRepo(t, 1100)
+	red := disastersv1.AlertLevel_RED
+
+	tests := []struct {
+		name   string
+		filter repository.Filter
+	}{
+		{"limit across pages", repository.Filter{Limit: 700}},
+		{"offset", repository.Filter{Offset: 10, Limit: 600}},
+		{"attributes and order", repository.Filter{AlertLevel: &red, Sort: repository.SortMagnitude, Order: repository.SortOrderAsc}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			all := tt.filter
+			all.Limit, all.Offset = 0, 0
+			everything, err := repo.ListDisasters(ctx, all)
+			if err != nil {
+				t.Fatalf("ListDisasters failed: %v", err)
+			}
+			want := everything[min(tt.filter.Offset, len(everything)):]
+			if tt.filter.Limit > 0 {
+				want = want[:min(tt.filter.Limit, len(want))]
+			}
+
+			var buf bytes.Buffer
+			n, err := Export(ctx, repo, tt.filter, &buf, FormatNDJSON)
+			if err != nil {
+				t.Fatalf("Export failed: %v", err)
+			}
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if n != len(want) || len(lines) != len(want) {
+				t.Fatalf("expected %d disasters, got %d in %d lines", len(want), n, len(lines))
+			}
+			for i, d := range want {
+				if !strings.Contains(lines[i], `"id":"`+d.ID+`"`) {
+					t.Fatalf("line %d: expected %s, got %s", i, d.ID, lines[i])
+				}
+			}
+		})
+	}
+}
+
+func TestImport_Upserts(t *testing.T) {
+	ctx := context.Background()
+	repo := seedRepo(t, 2)
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	// gdacs_0000 is green and closed, gdacs_0001 orange and open
+	input := `id,type,alert_level,title,closed,latitude,longitude
+gdacs_0000,flood,red,Escalated,true,1,2
+gdacs_0001,cyclone,orange,Now closed,true,3,4
+new_1,earthquake,,Brand new,false,5,6
+`
+	result, err := Import(ctx, repo, strings.NewReader(input), FormatCSV, now)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if result != (ImportResult{Created: 1, Updated: 2}) {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	events, _ := repo.ListEvents(ctx, 2, 0)
+	var kinds []disastersv1.EventKind
+	for _, e := range events {
+		kinds = append(kinds, e.Kind)
+	}
+	want := []disastersv1.EventKind{
+		disastersv1.EventKind_EVENT_KIND_ESCALATED,
+		disastersv1.EventKind_EVENT_KIND_CLOSED,
+		disastersv1.EventKind_EVENT_KIND_CREATED,
+	}
+	if fmt.Sprint(kinds) != fmt.Sprint(want) {
+		t.Errorf("expected events %v, got %v", want, kinds)
+	}
+	if d, _ := repo.GetByID(ctx, "new_1"); d == nil || !d.CreatedAt.Equal(now) || d.AlertLevel != disastersv1.AlertLevel_UNKNOWN {
+		t.Errorf("unexpected new disaster %+v", d)
+	}
+	if d, _ := repo.GetByID(ctx, "gdacs_0000"); d == nil || !d.UpdatedAt.Equal(now) || d.CreatedAt.Equal(now) {
+		t.Errorf("expected the update to keep the creation time, got %+v", d)
+	}
+}
+
+func TestImport_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		input  string
+	}{
+		{"unknown json field", FormatNDJSON, `{"id":"x","seq":3}`},
+		{"malformed json", FormatNDJSON, `{"id":`},
+		{"missing id", FormatNDJSON, `{"title":"no id"}`},
+		{"unknown type", FormatNDJSON, `{"id":"x","type":"meteor"}`},
+		{"unknown csv column", FormatCSV, "id,color\nx,red\n"},
+		{"no id column", FormatCSV, "title\nx\n"},
+		{"invalid number", FormatCSV, "id,magnitude\nx,big\n"},
+		{"invalid time", FormatCSV, "id,timestamp\nx,yesterday\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Import(context.Background(), repository.NewMemoryDB(), strings.NewReader(tt.input), tt.format, time.Now())
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name, path string
+		want       Format
+		wantErr    bool
+	}{
+		{"", "out.csv", FormatCSV, false},
+		{"", "out.ndjson", FormatNDJSON, false},
+		{"", "-", FormatNDJSON, false},
+		{"CSV", "out.ndjson", FormatCSV, false},
+		{"jsonl", "", FormatNDJSON, false},
+		{"xml", "", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.name, tt.path)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseFormat(%q, %q) = %q, %v", tt.name, tt.path, got, err)
+		}
+	}
+}
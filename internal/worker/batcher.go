@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchFunc processes the jobs of one batch, in submission order
+type BatchFunc func(ctx context.Context, jobs []Job) error
+
+// Batcher coalesces submitted jobs into batches, handing a batch to its BatchFunc once it holds
+// size jobs or wait has passed since its first job, whichever comes first. Batches are processed
+// one at a time.
+type Batcher struct {
+	size      int
+	wait      time.Duration
+	jobs      chan Job
+	processor BatchFunc
+	wg        sync.WaitGroup
+}
+
+// NewBatcher returns a Batcher of up to size jobs; a size below 1 processes jobs one by one
+func NewBatcher(size int, wait time.Duration, bufferSize int, processor BatchFunc) *Batcher {
+	return &Batcher{
+		size:      max(size, 1),
+		wait:      wait,
+		jobs:      make(chan Job, bufferSize),
+		processor: processor,
+	}
+}
+
+func (b *Batcher) Start(ctx context.Context) {
+	b.wg.Add(1)
+	go b.run(ctx)
+}
+
+func (b *Batcher) run(ctx context.Context) {
+	defer b.wg.Done()
+
+	var batch []Job
+	timer := time.NewTimer(b.wait)
+	timer.Stop()
+	defer timer.Stop()
+	flush := func() {
+		timer.Stop()
+		if len(batch) > 0 {
+			b.processor(ctx, batch)
+			batch = nil
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job, ok := <-b.jobs:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, job)
+			if len(batch) >= b.size {
+				flush()
+			} else if len(batch) == 1 {
+				timer.Reset(b.wait)
+			}
+		case <-timer.C:
+			flush()
+		}
+	}
+}
+
+func (b *Batcher) Submit(job Job) {
+	b.jobs <- job
+}
+
+// Stop processes the jobs already submitted, unless the context passed to Start is canceled
+func (b *Batcher) Stop() {
+	close(b.jobs)
+	b.wg.Wait()
+}
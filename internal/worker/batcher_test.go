@@ -0,0 +1,91 @@
+package worker
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordBatches returns a BatchFunc recording the jobs of each batch
+func recordBatches() (BatchFunc, func() [][]Job) {
+	var mu sync.Mutex
+	var batches [][]Job
+	processor := func(ctx context.Context, jobs []Job) error {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, slices.Clone(jobs))
+		return nil
+	}
+	return processor, func() [][]Job {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(batches)
+	}
+}
+
+func TestBatcher_FlushesBySize(t *testing.T) {
+	processor, batches := recordBatches()
+	b := NewBatcher(3, time.Hour, 10, processor)
+	b.Start(context.Background())
+
+	for i := range 7 {
+		b.Submit(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := batches(); len(got) != 2 || len(got[0]) != 3 || len(got[1]) != 3 {
+		t.Errorf("expected two full batches before the wait passed, got %v", got)
+	}
+
+	// Stop processes the remainder
+	b.Stop()
+	got := batches()
+	if len(got) != 3 || !slices.Equal(got[2], []Job{6}) {
+		t.Errorf("expected the last job flushed on Stop, got %v", got)
+	}
+}
+
+func TestBatcher_FlushesByTime(t *testing.T) {
+	processor, batches := recordBatches()
+	b := NewBatcher(100, 20*time.Millisecond, 10, processor)
+	b.Start(context.Background())
+	defer b.Stop()
+
+	b.Submit("a")
+	b.Submit("b")
+	time.Sleep(100 * time.Millisecond)
+	if got := batches(); len(got) != 1 || !slices.Equal(got[0], []Job{"a", "b"}) {
+		t.Errorf("expected one batch of [a b] after the wait, got %v", got)
+	}
+
+	b.Submit("c")
+	time.Sleep(100 * time.Millisecond)
+	if got := batches(); len(got) != 2 || !slices.Equal(got[1], []Job{"c"}) {
+		t.Errorf("expected a second batch of [c], got %v", got)
+	}
+}
+
+func TestBatcher_CanceledContext(t *testing.T) {
+	processor, batches := recordBatches()
+	b := NewBatcher(100, time.Hour, 10, processor)
+	ctx, cancel := context.WithCancel(context.Background())
+	b.Start(ctx)
+
+	b.Submit(1)
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		b.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop timed out after cancel")
+	}
+	if got := batches(); len(got) > 1 {
+		t.Errorf("expected at most one batch, got %v", got)
+	}
+}